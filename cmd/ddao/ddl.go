@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+func runDDL(args []string) error {
+	fs := flag.NewFlagSet("ddl", flag.ExitOnError)
+	var src source
+	src.register(fs)
+	name := fs.String("dialect", "", "database to render for: "+strings.Join(dialect.Names(), ", "))
+	keyspace := fs.String("keyspace", "", "with -dialect scylla, the keyspace to create the tables in")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ddao ddl -dialect name [flags] [schema file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// The schema file may be given as an argument instead of with -file
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if src.file != "" {
+			return fmt.Errorf("schema file given both as -file and as an argument")
+		}
+		src.file = fs.Arg(0)
+	default:
+		return fmt.Errorf("unexpected arguments %q", fs.Args()[1:])
+	}
+
+	d, ok := dialect.Get(*name)
+	if !ok {
+		return fmt.Errorf("unknown dialect %q; known dialects are %s", *name, strings.Join(dialect.Names(), ", "))
+	}
+	if *keyspace != "" {
+		if d.Name() != "scylla" {
+			return fmt.Errorf("-keyspace only applies to the scylla dialect")
+		}
+		d = dialect.ScyllaKeyspace(*keyspace)
+	}
+
+	sch, err := src.load()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(d.RenderDDL(sch))
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema/gen"
+)
+
+func runGen(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	var src source
+	src.register(fs)
+	pkg := fs.String("package", "models", "package name of the generated file")
+	out := fs.String("out", "", "file to write; standard output when empty")
+	tables := fs.String("tables", "", "comma separated tables to generate; all when empty")
+	fs.Parse(args)
+
+	sch, err := src.load()
+	if err != nil {
+		return err
+	}
+	opts := gen.Options{Package: *pkg}
+	if *tables != "" {
+		opts.Tables = strings.Split(*tables, ",")
+	}
+	code, err := gen.Generate(sch, opts)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(*out, code, 0o644)
+}
@@ -0,0 +1,57 @@
+// Command ddao works with ddao schemas from the command line.
+//
+// Usage:
+//
+//	ddao ddl [flags]    print the DDL creating a schema in a database
+//	ddao gen [flags]    generate typed Go models from a schema
+//
+// Run `ddao <command> -h` for a command's flags.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a ddao subcommand
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"ddl": {"print the DDL creating a schema in a database", runDDL},
+	"gen": {"generate typed Go models from a schema", runGen},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ddao: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ddao %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ddao <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].summary)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/schema/parser/ddl"
+	"github.com/jadedragon942/ddao/schema/parser/infoschema"
+)
+
+// source is where a command reads its schema from: a schema or SQL file, a
+// function of a Go package, or a live database
+type source struct {
+	file     string
+	pkg      string
+	fn       string
+	driver   string
+	dsn      string
+	database string
+}
+
+func (s *source) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.file, "file", "", "read the schema from a JSON or YAML schema file, or a .sql file of CREATE and ALTER statements")
+	fs.StringVar(&s.pkg, "pkg", "", "read the schema from a Go package, by import path or relative directory")
+	fs.StringVar(&s.fn, "func", "Schema", "with -pkg, the package's function returning the *schema.Schema")
+	fs.StringVar(&s.driver, "driver", "", "read the schema from a live database: sqlite3, mysql or pgx")
+	fs.StringVar(&s.dsn, "dsn", "", "with -driver, the database's data source name")
+	fs.StringVar(&s.database, "database", "", "with -driver, the database (schema) to read")
+}
+
+func (s *source) load() (*schema.Schema, error) {
+	switch {
+	case s.file != "" && s.pkg == "" && s.driver == "":
+		if strings.EqualFold(filepath.Ext(s.file), ".sql") {
+			return ddl.ParseFile(s.file)
+		}
+		return schema.LoadFile(s.file)
+	case s.pkg != "" && s.file == "" && s.driver == "":
+		return loadPackage(s.pkg, s.fn)
+	case s.driver != "" && s.file == "" && s.pkg == "":
+		return loadDatabase(s.driver, s.dsn, s.database)
+	}
+	return nil, fmt.Errorf("exactly one of -file, -pkg and -driver is required")
+}
+
+// loaderSource is the program loadPackage runs to print a package's schema
+const loaderSource = `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jadedragon942/ddao/schema"
+
+	source %q
+)
+
+func main() {
+	emit(source.%s())
+}
+
+// emit takes the function's results, with or without an error
+func emit(sch *schema.Schema, errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err := schema.Save(os.Stdout, sch); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`
+
+// loadPackage builds and runs a program printing the schema returned by the
+// function fn of the package pkg, which returns a *schema.Schema and
+// optionally an error. The program is built in the current module, so pkg
+// must be importable from it.
+func loadPackage(pkg, fn string) (*schema.Schema, error) {
+	if strings.HasPrefix(pkg, ".") || filepath.IsAbs(pkg) {
+		out, err := exec.Command("go", "list", "-f", "{{.ImportPath}}", pkg).Output()
+		if err != nil {
+			return nil, fmt.Errorf("resolving package %s: %w", pkg, commandError(err))
+		}
+		pkg = strings.TrimSpace(string(out))
+	}
+
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("finding the current module: %w", commandError(err))
+	}
+	dir, err := os.MkdirTemp(strings.TrimSpace(string(out)), "ddaogen")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(fmt.Sprintf(loaderSource, pkg, fn)), 0o644); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("loading %s.%s: %v: %s", pkg, fn, err, strings.TrimSpace(stderr.String()))
+	}
+	return schema.Load(&stdout)
+}
+
+// schemaParser is implemented by infoschema.Parser and SQLiteAdapter
+type schemaParser interface {
+	ParseSchema(databaseName string) (*schema.Schema, error)
+}
+
+// loadDatabase reads the schema of a live database through information_schema,
+// or SQLite's pragmas
+func loadDatabase(driver, dsn, database string) (*schema.Schema, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("-dsn is required with -driver")
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", driver, err)
+	}
+
+	var parser schemaParser = infoschema.NewParser(db)
+	if driver == "sqlite3" {
+		parser = infoschema.NewSQLiteAdapter(db)
+		if database == "" {
+			database = "main"
+		}
+	}
+	if database == "" {
+		return nil, fmt.Errorf("-database is required with -driver %s", driver)
+	}
+	return parser.ParseSchema(database)
+}
+
+// commandError adds the stderr of a failed command to its error
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
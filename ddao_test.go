@@ -0,0 +1,30 @@
+package ddao
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	_ "github.com/jadedragon942/ddao/storage/sqlite"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := Open(ctx, "sqlite::memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.ResetConnection(ctx)
+	if err := store.CreateTables(ctx, schema.GetTestSchema()); err != nil {
+		t.Errorf("failed to create tables: %v", err)
+	}
+
+	if _, err := Open(ctx, "dbase://localhost/app"); err == nil || !strings.Contains(err.Error(), `no backend registered for scheme "dbase"`) {
+		t.Errorf("expected an unknown scheme error, got %v", err)
+	}
+	if _, err := Open(ctx, "app.db"); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
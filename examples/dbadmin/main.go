@@ -8,15 +8,15 @@ import (
 
 	"github.com/jadedragon942/ddao/schema"
 	"github.com/jadedragon942/ddao/storage"
-	"github.com/jadedragon942/ddao/storage/cockroach"
-	"github.com/jadedragon942/ddao/storage/oracle"
-	"github.com/jadedragon942/ddao/storage/postgres"
-	"github.com/jadedragon942/ddao/storage/s3"
-	"github.com/jadedragon942/ddao/storage/scylla"
-	"github.com/jadedragon942/ddao/storage/sqlite"
-	"github.com/jadedragon942/ddao/storage/sqlserver"
-	"github.com/jadedragon942/ddao/storage/tidb"
-	"github.com/jadedragon942/ddao/storage/yugabyte"
+	_ "github.com/jadedragon942/ddao/storage/cockroach"
+	_ "github.com/jadedragon942/ddao/storage/oracle"
+	_ "github.com/jadedragon942/ddao/storage/postgres"
+	_ "github.com/jadedragon942/ddao/storage/s3"
+	_ "github.com/jadedragon942/ddao/storage/scylla"
+	_ "github.com/jadedragon942/ddao/storage/sqlite"
+	_ "github.com/jadedragon942/ddao/storage/sqlserver"
+	_ "github.com/jadedragon942/ddao/storage/tidb"
+	_ "github.com/jadedragon942/ddao/storage/yugabyte"
 )
 
 type Config struct {
@@ -58,10 +58,12 @@ func main() {
 }
 
 func (s *AdminServer) Connect(storageType, connString string) error {
-	stor, err := createStorage(storageType)
-	if err != nil {
-		return fmt.Errorf("failed to create storage: %w", err)
+	// Each backend registers its storage type in init
+	newStorage, ok := storage.Get(storageType)
+	if !ok {
+		return fmt.Errorf("unsupported storage type: %s", storageType)
 	}
+	stor := newStorage()
 
 	ctx := context.Background()
 	if err := stor.Connect(ctx, connString); err != nil {
@@ -96,31 +98,6 @@ func (s *AdminServer) Disconnect() {
 	log.Printf("Disconnected from storage")
 }
 
-func createStorage(storageType string) (storage.Storage, error) {
-	switch storageType {
-	case "sqlite":
-		return sqlite.New(), nil
-	case "postgres":
-		return postgres.New(), nil
-	case "sqlserver":
-		return sqlserver.New(), nil
-	case "oracle":
-		return oracle.New(), nil
-	case "cockroach":
-		return cockroach.New(), nil
-	case "yugabyte":
-		return yugabyte.New(), nil
-	case "tidb":
-		return tidb.New(), nil
-	case "scylla":
-		return scylla.New(), nil
-	case "s3":
-		return s3.New(), nil
-	default:
-		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
-	}
-}
-
 func createTestSchema() *schema.Schema {
 	sch := &schema.Schema{
 		Tables: make(map[string]*schema.TableSchema),
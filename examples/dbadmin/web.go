@@ -11,8 +11,12 @@ import (
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
 )
 
+// tablePageSize is the number of rows shown per page when browsing a table
+const tablePageSize = 50
+
 type WebServer struct {
 	adminServer *AdminServer
 	port        int
@@ -619,7 +623,7 @@ func (ws *WebServer) handleTable(w http.ResponseWriter, r *http.Request) {
                 </div>
                 {{else if .Data}}
                 <div class="table-data">
-                    <h3>Data (Sample)</h3>
+                    <h3>Data</h3>
                     <table class="data-table">
                         <thead>
                             <tr>
@@ -631,16 +635,16 @@ func (ws *WebServer) handleTable(w http.ResponseWriter, r *http.Request) {
                             </tr>
                         </thead>
                         <tbody>
-                            {{range .Data}}
+                            {{range $row := .Data}}
                             <tr>
-                                <td>{{.ID}}</td>
+                                <td>{{$row.ID}}</td>
                                 {{range $.Fields}}
-                                <td>{{index $.FieldData .Name}}</td>
+                                <td>{{index $row.Fields .Name}}</td>
                                 {{end}}
                                 <td>
                                     <form method="POST" action="/delete" style="display: inline;">
                                         <input type="hidden" name="table" value="{{$.TableName}}">
-                                        <input type="hidden" name="id" value="{{.ID}}">
+                                        <input type="hidden" name="id" value="{{$row.ID}}">
                                         <button type="submit" class="btn btn-danger btn-small" onclick="return confirm('Are you sure?')">Delete</button>
                                     </form>
                                 </td>
@@ -648,6 +652,10 @@ func (ws *WebServer) handleTable(w http.ResponseWriter, r *http.Request) {
                             {{end}}
                         </tbody>
                     </table>
+                    <div class="pagination">
+                        {{if .PrevCursor}}<a href="/table/{{.TableName}}" class="btn">First Page</a>{{end}}
+                        {{if .NextCursor}}<a href="/table/{{.TableName}}?cursor={{.NextCursor}}" class="btn">Next Page</a>{{end}}
+                    </div>
                 </div>
                 {{else}}
                 <div class="empty-state">
@@ -673,18 +681,32 @@ func (ws *WebServer) handleTable(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		TableName string
-		Fields    []FieldInfo
-		Data      []ObjectData
-		Error     string
+		TableName  string
+		Fields     []FieldInfo
+		Data       []ObjectData
+		PrevCursor string
+		NextCursor string
+		Error      string
 	}{
-		TableName: tableName,
-		Fields:    tableInfo.Fields,
+		TableName:  tableName,
+		Fields:     tableInfo.Fields,
+		PrevCursor: r.URL.Query().Get("cursor"),
 	}
 
-	// Try to get some sample data, but don't fail if it doesn't work
-	// since some storage types might not support this operation
-	// For now, we'll just show the schema
+	// Show one page of rows; a listing error is displayed rather than failing the page
+	ctx := context.Background()
+	objs, next, err := ws.adminServer.storage.List(ctx, tableName, storage.ListOptions{
+		Limit:  tablePageSize,
+		Cursor: data.PrevCursor,
+	})
+	if err != nil {
+		data.Error = err.Error()
+	} else {
+		for _, obj := range objs {
+			data.Data = append(data.Data, ObjectData{ID: obj.ID, Fields: obj.Fields})
+		}
+		data.NextCursor = next
+	}
 
 	w.Header().Set("Content-Type", "text/html")
 	t.Execute(w, data)
@@ -818,7 +840,9 @@ func (ws *WebServer) processAlterTable(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	err := ws.adminServer.storage.AlterTable(ctx, tableName, columnName, dataType, nullable)
+	err := ws.adminServer.storage.AlterTable(ctx, tableName, []storage.AlterOp{
+		storage.AddColumn(schema.ColumnData{Name: columnName, DataType: dataType, Nullable: nullable}),
+	})
 	if err != nil {
 		ws.showAlterTableFormWithMessage(w, fmt.Sprintf("Failed to alter table: %v", err), false)
 		return
@@ -46,6 +46,9 @@ func main() {
 
 	// Create ORM
 	ormInstance := orm.New(schema).WithStorage(storage)
+	if err := addGroupRelations(ormInstance); err != nil {
+		log.Fatalf("Failed to declare group relations: %v", err)
+	}
 
 	// Initialize LDAP server
 	server := NewLDAPServer(*port, *baseDN, *bindDN, *bindPW, ormInstance, *verbose)
@@ -4,12 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/orm"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 // LDAPEntry represents an LDAP directory entry
@@ -45,40 +48,48 @@ func (s *LDAPServer) searchEntries(baseDN, filter string) ([]LDAPEntry, error) {
 	ctx := context.Background()
 	var entries []LDAPEntry
 
-	// For this example, we'll do a simple search by object class or DN
-	// In a real implementation, you would parse LDAP filters properly
+	// Match the base entry itself and everything below it in the tree
+	query := storage.Or(
+		storage.Eq("id", baseDN),
+		storage.Like("id", "%,"+baseDN),
+	)
 
-	if filter == "" || strings.Contains(filter, "objectClass") {
-		// Search all entries under base DN
-		// This is a simplified implementation - in practice you'd need proper LDAP filter parsing
-
-		// For demonstration, let's just return entries that start with the base DN
-		// In a real implementation, you'd implement proper hierarchical searching
+	// Only simple (objectClass=value) filters are supported;
+	// anything else falls back to returning the whole subtree
+	if objectClass := filterObjectClass(filter); objectClass != "" && objectClass != "*" {
+		query = storage.And(query, storage.Eq("object_class", objectClass))
+	}
 
-		// Since we can't do complex queries easily with DDAO's simple interface,
-		// we'll just search for specific patterns
+	objs, err := s.orm.Find(ctx, "entries", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search entries: %w", err)
+	}
 
-		entry, err := s.orm.FindByID(ctx, "entries", baseDN)
-		if err == nil && entry != nil {
-			ldapEntry := LDAPEntry{
-				DN:          entry.ID,
-				ObjectClass: entry.Fields["object_class"].(string),
-				Attributes:  entry.Fields["attributes"].(string),
-				CreatedAt:   entry.Fields["created_at"].(string),
-			}
-			if parentDN, exists := entry.Fields["parent_dn"]; exists && parentDN != nil {
-				ldapEntry.ParentDN = parentDN.(string)
-			}
-			if updatedAt, exists := entry.Fields["updated_at"]; exists && updatedAt != nil {
-				ldapEntry.UpdatedAt = updatedAt.(string)
-			}
-			entries = append(entries, ldapEntry)
-		}
+	for _, entry := range objs {
+		ldapEntry := LDAPEntry{DN: entry.ID}
+		ldapEntry.ObjectClass, _ = entry.GetString("object_class")
+		ldapEntry.Attributes, _ = entry.GetString("attributes")
+		ldapEntry.CreatedAt, _ = entry.GetString("created_at")
+		ldapEntry.ParentDN, _ = entry.GetString("parent_dn")
+		ldapEntry.UpdatedAt, _ = entry.GetString("updated_at")
+		entries = append(entries, ldapEntry)
 	}
 
 	return entries, nil
 }
 
+// filterObjectClass extracts the value of an (objectClass=value) filter
+func filterObjectClass(filter string) string {
+	filter = strings.TrimSpace(filter)
+	filter = strings.TrimSuffix(strings.TrimPrefix(filter, "("), ")")
+
+	name, value, found := strings.Cut(filter, "=")
+	if !found || !strings.EqualFold(strings.TrimSpace(name), "objectClass") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
 func (s *LDAPServer) addEntry(dn, attributesStr string) error {
 	ctx := context.Background()
 
@@ -154,12 +165,12 @@ func (s *LDAPServer) modifyEntry(dn, attributesStr string) error {
 
 	// Find existing entry
 	entry, err := s.orm.FindByID(ctx, "entries", dn)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return fmt.Errorf("entry with DN %s not found", dn)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to find entry: %w", err)
 	}
-	if entry == nil {
-		return fmt.Errorf("entry with DN %s not found", dn)
-	}
 
 	// Update attributes
 	entry.Fields["attributes"] = attributesStr
@@ -218,12 +229,12 @@ func (s *LDAPServer) authenticateUser(dn, password string) (bool, error) {
 
 	// Find user
 	user, err := s.orm.FindByID(ctx, "users", dn)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return false, nil
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to find user: %w", err)
 	}
-	if user == nil {
-		return false, nil
-	}
 
 	// Get stored hash and salt
 	storedHash := user.Fields["password_hash"].(string)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/jadedragon942/ddao/orm"
 	"github.com/jadedragon942/ddao/schema"
 )
 
@@ -97,7 +98,7 @@ func createLDAPSchema() *schema.Schema {
 		Name:     "group_dn",
 		DataType: "text",
 		Nullable: false,
-		Unique:   true,
+		Index:    true,
 		Comment:  "Group DN",
 	})
 	groupTable.AddField(schema.ColumnData{
@@ -114,8 +115,33 @@ func createLDAPSchema() *schema.Schema {
 		Comment:  "Creation timestamp",
 	})
 
+	// Each row makes one entry a member of another
+	groupTable.AddIndex("", true, "group_dn", "member_dn")
+	groupTable.AddForeignKey(schema.ForeignKey{
+		Columns:    []string{"group_dn"},
+		RefTable:   "entries",
+		RefColumns: []string{"id"},
+		OnDelete:   schema.Cascade,
+	})
+	groupTable.AddForeignKey(schema.ForeignKey{
+		Columns:    []string{"member_dn"},
+		RefTable:   "entries",
+		RefColumns: []string{"id"},
+		OnDelete:   schema.Cascade,
+	})
+
 	sch.AddTable(entryTable)
 	sch.AddTable(userTable)
 	sch.AddTable(groupTable)
 	return sch
+}
+
+// addGroupRelations declares the group memberships kept in the groups table
+// as relations of entries: the "members" of a group entry, and the groups an
+// entry is a "member_of"
+func addGroupRelations(o *orm.ORM) error {
+	if err := o.AddManyToMany("members", "groups", "fk_groups_group_dn", "fk_groups_member_dn"); err != nil {
+		return err
+	}
+	return o.AddManyToMany("member_of", "groups", "fk_groups_member_dn", "fk_groups_group_dn")
 }
\ No newline at end of file
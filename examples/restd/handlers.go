@@ -2,19 +2,29 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jadedragon942/ddao/orm"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 type RestdService struct {
-	orm *orm.ORM
+	users *orm.Repo[User]
+	posts *orm.Repo[Post]
 }
 
-func NewRestdService(orm *orm.ORM) *RestdService {
-	return &RestdService{orm: orm}
+func NewRestdService(o *orm.ORM) (*RestdService, error) {
+	users, err := orm.NewRepo[User](o)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := orm.NewRepo[Post](o)
+	if err != nil {
+		return nil, err
+	}
+	return &RestdService{users: users, posts: posts}, nil
 }
 
 // User handlers
@@ -28,14 +38,13 @@ func (s *RestdService) CreateUser(ctx context.Context, input *CreateUserInput) (
 		CreatedAt: time.Now(),
 	}
 
-	obj := userToObject(user)
-	_, created, err := s.orm.Insert(ctx, obj)
+	err := s.users.Insert(ctx, &user)
+	if errors.Is(err, ddaoerrors.ErrConflict) {
+		return nil, fmt.Errorf("user with email %s already exists", user.Email)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
-	if !created {
-		return nil, fmt.Errorf("user with email %s already exists", user.Email)
-	}
 
 	return &UserResponse{Body: user}, nil
 }
@@ -43,68 +52,64 @@ func (s *RestdService) CreateUser(ctx context.Context, input *CreateUserInput) (
 func (s *RestdService) GetUser(ctx context.Context, input *struct {
 	UserID string `path:"userId" example:"user123" doc:"User ID"`
 }) (*UserResponse, error) {
-	obj, err := s.orm.FindByID(ctx, "users", input.UserID)
+	user, err := s.users.Get(ctx, input.UserID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
-	if obj == nil {
-		return nil, fmt.Errorf("user not found")
-	}
 
-	user := objectToUser(obj)
-	return &UserResponse{Body: user}, nil
+	return &UserResponse{Body: *user}, nil
 }
 
 func (s *RestdService) GetUserByEmail(ctx context.Context, input *struct {
 	Email string `query:"email" example:"user@example.com" doc:"User email"`
 }) (*UserResponse, error) {
-	obj, err := s.orm.FindByKey(ctx, "users", "email", input.Email)
+	user, err := s.users.FindByKey(ctx, "email", input.Email)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
-	if obj == nil {
-		return nil, fmt.Errorf("user not found")
-	}
 
-	user := objectToUser(obj)
-	return &UserResponse{Body: user}, nil
+	return &UserResponse{Body: *user}, nil
 }
 
 func (s *RestdService) UpdateUser(ctx context.Context, input *UpdateUserInput) (*UserResponse, error) {
-	obj, err := s.orm.FindByID(ctx, "users", input.UserID)
+	user, err := s.users.Get(ctx, input.UserID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
-	if obj == nil {
-		return nil, fmt.Errorf("user not found")
-	}
 
 	now := time.Now()
 	if input.Body.Email != nil {
-		obj.SetField("email", *input.Body.Email)
+		user.Email = *input.Body.Email
 	}
 	if input.Body.Name != nil {
-		obj.SetField("name", *input.Body.Name)
+		user.Name = *input.Body.Name
 	}
 	if input.Body.Profile != nil {
-		profileJSON, _ := json.Marshal(input.Body.Profile)
-		obj.SetField("profile", string(profileJSON))
+		user.Profile = input.Body.Profile
 	}
-	obj.SetField("updated_at", now.Format(time.RFC3339))
+	user.UpdatedAt = &now
 
-	_, err = s.orm.Storage.Update(ctx, obj)
+	_, err = s.users.Update(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
-	user := objectToUser(obj)
-	return &UserResponse{Body: user}, nil
+	return &UserResponse{Body: *user}, nil
 }
 
 func (s *RestdService) DeleteUser(ctx context.Context, input *struct {
 	UserID string `path:"userId" example:"user123" doc:"User ID"`
 }) (*DeleteResponse, error) {
-	deleted, err := s.orm.DeleteByID(ctx, "users", input.UserID)
+	deleted, err := s.users.Delete(ctx, input.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -127,13 +132,13 @@ func (s *RestdService) DeleteUser(ctx context.Context, input *struct {
 
 func (s *RestdService) CreatePost(ctx context.Context, input *CreatePostInput) (*PostResponse, error) {
 	// Verify user exists
-	userObj, err := s.orm.FindByID(ctx, "users", input.Body.UserID)
+	_, err := s.users.Get(ctx, input.Body.UserID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify user: %w", err)
 	}
-	if userObj == nil {
-		return nil, fmt.Errorf("user not found")
-	}
 
 	published := false
 	if input.Body.Published != nil {
@@ -150,14 +155,13 @@ func (s *RestdService) CreatePost(ctx context.Context, input *CreatePostInput) (
 		CreatedAt: time.Now(),
 	}
 
-	obj := postToObject(post)
-	_, created, err := s.orm.Insert(ctx, obj)
+	err = s.posts.Insert(ctx, &post)
+	if errors.Is(err, ddaoerrors.ErrConflict) {
+		return nil, fmt.Errorf("post with ID %s already exists", post.ID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
-	if !created {
-		return nil, fmt.Errorf("post with ID %s already exists", post.ID)
-	}
 
 	return &PostResponse{Body: post}, nil
 }
@@ -165,56 +169,53 @@ func (s *RestdService) CreatePost(ctx context.Context, input *CreatePostInput) (
 func (s *RestdService) GetPost(ctx context.Context, input *struct {
 	PostID string `path:"postId" example:"post123" doc:"Post ID"`
 }) (*PostResponse, error) {
-	obj, err := s.orm.FindByID(ctx, "posts", input.PostID)
+	post, err := s.posts.Get(ctx, input.PostID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("post not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find post: %w", err)
 	}
-	if obj == nil {
-		return nil, fmt.Errorf("post not found")
-	}
 
-	post := objectToPost(obj)
-	return &PostResponse{Body: post}, nil
+	return &PostResponse{Body: *post}, nil
 }
 
 func (s *RestdService) UpdatePost(ctx context.Context, input *UpdatePostInput) (*PostResponse, error) {
-	obj, err := s.orm.FindByID(ctx, "posts", input.PostID)
+	post, err := s.posts.Get(ctx, input.PostID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, fmt.Errorf("post not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find post: %w", err)
 	}
-	if obj == nil {
-		return nil, fmt.Errorf("post not found")
-	}
 
 	now := time.Now()
 	if input.Body.Title != nil {
-		obj.SetField("title", *input.Body.Title)
+		post.Title = *input.Body.Title
 	}
 	if input.Body.Content != nil {
-		obj.SetField("content", *input.Body.Content)
+		post.Content = *input.Body.Content
 	}
 	if input.Body.Metadata != nil {
-		metadataJSON, _ := json.Marshal(input.Body.Metadata)
-		obj.SetField("metadata", string(metadataJSON))
+		post.Metadata = input.Body.Metadata
 	}
 	if input.Body.Published != nil {
-		obj.SetField("published", *input.Body.Published)
+		post.Published = *input.Body.Published
 	}
-	obj.SetField("updated_at", now.Format(time.RFC3339))
+	post.UpdatedAt = &now
 
-	_, err = s.orm.Storage.Update(ctx, obj)
+	_, err = s.posts.Update(ctx, post)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
 
-	post := objectToPost(obj)
-	return &PostResponse{Body: post}, nil
+	return &PostResponse{Body: *post}, nil
 }
 
 func (s *RestdService) DeletePost(ctx context.Context, input *struct {
 	PostID string `path:"postId" example:"post123" doc:"Post ID"`
 }) (*DeleteResponse, error) {
-	deleted, err := s.orm.DeleteByID(ctx, "posts", input.PostID)
+	deleted, err := s.posts.Delete(ctx, input.PostID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete post: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
@@ -12,24 +13,19 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/jadedragon942/ddao"
 	"github.com/jadedragon942/ddao/orm"
 )
 
 func main() {
 	cli := humacli.New(func(hooks humacli.Hooks, config *Config) {
-		// Create storage factory and get storage engine
-		factory := &StorageFactory{}
-		storage, err := factory.CreateStorage(StorageEngine(config.StorageEngine), config.ConnectionURL)
-		if err != nil {
-			log.Fatalf("Failed to create storage: %v", err)
-		}
-
-		// Connect to storage
+		// Open the storage engine named by the URL's scheme
 		ctx := context.Background()
-		err = factory.ConnectStorage(ctx, storage, config.ConnectionURL)
+		storage, err := ddao.Open(ctx, config.Storage)
 		if err != nil {
-			log.Fatalf("Failed to connect to storage: %v", err)
+			log.Fatalf("Failed to open storage: %v", err)
 		}
+		engine, _, _ := strings.Cut(config.Storage, ":")
 		defer storage.ResetConnection(ctx)
 
 		// Create schema and initialize tables
@@ -43,7 +39,10 @@ func main() {
 		ormInstance := orm.New(schema).WithStorage(storage)
 
 		// Create service
-		service := NewRestdService(ormInstance)
+		service, err := NewRestdService(ormInstance)
+		if err != nil {
+			log.Fatalf("Failed to create service: %v", err)
+		}
 
 		// Create router with middleware
 		router := chi.NewMux()
@@ -73,7 +72,7 @@ func main() {
 				}
 			}{}
 			resp.Body.Status = "ok"
-			resp.Body.Storage = config.StorageEngine
+			resp.Body.Storage = engine
 			return resp, nil
 		})
 
@@ -84,7 +83,7 @@ func main() {
 		}
 
 		hooks.OnStart(func() {
-			log.Printf("Starting DDAO REST API server on port %d with %s storage", config.Port, config.StorageEngine)
+			log.Printf("Starting DDAO REST API server on port %d with %s storage", config.Port, engine)
 			log.Printf("API documentation available at: http://localhost:%d/docs", config.Port)
 			log.Printf("OpenAPI spec available at: http://localhost:%d/openapi.json", config.Port)
 		})
@@ -1,18 +1,15 @@
 package main
 
 import (
-	"encoding/json"
 	"time"
-
-	"github.com/jadedragon942/ddao/object"
 )
 
 // User represents a user in the system
 type User struct {
 	ID        string                 `json:"id" example:"user123" doc:"User ID"`
-	Email     string                 `json:"email" example:"user@example.com" doc:"User email address"`
+	Email     string                 `json:"email" example:"user@example.com" doc:"User email address" ddao:",unique"`
 	Name      string                 `json:"name" example:"John Doe" doc:"User full name"`
-	Profile   map[string]interface{} `json:"profile,omitempty" doc:"User profile data"`
+	Profile   map[string]interface{} `json:"profile,omitempty" doc:"User profile data" ddao:",nullable"`
 	CreatedAt time.Time              `json:"created_at" doc:"Creation timestamp"`
 	UpdatedAt *time.Time             `json:"updated_at,omitempty" doc:"Last update timestamp"`
 }
@@ -20,10 +17,10 @@ type User struct {
 // Post represents a post in the system
 type Post struct {
 	ID        string                 `json:"id" example:"post123" doc:"Post ID"`
-	UserID    string                 `json:"user_id" example:"user123" doc:"User ID who created the post"`
+	UserID    string                 `json:"user_id" example:"user123" doc:"User ID who created the post" ddao:",index"`
 	Title     string                 `json:"title" example:"My First Post" doc:"Post title"`
 	Content   string                 `json:"content" example:"This is my first post content." doc:"Post content"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty" doc:"Post metadata"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty" doc:"Post metadata" ddao:",nullable"`
 	Published bool                   `json:"published" example:"false" doc:"Whether the post is published"`
 	CreatedAt time.Time              `json:"created_at" doc:"Creation timestamp"`
 	UpdatedAt *time.Time             `json:"updated_at,omitempty" doc:"Last update timestamp"`
@@ -104,118 +101,5 @@ type DeleteResponse struct {
 	}
 }
 
-// Helper functions to convert between models and objects
-
-func userToObject(user User) *object.Object {
-	obj := object.New()
-	obj.TableName = "users"
-	obj.ID = user.ID
-	obj.Fields = map[string]any{
-		"email":      user.Email,
-		"name":       user.Name,
-		"created_at": user.CreatedAt.Format(time.RFC3339),
-	}
-
-	if user.Profile != nil {
-		profileJSON, _ := json.Marshal(user.Profile)
-		obj.Fields["profile"] = string(profileJSON)
-	}
-
-	if user.UpdatedAt != nil {
-		obj.Fields["updated_at"] = user.UpdatedAt.Format(time.RFC3339)
-	}
-
-	return obj
-}
-
-func objectToUser(obj *object.Object) User {
-	user := User{
-		ID: obj.ID,
-	}
-
-	if email, exists := obj.GetString("email"); exists {
-		user.Email = email
-	}
-	if name, exists := obj.GetString("name"); exists {
-		user.Name = name
-	}
-	if createdAtStr, exists := obj.GetString("created_at"); exists {
-		if t, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-			user.CreatedAt = t
-		}
-	}
-	if updatedAtStr, exists := obj.GetString("updated_at"); exists && updatedAtStr != "" {
-		if t, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
-			user.UpdatedAt = &t
-		}
-	}
-	if profileStr, exists := obj.GetString("profile"); exists && profileStr != "" {
-		var profile map[string]interface{}
-		if err := json.Unmarshal([]byte(profileStr), &profile); err == nil {
-			user.Profile = profile
-		}
-	}
-
-	return user
-}
-
-func postToObject(post Post) *object.Object {
-	obj := object.New()
-	obj.TableName = "posts"
-	obj.ID = post.ID
-	obj.Fields = map[string]any{
-		"user_id":    post.UserID,
-		"title":      post.Title,
-		"content":    post.Content,
-		"published":  post.Published,
-		"created_at": post.CreatedAt.Format(time.RFC3339),
-	}
-
-	if post.Metadata != nil {
-		metadataJSON, _ := json.Marshal(post.Metadata)
-		obj.Fields["metadata"] = string(metadataJSON)
-	}
-
-	if post.UpdatedAt != nil {
-		obj.Fields["updated_at"] = post.UpdatedAt.Format(time.RFC3339)
-	}
-
-	return obj
-}
-
-func objectToPost(obj *object.Object) Post {
-	post := Post{
-		ID: obj.ID,
-	}
-
-	if userID, exists := obj.GetString("user_id"); exists {
-		post.UserID = userID
-	}
-	if title, exists := obj.GetString("title"); exists {
-		post.Title = title
-	}
-	if content, exists := obj.GetString("content"); exists {
-		post.Content = content
-	}
-	if published, exists := obj.GetBool("published"); exists {
-		post.Published = published
-	}
-	if createdAtStr, exists := obj.GetString("created_at"); exists {
-		if t, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-			post.CreatedAt = t
-		}
-	}
-	if updatedAtStr, exists := obj.GetString("updated_at"); exists && updatedAtStr != "" {
-		if t, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
-			post.UpdatedAt = &t
-		}
-	}
-	if metadataStr, exists := obj.GetString("metadata"); exists && metadataStr != "" {
-		var metadata map[string]interface{}
-		if err := json.Unmarshal([]byte(metadataStr), &metadata); err == nil {
-			post.Metadata = metadata
-		}
-	}
-
-	return post
-}
\ No newline at end of file
+func (User) TableName() string { return "users" }
+func (Post) TableName() string { return "posts" }
@@ -6,10 +6,11 @@ import (
 	"log"
 	"time"
 
+	"github.com/jadedragon942/ddao"
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/orm"
 	"github.com/jadedragon942/ddao/schema"
-	"github.com/jadedragon942/ddao/storage/s3"
+	_ "github.com/jadedragon942/ddao/storage/s3"
 )
 
 func main() {
@@ -24,9 +25,8 @@ func main() {
 	// Create schema
 	schema := createDemoSchema()
 
-	// Initialize S3 storage
-	storage := s3.New()
-	err := storage.Connect(ctx, *connStr)
+	// Initialize S3 storage, which the s3:// scheme selects
+	storage, err := ddao.Open(ctx, *connStr)
 	if err != nil {
 		log.Fatalf("Failed to connect to S3: %v", err)
 	}
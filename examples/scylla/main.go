@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
+	"github.com/jadedragon942/ddao"
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/orm"
 	"github.com/jadedragon942/ddao/schema"
-	"github.com/jadedragon942/ddao/storage/scylla"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	_ "github.com/jadedragon942/ddao/storage/scylla"
 )
 
 func createUserSchema() *schema.Schema {
@@ -72,16 +75,13 @@ func main() {
 	// Create schema
 	schema := createUserSchema()
 
-	// Initialize ScyllaDB storage
-	storage := scylla.New()
-
 	// Connect to ScyllaDB
-	// Format: "host:port,host:port/keyspace?consistency=level&timeout=duration"
-	// For local development: "localhost:9042/myapp?consistency=one&timeout=5s"
-	// For production cluster: "node1:9042,node2:9042,node3:9042/myapp?consistency=quorum&timeout=10s"
-	connStr := "localhost:9042/scylla_example?consistency=one&timeout=5s"
+	// Format: "scylla://host:port,host:port/keyspace?consistency=level&timeout=duration"
+	// For local development: "scylla://localhost:9042/myapp?consistency=one&timeout=5s"
+	// For production cluster: "scylla://node1:9042,node2:9042,node3:9042/myapp?consistency=quorum&timeout=10s"
+	connStr := "scylla://localhost:9042/scylla_example?consistency=one&timeout=5s"
 
-	err := storage.Connect(ctx, connStr)
+	storage, err := ddao.Open(ctx, connStr)
 	if err != nil {
 		log.Fatal("Failed to connect to ScyllaDB:", err)
 	}
@@ -197,14 +197,14 @@ func performCRUDOperations(ctx context.Context, orm *orm.ORM) {
 	log.Printf("✅ User deleted: %t", deleted)
 
 	// Verify deletion
-	deletedUser, err := orm.FindByID(ctx, "users", "scylla_user_123")
-	if err != nil {
+	_, err = orm.FindByID(ctx, "users", "scylla_user_123")
+	switch {
+	case errors.Is(err, ddaoerrors.ErrNotFound):
+		log.Println("✅ Confirmed: User was successfully deleted")
+	case err != nil:
 		log.Printf("Find deleted user failed: %v", err)
 		return
-	}
-	if deletedUser == nil {
-		log.Println("✅ Confirmed: User was successfully deleted")
-	} else {
+	default:
 		log.Println("⚠️ Warning: User still exists after deletion")
 	}
 
@@ -9,14 +9,24 @@ import (
 	"time"
 
 	"github.com/jadedragon942/ddao/orm"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 type AuthService struct {
-	orm *orm.ORM
+	users    *orm.Repo[User]
+	sessions *orm.Repo[Session]
 }
 
-func NewAuthService(orm *orm.ORM) *AuthService {
-	return &AuthService{orm: orm}
+func NewAuthService(o *orm.ORM) (*AuthService, error) {
+	users, err := orm.NewRepo[User](o)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := orm.NewRepo[Session](o)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthService{users: users, sessions: sessions}, nil
 }
 
 func (a *AuthService) Register(username, email, password string) (*User, error) {
@@ -37,8 +47,7 @@ func (a *AuthService) Register(username, email, password string) (*User, error)
 		return nil, err
 	}
 
-	obj := userToObject(user)
-	_, _, err = a.orm.Insert(context.Background(), obj)
+	err = a.users.Insert(context.Background(), user)
 	if err != nil {
 		return nil, err
 	}
@@ -48,15 +57,14 @@ func (a *AuthService) Register(username, email, password string) (*User, error)
 }
 
 func (a *AuthService) Login(username, password string) (*User, string, error) {
-	obj, err := a.orm.FindByKey(context.Background(), "users", "username", username)
+	user, err := a.users.FindByKey(context.Background(), "username", username)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, "", errors.New("invalid username or password")
+	}
 	if err != nil {
 		return nil, "", err
 	}
-	if obj == nil {
-		return nil, "", errors.New("invalid username or password")
-	}
 
-	user := objectToUser(obj)
 	if err := user.CheckPassword(password); err != nil {
 		return nil, "", errors.New("invalid username or password")
 	}
@@ -83,8 +91,7 @@ func (a *AuthService) CreateSession(userID string) (string, error) {
 		ExpiresAt: time.Now().Add(24 * time.Hour),
 	}
 
-	obj := sessionToObject(session)
-	_, _, err = a.orm.Insert(context.Background(), obj)
+	err = a.sessions.Insert(context.Background(), session)
 	if err != nil {
 		return "", err
 	}
@@ -93,35 +100,33 @@ func (a *AuthService) CreateSession(userID string) (string, error) {
 }
 
 func (a *AuthService) ValidateSession(sessionID string) (*User, error) {
-	obj, err := a.orm.FindByID(context.Background(), "sessions", sessionID)
+	session, err := a.sessions.Get(context.Background(), sessionID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, errors.New("invalid session")
+	}
 	if err != nil {
 		return nil, err
 	}
-	if obj == nil {
-		return nil, errors.New("invalid session")
-	}
 
-	session := objectToSession(obj)
 	if time.Now().After(session.ExpiresAt) {
-		a.orm.DeleteByID(context.Background(), "sessions", sessionID)
+		a.sessions.Delete(context.Background(), sessionID)
 		return nil, errors.New("session expired")
 	}
 
-	userObj, err := a.orm.FindByID(context.Background(), "users", session.UserID)
+	user, err := a.users.Get(context.Background(), session.UserID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, errors.New("user not found")
+	}
 	if err != nil {
 		return nil, err
 	}
-	if userObj == nil {
-		return nil, errors.New("user not found")
-	}
 
-	user := objectToUser(userObj)
 	user.Password = ""
 	return user, nil
 }
 
 func (a *AuthService) Logout(sessionID string) error {
-	_, err := a.orm.DeleteByID(context.Background(), "sessions", sessionID)
+	_, err := a.sessions.Delete(context.Background(), sessionID)
 	return err
 }
 
@@ -7,6 +7,13 @@ require (
 	golang.org/x/crypto v0.37.0
 )
 
-require github.com/mattn/go-sqlite3 v1.14.28 // indirect
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/pgx/v5 v5.7.6 // indirect
+	github.com/mattn/go-sqlite3 v1.14.28 // indirect
+	golang.org/x/text v0.24.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
 
 replace github.com/jadedragon942/ddao => ../../
@@ -28,8 +28,14 @@ func main() {
 
 	ormInstance := orm.New(schema).WithStorage(storage)
 
-	authService := NewAuthService(ormInstance)
-	wikiService := NewWikiService(ormInstance)
+	authService, err := NewAuthService(ormInstance)
+	if err != nil {
+		log.Fatalf("Failed to create auth service: %v", err)
+	}
+	wikiService, err := NewWikiService(ormInstance)
+	if err != nil {
+		log.Fatalf("Failed to create wiki service: %v", err)
+	}
 	handlers := NewWikiHandlers(authService, wikiService)
 
 	http.HandleFunc("/", handlers.HomeHandler)
@@ -7,14 +7,20 @@ import (
 	"time"
 
 	"github.com/jadedragon942/ddao/orm"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 type WikiService struct {
-	orm *orm.ORM
+	pages *orm.Repo[WikiPage]
 }
 
-func NewWikiService(orm *orm.ORM) *WikiService {
-	return &WikiService{orm: orm}
+func NewWikiService(o *orm.ORM) (*WikiService, error) {
+	pages, err := orm.NewRepo[WikiPage](o)
+	if err != nil {
+		return nil, err
+	}
+	return &WikiService{pages: pages}, nil
 }
 
 func (w *WikiService) CreatePage(title, content, authorID string) (*WikiPage, error) {
@@ -32,8 +38,7 @@ func (w *WikiService) CreatePage(title, content, authorID string) (*WikiPage, er
 		UpdatedAt: time.Now(),
 	}
 
-	obj := wikiPageToObject(page)
-	_, _, err = w.orm.Insert(context.Background(), obj)
+	err = w.pages.Insert(context.Background(), page)
 	if err != nil {
 		return nil, err
 	}
@@ -42,27 +47,27 @@ func (w *WikiService) CreatePage(title, content, authorID string) (*WikiPage, er
 }
 
 func (w *WikiService) GetPage(pageID string) (*WikiPage, error) {
-	obj, err := w.orm.FindByID(context.Background(), "wiki_pages", pageID)
+	page, err := w.pages.Get(context.Background(), pageID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, errors.New("page not found")
+	}
 	if err != nil {
 		return nil, err
 	}
-	if obj == nil {
-		return nil, errors.New("page not found")
-	}
 
-	return objectToWikiPage(obj), nil
+	return page, nil
 }
 
 func (w *WikiService) GetPageByTitle(title string) (*WikiPage, error) {
-	obj, err := w.orm.FindByKey(context.Background(), "wiki_pages", "title", title)
+	page, err := w.pages.FindByKey(context.Background(), "title", title)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, errors.New("page not found")
+	}
 	if err != nil {
 		return nil, err
 	}
-	if obj == nil {
-		return nil, errors.New("page not found")
-	}
 
-	return objectToWikiPage(obj), nil
+	return page, nil
 }
 
 func (w *WikiService) UpdatePage(pageID, title, content string) (*WikiPage, error) {
@@ -75,8 +80,7 @@ func (w *WikiService) UpdatePage(pageID, title, content string) (*WikiPage, erro
 	page.Content = content
 	page.UpdatedAt = time.Now()
 
-	obj := wikiPageToObject(page)
-	_, err = w.orm.Storage.Update(context.Background(), obj)
+	_, err = w.pages.Update(context.Background(), page)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +89,7 @@ func (w *WikiService) UpdatePage(pageID, title, content string) (*WikiPage, erro
 }
 
 func (w *WikiService) DeletePage(pageID string) error {
-	_, err := w.orm.DeleteByID(context.Background(), "wiki_pages", pageID)
+	_, err := w.pages.Delete(context.Background(), pageID)
 	return err
 }
 
@@ -94,21 +98,42 @@ func (w *WikiService) SearchPages(query string) ([]*WikiPage, error) {
 		return []*WikiPage{}, nil
 	}
 
-	results := []*WikiPage{}
-
 	searchTerms := strings.Fields(strings.ToLower(query))
 	if len(searchTerms) == 0 {
-		return results, nil
+		return []*WikiPage{}, nil
 	}
 
-	return results, nil
+	// Every term must appear in either the title or the content
+	conditions := make([]storage.Query, 0, len(searchTerms))
+	for _, term := range searchTerms {
+		pattern := "%" + term + "%"
+		conditions = append(conditions, storage.Or(
+			storage.Like("title", pattern),
+			storage.Like("content", pattern),
+		))
+	}
+
+	return w.findPages(storage.And(conditions...))
 }
 
 func (w *WikiService) GetAllPages() ([]*WikiPage, error) {
-	return []*WikiPage{}, nil
+	return w.findPages(storage.Query{})
 }
 
 func (w *WikiService) GetPagesByAuthor(authorID string) ([]*WikiPage, error) {
-	results := []*WikiPage{}
+	return w.findPages(storage.Eq("author_id", authorID))
+}
+
+func (w *WikiService) findPages(q storage.Query) ([]*WikiPage, error) {
+	pages, err := w.pages.Find(context.Background(), q)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*WikiPage, 0, len(pages))
+	for i := range pages {
+		results = append(results, &pages[i])
+	}
+
 	return results, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,176 @@
+package object
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TimeLayouts are the layouts AsTime parses, covering the ways backends
+// return datetime columns
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// The As functions convert a field value as read back from a backend, which
+// may return numbers, booleans, datetimes and JSON in several
+// representations, to one Go type. Unlike the Get methods they report why a
+// value cannot be converted. A nil value is an error; check for NULL first.
+
+// AsString converts value to a string; non-string values are formatted with fmt
+func AsString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", errNil
+	case string:
+		return v, nil
+	case *string:
+		if v == nil {
+			return "", errNil
+		}
+		return *v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// AsInt64 converts a number, numeric string or boolean to an int64
+func AsInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+	case []byte:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := AsFloat64(value)
+	if err != nil {
+		return 0, err
+	}
+	if f != float64(int64(f)) {
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+	return int64(f), nil
+}
+
+// AsFloat64 converts a number, numeric string or boolean to a float64
+func AsFloat64(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case json.Number:
+		return v.Float64()
+	case nil:
+		return 0, errNil
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", value)
+}
+
+// AsBool converts a boolean, a number (true unless zero) or a string
+// accepted by strconv.ParseBool to a bool
+func AsBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	case []byte:
+		return strconv.ParseBool(string(v))
+	}
+	f, err := AsFloat64(value)
+	if err != nil {
+		return false, err
+	}
+	return f != 0, nil
+}
+
+// AsTime converts a time.Time, or a string in one of TimeLayouts, to a time.Time
+func AsTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return parseTime(v)
+	case []byte:
+		return parseTime(string(v))
+	case nil:
+		return time.Time{}, errNil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to a time", value)
+}
+
+// AsBytes converts bytes or a string to a copy of its bytes
+func AsBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return append([]byte(nil), v...), nil
+	case string:
+		return []byte(v), nil
+	case nil:
+		return nil, errNil
+	}
+	return nil, fmt.Errorf("cannot convert %T to bytes", value)
+}
+
+// AsJSON returns the JSON encoding of a json column's value, which backends
+// return as a string, bytes, or already decoded
+func AsJSON(value any) (json.RawMessage, error) {
+	switch v := value.(type) {
+	case string:
+		return json.RawMessage(v), nil
+	case []byte:
+		return append(json.RawMessage(nil), v...), nil
+	case json.RawMessage:
+		return append(json.RawMessage(nil), v...), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+var errNil = errors.New("value is nil")
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range TimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", s)
+}
@@ -0,0 +1,69 @@
+package object
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsNumbers(t *testing.T) {
+	for _, value := range []any{int64(7), 7, float64(7), "7", []byte("7"), json.Number("7")} {
+		i, err := AsInt64(value)
+		require.NoError(t, err, "%T", value)
+		assert.Equal(t, int64(7), i)
+	}
+	_, err := AsInt64(7.5)
+	assert.Error(t, err)
+	_, err = AsInt64("seven")
+	assert.Error(t, err)
+
+	f, err := AsFloat64("2.5")
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, f)
+	_, err = AsFloat64(nil)
+	assert.Error(t, err)
+}
+
+func TestAsBool(t *testing.T) {
+	for value, expected := range map[any]bool{true: true, int64(0): false, int64(1): true, "true": true, "0": false} {
+		b, err := AsBool(value)
+		require.NoError(t, err, "%v", value)
+		assert.Equal(t, expected, b, "%v", value)
+	}
+	_, err := AsBool("maybe")
+	assert.Error(t, err)
+}
+
+func TestAsTime(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, value := range []any{expected, "2024-01-02T03:04:05Z", "2024-01-02 03:04:05+00:00", []byte("2024-01-02 03:04:05")} {
+		got, err := AsTime(value)
+		require.NoError(t, err, "%v", value)
+		assert.True(t, expected.Equal(got), "%v: got %v", value, got)
+	}
+	_, err := AsTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestAsStringBytesJSON(t *testing.T) {
+	s, err := AsString([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", s)
+	s, err = AsString(int64(3))
+	require.NoError(t, err)
+	assert.Equal(t, "3", s)
+
+	b, err := AsBytes("abc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abc"), b)
+
+	raw, err := AsJSON(`{"a":1}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(raw))
+	raw, err = AsJSON(map[string]any{"a": 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(raw))
+}
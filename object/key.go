@@ -0,0 +1,119 @@
+package object
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KeyPart is the value of one primary key column
+type KeyPart struct {
+	Column string
+	Value  any
+}
+
+// Key identifies an object by the values of its table's primary key columns,
+// in key order. Single-column keys such as the default "id" have one part.
+type Key []KeyPart
+
+// Columns returns the key's column names in key order
+func (k Key) Columns() []string {
+	columns := make([]string, len(k))
+	for i, part := range k {
+		columns[i] = part.Column
+	}
+	return columns
+}
+
+// Values returns the key's values in key order
+func (k Key) Values() []any {
+	values := make([]any, len(k))
+	for i, part := range k {
+		values[i] = part.Value
+	}
+	return values
+}
+
+// String returns the key in the form stored in Object.ID. A single-column key
+// is its value as is; a composite key joins its path-escaped values with "/",
+// so ParseKey can split it again. Times are written in UTC as RFC 3339 with
+// nanoseconds and bytes as unpadded URL-safe base64, so they parse back.
+func (k Key) String() string {
+	if len(k) == 1 {
+		return formatKeyValue(k[0].Value)
+	}
+	parts := make([]string, len(k))
+	for i, part := range k {
+		parts[i] = url.PathEscape(formatKeyValue(part.Value))
+	}
+	return strings.Join(parts, "/")
+}
+
+func formatKeyValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return base64.RawURLEncoding.EncodeToString(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// ParseKey splits an ID produced by Key.String into the values of columns.
+// The values are returned as strings.
+func ParseKey(columns []string, id string) (Key, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no key columns to parse id %q into", id)
+	}
+	if len(columns) == 1 {
+		return Key{{Column: columns[0], Value: id}}, nil
+	}
+
+	parts := strings.Split(id, "/")
+	if len(parts) != len(columns) {
+		return nil, fmt.Errorf("id %q has %d parts, want %d (%s)", id, len(parts), len(columns), strings.Join(columns, ", "))
+	}
+	key := make(Key, len(columns))
+	for i, part := range parts {
+		value, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q: %w", id, err)
+		}
+		key[i] = KeyPart{Column: columns[i], Value: value}
+	}
+	return key, nil
+}
+
+// Key returns the object's key over columns. Each part is read from Fields;
+// for a single-column key missing from Fields the ID is used instead, so
+// objects identified only by ID keep working.
+func (o *Object) Key(columns []string) (Key, error) {
+	key := make(Key, 0, len(columns))
+	for _, column := range columns {
+		value, ok := o.Fields[column]
+		if !ok || value == nil {
+			if len(columns) != 1 {
+				return nil, fmt.Errorf("object in table %s has no value for key column %s", o.TableName, column)
+			}
+			value = o.ID
+		}
+		key = append(key, KeyPart{Column: column, Value: value})
+	}
+	return key, nil
+}
+
+// SetIDFromKey sets ID from the key columns in Fields, if they are all present
+func (o *Object) SetIDFromKey(columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	for _, column := range columns {
+		if value, ok := o.Fields[column]; !ok || value == nil {
+			return
+		}
+	}
+	key, _ := o.Key(columns)
+	o.ID = key.String()
+}
@@ -0,0 +1,74 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyString(t *testing.T) {
+	assert.Equal(t, "42", Key{{Column: "id", Value: int64(42)}}.String())
+	assert.Equal(t, "a/b", Key{{Column: "id", Value: "a/b"}}.String())
+	assert.Equal(t, "eu/7", Key{{Column: "region", Value: "eu"}, {Column: "sku", Value: int64(7)}}.String())
+	assert.Equal(t, "north%2Feast/7", Key{{Column: "region", Value: "north/east"}, {Column: "sku", Value: 7}}.String())
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey([]string{"id"}, "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, Key{{Column: "id", Value: "a/b"}}, key)
+
+	key, err = ParseKey([]string{"region", "sku"}, "north%2Feast/7")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"region", "sku"}, key.Columns())
+	assert.Equal(t, []any{"north/east", "7"}, key.Values())
+
+	_, err = ParseKey([]string{"region", "sku"}, "eu")
+	assert.Error(t, err)
+	_, err = ParseKey([]string{"region", "sku"}, "eu/7/1")
+	assert.Error(t, err)
+	_, err = ParseKey(nil, "eu")
+	assert.Error(t, err)
+}
+
+func TestObjectKey(t *testing.T) {
+	obj := &Object{TableName: "stock", ID: "ignored", Fields: map[string]any{"region": "eu", "sku": int64(7)}}
+	key, err := obj.Key([]string{"region", "sku"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu/7", key.String())
+
+	// A single-column key falls back to the ID
+	obj = &Object{TableName: "users", ID: "u1", Fields: map[string]any{"name": "Ann"}}
+	key, err = obj.Key([]string{"id"})
+	require.NoError(t, err)
+	assert.Equal(t, "u1", key.String())
+
+	// A composite key cannot
+	obj = &Object{TableName: "stock", ID: "eu/7", Fields: map[string]any{"region": "eu"}}
+	_, err = obj.Key([]string{"region", "sku"})
+	assert.Error(t, err)
+}
+
+func TestSetIDFromKey(t *testing.T) {
+	obj := &Object{Fields: map[string]any{"region": "eu", "sku": int64(7)}}
+	obj.SetIDFromKey([]string{"region", "sku"})
+	assert.Equal(t, "eu/7", obj.ID)
+
+	// Objects missing a key column keep their ID
+	obj = &Object{ID: "keep", Fields: map[string]any{"region": "eu"}}
+	obj.SetIDFromKey([]string{"region", "sku"})
+	assert.Equal(t, "keep", obj.ID)
+}
+
+func TestKeyStringTimeAndBytes(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.FixedZone("CET", 3600))
+	assert.Equal(t, "2024-03-01T11:30:00.123456789Z", Key{{Column: "at", Value: at}}.String())
+	assert.Equal(t, "AP-_", Key{{Column: "hash", Value: []byte{0x00, 0xff, 0xbf}}}.String())
+
+	key := Key{{Column: "at", Value: at}, {Column: "hash", Value: []byte("a/b")}}
+	parsed, err := ParseKey(key.Columns(), key.String())
+	require.NoError(t, err)
+	assert.Equal(t, []any{"2024-03-01T11:30:00.123456789Z", "YS9i"}, parsed.Values())
+}
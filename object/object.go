@@ -9,6 +9,9 @@ type Object struct {
 	TableName string
 	ID        string // Unique identifier for the object
 	Fields    map[string]any
+	// Relations holds the related objects loaded with the object, by
+	// relation name; see orm.With
+	Relations map[string][]*Object
 }
 
 func New() *Object {
@@ -27,6 +30,31 @@ func (o *Object) SetField(fieldName string, value any) {
 	o.Fields[fieldName] = value
 }
 
+// Related returns the objects loaded for relation name, and whether the
+// relation was loaded
+func (o *Object) Related(name string) ([]*Object, bool) {
+	related, loaded := o.Relations[name]
+	return related, loaded
+}
+
+// RelatedOne returns the object loaded for a relation to at most one object,
+// such as a belongs-to relation, and whether there is one
+func (o *Object) RelatedOne(name string) (*Object, bool) {
+	related := o.Relations[name]
+	if len(related) == 0 {
+		return nil, false
+	}
+	return related[0], true
+}
+
+// SetRelated sets the objects loaded for relation name
+func (o *Object) SetRelated(name string, related []*Object) {
+	if o.Relations == nil {
+		o.Relations = make(map[string][]*Object)
+	}
+	o.Relations[name] = related
+}
+
 func (o *Object) GetTableName() string {
 	return o.TableName
 }
@@ -26,6 +26,25 @@ func TestSetGetField(t *testing.T) {
 	assert.False(t, exists)
 }
 
+func TestRelated(t *testing.T) {
+	obj := New()
+	_, loaded := obj.Related("posts")
+	assert.False(t, loaded)
+
+	author := New()
+	obj.SetRelated("author", []*Object{author})
+	obj.SetRelated("posts", []*Object{})
+
+	one, ok := obj.RelatedOne("author")
+	assert.True(t, ok)
+	assert.Same(t, author, one)
+	posts, loaded := obj.Related("posts")
+	assert.True(t, loaded)
+	assert.Empty(t, posts)
+	_, ok = obj.RelatedOne("posts")
+	assert.False(t, ok)
+}
+
 func TestSetGetTableName(t *testing.T) {
 	obj := New()
 	obj.SetTableName("test_table")
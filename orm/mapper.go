@@ -0,0 +1,321 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	nullTimeType   = reflect.TypeOf(sql.NullTime{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	scannerType    = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// mapper converts between a struct type and the objects of the table
+// schema.FromType derives from it
+type mapper struct {
+	table      *schema.TableSchema
+	columns    []schema.StructColumn
+	keyColumns []string
+	relations  []schema.StructRelation
+}
+
+// mappers caches the mapper of every struct type, keyed by reflect.Type
+var mappers sync.Map
+
+func mapperFor(t reflect.Type) (*mapper, error) {
+	if m, ok := mappers.Load(t); ok {
+		return m.(*mapper), nil
+	}
+	table, err := schema.FromType(t)
+	if err != nil {
+		return nil, err
+	}
+	if !table.HasDeclaredPrimaryKey() {
+		return nil, fmt.Errorf("%s has no primary key; tag one with pk or add an id field", t)
+	}
+	columns, err := schema.StructColumns(t)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := schema.StructRelations(t)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := mappers.LoadOrStore(t, &mapper{
+		table:      table,
+		columns:    columns,
+		keyColumns: table.PrimaryKeyColumns(),
+		relations:  relations,
+	})
+	return m.(*mapper), nil
+}
+
+// toObject returns the object storing the struct v points to
+func (m *mapper) toObject(v reflect.Value) (*object.Object, error) {
+	obj := object.New()
+	obj.TableName = m.table.TableName
+	for _, column := range m.columns {
+		field, err := v.FieldByIndexErr(column.Index)
+		if err != nil {
+			// A field of a nil embedded struct
+			obj.Fields[column.Column.Name] = nil
+			continue
+		}
+		value, err := encodeValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", m.table.TableName, column.Column.Name, err)
+		}
+		obj.Fields[column.Column.Name] = value
+	}
+	obj.SetIDFromKey(m.keyColumns)
+	return obj, nil
+}
+
+// fromObject sets the fields of the struct v points to from obj, and its
+// relation fields from the relations loaded with obj. Columns and relations
+// missing from obj leave their field unchanged.
+func (m *mapper) fromObject(obj *object.Object, v reflect.Value) error {
+	for _, column := range m.columns {
+		value, ok := obj.Fields[column.Column.Name]
+		if !ok {
+			continue
+		}
+		field, err := fieldByIndexAlloc(v, column.Index, value == nil)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", m.table.TableName, column.Column.Name, err)
+		}
+		if !field.IsValid() {
+			continue // A NULL column of a nil embedded struct
+		}
+		if err := decodeValue(field, value); err != nil {
+			return fmt.Errorf("%s.%s: %w", m.table.TableName, column.Column.Name, err)
+		}
+	}
+	for _, relation := range m.relations {
+		related, ok := obj.Related(relation.Name)
+		if !ok {
+			continue
+		}
+		if err := setRelation(v.FieldByIndex(relation.Index), related); err != nil {
+			return fmt.Errorf("%s relation %s: %w", m.table.TableName, relation.Name, err)
+		}
+	}
+	return nil
+}
+
+// setRelation sets a field tagged with the relation flag from the related
+// objects: a slice to all of them, and a struct or pointer to the first
+func setRelation(field reflect.Value, related []*object.Object) error {
+	if field.Kind() != reflect.Slice {
+		if len(related) == 0 {
+			field.SetZero()
+			return nil
+		}
+		return setRelated(field, related[0])
+	}
+	values := reflect.MakeSlice(field.Type(), len(related), len(related))
+	for i, obj := range related {
+		if err := setRelated(values.Index(i), obj); err != nil {
+			return err
+		}
+	}
+	field.Set(values)
+	return nil
+}
+
+// setRelated sets v, a struct or a pointer to one, from obj
+func setRelated(v reflect.Value, obj *object.Object) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setRelated(elem.Elem(), obj); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	m, err := mapperFor(v.Type())
+	if err != nil {
+		return err
+	}
+	return m.fromObject(obj, v)
+}
+
+// fieldByIndexAlloc returns the field of v at index, allocating the nil
+// embedded structs on the way, or an invalid value when skipNil is set and
+// one of them is nil
+func fieldByIndexAlloc(v reflect.Value, index []int, skipNil bool) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if skipNil {
+					return reflect.Value{}, nil
+				}
+				if !v.CanSet() {
+					return reflect.Value{}, errors.New("cannot allocate embedded struct")
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// encodeValue returns the value stored for field: nil for a nil pointer,
+// datetimes formatted as RFC 3339, and other values as their underlying
+// basic type, leaving maps, slices and structs for json columns as they are
+func encodeValue(field reflect.Value) (any, error) {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil, nil
+		}
+		field = field.Elem()
+	}
+
+	if field.Type().Implements(valuerType) && field.Type() != rawMessageType {
+		value, err := field.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := value.(time.Time); ok {
+			return t.Format(time.RFC3339Nano), nil
+		}
+		return value, nil
+	}
+	if field.Type() == timeType {
+		return field.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Bool:
+		return field.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(field.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 && field.Type() != rawMessageType {
+			if field.IsNil() {
+				return []byte{}, nil // Only nil pointers are stored as NULL
+			}
+			return field.Bytes(), nil
+		}
+	}
+	return field.Interface(), nil
+}
+
+// decodeValue sets field from value as read back from a backend
+func decodeValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := decodeValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if reflect.PointerTo(field.Type()).Implements(scannerType) {
+		if s, ok := value.(string); ok && field.Type() == nullTimeType {
+			t, err := object.AsTime(s)
+			if err != nil {
+				return err
+			}
+			value = t
+		}
+		return field.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+
+	switch {
+	case field.Type() == timeType:
+		t, err := object.AsTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case field.Type() == rawMessageType:
+		raw, err := object.AsJSON(value)
+		if err != nil {
+			return err
+		}
+		field.SetBytes(raw)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		b, err := object.AsBytes(value)
+		if err != nil {
+			return err
+		}
+		field.SetBytes(b)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		s, err := object.AsString(value)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := object.AsBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := object.AsInt64(value)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %s", i, field.Type())
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := object.AsInt64(value)
+		if err != nil {
+			return err
+		}
+		if i < 0 || field.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%d overflows %s", i, field.Type())
+		}
+		field.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := object.AsFloat64(value)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Interface:
+		raw, err := object.AsJSON(value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, field.Addr().Interface())
+	default:
+		return fmt.Errorf("cannot read %T into %s", value, field.Type())
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package orm
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
@@ -12,6 +11,9 @@ import (
 type ORM struct {
 	Schema  *schema.Schema
 	Storage storage.Storage
+
+	// relations holds the relations declared with AddRelation, by table and name
+	relations map[string]map[string]Relation
 }
 
 func New(schema *schema.Schema) *ORM {
@@ -33,18 +35,56 @@ func (orm *ORM) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, e
 	return orm.Storage.Upsert(ctx, obj)
 }
 
-func (orm *ORM) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
-	return orm.Storage.FindByKey(ctx, tblName, "id", id)
+func (orm *ORM) FindByID(ctx context.Context, tblName, id string, opts ...FindOption) (*object.Object, error) {
+	obj, err := orm.Storage.FindByID(ctx, tblName, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := orm.withRelations(ctx, orm.Storage, []*object.Object{obj}, opts); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (orm *ORM) FindByKey(ctx context.Context, tblName, key, value string, opts ...FindOption) (*object.Object, error) {
+	return orm.findOne(ctx, orm.Storage, tblName, key, value, opts)
+}
+
+func (orm *ORM) Find(ctx context.Context, tblName string, q storage.Query, opts ...FindOption) ([]*object.Object, error) {
+	return orm.find(ctx, orm.Storage, tblName, q, opts)
+}
+
+func (orm *ORM) List(ctx context.Context, tblName string, opts storage.ListOptions, findOpts ...FindOption) ([]*object.Object, string, error) {
+	objs, cursor, err := orm.Storage.List(ctx, tblName, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := orm.withRelations(ctx, orm.Storage, objs, findOpts); err != nil {
+		return nil, "", err
+	}
+	return objs, cursor, nil
 }
 
-func (orm *ORM) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
-	return orm.Storage.FindByKey(ctx, tblName, key, value)
+func (orm *ORM) FindRows(ctx context.Context, tblName string, q storage.Query) (storage.Rows, error) {
+	return orm.Storage.FindRows(ctx, tblName, q)
 }
 
 func (orm *ORM) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
 	return orm.Storage.DeleteByID(ctx, tblName, id)
 }
 
+func (orm *ORM) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return orm.Storage.InsertMany(ctx, objs, opts)
+}
+
+func (orm *ORM) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return orm.Storage.UpsertMany(ctx, objs, opts)
+}
+
+func (orm *ORM) DeleteMany(ctx context.Context, tblName string, ids []string, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return orm.Storage.DeleteMany(ctx, tblName, ids, opts)
+}
+
 func (orm *ORM) ResetConnection(ctx context.Context) error {
 	return orm.Storage.ResetConnection(ctx)
 }
@@ -54,38 +94,77 @@ func (orm *ORM) Connect(ctx context.Context, connStr string) error {
 }
 
 // Transaction support methods
-func (orm *ORM) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	return orm.Storage.BeginTx(ctx)
+func (orm *ORM) BeginTx(ctx context.Context) (storage.Tx, error) {
+	return orm.Storage.BeginTx(ctx, nil)
+}
+
+func (orm *ORM) CommitTx(tx storage.Tx) error {
+	return tx.Commit()
+}
+
+func (orm *ORM) RollbackTx(tx storage.Tx) error {
+	return tx.Rollback()
 }
 
-func (orm *ORM) CommitTx(tx *sql.Tx) error {
-	return orm.Storage.CommitTx(tx)
+func (orm *ORM) InsertTx(ctx context.Context, tx storage.Tx, obj *object.Object) ([]byte, bool, error) {
+	return tx.Insert(ctx, obj)
 }
 
-func (orm *ORM) RollbackTx(tx *sql.Tx) error {
-	return orm.Storage.RollbackTx(tx)
+func (orm *ORM) UpdateTx(ctx context.Context, tx storage.Tx, obj *object.Object) (bool, error) {
+	return tx.Update(ctx, obj)
 }
 
-func (orm *ORM) InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	return orm.Storage.InsertTx(ctx, tx, obj)
+func (orm *ORM) UpsertTx(ctx context.Context, tx storage.Tx, obj *object.Object) ([]byte, bool, error) {
+	return tx.Upsert(ctx, obj)
 }
 
-func (orm *ORM) UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error) {
-	return orm.Storage.UpdateTx(ctx, tx, obj)
+func (orm *ORM) FindByIDTx(ctx context.Context, tx storage.Tx, tblName, id string, opts ...FindOption) (*object.Object, error) {
+	obj, err := tx.FindByID(ctx, tblName, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := orm.withRelations(ctx, tx, []*object.Object{obj}, opts); err != nil {
+		return nil, err
+	}
+	return obj, nil
 }
 
-func (orm *ORM) UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	return orm.Storage.UpsertTx(ctx, tx, obj)
+func (orm *ORM) FindByKeyTx(ctx context.Context, tx storage.Tx, tblName, key, value string, opts ...FindOption) (*object.Object, error) {
+	return orm.findOne(ctx, tx, tblName, key, value, opts)
 }
 
-func (orm *ORM) FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error) {
-	return orm.Storage.FindByIDTx(ctx, tx, tblName, id)
+func (orm *ORM) FindTx(ctx context.Context, tx storage.Tx, tblName string, q storage.Query, opts ...FindOption) ([]*object.Object, error) {
+	return orm.find(ctx, tx, tblName, q, opts)
 }
 
-func (orm *ORM) FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error) {
-	return orm.Storage.FindByKeyTx(ctx, tx, tblName, key, value)
+func (orm *ORM) DeleteByIDTx(ctx context.Context, tx storage.Tx, tblName, id string) (bool, error) {
+	return tx.DeleteByID(ctx, tblName, id)
 }
 
-func (orm *ORM) DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error) {
-	return orm.Storage.DeleteByIDTx(ctx, tx, tblName, id)
+// keyFinder is the part of storage.Storage and storage.Tx the ORM reads with
+type keyFinder interface {
+	finder
+	FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error)
+}
+
+func (orm *ORM) findOne(ctx context.Context, f keyFinder, tblName, key, value string, opts []FindOption) (*object.Object, error) {
+	obj, err := f.FindByKey(ctx, tblName, key, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := orm.withRelations(ctx, f, []*object.Object{obj}, opts); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (orm *ORM) find(ctx context.Context, f finder, tblName string, q storage.Query, opts []FindOption) ([]*object.Object, error) {
+	objs, err := f.Find(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+	if err := orm.withRelations(ctx, f, objs, opts); err != nil {
+		return nil, err
+	}
+	return objs, nil
 }
@@ -2,10 +2,12 @@ package orm
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 	sqliteStorage "github.com/jadedragon942/ddao/storage/sqlite"
 )
 
@@ -160,8 +162,8 @@ func TestORMTransactions(t *testing.T) {
 
 		// Verify object does not exist after rollback
 		foundObj, err := o.FindByID(ctx, "people", "orm_tx_user2")
-		if err != nil {
-			t.Fatalf("unexpected error finding rolled back object via ORM: %v", err)
+		if !errors.Is(err, ddaoerrors.ErrNotFound) {
+			t.Fatalf("expected not found error for rolled back object via ORM, got %v", err)
 		}
 		if foundObj != nil {
 			t.Error("rolled back object should not be visible via ORM")
@@ -277,8 +279,8 @@ func TestORMTransactions(t *testing.T) {
 
 		// Verify object is deleted after commit
 		foundObj, err := o.FindByID(ctx, "people", "orm_tx_user4")
-		if err != nil {
-			t.Fatalf("unexpected error finding deleted object via ORM: %v", err)
+		if !errors.Is(err, ddaoerrors.ErrNotFound) {
+			t.Fatalf("expected not found error for deleted object via ORM, got %v", err)
 		}
 		if foundObj != nil {
 			t.Error("deleted object should not be visible via ORM")
@@ -343,3 +345,41 @@ func TestORMTransactions(t *testing.T) {
 		}
 	})
 }
+
+func TestORMFindByIDCustomPrimaryKey(t *testing.T) {
+	sch := schema.New()
+	table := schema.NewTableSchema("countries")
+	table.AddField(schema.ColumnData{Name: "code", DataType: "text", PrimaryKey: true})
+	table.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	sch.AddTable(table)
+
+	o := New(sch).WithStorage(sqliteStorage.New())
+	ctx := context.Background()
+	if err := o.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("Failed to connect to storage: %v", err)
+	}
+	defer o.ResetConnection(ctx)
+	if err := o.Storage.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	country := object.New()
+	country.TableName = "countries"
+	country.ID = "fr"
+	country.Fields["name"] = "France"
+	if _, _, err := o.Insert(ctx, country); err != nil {
+		t.Fatalf("Failed to insert object: %v", err)
+	}
+
+	found, err := o.FindByID(ctx, "countries", "fr")
+	if err != nil {
+		t.Fatalf("Failed to find object by ID: %v", err)
+	}
+	if name, _ := found.GetString("name"); name != "France" {
+		t.Fatalf("Expected name France, got %q", name)
+	}
+
+	if _, err := o.FindByID(ctx, "countries", "de"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
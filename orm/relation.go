@@ -0,0 +1,456 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// RelationKind is how the objects of a relation are related
+type RelationKind int
+
+const (
+	// BelongsTo relates an object to the object its foreign key references
+	BelongsTo RelationKind = iota
+	// HasMany relates an object to the objects whose foreign key references it
+	HasMany
+	// ManyToMany relates an object to objects through the rows of a join
+	// table referencing both
+	ManyToMany
+)
+
+func (k RelationKind) String() string {
+	switch k {
+	case BelongsTo:
+		return "belongs-to"
+	case HasMany:
+		return "has-many"
+	case ManyToMany:
+		return "many-to-many"
+	}
+	return fmt.Sprintf("RelationKind(%d)", int(k))
+}
+
+// Relation relates objects of Table to objects of Target whose
+// TargetColumns hold the values of Columns. A ManyToMany relation matches
+// them through the rows of the Through table instead: Columns against
+// ThroughColumns, and ThroughTargetColumns against TargetColumns.
+type Relation struct {
+	Name    string
+	Kind    RelationKind
+	Table   string
+	Columns []string
+
+	Target        string
+	TargetColumns []string
+
+	Through              string
+	ThroughColumns       []string
+	ThroughTargetColumns []string
+}
+
+// One reports whether the relation relates an object to at most one object
+func (r Relation) One() bool {
+	return r.Kind == BelongsTo
+}
+
+// loadBatchSize is the number of keys each query loading a relation
+// matches, keeping IN lists within every database's limits
+const loadBatchSize = 500
+
+// FindOption changes how objects are read
+type FindOption func(*findOptions)
+
+type findOptions struct {
+	relations []string
+}
+
+// With loads the named relations of the objects read, attaching the related
+// objects to object.Object.Relations, or to the fields of a Repo's type
+// tagged with the relation flag. A dotted name loads a relation of the
+// related objects too, as in With("posts.comments"). Each relation is
+// loaded with one query per 500 objects, whatever the number of objects.
+func With(relations ...string) FindOption {
+	return func(o *findOptions) {
+		o.relations = append(o.relations, relations...)
+	}
+}
+
+// withRelations loads the relations opts name for objs with f
+func (orm *ORM) withRelations(ctx context.Context, f finder, objs []*object.Object, opts []FindOption) error {
+	var o findOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return orm.load(ctx, f, objs, o.relations)
+}
+
+// finder is the part of storage.Storage and storage.Tx loading relations uses
+type finder interface {
+	Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error)
+}
+
+// AddRelation declares a relation the schema's foreign keys do not imply,
+// or replaces one they do under the same name
+func (orm *ORM) AddRelation(rel Relation) error {
+	if rel.Name == "" {
+		return fmt.Errorf("relation of %s has no name", rel.Table)
+	}
+	if strings.Contains(rel.Name, ".") {
+		return fmt.Errorf("relation name %q cannot contain a dot", rel.Name)
+	}
+	tables := []string{rel.Table, rel.Target}
+	if rel.Kind == ManyToMany {
+		tables = append(tables, rel.Through)
+	}
+	for _, table := range tables {
+		if _, ok := orm.Schema.GetTable(table); !ok {
+			return fmt.Errorf("relation %s: table %q is not defined", rel.Name, table)
+		}
+	}
+	if len(rel.Columns) == 0 || len(rel.Columns) != len(rel.TargetColumns) {
+		return fmt.Errorf("relation %s: matches %d columns to %d", rel.Name, len(rel.Columns), len(rel.TargetColumns))
+	}
+	if rel.Kind == ManyToMany && (len(rel.ThroughColumns) != len(rel.Columns) || len(rel.ThroughTargetColumns) != len(rel.TargetColumns)) {
+		return fmt.Errorf("relation %s: the columns of %s do not match the columns it joins", rel.Name, rel.Through)
+	}
+
+	if orm.relations == nil {
+		orm.relations = make(map[string]map[string]Relation)
+	}
+	if orm.relations[rel.Table] == nil {
+		orm.relations[rel.Table] = make(map[string]Relation)
+	}
+	orm.relations[rel.Table][rel.Name] = rel
+	return nil
+}
+
+// AddManyToMany declares a many-to-many relation through the join table
+// through, from the table its foreign key named from references to the
+// table its foreign key named to references
+func (orm *ORM) AddManyToMany(name, through, from, to string) error {
+	table, ok := orm.Schema.GetTable(through)
+	if !ok {
+		return fmt.Errorf("relation %s: table %q is not defined", name, through)
+	}
+	var fromKey, toKey *schema.ForeignKey
+	for i, fk := range table.ForeignKeys {
+		switch fk.Name {
+		case from:
+			fromKey = &table.ForeignKeys[i]
+		case to:
+			toKey = &table.ForeignKeys[i]
+		}
+	}
+	if fromKey == nil || toKey == nil {
+		return fmt.Errorf("relation %s: %s has no foreign keys named %s and %s", name, through, from, to)
+	}
+	return orm.AddRelation(Relation{
+		Name:                 name,
+		Kind:                 ManyToMany,
+		Table:                fromKey.RefTable,
+		Columns:              fromKey.RefColumns,
+		Target:               toKey.RefTable,
+		TargetColumns:        toKey.RefColumns,
+		Through:              through,
+		ThroughColumns:       fromKey.Columns,
+		ThroughTargetColumns: toKey.Columns,
+	})
+}
+
+// Relations returns the relations of table, sorted by name: those declared
+// with AddRelation and AddManyToMany, and those the schema's foreign keys
+// imply. A foreign key of table is a belongs-to relation named after its
+// column without the _id suffix, as "author" for author_id, or after the
+// foreign key when it has several columns. A foreign key of another table
+// referencing table is a has-many relation named after that table, or after
+// the foreign key when that table references table more than once.
+func (orm *ORM) Relations(table string) []Relation {
+	byName := make(map[string]Relation)
+	if ts, ok := orm.Schema.GetTable(table); ok {
+		for _, fk := range ts.ForeignKeys {
+			name := fk.Name
+			if len(fk.Columns) == 1 {
+				name = strings.TrimSuffix(fk.Columns[0], "_id")
+			}
+			byName[name] = Relation{
+				Name:          name,
+				Kind:          BelongsTo,
+				Table:         table,
+				Columns:       fk.Columns,
+				Target:        fk.RefTable,
+				TargetColumns: fk.RefColumns,
+			}
+		}
+	}
+	for _, name := range orm.Schema.TableNames() {
+		ts, _ := orm.Schema.GetTable(name)
+		refs := ts.References(table)
+		for _, fk := range refs {
+			relName := name
+			if len(refs) > 1 {
+				relName = fk.Name
+			}
+			if _, ok := byName[relName]; ok {
+				continue
+			}
+			byName[relName] = Relation{
+				Name:          relName,
+				Kind:          HasMany,
+				Table:         table,
+				Columns:       fk.RefColumns,
+				Target:        name,
+				TargetColumns: fk.Columns,
+			}
+		}
+	}
+	for name, rel := range orm.relations[table] {
+		byName[name] = rel
+	}
+
+	relations := make([]Relation, 0, len(byName))
+	for _, rel := range byName {
+		relations = append(relations, rel)
+	}
+	sort.Slice(relations, func(i, j int) bool { return relations[i].Name < relations[j].Name })
+	return relations
+}
+
+// Relation returns the relation of table named name; see Relations
+func (orm *ORM) Relation(table, name string) (Relation, bool) {
+	if rel, ok := orm.relations[table][name]; ok {
+		return rel, true
+	}
+	for _, rel := range orm.Relations(table) {
+		if rel.Name == name {
+			return rel, true
+		}
+	}
+	return Relation{}, false
+}
+
+// Load loads the named relations of objs, which have already been read, as
+// With does for the objects Find returns
+func (orm *ORM) Load(ctx context.Context, objs []*object.Object, relations ...string) error {
+	return orm.load(ctx, orm.Storage, objs, relations)
+}
+
+// LoadTx is Load within tx
+func (orm *ORM) LoadTx(ctx context.Context, tx storage.Tx, objs []*object.Object, relations ...string) error {
+	return orm.load(ctx, tx, objs, relations)
+}
+
+// load loads the relations, given as dotted paths, of objs with f
+func (orm *ORM) load(ctx context.Context, f finder, objs []*object.Object, paths []string) error {
+	if len(objs) == 0 || len(paths) == 0 {
+		return nil
+	}
+
+	// Load each relation once, then the rest of the paths through it
+	var names []string
+	nested := make(map[string][]string)
+	for _, path := range paths {
+		name, rest, _ := strings.Cut(path, ".")
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+
+	for _, table := range tablesOf(objs) {
+		tableObjs := objectsOf(objs, table)
+		for _, name := range names {
+			rel, ok := orm.Relation(table, name)
+			if !ok {
+				return fmt.Errorf("%s has no relation %q", table, name)
+			}
+			related, err := orm.loadRelation(ctx, f, rel, tableObjs)
+			if err != nil {
+				return fmt.Errorf("failed to load %s of %s: %w", name, table, err)
+			}
+			if err := orm.load(ctx, f, related, nested[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadRelation attaches the objects rel relates objs to, and returns them
+func (orm *ORM) loadRelation(ctx context.Context, f finder, rel Relation, objs []*object.Object) ([]*object.Object, error) {
+	// The keys of the target objects each object relates to
+	targetKeys := make(map[*object.Object][]string, len(objs))
+	var keys [][]any
+	seen := make(map[string]bool)
+	if rel.Kind == ManyToMany {
+		rows, err := findMatching(ctx, f, rel.Through, rel.ThroughColumns, keyValues(objs, rel.Columns))
+		if err != nil {
+			return nil, err
+		}
+		byKey := make(map[string][]string)
+		for _, row := range rows {
+			values, ok := columnValues(row, rel.ThroughTargetColumns)
+			if !ok {
+				continue
+			}
+			from, _ := columnValues(row, rel.ThroughColumns)
+			target := keyString(values)
+			byKey[keyString(from)] = append(byKey[keyString(from)], target)
+			if !seen[target] {
+				seen[target] = true
+				keys = append(keys, values)
+			}
+		}
+		for _, obj := range objs {
+			if values, ok := columnValues(obj, rel.Columns); ok {
+				targetKeys[obj] = byKey[keyString(values)]
+			}
+		}
+	} else {
+		for _, obj := range objs {
+			values, ok := columnValues(obj, rel.Columns)
+			if !ok {
+				continue
+			}
+			key := keyString(values)
+			targetKeys[obj] = []string{key}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, values)
+			}
+		}
+	}
+
+	targets, err := findMatching(ctx, f, rel.Target, rel.TargetColumns, keys)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string][]*object.Object)
+	for _, target := range targets {
+		if values, ok := columnValues(target, rel.TargetColumns); ok {
+			byKey[keyString(values)] = append(byKey[keyString(values)], target)
+		}
+	}
+
+	for _, obj := range objs {
+		related := []*object.Object{}
+		for _, key := range targetKeys[obj] {
+			related = append(related, byKey[key]...)
+		}
+		if rel.One() && len(related) > 1 {
+			related = related[:1]
+		}
+		obj.SetRelated(rel.Name, related)
+	}
+	return targets, nil
+}
+
+// findMatching returns the objects of table whose columns hold one of keys,
+// querying loadBatchSize keys at a time
+func findMatching(ctx context.Context, f finder, table string, columns []string, keys [][]any) ([]*object.Object, error) {
+	var objs []*object.Object
+	for start := 0; start < len(keys); start += loadBatchSize {
+		batch := keys[start:min(start+loadBatchSize, len(keys))]
+		found, err := f.Find(ctx, table, matchKeys(columns, batch))
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, found...)
+	}
+	return objs, nil
+}
+
+// matchKeys returns the query matching rows whose columns hold one of keys:
+// an IN query for a single column, and an OR of each key otherwise
+func matchKeys(columns []string, keys [][]any) storage.Query {
+	if len(columns) == 1 {
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return storage.In(columns[0], values...)
+	}
+	matches := make([]storage.Query, len(keys))
+	for i, key := range keys {
+		eqs := make([]storage.Query, len(columns))
+		for j, column := range columns {
+			eqs[j] = storage.Eq(column, key[j])
+		}
+		matches[i] = storage.And(eqs...)
+	}
+	return storage.Or(matches...)
+}
+
+// keyValues returns the distinct values of columns in objs
+func keyValues(objs []*object.Object, columns []string) [][]any {
+	var keys [][]any
+	seen := make(map[string]bool)
+	for _, obj := range objs {
+		values, ok := columnValues(obj, columns)
+		if !ok || seen[keyString(values)] {
+			continue
+		}
+		seen[keyString(values)] = true
+		keys = append(keys, values)
+	}
+	return keys
+}
+
+// columnValues returns the values of columns in obj, reading the id column
+// from its ID when it is not a field, and reports false if any is NULL
+func columnValues(obj *object.Object, columns []string) ([]any, bool) {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		value, ok := obj.Fields[column]
+		if !ok && column == "id" {
+			value, ok = obj.ID, obj.ID != ""
+		}
+		if !ok || value == nil {
+			return nil, false
+		}
+		values[i] = value
+	}
+	return values, true
+}
+
+// keyString returns the values of a key as one comparable string, so keys
+// read back as different types, such as int64 and string, still match
+func keyString(values []any) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i], _ = object.AsString(value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// tablesOf returns the tables of objs in order of first appearance
+func tablesOf(objs []*object.Object) []string {
+	var tables []string
+	seen := make(map[string]bool)
+	for _, obj := range objs {
+		if !seen[obj.TableName] {
+			seen[obj.TableName] = true
+			tables = append(tables, obj.TableName)
+		}
+	}
+	return tables
+}
+
+// objectsOf returns the objects of objs in table
+func objectsOf(objs []*object.Object, table string) []*object.Object {
+	var tableObjs []*object.Object
+	for _, obj := range objs {
+		if obj.TableName == table {
+			tableObjs = append(tableObjs, obj)
+		}
+	}
+	return tableObjs
+}
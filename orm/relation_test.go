@@ -0,0 +1,296 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	sqliteStorage "github.com/jadedragon942/ddao/storage/sqlite"
+)
+
+type relUser struct {
+	ID    string
+	Name  string
+	Posts []relPost `ddao:"posts,relation"`
+}
+
+func (relUser) TableName() string { return "users" }
+
+type relPost struct {
+	ID       string
+	Title    string
+	AuthorID *string    `ddao:",references=users(id)"`
+	Author   *relUser   `ddao:"author,relation"`
+	Tags     []*relTag  `ddao:"tags,relation"`
+	Comments []relReply `ddao:"comments,relation"`
+}
+
+func (relPost) TableName() string { return "posts" }
+
+type relReply struct {
+	ID     string
+	PostID string `ddao:",references=posts(id)"`
+	Body   string
+}
+
+func (relReply) TableName() string { return "comments" }
+
+type relTag struct {
+	ID    string
+	Label string
+}
+
+func (relTag) TableName() string { return "tags" }
+
+type relPostTag struct {
+	PostID string `ddao:",pk,references=posts(id)"`
+	TagID  string `ddao:",pk,references=tags(id)"`
+}
+
+func (relPostTag) TableName() string { return "post_tags" }
+
+// countingFinder counts the queries loading relations runs
+type countingFinder struct {
+	finder
+	queries []string
+}
+
+func (c *countingFinder) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	c.queries = append(c.queries, tblName)
+	return c.finder.Find(ctx, tblName, q)
+}
+
+func newRelationORM(t *testing.T) *ORM {
+	t.Helper()
+	sch := schema.New()
+	sch.AddTable(schema.MustFromStruct[relUser]())
+	sch.AddTable(schema.MustFromStruct[relPost]())
+	sch.AddTable(schema.MustFromStruct[relReply]())
+	sch.AddTable(schema.MustFromStruct[relTag]())
+	sch.AddTable(schema.MustFromStruct[relPostTag]())
+
+	ctx := context.Background()
+	o := New(sch).WithStorage(sqliteStorage.New())
+	if err := o.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { o.ResetConnection(ctx) })
+	if err := o.Storage.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	if err := o.AddManyToMany("tags", "post_tags", "fk_post_tags_post_id", "fk_post_tags_tag_id"); err != nil {
+		t.Fatalf("AddManyToMany failed: %v", err)
+	}
+
+	insert := func(table string, fields map[string]any) {
+		t.Helper()
+		obj := object.New()
+		obj.TableName = table
+		obj.Fields = fields
+		if id, ok := fields["id"].(string); ok {
+			obj.ID = id
+		}
+		if _, _, err := o.Insert(ctx, obj); err != nil {
+			t.Fatalf("failed to insert into %s: %v", table, err)
+		}
+	}
+	insert("users", map[string]any{"id": "ada", "name": "Ada"})
+	insert("users", map[string]any{"id": "bob", "name": "Bob"})
+	insert("posts", map[string]any{"id": "p1", "title": "Engines", "author_id": "ada"})
+	insert("posts", map[string]any{"id": "p2", "title": "Notes", "author_id": "ada"})
+	insert("posts", map[string]any{"id": "p3", "title": "Anonymous", "author_id": nil})
+	insert("comments", map[string]any{"id": "c1", "post_id": "p1", "body": "Great"})
+	insert("comments", map[string]any{"id": "c2", "post_id": "p1", "body": "Agreed"})
+	insert("tags", map[string]any{"id": "t1", "label": "math"})
+	insert("tags", map[string]any{"id": "t2", "label": "history"})
+	insert("post_tags", map[string]any{"post_id": "p1", "tag_id": "t1"})
+	insert("post_tags", map[string]any{"post_id": "p1", "tag_id": "t2"})
+	insert("post_tags", map[string]any{"post_id": "p2", "tag_id": "t2"})
+	return o
+}
+
+// relatedIDs returns the IDs of the objects loaded for relation name, sorted
+func relatedIDs(t *testing.T, obj *object.Object, name string) []string {
+	t.Helper()
+	related, ok := obj.Related(name)
+	if !ok {
+		t.Fatalf("expected %s of %s to be loaded", name, obj.ID)
+	}
+	ids := []string{}
+	for _, r := range related {
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRelations(t *testing.T) {
+	o := newRelationORM(t)
+
+	var names []string
+	for _, rel := range o.Relations("posts") {
+		names = append(names, fmt.Sprintf("%s %s %s", rel.Name, rel.Kind, rel.Target))
+	}
+	want := []string{"author belongs-to users", "comments has-many comments", "post_tags has-many post_tags", "tags many-to-many tags"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected relations %v, got %v", want, names)
+	}
+	if rel, ok := o.Relation("users", "posts"); !ok || rel.Kind != HasMany || !reflect.DeepEqual(rel.TargetColumns, []string{"author_id"}) {
+		t.Errorf("expected users to have many posts, got %+v", rel)
+	}
+
+	if err := o.AddRelation(Relation{Name: "editor", Table: "posts", Target: "editors", Columns: []string{"id"}, TargetColumns: []string{"id"}}); err == nil {
+		t.Error("expected an error for an undefined table")
+	}
+	if err := o.AddManyToMany("tags", "post_tags", "fk_post_tags_post_id", "fk_missing"); err == nil {
+		t.Error("expected an error for an undefined foreign key")
+	}
+}
+
+func TestFindWithRelations(t *testing.T) {
+	o := newRelationORM(t)
+	ctx := context.Background()
+
+	post, err := o.FindByID(ctx, "posts", "p1", With("author", "comments", "tags"))
+	if err != nil {
+		t.Fatalf("FindByID failed: %v", err)
+	}
+	if author, ok := post.RelatedOne("author"); !ok || author.ID != "ada" {
+		t.Errorf("expected ada as the author, got %v", author)
+	}
+	if ids := relatedIDs(t, post, "comments"); !reflect.DeepEqual(ids, []string{"c1", "c2"}) {
+		t.Errorf("expected comments c1 and c2, got %v", ids)
+	}
+	if ids := relatedIDs(t, post, "tags"); !reflect.DeepEqual(ids, []string{"t1", "t2"}) {
+		t.Errorf("expected tags t1 and t2, got %v", ids)
+	}
+
+	posts, err := o.Find(ctx, "posts", storage.Query{}, With("author"))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	for _, post := range posts {
+		author, ok := post.RelatedOne("author")
+		if post.ID == "p3" && ok {
+			t.Errorf("expected no author for p3, got %v", author)
+		}
+		if post.ID != "p3" && (!ok || author.ID != "ada") {
+			t.Errorf("expected ada as the author of %s, got %v", post.ID, author)
+		}
+	}
+
+	user, err := o.FindByID(ctx, "users", "ada", With("posts.comments", "posts.tags"))
+	if err != nil {
+		t.Fatalf("FindByID failed: %v", err)
+	}
+	if ids := relatedIDs(t, user, "posts"); !reflect.DeepEqual(ids, []string{"p1", "p2"}) {
+		t.Errorf("expected posts p1 and p2, got %v", ids)
+	}
+	posts, _ = user.Related("posts")
+	for _, post := range posts {
+		relatedIDs(t, post, "comments")
+		relatedIDs(t, post, "tags")
+	}
+
+	if _, err := o.FindByID(ctx, "users", "ada", With("friends")); err == nil || !strings.Contains(err.Error(), `no relation "friends"`) {
+		t.Errorf("expected an unknown relation error, got %v", err)
+	}
+}
+
+func TestLoadBatchesQueries(t *testing.T) {
+	o := newRelationORM(t)
+	ctx := context.Background()
+
+	posts, err := o.Find(ctx, "posts", storage.Query{})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	f := &countingFinder{finder: o.Storage}
+	if err := o.load(ctx, f, posts, []string{"author", "comments", "tags"}); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	want := []string{"users", "comments", "post_tags", "tags"}
+	if !reflect.DeepEqual(f.queries, want) {
+		t.Errorf("expected one query per table %v, got %v", want, f.queries)
+	}
+
+	users, err := o.Find(ctx, "users", storage.Query{})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if err := o.Load(ctx, users, "posts"); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for _, user := range users {
+		if _, ok := user.Related("posts"); !ok {
+			t.Errorf("expected the posts of %s to be loaded", user.ID)
+		}
+	}
+}
+
+func TestMatchKeys(t *testing.T) {
+	q := matchKeys([]string{"id"}, [][]any{{"a"}, {"b"}})
+	if !reflect.DeepEqual(q, storage.In("id", "a", "b")) {
+		t.Errorf("expected an IN query, got %+v", q)
+	}
+	q = matchKeys([]string{"region", "sku"}, [][]any{{"eu", "a"}})
+	want := storage.Or(storage.And(storage.Eq("region", "eu"), storage.Eq("sku", "a")))
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("expected %+v, got %+v", want, q)
+	}
+}
+
+func TestRepoWithRelations(t *testing.T) {
+	o := newRelationORM(t)
+	ctx := context.Background()
+
+	posts, err := NewRepo[relPost](o)
+	if err != nil {
+		t.Fatalf("NewRepo failed: %v", err)
+	}
+	post, err := posts.Get(ctx, "p1", With("author", "tags", "comments"))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if post.Author == nil || post.Author.Name != "Ada" {
+		t.Errorf("expected Ada as the author, got %+v", post.Author)
+	}
+	if len(post.Tags) != 2 || len(post.Comments) != 2 {
+		t.Errorf("expected 2 tags and 2 comments, got %+v and %+v", post.Tags, post.Comments)
+	}
+
+	post, err = posts.Get(ctx, "p2")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if post.Author != nil || post.Tags != nil {
+		t.Errorf("expected no relations loaded, got %+v", post)
+	}
+
+	users, err := NewRepo[relUser](o)
+	if err != nil {
+		t.Fatalf("NewRepo failed: %v", err)
+	}
+	all, err := users.Find(ctx, storage.Query{}, With("posts.author"))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	for _, user := range all {
+		switch user.ID {
+		case "ada":
+			if len(user.Posts) != 2 || user.Posts[0].Author == nil || user.Posts[0].Author.ID != "ada" {
+				t.Errorf("expected ada's 2 posts with their author, got %+v", user.Posts)
+			}
+		case "bob":
+			if user.Posts == nil || len(user.Posts) != 0 {
+				t.Errorf("expected bob to have no posts, got %+v", user.Posts)
+			}
+		}
+	}
+}
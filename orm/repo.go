@@ -0,0 +1,211 @@
+package orm
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// Repo stores values of struct type T in the table schema.FromStruct[T]
+// derives, mapping fields to object fields through the same ddao tags. The
+// mapping is worked out once per type and cached.
+//
+//	users, err := orm.NewRepo[User](o)
+//	err = users.Insert(ctx, &User{ID: "u1", Email: "a@example.com"})
+//	user, err := users.Get(ctx, "u1")
+//
+// T must have a primary key: a field tagged pk, or an id column. Values of
+// auto-increment keys are not read back on Insert. Get, FindByKey and Find
+// load the relations With names into the fields tagged with the relation
+// flag:
+//
+//	page, err := pages.Get(ctx, "p1", orm.With("author")) // sets page.Author
+type Repo[T any] struct {
+	orm    *ORM
+	mapper *mapper
+}
+
+// NewRepo returns the repository of T's table in orm's storage
+func NewRepo[T any](orm *ORM) (*Repo[T], error) {
+	m, err := mapperFor(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &Repo[T]{orm: orm, mapper: m}, nil
+}
+
+// Table returns the table storing T
+func (r *Repo[T]) Table() *schema.TableSchema {
+	return r.mapper.table
+}
+
+// ToObject returns the object storing v
+func (r *Repo[T]) ToObject(v *T) (*object.Object, error) {
+	return r.mapper.toObject(reflect.ValueOf(v).Elem())
+}
+
+// FromObject returns the value stored in obj
+func (r *Repo[T]) FromObject(obj *object.Object) (*T, error) {
+	v := new(T)
+	if err := r.mapper.fromObject(obj, reflect.ValueOf(v).Elem()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ID returns the ID identifying v, as taken by Get and Delete
+func (r *Repo[T]) ID(v *T) (string, error) {
+	obj, err := r.ToObject(v)
+	if err != nil {
+		return "", err
+	}
+	return obj.ID, nil
+}
+
+// Insert creates v, failing with a DuplicateKeyError if its key already exists
+func (r *Repo[T]) Insert(ctx context.Context, v *T) error {
+	return r.insert(ctx, r.orm.Storage, v)
+}
+
+// Get returns the value with id, or errors.ErrNotFound
+func (r *Repo[T]) Get(ctx context.Context, id string, opts ...FindOption) (*T, error) {
+	return r.get(ctx, r.orm.Storage, id, opts)
+}
+
+// FindByKey returns the value whose key column holds value, or errors.ErrNotFound
+func (r *Repo[T]) FindByKey(ctx context.Context, key, value string, opts ...FindOption) (*T, error) {
+	return r.findByKey(ctx, r.orm.Storage, key, value, opts)
+}
+
+// Update overwrites the stored value with v's key, reporting whether there was one
+func (r *Repo[T]) Update(ctx context.Context, v *T) (bool, error) {
+	return r.update(ctx, r.orm.Storage, v)
+}
+
+// Upsert creates v, or overwrites the stored value with v's key
+func (r *Repo[T]) Upsert(ctx context.Context, v *T) error {
+	return r.upsert(ctx, r.orm.Storage, v)
+}
+
+// Delete deletes the value with id, reporting whether there was one
+func (r *Repo[T]) Delete(ctx context.Context, id string) (bool, error) {
+	return r.orm.Storage.DeleteByID(ctx, r.mapper.table.TableName, id)
+}
+
+// Find returns the values matching q
+func (r *Repo[T]) Find(ctx context.Context, q storage.Query, opts ...FindOption) ([]T, error) {
+	return r.find(ctx, r.orm.Storage, q, opts)
+}
+
+// InsertTx is Insert within tx
+func (r *Repo[T]) InsertTx(ctx context.Context, tx storage.Tx, v *T) error {
+	return r.insert(ctx, tx, v)
+}
+
+// GetTx is Get within tx
+func (r *Repo[T]) GetTx(ctx context.Context, tx storage.Tx, id string, opts ...FindOption) (*T, error) {
+	return r.get(ctx, tx, id, opts)
+}
+
+// FindByKeyTx is FindByKey within tx
+func (r *Repo[T]) FindByKeyTx(ctx context.Context, tx storage.Tx, key, value string, opts ...FindOption) (*T, error) {
+	return r.findByKey(ctx, tx, key, value, opts)
+}
+
+// UpdateTx is Update within tx
+func (r *Repo[T]) UpdateTx(ctx context.Context, tx storage.Tx, v *T) (bool, error) {
+	return r.update(ctx, tx, v)
+}
+
+// UpsertTx is Upsert within tx
+func (r *Repo[T]) UpsertTx(ctx context.Context, tx storage.Tx, v *T) error {
+	return r.upsert(ctx, tx, v)
+}
+
+// DeleteTx is Delete within tx
+func (r *Repo[T]) DeleteTx(ctx context.Context, tx storage.Tx, id string) (bool, error) {
+	return tx.DeleteByID(ctx, r.mapper.table.TableName, id)
+}
+
+// FindTx is Find within tx
+func (r *Repo[T]) FindTx(ctx context.Context, tx storage.Tx, q storage.Query, opts ...FindOption) ([]T, error) {
+	return r.find(ctx, tx, q, opts)
+}
+
+// store is the part of storage.Storage and storage.Tx a Repo uses
+type store interface {
+	Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	Update(ctx context.Context, obj *object.Object) (bool, error)
+	Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	FindByID(ctx context.Context, tblName, id string) (*object.Object, error)
+	FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error)
+	Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error)
+}
+
+func (r *Repo[T]) insert(ctx context.Context, s store, v *T) error {
+	obj, err := r.ToObject(v)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.Insert(ctx, obj)
+	return err
+}
+
+func (r *Repo[T]) get(ctx context.Context, s store, id string, opts []FindOption) (*T, error) {
+	obj, err := s.FindByID(ctx, r.mapper.table.TableName, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.orm.withRelations(ctx, s, []*object.Object{obj}, opts); err != nil {
+		return nil, err
+	}
+	return r.FromObject(obj)
+}
+
+func (r *Repo[T]) findByKey(ctx context.Context, s store, key, value string, opts []FindOption) (*T, error) {
+	obj, err := s.FindByKey(ctx, r.mapper.table.TableName, key, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.orm.withRelations(ctx, s, []*object.Object{obj}, opts); err != nil {
+		return nil, err
+	}
+	return r.FromObject(obj)
+}
+
+func (r *Repo[T]) update(ctx context.Context, s store, v *T) (bool, error) {
+	obj, err := r.ToObject(v)
+	if err != nil {
+		return false, err
+	}
+	return s.Update(ctx, obj)
+}
+
+func (r *Repo[T]) upsert(ctx context.Context, s store, v *T) error {
+	obj, err := r.ToObject(v)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.Upsert(ctx, obj)
+	return err
+}
+
+func (r *Repo[T]) find(ctx context.Context, s store, q storage.Query, opts []FindOption) ([]T, error) {
+	objs, err := s.Find(ctx, r.mapper.table.TableName, q)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.orm.withRelations(ctx, s, objs, opts); err != nil {
+		return nil, err
+	}
+	values := make([]T, len(objs))
+	for i, obj := range objs {
+		if err := r.mapper.fromObject(obj, reflect.ValueOf(&values[i]).Elem()); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
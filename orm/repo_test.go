@@ -0,0 +1,238 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	sqliteStorage "github.com/jadedragon942/ddao/storage/sqlite"
+)
+
+type repoTimestamps struct {
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type repoAccount struct {
+	ID       string `ddao:"id"`
+	Email    string `ddao:",unique"`
+	Age      int
+	Score    float64
+	Active   bool
+	Nickname *string
+	Avatar   []byte
+	Profile  json.RawMessage
+	Tags     []string
+	Phone    sql.NullString
+	Secret   string `ddao:"-"`
+	repoTimestamps
+}
+
+func (repoAccount) TableName() string { return "accounts" }
+
+func newTestRepo(t *testing.T) (*ORM, *Repo[repoAccount]) {
+	t.Helper()
+	table, err := schema.FromStruct[repoAccount]()
+	if err != nil {
+		t.Fatalf("failed to derive table: %v", err)
+	}
+	sch := schema.New()
+	sch.AddTable(table)
+
+	ctx := context.Background()
+	o := New(sch).WithStorage(sqliteStorage.New())
+	if err := o.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { o.ResetConnection(ctx) })
+	if err := o.Storage.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	repo, err := NewRepo[repoAccount](o)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	return o, repo
+}
+
+func TestRepoCRUD(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newTestRepo(t)
+
+	nickname := "ada"
+	updated := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	account := &repoAccount{
+		ID:       "a1",
+		Email:    "ada@example.com",
+		Age:      36,
+		Score:    9.5,
+		Active:   true,
+		Nickname: &nickname,
+		Avatar:   []byte{1, 2, 3},
+		Profile:  json.RawMessage(`{"lang":"en"}`),
+		Tags:     []string{"admin", "ops"},
+		Phone:    sql.NullString{String: "555", Valid: true},
+		Secret:   "not stored",
+		repoTimestamps: repoTimestamps{
+			CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			UpdatedAt: &updated,
+		},
+	}
+	if err := repo.Insert(ctx, account); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	got, err := repo.Get(ctx, "a1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	expected := *account
+	expected.Secret = ""
+	if !got.CreatedAt.Equal(expected.CreatedAt) || got.UpdatedAt == nil || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("expected times %v and %v, got %v and %v", expected.CreatedAt, updated, got.CreatedAt, got.UpdatedAt)
+	}
+	got.repoTimestamps, expected.repoTimestamps = repoTimestamps{}, repoTimestamps{}
+	if !reflect.DeepEqual(*got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *got)
+	}
+
+	account.Age = 37
+	account.Nickname = nil
+	found, err := repo.Update(ctx, account)
+	if err != nil || !found {
+		t.Fatalf("Update failed: %v (found %v)", err, found)
+	}
+	got, err = repo.Get(ctx, "a1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Age != 37 || got.Nickname != nil {
+		t.Errorf("expected age 37 and no nickname, got %d and %v", got.Age, got.Nickname)
+	}
+
+	if err := repo.Upsert(ctx, &repoAccount{ID: "a2", Email: "bob@example.com", Age: 20}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	byEmail, err := repo.FindByKey(ctx, "email", "bob@example.com")
+	if err != nil || byEmail.ID != "a2" {
+		t.Errorf("expected FindByKey to find a2, got %+v (%v)", byEmail, err)
+	}
+	adults, err := repo.Find(ctx, storage.Gt("age", 30))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(adults) != 1 || adults[0].ID != "a1" {
+		t.Errorf("expected to find a1, got %+v", adults)
+	}
+
+	if err := repo.Insert(ctx, &repoAccount{ID: "a1", Email: "other@example.com"}); !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected a conflict inserting a1 again, got %v", err)
+	}
+
+	deleted, err := repo.Delete(ctx, "a1")
+	if err != nil || !deleted {
+		t.Fatalf("Delete failed: %v (deleted %v)", err, deleted)
+	}
+	if _, err := repo.Get(ctx, "a1"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestRepoTransactions(t *testing.T) {
+	ctx := context.Background()
+	o, repo := newTestRepo(t)
+
+	err := o.RunInTx(ctx, nil, func(tx storage.Tx) error {
+		if err := repo.InsertTx(ctx, tx, &repoAccount{ID: "t1", Email: "t1@example.com", Age: 1}); err != nil {
+			return err
+		}
+		account, err := repo.GetTx(ctx, tx, "t1")
+		if err != nil {
+			return err
+		}
+		account.Age++
+		if _, err := repo.UpdateTx(ctx, tx, account); err != nil {
+			return err
+		}
+		return repo.UpsertTx(ctx, tx, &repoAccount{ID: "t2", Email: "t2@example.com"})
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	got, err := repo.Get(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Age != 2 {
+		t.Errorf("expected age 2, got %d", got.Age)
+	}
+
+	tx, err := o.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	if _, err := repo.DeleteTx(ctx, tx, "t2"); err != nil {
+		t.Fatalf("DeleteTx failed: %v", err)
+	}
+	accounts, err := repo.FindTx(ctx, tx, storage.Eq("email", "t2@example.com"))
+	if err != nil {
+		t.Fatalf("FindTx failed: %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Errorf("expected t2 to be deleted within the transaction, got %+v", accounts)
+	}
+	tx.Rollback()
+
+	if _, err := repo.Get(ctx, "t2"); err != nil {
+		t.Errorf("expected t2 to survive the rollback, got %v", err)
+	}
+}
+
+func TestRepoCompositeKey(t *testing.T) {
+	type stockItem struct {
+		Region string `ddao:"region,pk"`
+		SKU    int64  `ddao:"sku,pk"`
+		Qty    uint
+	}
+	repo, err := NewRepo[stockItem](New(nil))
+	if err != nil {
+		t.Fatalf("NewRepo failed: %v", err)
+	}
+	id, err := repo.ID(&stockItem{Region: "eu west", SKU: 7})
+	if err != nil {
+		t.Fatalf("ID failed: %v", err)
+	}
+	if id != "eu%20west/7" {
+		t.Errorf("expected id eu%%20west/7, got %q", id)
+	}
+
+	item, err := repo.FromObject(&object.Object{Fields: map[string]any{"region": "eu", "sku": float64(7), "qty": "3"}})
+	if err != nil {
+		t.Fatalf("FromObject failed: %v", err)
+	}
+	if *item != (stockItem{Region: "eu", SKU: 7, Qty: 3}) {
+		t.Errorf("unexpected item %+v", *item)
+	}
+	if _, err := repo.FromObject(&object.Object{Fields: map[string]any{"qty": int64(-1)}}); err == nil {
+		t.Error("expected an error reading a negative quantity")
+	}
+}
+
+func TestNewRepoRequiresPrimaryKey(t *testing.T) {
+	type keyless struct {
+		Name string
+	}
+	if _, err := NewRepo[keyless](New(nil)); err == nil {
+		t.Error("expected an error for a struct without a primary key")
+	}
+}
@@ -0,0 +1,119 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jadedragon942/ddao/storage"
+)
+
+const (
+	// DefaultTxMaxAttempts is how many times RunInTx runs a transaction when TxOptions.MaxAttempts is not set
+	DefaultTxMaxAttempts = 5
+	// DefaultTxBackoff is the delay before the first retry when TxOptions.Backoff is not set
+	DefaultTxBackoff = 10 * time.Millisecond
+	// DefaultTxMaxBackoff caps the delay between retries when TxOptions.MaxBackoff is not set
+	DefaultTxMaxBackoff = time.Second
+)
+
+// TxOptions configures RunInTx
+type TxOptions struct {
+	// Isolation is the isolation level; sql.LevelDefault leaves it to the backend
+	Isolation sql.IsolationLevel
+	// ReadOnly rejects writes made through the transaction
+	ReadOnly bool
+	// MaxAttempts bounds how many times the transaction is run, including the first attempt
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles after every attempt
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+}
+
+func (opts *TxOptions) withDefaults() TxOptions {
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultTxMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultTxBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultTxMaxBackoff
+	}
+	return o
+}
+
+// RunInTx runs fn inside a transaction, committing if it returns nil and
+// rolling back if it returns an error or panics. When the storage reports the
+// failure as retryable (see storage.RetryClassifier), such as a serialization
+// failure on CockroachDB or a write conflict on TiDB, the whole transaction is
+// run again after a jittered exponential backoff, up to opts.MaxAttempts times.
+// fn may therefore run more than once and must not have side effects outside
+// the transaction. A nil opts uses the defaults.
+//
+//	err := o.RunInTx(ctx, nil, func(tx storage.Tx) error {
+//		from, err := tx.FindByID(ctx, "accounts", fromID)
+//		...
+//		_, err = tx.Update(ctx, from)
+//		return err
+//	})
+func (orm *ORM) RunInTx(ctx context.Context, opts *TxOptions, fn func(tx storage.Tx) error) error {
+	o := opts.withDefaults()
+	txOpts := &storage.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+
+	backoff := o.Backoff
+	for attempt := 1; ; attempt++ {
+		err := orm.runTx(ctx, txOpts, fn)
+		if err == nil {
+			return nil
+		}
+		if !orm.isRetryable(err) {
+			return err
+		}
+		if attempt >= o.MaxAttempts {
+			return fmt.Errorf("transaction failed after %d attempts: %w", attempt, err)
+		}
+
+		// Jitter keeps competing transactions from retrying in lockstep
+		delay := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, o.MaxBackoff)
+	}
+}
+
+// runTx makes a single attempt at running fn in a transaction
+func (orm *ORM) runTx(ctx context.Context, opts *storage.TxOptions, fn func(tx storage.Tx) error) error {
+	tx, err := orm.Storage.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (orm *ORM) isRetryable(err error) bool {
+	classifier, ok := orm.Storage.(storage.RetryClassifier)
+	return ok && classifier.IsRetryable(err)
+}
@@ -0,0 +1,192 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	sqliteStorage "github.com/jadedragon942/ddao/storage/sqlite"
+)
+
+var errConflict = errors.New("conflict")
+
+// conflictStorage reports errConflict as retryable, standing in for a backend
+// that surfaces serialization failures
+type conflictStorage struct {
+	storage.Storage
+}
+
+func (s conflictStorage) IsRetryable(err error) bool {
+	return errors.Is(err, errConflict)
+}
+
+func newTxTestORM(t *testing.T) *ORM {
+	t.Helper()
+
+	sch := getTestSchema()
+	store := sqliteStorage.New()
+	o := New(sch).WithStorage(conflictStorage{store})
+
+	ctx := context.Background()
+	if err := o.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("Failed to connect to storage: %v", err)
+	}
+	t.Cleanup(func() { o.ResetConnection(ctx) })
+
+	if err := store.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+	return o
+}
+
+func person(id, name string) *object.Object {
+	return &object.Object{
+		TableName: "people",
+		ID:        id,
+		Fields:    map[string]any{"name": name},
+	}
+}
+
+func TestRunInTx(t *testing.T) {
+	ctx := context.Background()
+	fast := &TxOptions{Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	t.Run("Commit", func(t *testing.T) {
+		o := newTxTestORM(t)
+
+		err := o.RunInTx(ctx, nil, func(tx storage.Tx) error {
+			_, _, err := tx.Insert(ctx, person("run_tx_commit", "Committed"))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("RunInTx failed: %v", err)
+		}
+
+		found, err := o.FindByID(ctx, "people", "run_tx_commit")
+		if err != nil {
+			t.Fatalf("failed to find committed object: %v", err)
+		}
+		if found == nil {
+			t.Fatal("committed object not found")
+		}
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		o := newTxTestORM(t)
+		errFailed := errors.New("failed")
+
+		attempts := 0
+		err := o.RunInTx(ctx, fast, func(tx storage.Tx) error {
+			attempts++
+			if _, _, err := tx.Insert(ctx, person("run_tx_error", "Rolled back")); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("expected errFailed, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("non-retryable error should not be retried, ran %d times", attempts)
+		}
+
+		found, err := o.FindByID(ctx, "people", "run_tx_error")
+		if !errors.Is(err, ddaoerrors.ErrNotFound) {
+			t.Fatalf("expected not found error for rolled back object, got %v", err)
+		}
+		if found != nil {
+			t.Error("rolled back object should not be visible")
+		}
+	})
+
+	t.Run("RollbackOnPanic", func(t *testing.T) {
+		o := newTxTestORM(t)
+
+		func() {
+			defer func() {
+				if p := recover(); p != "boom" {
+					t.Errorf("expected panic to propagate, got %v", p)
+				}
+			}()
+			o.RunInTx(ctx, nil, func(tx storage.Tx) error {
+				if _, _, err := tx.Insert(ctx, person("run_tx_panic", "Panicked")); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+
+		found, err := o.FindByID(ctx, "people", "run_tx_panic")
+		if !errors.Is(err, ddaoerrors.ErrNotFound) {
+			t.Fatalf("expected not found error for rolled back object, got %v", err)
+		}
+		if found != nil {
+			t.Error("object inserted before a panic should be rolled back")
+		}
+	})
+
+	t.Run("RetryRetryableError", func(t *testing.T) {
+		o := newTxTestORM(t)
+
+		attempts := 0
+		err := o.RunInTx(ctx, fast, func(tx storage.Tx) error {
+			attempts++
+			if _, _, err := tx.Insert(ctx, person("run_tx_retry", "Retried")); err != nil {
+				return err
+			}
+			if attempts < 3 {
+				return errConflict
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunInTx failed: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+
+		found, err := o.FindByID(ctx, "people", "run_tx_retry")
+		if err != nil {
+			t.Fatalf("failed to find committed object: %v", err)
+		}
+		if found == nil {
+			t.Fatal("object from the successful attempt not found")
+		}
+	})
+
+	t.Run("MaxAttempts", func(t *testing.T) {
+		o := newTxTestORM(t)
+		opts := *fast
+		opts.MaxAttempts = 2
+
+		attempts := 0
+		err := o.RunInTx(ctx, &opts, func(tx storage.Tx) error {
+			attempts++
+			return errConflict
+		})
+		if !errors.Is(err, errConflict) {
+			t.Fatalf("expected errConflict, got %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		o := newTxTestORM(t)
+		cctx, cancel := context.WithCancel(ctx)
+
+		err := o.RunInTx(cctx, &TxOptions{Backoff: time.Hour}, func(tx storage.Tx) error {
+			cancel()
+			return errConflict
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	})
+}
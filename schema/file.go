@@ -0,0 +1,554 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is the version of the schema file format Save writes. Load
+// reads it and older versions, including the unversioned dump of a Schema's
+// fields that earlier releases wrote.
+//
+// A schema file lists the tables in order, each with its columns in order:
+//
+//	version: 1
+//	database: shop
+//	tables:
+//	  - name: products
+//	    comment: Items for sale
+//	    primary_key: [id]
+//	    columns:
+//	      - {name: id, type: integer, auto_increment: true}
+//	      - {name: sku, type: varchar(32), unique: true}
+//	      - {name: price, type: real, default: 0}
+//	      - {name: category, type: text, nullable: true, index: true}
+//	      - {name: added, type: timestamp, default: {expr: CURRENT_TIMESTAMP}}
+//	    indexes:
+//	      - {name: idx_products_category_price, columns: [category, price]}
+//	  - name: reviews
+//	    columns:
+//	      - {name: product_id, type: integer}
+//	      - {name: body, type: text}
+//	    foreign_keys:
+//	      - {name: fk_reviews_product, columns: [product_id], ref_table: products, ref_columns: [id], on_delete: cascade}
+//
+// Column keys other than name and type are optional: nullable, default (a
+// string, number or boolean, or {expr: ...} for an Expr), comment, unique,
+// index and auto_increment. A
+// table without primary_key is keyed on the implicit "id" text column. The
+// on_delete and on_update actions of foreign keys are optional, and written
+// as in SQL: no action, restrict, cascade, set null or set default. JSON
+// files use the same keys.
+const FormatVersion = 1
+
+type fileSchema struct {
+	Version  int         `json:"version" yaml:"version"`
+	Database string      `json:"database,omitempty" yaml:"database,omitempty"`
+	Tables   []fileTable `json:"tables" yaml:"tables"`
+}
+
+type fileTable struct {
+	Name        string           `json:"name" yaml:"name"`
+	Comment     string           `json:"comment,omitempty" yaml:"comment,omitempty"`
+	PrimaryKey  []string         `json:"primary_key,omitempty" yaml:"primary_key,omitempty,flow"`
+	Columns     []fileColumn     `json:"columns" yaml:"columns"`
+	Indexes     []fileIndex      `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	ForeignKeys []fileForeignKey `json:"foreign_keys,omitempty" yaml:"foreign_keys,omitempty"`
+}
+
+type fileColumn struct {
+	Name          string `json:"name" yaml:"name"`
+	Type          string `json:"type" yaml:"type"`
+	Nullable      bool   `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Default       any    `json:"default,omitempty" yaml:"default,omitempty"`
+	Comment       string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Unique        bool   `json:"unique,omitempty" yaml:"unique,omitempty"`
+	Index         bool   `json:"index,omitempty" yaml:"index,omitempty"`
+	AutoIncrement bool   `json:"auto_increment,omitempty" yaml:"auto_increment,omitempty"`
+}
+
+type fileIndex struct {
+	Name    string   `json:"name" yaml:"name"`
+	Columns []string `json:"columns" yaml:"columns,flow"`
+	Unique  bool     `json:"unique,omitempty" yaml:"unique,omitempty"`
+}
+
+type fileForeignKey struct {
+	Name       string   `json:"name" yaml:"name"`
+	Columns    []string `json:"columns" yaml:"columns,flow"`
+	RefTable   string   `json:"ref_table" yaml:"ref_table"`
+	RefColumns []string `json:"ref_columns" yaml:"ref_columns,flow"`
+	OnDelete   string   `json:"on_delete,omitempty" yaml:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty" yaml:"on_update,omitempty"`
+}
+
+// legacySchema is the unversioned JSON encoding of a Schema's fields, as the
+// S3 backend wrote to _schema.json before the file format existed
+type legacySchema struct {
+	DatabaseName string
+	Tables       map[string]*TableSchema
+	FieldOrder   []string
+}
+
+// Load reads a schema file in JSON or YAML, telling them apart by the first
+// character, and validates it
+func Load(r io.Reader) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sch := New()
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = sch.UnmarshalJSON(data)
+	} else {
+		err = sch.unmarshalYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+// LoadFile reads the schema file at path; see Load
+func LoadFile(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sch, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sch, nil
+}
+
+// Save writes s as an indented JSON schema file
+func Save(w io.Writer, s *Schema) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// SaveYAML writes s as a YAML schema file
+func SaveYAML(w io.Writer, s *Schema) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// SaveFile writes s to path, as YAML if its extension is .yaml or .yml and
+// as JSON otherwise
+func SaveFile(path string, s *Schema) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = SaveYAML(w, s)
+	default:
+		err = Save(w, s)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// MarshalJSON encodes s in the schema file format
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toFile())
+}
+
+// UnmarshalJSON decodes and validates a JSON schema file, or the unversioned
+// encoding of a Schema's fields, into s
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("decoding schema: %w", err)
+	}
+	if probe.Version == 0 {
+		var legacy legacySchema
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return fmt.Errorf("decoding schema: %w", err)
+		}
+		return s.setFromLegacy(legacy)
+	}
+
+	var file fileSchema
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return fmt.Errorf("decoding schema: %w", err)
+	}
+	return s.setFromFile(file)
+}
+
+// MarshalYAML encodes s in the schema file format
+func (s *Schema) MarshalYAML() (any, error) {
+	return s.toFile(), nil
+}
+
+// UnmarshalYAML decodes and validates a YAML schema file into s
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	var file fileSchema
+	if err := node.Decode(&file); err != nil {
+		return fmt.Errorf("decoding schema: %w", err)
+	}
+	return s.setFromFile(file)
+}
+
+// unmarshalYAML is UnmarshalYAML rejecting unknown keys, which yaml.v3 only
+// does when decoding a document
+func (s *Schema) unmarshalYAML(data []byte) error {
+	var file fileSchema
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return fmt.Errorf("decoding schema: %w", err)
+	}
+	return s.setFromFile(file)
+}
+
+// TableNames returns the names of s's tables in FieldOrder, then the rest by
+// name. Schema files, generated code and rendered DDL list tables in this order.
+func (s *Schema) TableNames() []string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.tableOrder()
+}
+
+func (s *Schema) tableOrder() []string {
+	names := make([]string, 0, len(s.Tables))
+	for _, name := range s.FieldOrder {
+		if _, ok := s.Tables[name]; ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range s.Tables {
+		if !slices.Contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+func (s *Schema) toFile() fileSchema {
+	s.RLock()
+	defer s.RUnlock()
+	file := fileSchema{Version: FormatVersion, Database: s.DatabaseName, Tables: []fileTable{}}
+	for _, name := range s.tableOrder() {
+		ts := s.Tables[name]
+		if ts == nil {
+			continue
+		}
+		table := fileTable{Name: ts.TableName, Comment: ts.Comment, Columns: []fileColumn{}}
+		if table.Name == "" {
+			table.Name = name
+		}
+		if ts.HasDeclaredPrimaryKey() {
+			table.PrimaryKey = ts.PrimaryKeyColumns()
+		}
+		for _, column := range ts.ColumnNames() {
+			field := ts.Fields[column]
+			table.Columns = append(table.Columns, fileColumn{
+				Name:          column,
+				Type:          field.DataType,
+				Nullable:      field.Nullable,
+				Default:       field.Default,
+				Comment:       field.Comment,
+				Unique:        field.Unique,
+				Index:         field.Index,
+				AutoIncrement: field.AutoIncrement,
+			})
+		}
+		for _, index := range ts.IndexDefs {
+			table.Indexes = append(table.Indexes, fileIndex{Name: index.Name, Columns: index.Columns, Unique: index.Unique})
+		}
+		for _, fk := range ts.ForeignKeys {
+			table.ForeignKeys = append(table.ForeignKeys, fileForeignKey{
+				Name:       fk.Name,
+				Columns:    fk.Columns,
+				RefTable:   fk.RefTable,
+				RefColumns: fk.RefColumns,
+				OnDelete:   strings.ToLower(string(fk.OnDelete)),
+				OnUpdate:   strings.ToLower(string(fk.OnUpdate)),
+			})
+		}
+		file.Tables = append(file.Tables, table)
+	}
+	return file
+}
+
+// ColumnNames returns the table's columns in FieldOrder, then any missing
+// from it by name
+func (ts TableSchema) ColumnNames() []string {
+	names := make([]string, 0, len(ts.Fields))
+	for _, name := range ts.FieldOrder {
+		if _, ok := ts.Fields[name]; ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range ts.Fields {
+		if !slices.Contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+func (s *Schema) setFromFile(file fileSchema) error {
+	if file.Version > FormatVersion {
+		return fmt.Errorf("schema file version %d is newer than the supported version %d", file.Version, FormatVersion)
+	}
+	if file.Version < 1 {
+		return fmt.Errorf("schema file has no version")
+	}
+
+	loaded := New()
+	loaded.DatabaseName = file.Database
+	for _, table := range file.Tables {
+		ts := NewTableSchema(table.Name)
+		ts.Comment = table.Comment
+		for _, column := range table.Columns {
+			if column.Name == "" {
+				return fmt.Errorf("table %s: column without a name", table.Name)
+			}
+			ts.AddField(ColumnData{
+				Name:          column.Name,
+				DataType:      column.Type,
+				Nullable:      column.Nullable,
+				Default:       normalizeDefault(column.Default),
+				Comment:       column.Comment,
+				Unique:        column.Unique,
+				Index:         column.Index,
+				AutoIncrement: column.AutoIncrement,
+			})
+		}
+		if len(table.PrimaryKey) > 0 {
+			ts.SetPrimaryKey(table.PrimaryKey...)
+		}
+		for _, index := range table.Indexes {
+			if len(index.Columns) == 0 {
+				return fmt.Errorf("table %s: index %s has no columns", table.Name, index.Name)
+			}
+			ts.AddIndex(index.Name, index.Unique, index.Columns...)
+		}
+		for _, fk := range table.ForeignKeys {
+			onDelete, err := ParseReferentialAction(fk.OnDelete)
+			if err != nil {
+				return fmt.Errorf("table %s: foreign key %s: %w", table.Name, fk.Name, err)
+			}
+			onUpdate, err := ParseReferentialAction(fk.OnUpdate)
+			if err != nil {
+				return fmt.Errorf("table %s: foreign key %s: %w", table.Name, fk.Name, err)
+			}
+			ts.AddForeignKey(ForeignKey{
+				Name:       fk.Name,
+				Columns:    fk.Columns,
+				RefTable:   fk.RefTable,
+				RefColumns: fk.RefColumns,
+				OnDelete:   onDelete,
+				OnUpdate:   onUpdate,
+			})
+		}
+		if _, exists := loaded.Tables[table.Name]; exists {
+			return fmt.Errorf("table %s is defined twice", table.Name)
+		}
+		loaded.Tables[table.Name] = ts
+		loaded.FieldOrder = append(loaded.FieldOrder, table.Name)
+	}
+	return s.replaceWith(loaded)
+}
+
+func (s *Schema) setFromLegacy(legacy legacySchema) error {
+	loaded := New()
+	loaded.DatabaseName = legacy.DatabaseName
+	loaded.FieldOrder = legacy.FieldOrder
+	for name, table := range legacy.Tables {
+		if table == nil {
+			continue
+		}
+		if table.TableName == "" {
+			table.TableName = name
+		}
+		if table.Fields == nil {
+			table.Fields = make(map[string]ColumnData)
+		}
+		for column, field := range table.Fields {
+			field.Name = column
+			field.Default = normalizeDefault(field.Default)
+			table.Fields[column] = field
+		}
+		loaded.Tables[name] = table
+	}
+	return s.replaceWith(loaded)
+}
+
+// replaceWith validates loaded and moves its contents into s
+func (s *Schema) replaceWith(loaded *Schema) error {
+	if err := loaded.Validate(); err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.DatabaseName = loaded.DatabaseName
+	s.Tables = loaded.Tables
+	s.FieldOrder = loaded.FieldOrder
+	return nil
+}
+
+// MarshalJSON writes e as {"expr": ...}, which Load reads back as an Expr
+// rather than a string
+func (e Expr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"expr": string(e)})
+}
+
+// MarshalYAML writes e as {expr: ...}, as MarshalJSON does
+func (e Expr) MarshalYAML() (any, error) {
+	return map[string]string{"expr": string(e)}, nil
+}
+
+// normalizeDefault turns whole numbers decoded from JSON as float64 into
+// int64 and {expr: ...} into an Expr, so defaults read back as they were
+// written
+func normalizeDefault(value any) any {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case int:
+		return int64(v)
+	case map[string]any:
+		if expr, ok := v["expr"].(string); ok && len(v) == 1 {
+			return Expr(expr)
+		}
+	}
+	return value
+}
+
+// Validate reports every inconsistency in s: tables and columns without
+// names or types, columns listed twice or missing from FieldOrder, keys,
+// indexes and defaults that do not fit the table, and foreign keys
+// referencing missing tables or columns
+func (s *Schema) Validate() error {
+	s.RLock()
+	defer s.RUnlock()
+	var errs []error
+	for _, name := range s.tableOrder() {
+		ts := s.Tables[name]
+		if ts == nil {
+			errs = append(errs, fmt.Errorf("table %s: no definition", name))
+			continue
+		}
+		if name == "" || ts.TableName == "" {
+			errs = append(errs, fmt.Errorf("table without a name"))
+			continue
+		}
+		if ts.TableName != name {
+			errs = append(errs, fmt.Errorf("table %s: stored under the name %s", ts.TableName, name))
+		}
+		for _, err := range append(ts.validate(), s.validateReferences(*ts)...) {
+			errs = append(errs, fmt.Errorf("table %s: %w", ts.TableName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ts TableSchema) validate() []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, name := range ts.FieldOrder {
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("column %s is listed twice", name))
+		}
+		seen[name] = true
+		if _, ok := ts.Fields[name]; !ok {
+			errs = append(errs, fmt.Errorf("column %s is listed but not defined", name))
+		}
+	}
+	for _, name := range ts.ColumnNames() {
+		field := ts.Fields[name]
+		if !seen[name] && len(ts.FieldOrder) > 0 {
+			errs = append(errs, fmt.Errorf("column %s is missing from the column order", name))
+		}
+		if field.Name != name {
+			errs = append(errs, fmt.Errorf("column %s is stored under the name %s", field.Name, name))
+		}
+		if field.DataType == "" {
+			errs = append(errs, fmt.Errorf("column %s has no type", name))
+		}
+		switch v := field.Default.(type) {
+		case nil, string, bool, int, int64, float64:
+		case Expr:
+			if strings.TrimSpace(string(v)) == "" {
+				errs = append(errs, fmt.Errorf("column %s: default expression is empty", name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("column %s: default %v is not a string, number, boolean or expression", name, field.Default))
+		}
+	}
+
+	if ts.HasDeclaredPrimaryKey() {
+		keySeen := make(map[string]bool)
+		for _, column := range ts.PrimaryKeyColumns() {
+			field, ok := ts.Fields[column]
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("primary key column %s is not defined", column))
+			case field.Nullable:
+				errs = append(errs, fmt.Errorf("primary key column %s is nullable", column))
+			case keySeen[column]:
+				errs = append(errs, fmt.Errorf("primary key column %s is listed twice", column))
+			}
+			keySeen[column] = true
+		}
+	}
+
+	indexNames := make(map[string]bool)
+	for _, index := range ts.IndexDefs {
+		if indexNames[index.Name] {
+			errs = append(errs, fmt.Errorf("index %s is defined twice", index.Name))
+		}
+		indexNames[index.Name] = true
+		if len(index.Columns) == 0 {
+			errs = append(errs, fmt.Errorf("index %s has no columns", index.Name))
+		}
+		for _, column := range index.Columns {
+			if _, ok := ts.Fields[column]; !ok {
+				errs = append(errs, fmt.Errorf("index %s: column %s is not defined", index.Name, column))
+			}
+		}
+	}
+	return append(errs, ts.validateForeignKeys()...)
+}
@@ -0,0 +1,238 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fileTestSchema() *Schema {
+	sch := New()
+	sch.SetDatabaseName("shop")
+
+	products := NewTableSchema("products")
+	products.Comment = "Items for sale"
+	products.AddField(ColumnData{Name: "id", DataType: "integer", PrimaryKey: true, AutoIncrement: true})
+	products.AddField(ColumnData{Name: "sku", DataType: "varchar(32)", Unique: true})
+	products.AddField(ColumnData{Name: "price", DataType: "real", Default: int64(0)})
+	products.AddField(ColumnData{Name: "category", DataType: "text", Nullable: true, Index: true, Comment: "Shelf"})
+	products.AddField(ColumnData{Name: "added", DataType: "timestamp", Default: Expr("CURRENT_TIMESTAMP")})
+	products.AddIndex("idx_products_category_price", false, "category", "price")
+	sch.AddTable(products)
+
+	lines := NewTableSchema("order_lines")
+	lines.AddField(ColumnData{Name: "order_id", DataType: "integer"})
+	lines.AddField(ColumnData{Name: "line", DataType: "integer"})
+	lines.AddField(ColumnData{Name: "status", DataType: "text", Default: "open"})
+	lines.AddField(ColumnData{Name: "product_id", DataType: "integer"})
+	lines.SetPrimaryKey("order_id", "line")
+	lines.AddForeignKey(ForeignKey{Columns: []string{"product_id"}, RefTable: "products", RefColumns: []string{"id"}, OnDelete: Restrict, OnUpdate: Cascade})
+	sch.AddTable(lines)
+
+	notes := NewTableSchema("notes")
+	notes.AddField(ColumnData{Name: "body", DataType: "text"})
+	sch.AddTable(notes)
+	return sch
+}
+
+func assertSameSchema(t *testing.T, expected, got *Schema) {
+	t.Helper()
+	if got.DatabaseName != expected.DatabaseName {
+		t.Errorf("expected database %q, got %q", expected.DatabaseName, got.DatabaseName)
+	}
+	if len(got.Tables) != len(expected.Tables) {
+		t.Fatalf("expected %d tables, got %d", len(expected.Tables), len(got.Tables))
+	}
+	for name, want := range expected.Tables {
+		table := got.Tables[name]
+		if table == nil {
+			t.Errorf("missing table %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(table, want) {
+			t.Errorf("table %s: expected %+v, got %+v", name, want, table)
+		}
+	}
+}
+
+func TestSaveLoadJSON(t *testing.T) {
+	sch := fileTestSchema()
+	var buf bytes.Buffer
+	if err := Save(&buf, sch); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"version": 1`) {
+		t.Errorf("expected a versioned file, got %s", buf.String())
+	}
+
+	loaded, err := Load(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertSameSchema(t, sch, loaded)
+
+	// Tables keep the order they were saved in, so saving again is stable
+	var again bytes.Buffer
+	if err := Save(&again, loaded); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if again.String() != buf.String() {
+		t.Errorf("expected saving a loaded schema to be stable, got\n%s\nthen\n%s", buf.String(), again.String())
+	}
+}
+
+func TestSaveLoadYAML(t *testing.T) {
+	sch := fileTestSchema()
+	var buf bytes.Buffer
+	if err := SaveYAML(&buf, sch); err != nil {
+		t.Fatalf("SaveYAML failed: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertSameSchema(t, sch, loaded)
+
+	path := filepath.Join(t.TempDir(), "schema.yml")
+	if err := SaveFile(path, sch); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	fromFile, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	assertSameSchema(t, sch, fromFile)
+}
+
+func TestLoadHandWritten(t *testing.T) {
+	sch, err := Load(strings.NewReader(`
+version: 1
+tables:
+  - name: users
+    primary_key: [id]
+    columns:
+      - {name: id, type: text}
+      - {name: email, type: text, unique: true}
+      - {name: age, type: integer, nullable: true, default: 18}
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	users, ok := sch.GetTable("users")
+	if !ok {
+		t.Fatal("expected a users table")
+	}
+	if !reflect.DeepEqual(users.FieldOrder, []string{"id", "email", "age"}) {
+		t.Errorf("expected columns in file order, got %v", users.FieldOrder)
+	}
+	if !users.Fields["id"].PrimaryKey || users.Fields["age"].Default != int64(18) {
+		t.Errorf("unexpected columns %+v", users.Fields)
+	}
+}
+
+func TestLoadForeignKeys(t *testing.T) {
+	sch, err := Load(strings.NewReader(`
+version: 1
+tables:
+  - name: reviews
+    columns:
+      - {name: product_id, type: integer}
+    foreign_keys:
+      - {columns: [product_id], ref_table: products, ref_columns: [id], on_delete: set  null}
+  - name: products
+    columns:
+      - {name: id, type: integer}
+    primary_key: [id]
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []ForeignKey{{Name: "fk_reviews_product_id", Columns: []string{"product_id"}, RefTable: "products", RefColumns: []string{"id"}, OnDelete: SetNull}}
+	if got := sch.Tables["reviews"].ForeignKeys; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	_, err = Load(strings.NewReader(`
+version: 1
+tables:
+  - name: reviews
+    columns:
+      - {name: product_id, type: integer}
+    foreign_keys:
+      - {columns: [product_id], ref_table: reviews, ref_columns: [id], on_update: explode}
+`))
+	if err == nil || !strings.Contains(err.Error(), "unknown referential action") {
+		t.Errorf("expected an unknown action error, got %v", err)
+	}
+}
+
+func TestLoadLegacyJSON(t *testing.T) {
+	// The unversioned dump the S3 backend wrote before the file format
+	sch := fileTestSchema()
+	legacy, err := json.Marshal(legacySchema{DatabaseName: sch.DatabaseName, Tables: sch.Tables})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	loaded, err := Load(bytes.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertSameSchema(t, sch, loaded)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		error string
+	}{
+		{"newer version", `{"version": 2, "tables": []}`, "newer"},
+		{"unknown key", "version: 1\ntables:\n  - name: t\n    colums: []\n", "colums"},
+		{"untyped column", "version: 1\ntables:\n  - name: t\n    columns: [{name: a}]\n", "column a has no type"},
+		{"duplicate column", "version: 1\ntables:\n  - name: t\n    columns: [{name: a, type: text}, {name: a, type: text}]\n", "listed twice"},
+		{"unknown key column", "version: 1\ntables:\n  - name: t\n    primary_key: [b]\n    columns: [{name: a, type: text}]\n", "primary key column b is not defined"},
+		{"nullable key", "version: 1\ntables:\n  - name: t\n    primary_key: [a]\n    columns: [{name: a, type: text, nullable: true}]\n", "nullable"},
+		{"unknown index column", "version: 1\ntables:\n  - name: t\n    columns: [{name: a, type: text}]\n    indexes: [{name: i, columns: [b]}]\n", "index i: column b"},
+		{"duplicate table", "version: 1\ntables:\n  - {name: t, columns: [{name: a, type: text}]}\n  - {name: t, columns: [{name: a, type: text}]}\n", "defined twice"},
+		{"structured default", "version: 1\ntables:\n  - name: t\n    columns: [{name: a, type: json, default: {x: 1}}]\n", "default"},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.error) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.error, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	sch := fileTestSchema()
+	if err := sch.Validate(); err != nil {
+		t.Fatalf("expected a valid schema, got %v", err)
+	}
+	products := sch.Tables["products"]
+	products.FieldOrder = append(products.FieldOrder, "missing")
+	products.IndexDefs = append(products.IndexDefs, Index{Name: "idx_products_category_price", Columns: []string{"sku"}})
+	products.Fields["added"] = ColumnData{Name: "added", DataType: "timestamp", Default: Expr(" ")}
+	lines := sch.Tables["order_lines"]
+	lines.ForeignKeys[0].RefColumns = []string{"code"}
+	lines.AddForeignKey(ForeignKey{Columns: []string{"order_id", "line"}, RefTable: "orders", RefColumns: []string{"id"}})
+	err := sch.Validate()
+	if err == nil {
+		t.Fatal("expected an invalid schema")
+	}
+	for _, expected := range []string{
+		"column missing is listed but not defined",
+		"index idx_products_category_price is defined twice",
+		"column added: default expression is empty",
+		"foreign key fk_order_lines_product_id: referenced column products.code is not defined",
+		"foreign key fk_order_lines_order_id_line has 2 columns but references 1",
+		"foreign key fk_order_lines_order_id_line: referenced table orders is not defined",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %v", expected, err)
+		}
+	}
+}
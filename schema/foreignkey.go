@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ForeignKey references rows of another table: the values of Columns in a
+// row of this table match RefColumns of a row of RefTable
+type ForeignKey struct {
+	Name       string
+	Columns    []string // Referencing columns of this table, in key order
+	RefTable   string
+	RefColumns []string // Referenced columns of RefTable, matching Columns
+	OnDelete   ReferentialAction
+	OnUpdate   ReferentialAction
+}
+
+// ReferentialAction is what the database does to referencing rows when the
+// row they reference is deleted or its key changes. The zero value leaves
+// it to the database's default, which is NoAction for SQL databases.
+type ReferentialAction string
+
+const (
+	NoAction   ReferentialAction = "NO ACTION"
+	Restrict   ReferentialAction = "RESTRICT"
+	Cascade    ReferentialAction = "CASCADE"
+	SetNull    ReferentialAction = "SET NULL"
+	SetDefault ReferentialAction = "SET DEFAULT"
+)
+
+// ParseReferentialAction parses an action as written in SQL, case
+// insensitively. An empty string is the zero ReferentialAction.
+func ParseReferentialAction(s string) (ReferentialAction, error) {
+	action := ReferentialAction(strings.ToUpper(strings.Join(strings.Fields(s), " ")))
+	if !action.valid() {
+		return "", fmt.Errorf("unknown referential action %q", s)
+	}
+	return action, nil
+}
+
+func (a ReferentialAction) valid() bool {
+	switch a {
+	case "", NoAction, Restrict, Cascade, SetNull, SetDefault:
+		return true
+	}
+	return false
+}
+
+// AddForeignKey declares a foreign key. An empty name defaults to
+// "fk_<table>_<columns>".
+func (ts *TableSchema) AddForeignKey(fk ForeignKey) {
+	if len(fk.Columns) == 0 {
+		return
+	}
+	if fk.Name == "" {
+		fk.Name = "fk_" + ts.TableName + "_" + strings.Join(fk.Columns, "_")
+	}
+	ts.ForeignKeys = append(ts.ForeignKeys, fk)
+}
+
+// References returns the table's foreign keys referencing the table named
+// refTable
+func (ts TableSchema) References(refTable string) []ForeignKey {
+	var fks []ForeignKey
+	for _, fk := range ts.ForeignKeys {
+		if fk.RefTable == refTable {
+			fks = append(fks, fk)
+		}
+	}
+	return fks
+}
+
+// CreationOrder returns the table names in TableNames order, except that
+// each table comes after the tables its foreign keys reference, so tables
+// can be created in this order. References closing a cycle are ignored.
+func (s *Schema) CreationOrder() []string {
+	s.RLock()
+	defer s.RUnlock()
+	names := s.tableOrder()
+
+	const visiting, done = 1, 2
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(name string)
+	visit = func(name string) {
+		if state[name] != 0 {
+			return
+		}
+		state[name] = visiting
+		if ts := s.Tables[name]; ts != nil {
+			for _, fk := range ts.ForeignKeys {
+				if _, ok := s.Tables[fk.RefTable]; ok {
+					visit(fk.RefTable)
+				}
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return order
+}
+
+// validateForeignKeys reports foreign keys of ts that do not fit it
+func (ts TableSchema) validateForeignKeys() []error {
+	var errs []error
+	for _, fk := range ts.ForeignKeys {
+		if fk.Name == "" {
+			errs = append(errs, fmt.Errorf("foreign key without a name"))
+		}
+		if len(fk.Columns) == 0 {
+			errs = append(errs, fmt.Errorf("foreign key %s has no columns", fk.Name))
+		}
+		for _, column := range fk.Columns {
+			if !ts.hasColumn(column) {
+				errs = append(errs, fmt.Errorf("foreign key %s: column %s is not defined", fk.Name, column))
+			}
+		}
+		if fk.RefTable == "" {
+			errs = append(errs, fmt.Errorf("foreign key %s references no table", fk.Name))
+		}
+		if len(fk.RefColumns) != len(fk.Columns) {
+			errs = append(errs, fmt.Errorf("foreign key %s has %d columns but references %d", fk.Name, len(fk.Columns), len(fk.RefColumns)))
+		}
+		for _, action := range []ReferentialAction{fk.OnDelete, fk.OnUpdate} {
+			if !action.valid() {
+				errs = append(errs, fmt.Errorf("foreign key %s: unknown referential action %q", fk.Name, action))
+			}
+		}
+	}
+	return errs
+}
+
+// validateReferences reports foreign keys of ts referencing tables or
+// columns missing from s
+func (s *Schema) validateReferences(ts TableSchema) []error {
+	var errs []error
+	for _, fk := range ts.ForeignKeys {
+		if fk.RefTable == "" {
+			continue
+		}
+		ref := s.Tables[fk.RefTable]
+		if ref == nil {
+			errs = append(errs, fmt.Errorf("foreign key %s: referenced table %s is not defined", fk.Name, fk.RefTable))
+			continue
+		}
+		for _, column := range fk.RefColumns {
+			if !ref.hasColumn(column) {
+				errs = append(errs, fmt.Errorf("foreign key %s: referenced column %s.%s is not defined", fk.Name, fk.RefTable, column))
+			}
+		}
+	}
+	return errs
+}
+
+// hasColumn reports whether column is defined, or is the implicit id column
+// of a table without a declared primary key
+func (ts TableSchema) hasColumn(column string) bool {
+	if _, ok := ts.Fields[column]; ok {
+		return true
+	}
+	return column == "id" && !ts.HasDeclaredPrimaryKey()
+}
+
+// renameReferences points foreign keys referencing the table oldName at
+// newName
+func (s *Schema) renameReferences(oldName, newName string) {
+	for _, ts := range s.Tables {
+		for i := range ts.ForeignKeys {
+			if ts.ForeignKeys[i].RefTable == oldName {
+				ts.ForeignKeys[i].RefTable = newName
+			}
+		}
+	}
+}
+
+// cloneForeignKeys copies fks, sharing no slices with them
+func cloneForeignKeys(fks []ForeignKey) []ForeignKey {
+	fks = slices.Clone(fks)
+	for i := range fks {
+		fks[i].Columns = slices.Clone(fks[i].Columns)
+		fks[i].RefColumns = slices.Clone(fks[i].RefColumns)
+	}
+	return fks
+}
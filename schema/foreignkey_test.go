@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReferentialAction(t *testing.T) {
+	for input, want := range map[string]ReferentialAction{
+		"":            "",
+		"cascade":     Cascade,
+		"Set  Null":   SetNull,
+		" no action ": NoAction,
+		"SET DEFAULT": SetDefault,
+		"restrict":    Restrict,
+	} {
+		got, err := ParseReferentialAction(input)
+		if err != nil || got != want {
+			t.Errorf("ParseReferentialAction(%q): expected %q, got %q, %v", input, want, got, err)
+		}
+	}
+	if _, err := ParseReferentialAction("drop"); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
+
+func TestAddForeignKey(t *testing.T) {
+	ts := NewTableSchema("comments")
+	ts.AddForeignKey(ForeignKey{Columns: []string{"post_id"}, RefTable: "posts", RefColumns: []string{"id"}})
+	ts.AddForeignKey(ForeignKey{Name: "by_author", Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"}})
+	ts.AddForeignKey(ForeignKey{Name: "empty", RefTable: "users"})
+
+	if len(ts.ForeignKeys) != 2 {
+		t.Fatalf("expected 2 foreign keys, got %+v", ts.ForeignKeys)
+	}
+	if ts.ForeignKeys[0].Name != "fk_comments_post_id" || ts.ForeignKeys[1].Name != "by_author" {
+		t.Errorf("unexpected names %q and %q", ts.ForeignKeys[0].Name, ts.ForeignKeys[1].Name)
+	}
+	if refs := ts.References("users"); len(refs) != 1 || refs[0].Name != "by_author" {
+		t.Errorf("expected by_author to reference users, got %+v", refs)
+	}
+}
+
+func TestCreationOrder(t *testing.T) {
+	sch := New()
+	for _, name := range []string{"comments", "posts", "users", "tags"} {
+		sch.AddTable(NewTableSchema(name))
+	}
+	sch.Tables["comments"].AddForeignKey(ForeignKey{Columns: []string{"post_id"}, RefTable: "posts", RefColumns: []string{"id"}})
+	sch.Tables["comments"].AddForeignKey(ForeignKey{Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"}})
+	sch.Tables["posts"].AddForeignKey(ForeignKey{Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"}})
+	// A reference closing a cycle, and one to a table outside the schema
+	sch.Tables["users"].AddForeignKey(ForeignKey{Columns: []string{"pinned_id"}, RefTable: "comments", RefColumns: []string{"id"}})
+	sch.Tables["tags"].AddForeignKey(ForeignKey{Columns: []string{"owner"}, RefTable: "accounts", RefColumns: []string{"id"}})
+
+	want := []string{"users", "posts", "comments", "tags"}
+	if got := sch.CreationOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestForeignKeysFollowRenames(t *testing.T) {
+	posts := NewTableSchema("posts")
+	comments := NewTableSchema("comments")
+	comments.AddForeignKey(ForeignKey{Columns: []string{"post_id"}, RefTable: "posts", RefColumns: []string{"id"}})
+	sch := New()
+	sch.AddTable(posts)
+	sch.AddTable(comments)
+
+	clone := comments.Clone()
+	clone.ForeignKeys[0].Columns[0] = "article_id"
+	if comments.ForeignKeys[0].Columns[0] != "post_id" {
+		t.Errorf("expected the original foreign key to be unchanged, got %v", comments.ForeignKeys[0].Columns)
+	}
+
+	articles := posts.Clone()
+	articles.TableName = "articles"
+	sch.ReplaceTable("posts", articles)
+	if got := sch.Tables["comments"].ForeignKeys[0].RefTable; got != "articles" {
+		t.Errorf("expected the reference to follow the rename, got %s", got)
+	}
+}
@@ -0,0 +1,724 @@
+// Package gen generates typed Go models from a schema: a struct per table
+// with ToObject/FromObject converters, column name constants, typed columns
+// for building queries, and a Queries type with a finder per primary key,
+// unique column and index. It is the library behind `ddao gen`.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// Options configures Generate
+type Options struct {
+	// Package is the generated file's package name; "models" when empty
+	Package string
+	// Tables limits generation to the named tables; all tables when empty
+	Tables []string
+}
+
+// Generate returns the Go source of the models of sch's tables, in table
+// name order
+func Generate(sch *schema.Schema, opts Options) ([]byte, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "models"
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+
+	var names []string
+	for name := range sch.Tables {
+		if len(opts.Tables) == 0 || slices.Contains(opts.Tables, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range opts.Tables {
+		if _, ok := sch.Tables[name]; !ok {
+			return nil, fmt.Errorf("unknown table %s", name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("schema has no tables")
+	}
+	sort.Strings(names)
+
+	tables := make([]*table, 0, len(names))
+	typeNames := make(map[string]string)
+	for _, name := range names {
+		ts := *sch.Tables[name]
+		if ts.TableName == "" {
+			ts.TableName = name
+		}
+		t, err := newTable(ts)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := typeNames[t.Name]; ok {
+			return nil, fmt.Errorf("tables %s and %s both generate type %s", other, name, t.Name)
+		}
+		typeNames[t.Name] = name
+		tables = append(tables, t)
+	}
+
+	w := &writer{}
+	w.line("// Code generated by ddao gen. DO NOT EDIT.")
+	w.line("")
+	w.line("package %s", pkg)
+	w.line("")
+	w.imports(tables)
+	w.line(queriesSource)
+	for _, t := range tables {
+		t.write(w)
+	}
+
+	source, err := format.Source(w.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return source, nil
+}
+
+const queriesSource = `
+// DB is the part of storage.Storage and storage.Tx the queries use
+type DB interface {
+	Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	Update(ctx context.Context, obj *object.Object) (bool, error)
+	Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	FindByID(ctx context.Context, tblName, id string) (*object.Object, error)
+	Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error)
+	DeleteByID(ctx context.Context, tblName, id string) (bool, error)
+}
+
+// Queries reads and writes the tables through a storage.Storage or a storage.Tx
+type Queries struct {
+	db DB
+}
+
+// New returns the queries running on db
+func New(db DB) *Queries {
+	return &Queries{db: db}
+}
+
+// WithTx returns the queries running in tx
+func (q *Queries) WithTx(tx storage.Tx) *Queries {
+	return &Queries{db: tx}
+}
+`
+
+// kind is how a column is represented in Go
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindTime
+	kindBytes
+	kindJSON
+)
+
+// goType returns the Go type of a non-NULL value of the kind
+func (k kind) goType() string {
+	return [...]string{"string", "int64", "float64", "bool", "time.Time", "[]byte", "json.RawMessage"}[k]
+}
+
+// dataType returns the schema data type schema.FromStruct infers for the kind
+func (k kind) dataType() string {
+	return [...]string{"text", "integer", "real", "boolean", "datetime", "blob", "json"}[k]
+}
+
+// convert returns the object function converting field values to the kind
+func (k kind) convert() string {
+	return [...]string{"AsString", "AsInt64", "AsFloat64", "AsBool", "AsTime", "AsBytes", "AsJSON"}[k]
+}
+
+// columnType returns the storage type of the kind's typed column
+func (k kind) columnType() string {
+	switch k {
+	case kindTime:
+		return "storage.TimeColumn"
+	case kindJSON:
+		return "storage.Column[string]"
+	}
+	return "storage.Column[" + k.goType() + "]"
+}
+
+// pointer reports whether nullable columns of the kind are pointers; bytes
+// and JSON are nil instead
+func (k kind) pointer() bool {
+	return k != kindBytes && k != kindJSON
+}
+
+// kindOf maps a column's data type, as declared in a schema or read from a
+// database, to its kind. Unknown types are strings.
+func kindOf(dataType string) kind {
+	base := strings.ToLower(strings.TrimSpace(dataType))
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = strings.TrimSpace(base[:i])
+	}
+	switch {
+	case strings.Contains(base, "int") || strings.HasSuffix(base, "serial"):
+		return kindInt
+	case base == "real" || base == "float" || base == "double" || base == "double precision" ||
+		base == "decimal" || base == "numeric" || strings.HasPrefix(base, "float"):
+		return kindFloat
+	case base == "boolean" || base == "bool" || base == "bit":
+		return kindBool
+	case base == "datetime" || base == "datetime2" || strings.HasPrefix(base, "timestamp"):
+		return kindTime
+	case base == "blob" || base == "bytea" || strings.HasSuffix(base, "binary") || base == "raw":
+		return kindBytes
+	case base == "json" || base == "jsonb":
+		return kindJSON
+	}
+	return kindString
+}
+
+// table is the code generated for one table
+type table struct {
+	schema.TableSchema
+	// Name is the model's type name, the table name in singular
+	Name string
+	// Plural names finders returning several rows
+	Plural string
+	// Receiver is the name of the model's method receiver
+	Receiver string
+	Columns  []*column
+	Key      []*column
+	// ImplicitKey is set for tables keyed on the implicit id column
+	ImplicitKey bool
+	Finders     []finder
+}
+
+// column is the code generated for one column
+type column struct {
+	schema.ColumnData
+	Kind kind
+	// Field is the model's field and the suffix of the column's constant
+	Field string
+	// Param is the name of finder parameters taking the column's values
+	Param string
+}
+
+// GoType returns the type of the column's field
+func (c *column) GoType() string {
+	if c.Nullable && c.Kind.pointer() {
+		return "*" + c.Kind.goType()
+	}
+	return c.Kind.goType()
+}
+
+// finder is a Find<Model>By... query on a set of columns
+type finder struct {
+	Columns []*column
+	// Unique finders return one row, or ErrNotFound
+	Unique bool
+}
+
+func newTable(ts schema.TableSchema) (*table, error) {
+	t := &table{TableSchema: ts}
+	t.Name = singular(exportedName(ts.TableName))
+	t.Plural = exportedName(ts.TableName)
+	if t.Plural == t.Name {
+		t.Plural += "s"
+	}
+	t.Receiver = strings.ToLower(t.Name[:1])
+	if t.Receiver == "q" || t.Receiver == "v" {
+		t.Receiver = "m"
+	}
+
+	names := ts.FieldOrder
+	if len(names) == 0 {
+		for name := range ts.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if !ts.HasDeclaredPrimaryKey() {
+		t.ImplicitKey = true
+		names = append([]string{"id"}, slices.DeleteFunc(slices.Clone(names), func(name string) bool { return name == "id" })...)
+	}
+
+	byName := make(map[string]*column)
+	fields := make(map[string]string)
+	for _, name := range names {
+		data, ok := ts.Fields[name]
+		if !ok {
+			data = schema.ColumnData{DataType: "text"}
+		}
+		data.Name = name
+		if t.ImplicitKey && name == "id" {
+			data.Nullable = false
+			data.PrimaryKey = true
+		}
+		c := &column{ColumnData: data, Kind: kindOf(data.DataType), Field: exportedName(name)}
+		c.Param = paramName(c.Field)
+		if other, ok := fields[c.Field]; ok {
+			return nil, fmt.Errorf("table %s: columns %s and %s both generate field %s", ts.TableName, other, name, c.Field)
+		}
+		fields[c.Field] = name
+		byName[name] = c
+		t.Columns = append(t.Columns, c)
+	}
+
+	for _, name := range ts.PrimaryKeyColumns() {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("table %s: unknown primary key column %s", ts.TableName, name)
+		}
+		c.PrimaryKey = true
+		c.Nullable = false // SQLite reports INTEGER PRIMARY KEY columns as nullable
+		t.Key = append(t.Key, c)
+	}
+
+	add := func(columns []string, unique bool) {
+		f := finder{Unique: unique}
+		for _, name := range columns {
+			c, ok := byName[name]
+			if !ok {
+				return
+			}
+			f.Columns = append(f.Columns, c)
+		}
+		if slices.Equal(columns, ts.PrimaryKeyColumns()) {
+			return // Found by Find<Model>
+		}
+		for _, existing := range t.Finders {
+			if t.finderName(existing) == t.finderName(f) {
+				return
+			}
+		}
+		t.Finders = append(t.Finders, f)
+	}
+	for _, c := range t.Columns {
+		if c.Unique {
+			add([]string{c.Name}, true)
+		}
+	}
+	for _, index := range ts.IndexDefs {
+		add(index.Columns, index.Unique)
+	}
+	for _, c := range t.Columns {
+		if c.Index && !c.Unique {
+			add([]string{c.Name}, false)
+		}
+	}
+	return t, nil
+}
+
+func (t *table) finderName(f finder) string {
+	fields := make([]string, len(f.Columns))
+	for i, c := range f.Columns {
+		fields[i] = c.Field
+	}
+	name := t.Plural
+	if f.Unique {
+		name = t.Name
+	}
+	return "Find" + name + "By" + strings.Join(fields, "And")
+}
+
+// constant returns the name of c's column name constant
+func (t *table) constant(c *column) string {
+	return t.Name + "Column" + c.Field
+}
+
+func (t *table) write(w *writer) {
+	w.line("// %sTable is the name of the %s table", t.Name, t.TableName)
+	w.line("const %sTable = %q", t.Name, t.TableName)
+	w.line("")
+	w.line("// Columns of the %s table", t.TableName)
+	w.line("const (")
+	for _, c := range t.Columns {
+		w.line("%s = %q", t.constant(c), c.Name)
+	}
+	w.line(")")
+	w.line("")
+
+	w.line("// %sColumns are the typed columns of the %s table, for building queries", t.Name, t.TableName)
+	w.line("var %sColumns = struct {", t.Name)
+	for _, c := range t.Columns {
+		w.line("%s %s", c.Field, c.Kind.columnType())
+	}
+	w.line("}{")
+	for _, c := range t.Columns {
+		w.line("%s: %s,", c.Field, t.constant(c))
+	}
+	w.line("}")
+	w.line("")
+
+	w.line("// %s is a row of the %s table", t.Name, t.TableName)
+	if t.Comment != "" {
+		w.line("//")
+		w.line("// %s", t.Comment)
+	}
+	w.line("type %s struct {", t.Name)
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			w.line("// %s", c.Comment)
+		}
+		w.line("%s %s `ddao:%q`", c.Field, c.GoType(), t.tag(c))
+	}
+	w.line("}")
+	w.line("")
+
+	w.line("// TableName returns %sTable", t.Name)
+	w.line("func (%s) TableName() string {", t.Name)
+	w.line("return %sTable", t.Name)
+	w.line("}")
+	w.line("")
+
+	t.writeToObject(w)
+	t.writeFromObject(w)
+	t.writeQueries(w)
+}
+
+// tag returns c's ddao struct tag, which schema.FromStruct reads back as c
+func (t *table) tag(c *column) string {
+	parts := []string{c.Name}
+	if !strings.EqualFold(c.DataType, c.Kind.dataType()) && c.DataType != "" {
+		parts = append(parts, "type="+c.DataType)
+	}
+	if c.Nullable && !c.Kind.pointer() {
+		parts = append(parts, "nullable")
+	}
+	if c.Unique {
+		parts = append(parts, "unique")
+	}
+	if c.Index && !c.Unique && !c.PrimaryKey {
+		parts = append(parts, "index")
+	}
+	if c.PrimaryKey && !t.ImplicitKey {
+		parts = append(parts, "pk")
+	}
+	if c.AutoIncrement {
+		parts = append(parts, "autoincrement")
+	}
+	for _, fk := range t.ForeignKeys {
+		// Tags only declare single-column foreign keys
+		if len(fk.Columns) == 1 && fk.Columns[0] == c.Name && len(fk.RefColumns) == 1 {
+			parts = append(parts, "references="+fk.RefTable+"("+fk.RefColumns[0]+")")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encode returns the expression of the value stored for the non-NULL
+// value expr of c's kind
+func encode(c *column, expr string) string {
+	switch c.Kind {
+	case kindTime:
+		return expr + ".Format(time.RFC3339Nano)"
+	case kindBytes:
+		if !c.Nullable {
+			return "append([]byte{}, " + expr + "...)" // Only NULL columns store nil
+		}
+	}
+	return expr
+}
+
+func (t *table) writeToObject(w *writer) {
+	r := t.Receiver
+	w.line("// ToObject returns the object storing %s", r)
+	w.line("func (%s *%s) ToObject() *object.Object {", r, t.Name)
+	w.line("obj := object.New()")
+	w.line("obj.TableName = %sTable", t.Name)
+	for _, c := range t.Columns {
+		field := r + "." + c.Field
+		if c.Nullable && c.Kind.pointer() {
+			w.line("if %s != nil {", field)
+			value := "*" + field
+			if c.Kind == kindTime {
+				value = field // Format dereferences the pointer
+			}
+			w.line("obj.Fields[%s] = %s", t.constant(c), encode(c, value))
+			w.line("} else {")
+			w.line("obj.Fields[%s] = nil", t.constant(c))
+			w.line("}")
+			continue
+		}
+		if c.Nullable {
+			w.line("if %s != nil {", field)
+			w.line("obj.Fields[%s] = %s", t.constant(c), field)
+			w.line("} else {")
+			w.line("obj.Fields[%s] = nil", t.constant(c))
+			w.line("}")
+			continue
+		}
+		w.line("obj.Fields[%s] = %s", t.constant(c), encode(c, field))
+	}
+	w.line("obj.SetIDFromKey([]string{%s})", t.keyConstants())
+	w.line("return obj")
+	w.line("}")
+	w.line("")
+}
+
+func (t *table) keyConstants() string {
+	constants := make([]string, len(t.Key))
+	for i, c := range t.Key {
+		constants[i] = t.constant(c)
+	}
+	return strings.Join(constants, ", ")
+}
+
+func (t *table) writeFromObject(w *writer) {
+	r := t.Receiver
+	w.line("// %sFromObject returns the %s stored in obj", t.Name, t.Name)
+	w.line("func %sFromObject(obj *object.Object) (*%s, error) {", t.Name, t.Name)
+	w.line("%s := &%s{}", r, t.Name)
+	for _, c := range t.Columns {
+		w.line("if value := obj.Fields[%s]; value != nil {", t.constant(c))
+		w.line("v, err := object.%s(value)", c.Kind.convert())
+		w.line("if err != nil {")
+		w.line("return nil, fmt.Errorf(\"%s.%s: %%w\", err)", t.TableName, c.Name)
+		w.line("}")
+		if c.Nullable && c.Kind.pointer() {
+			w.line("%s.%s = &v", r, c.Field)
+		} else {
+			w.line("%s.%s = v", r, c.Field)
+		}
+		if t.ImplicitKey && c.Name == "id" {
+			// The implicit id column is not read back as a field
+			w.line("} else {")
+			w.line("%s.%s = obj.ID", r, c.Field)
+		}
+		w.line("}")
+	}
+	w.line("return %s, nil", r)
+	w.line("}")
+	w.line("")
+}
+
+// params returns the parameter list and argument names taking columns' values
+func params(columns []*column) (string, []string) {
+	list := make([]string, len(columns))
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		list[i] = c.Param + " " + c.Kind.goType()
+		names[i] = c.Param
+	}
+	return strings.Join(list, ", "), names
+}
+
+func (t *table) writeQueries(w *writer) {
+	r := t.Receiver
+	keyParams, keyArgs := params(t.Key)
+	keyParts := make([]string, len(t.Key))
+	for i, c := range t.Key {
+		keyParts[i] = fmt.Sprintf("{Column: %s, Value: %s}", t.constant(c), encode(c, keyArgs[i]))
+	}
+
+	w.line("// %sKey returns the ID of the %s row with the given key, as taken by", t.Name, t.TableName)
+	w.line("// storage.Storage.FindByID")
+	w.line("func %sKey(%s) string {", t.Name, keyParams)
+	w.line("return object.Key{%s}.String()", strings.Join(keyParts, ", "))
+	w.line("}")
+	w.line("")
+
+	w.line("// Insert%s creates %s, failing with a DuplicateKeyError if its key already exists", t.Name, r)
+	w.line("func (q *Queries) Insert%s(ctx context.Context, %s *%s) error {", t.Name, r, t.Name)
+	w.line("_, _, err := q.db.Insert(ctx, %s.ToObject())", r)
+	w.line("return err")
+	w.line("}")
+	w.line("")
+
+	w.line("// Update%s overwrites the row with %s's key, reporting whether there was one", t.Name, r)
+	w.line("func (q *Queries) Update%s(ctx context.Context, %s *%s) (bool, error) {", t.Name, r, t.Name)
+	w.line("return q.db.Update(ctx, %s.ToObject())", r)
+	w.line("}")
+	w.line("")
+
+	w.line("// Upsert%s creates %s, or overwrites the row with its key", t.Name, r)
+	w.line("func (q *Queries) Upsert%s(ctx context.Context, %s *%s) error {", t.Name, r, t.Name)
+	w.line("_, _, err := q.db.Upsert(ctx, %s.ToObject())", r)
+	w.line("return err")
+	w.line("}")
+	w.line("")
+
+	w.line("// Delete%s deletes the row with the given key, reporting whether there was one", t.Name)
+	w.line("func (q *Queries) Delete%s(ctx context.Context, %s) (bool, error) {", t.Name, keyParams)
+	w.line("return q.db.DeleteByID(ctx, %sTable, %sKey(%s))", t.Name, t.Name, strings.Join(keyArgs, ", "))
+	w.line("}")
+	w.line("")
+
+	w.line("// Find%s returns the row with the given key, or errors.ErrNotFound", t.Name)
+	w.line("func (q *Queries) Find%s(ctx context.Context, %s) (*%s, error) {", t.Name, keyParams, t.Name)
+	w.line("obj, err := q.db.FindByID(ctx, %sTable, %sKey(%s))", t.Name, t.Name, strings.Join(keyArgs, ", "))
+	w.line("if err != nil {")
+	w.line("return nil, err")
+	w.line("}")
+	w.line("return %sFromObject(obj)", t.Name)
+	w.line("}")
+	w.line("")
+
+	w.line("// Find%s returns the rows matching query", t.Plural)
+	w.line("func (q *Queries) Find%s(ctx context.Context, query storage.Query) ([]*%s, error) {", t.Plural, t.Name)
+	w.line("objs, err := q.db.Find(ctx, %sTable, query)", t.Name)
+	w.line("if err != nil {")
+	w.line("return nil, err")
+	w.line("}")
+	w.line("rows := make([]*%s, len(objs))", t.Name)
+	w.line("for i, obj := range objs {")
+	w.line("if rows[i], err = %sFromObject(obj); err != nil {", t.Name)
+	w.line("return nil, err")
+	w.line("}")
+	w.line("}")
+	w.line("return rows, nil")
+	w.line("}")
+	w.line("")
+
+	for _, f := range t.Finders {
+		fParams, fArgs := params(f.Columns)
+		conditions := make([]string, len(f.Columns))
+		described := make([]string, len(f.Columns))
+		for i, c := range f.Columns {
+			conditions[i] = fmt.Sprintf("%sColumns.%s.Eq(%s)", t.Name, c.Field, fArgs[i])
+			described[i] = c.Name
+		}
+		query := conditions[0]
+		if len(conditions) > 1 {
+			query = "storage.And(" + strings.Join(conditions, ", ") + ")"
+		}
+
+		if f.Unique {
+			w.line("// %s returns the row with the given %s, or errors.ErrNotFound", t.finderName(f), strings.Join(described, " and "))
+			w.line("func (q *Queries) %s(ctx context.Context, %s) (*%s, error) {", t.finderName(f), fParams, t.Name)
+			w.line("rows, err := q.Find%s(ctx, %s)", t.Plural, query)
+			w.line("if err != nil {")
+			w.line("return nil, err")
+			w.line("}")
+			w.line("if len(rows) == 0 {")
+			w.line("return nil, ddaoerrors.ErrNotFound")
+			w.line("}")
+			w.line("return rows[0], nil")
+			w.line("}")
+		} else {
+			w.line("// %s returns the rows with the given %s", t.finderName(f), strings.Join(described, " and "))
+			w.line("func (q *Queries) %s(ctx context.Context, %s) ([]*%s, error) {", t.finderName(f), fParams, t.Name)
+			w.line("return q.Find%s(ctx, %s)", t.Plural, query)
+			w.line("}")
+		}
+		w.line("")
+	}
+}
+
+// writer accumulates generated source
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) line(format string, args ...any) {
+	fmt.Fprintf(&w.buf, format, args...)
+	w.buf.WriteByte('\n')
+}
+
+func (w *writer) imports(tables []*table) {
+	needs := map[kind]bool{}
+	unique := false
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			needs[c.Kind] = true
+		}
+		for _, f := range t.Finders {
+			unique = unique || f.Unique
+		}
+	}
+
+	w.line("import (")
+	w.line("%q", "context")
+	if needs[kindJSON] {
+		w.line("%q", "encoding/json")
+	}
+	w.line("%q", "fmt")
+	if needs[kindTime] {
+		w.line("%q", "time")
+	}
+	w.line("")
+	w.line("%q", "github.com/jadedragon942/ddao/object")
+	w.line("%q", "github.com/jadedragon942/ddao/storage")
+	if unique {
+		w.line("ddaoerrors %q", "github.com/jadedragon942/ddao/storage/errors")
+	}
+	w.line(")")
+}
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "CSS": true, "DB": true, "DNS": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "SKU": true, "SQL": true, "TTL": true,
+	"UID": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// exportedName converts a table or column name such as "author_id" to an
+// exported Go name such as "AuthorID"
+func exportedName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	result := b.String()
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// paramName converts an exported field name to a parameter name: "AuthorID"
+// becomes "authorID" and "ID" "id"
+func paramName(field string) string {
+	runes := []rune(field)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	switch {
+	case upper == len(runes):
+		upper = len(runes) // All caps, such as ID
+	case upper > 1:
+		upper-- // Keep the capital starting the next word, as in URLPath
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	name := string(runes)
+	if token.IsKeyword(name) || name == "ctx" || name == "q" {
+		name += "Value"
+	}
+	return name
+}
+
+// singular returns the singular of an English plural such as "WikiPages"
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
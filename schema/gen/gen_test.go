@@ -0,0 +1,153 @@
+package gen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden generated models")
+
+const (
+	exampleSchema = "internal/genexample/schema.json"
+	exampleModels = "internal/genexample/models_gen.go"
+)
+
+func TestGenerateGolden(t *testing.T) {
+	sch, err := schema.LoadFile(exampleSchema)
+	if err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+
+	code, err := Generate(sch, Options{Package: "genexample"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(exampleModels, code, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	golden, err := os.ReadFile(exampleModels)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(code, golden) {
+		t.Errorf("generated code differs from %s; run go generate ./schema/gen/... or go test ./schema/gen -update", exampleModels)
+	}
+}
+
+func TestGenerateTables(t *testing.T) {
+	sch := schema.New()
+	for _, name := range []string{"categories", "posts"} {
+		table := schema.NewTableSchema(name)
+		table.AddField(schema.ColumnData{Name: "id", DataType: "integer", PrimaryKey: true})
+		table.AddField(schema.ColumnData{Name: "title", DataType: "varchar(255)"})
+		sch.AddTable(table)
+	}
+	categories, _ := sch.GetTable("categories")
+	categories.AddField(schema.ColumnData{Name: "parent_id", DataType: "integer", Nullable: true})
+	categories.AddForeignKey(schema.ForeignKey{Columns: []string{"parent_id"}, RefTable: "categories", RefColumns: []string{"id"}})
+	sch.ReplaceTable("categories", &categories)
+
+	code, err := Generate(sch, Options{Tables: []string{"categories"}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	source := string(code)
+	for _, expected := range []string{
+		"package models",
+		"type Category struct",
+		"ID       int64  `ddao:\"id,pk\"`",
+		"Title    string `ddao:\"title,type=varchar(255)\"`",
+		"ParentID *int64 `ddao:\"parent_id,references=categories(id)\"`",
+		"func (q *Queries) FindCategories(ctx context.Context, query storage.Query) ([]*Category, error)",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected generated code to contain %q", expected)
+		}
+	}
+	if strings.Contains(source, "Post") {
+		t.Error("expected the posts table to be left out")
+	}
+
+	if _, err := Generate(sch, Options{Tables: []string{"comments"}}); err == nil {
+		t.Error("expected an error for an unknown table")
+	}
+	if _, err := Generate(sch, Options{Package: "not a package"}); err == nil {
+		t.Error("expected an error for an invalid package name")
+	}
+}
+
+func TestGenerateFieldCollision(t *testing.T) {
+	sch := schema.New()
+	table := schema.NewTableSchema("users")
+	table.AddField(schema.ColumnData{Name: "user_id", DataType: "text", PrimaryKey: true})
+	table.AddField(schema.ColumnData{Name: "user-id", DataType: "text"})
+	sch.AddTable(table)
+	if _, err := Generate(sch, Options{}); err == nil {
+		t.Error("expected an error for columns generating the same field")
+	}
+}
+
+func TestNames(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_id":    "UserID",
+		"api_url":    "APIURL",
+		"createdAt":  "CreatedAt",
+		"wiki-pages": "WikiPages",
+		"2fa_secret": "X2faSecret",
+	} {
+		if got := exportedName(name); got != expected {
+			t.Errorf("exportedName(%q): expected %q, got %q", name, expected, got)
+		}
+	}
+	for field, expected := range map[string]string{
+		"ID":      "id",
+		"UserID":  "userID",
+		"URLPath": "urlPath",
+		"Type":    "typeValue",
+		"Name":    "name",
+	} {
+		if got := paramName(field); got != expected {
+			t.Errorf("paramName(%q): expected %q, got %q", field, expected, got)
+		}
+	}
+	for plural, expected := range map[string]string{
+		"Users":        "User",
+		"Categories":   "Category",
+		"Addresses":    "Address",
+		"Boxes":        "Box",
+		"Status":       "Status",
+		"StockEntries": "StockEntry",
+		"Data":         "Data",
+	} {
+		if got := singular(plural); got != expected {
+			t.Errorf("singular(%q): expected %q, got %q", plural, expected, got)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	for dataType, expected := range map[string]kind{
+		"varchar(36)":              kindString,
+		"BIGINT":                   kindInt,
+		"tinyint(1)":               kindInt,
+		"double precision":         kindFloat,
+		"decimal(10,2)":            kindFloat,
+		"boolean":                  kindBool,
+		"timestamp with time zone": kindTime,
+		"bytea":                    kindBytes,
+		"varbinary(16)":            kindBytes,
+		"jsonb":                    kindJSON,
+		"uuid":                     kindString,
+	} {
+		if got := kindOf(dataType); got != expected {
+			t.Errorf("kindOf(%q): expected %v, got %v", dataType, expected, got)
+		}
+	}
+}
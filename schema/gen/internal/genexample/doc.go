@@ -0,0 +1,6 @@
+// Package genexample holds models generated by `ddao gen` from schema.json.
+// Its tests run the generated code against SQLite, and schema/gen's tests
+// check the generator still produces models_gen.go.
+package genexample
+
+//go:generate go run ../../../../cmd/ddao gen -file schema.json -package genexample -out models_gen.go
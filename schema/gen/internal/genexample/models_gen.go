@@ -0,0 +1,558 @@
+// Code generated by ddao gen. DO NOT EDIT.
+
+package genexample
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// DB is the part of storage.Storage and storage.Tx the queries use
+type DB interface {
+	Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	Update(ctx context.Context, obj *object.Object) (bool, error)
+	Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	FindByID(ctx context.Context, tblName, id string) (*object.Object, error)
+	Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error)
+	DeleteByID(ctx context.Context, tblName, id string) (bool, error)
+}
+
+// Queries reads and writes the tables through a storage.Storage or a storage.Tx
+type Queries struct {
+	db DB
+}
+
+// New returns the queries running on db
+func New(db DB) *Queries {
+	return &Queries{db: db}
+}
+
+// WithTx returns the queries running in tx
+func (q *Queries) WithTx(tx storage.Tx) *Queries {
+	return &Queries{db: tx}
+}
+
+// NoteTable is the name of the notes table
+const NoteTable = "notes"
+
+// Columns of the notes table
+const (
+	NoteColumnID   = "id"
+	NoteColumnBody = "body"
+)
+
+// NoteColumns are the typed columns of the notes table, for building queries
+var NoteColumns = struct {
+	ID   storage.Column[string]
+	Body storage.Column[string]
+}{
+	ID:   NoteColumnID,
+	Body: NoteColumnBody,
+}
+
+// Note is a row of the notes table
+type Note struct {
+	ID   string `ddao:"id"`
+	Body string `ddao:"body"`
+}
+
+// TableName returns NoteTable
+func (Note) TableName() string {
+	return NoteTable
+}
+
+// ToObject returns the object storing n
+func (n *Note) ToObject() *object.Object {
+	obj := object.New()
+	obj.TableName = NoteTable
+	obj.Fields[NoteColumnID] = n.ID
+	obj.Fields[NoteColumnBody] = n.Body
+	obj.SetIDFromKey([]string{NoteColumnID})
+	return obj
+}
+
+// NoteFromObject returns the Note stored in obj
+func NoteFromObject(obj *object.Object) (*Note, error) {
+	n := &Note{}
+	if value := obj.Fields[NoteColumnID]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("notes.id: %w", err)
+		}
+		n.ID = v
+	} else {
+		n.ID = obj.ID
+	}
+	if value := obj.Fields[NoteColumnBody]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("notes.body: %w", err)
+		}
+		n.Body = v
+	}
+	return n, nil
+}
+
+// NoteKey returns the ID of the notes row with the given key, as taken by
+// storage.Storage.FindByID
+func NoteKey(id string) string {
+	return object.Key{{Column: NoteColumnID, Value: id}}.String()
+}
+
+// InsertNote creates n, failing with a DuplicateKeyError if its key already exists
+func (q *Queries) InsertNote(ctx context.Context, n *Note) error {
+	_, _, err := q.db.Insert(ctx, n.ToObject())
+	return err
+}
+
+// UpdateNote overwrites the row with n's key, reporting whether there was one
+func (q *Queries) UpdateNote(ctx context.Context, n *Note) (bool, error) {
+	return q.db.Update(ctx, n.ToObject())
+}
+
+// UpsertNote creates n, or overwrites the row with its key
+func (q *Queries) UpsertNote(ctx context.Context, n *Note) error {
+	_, _, err := q.db.Upsert(ctx, n.ToObject())
+	return err
+}
+
+// DeleteNote deletes the row with the given key, reporting whether there was one
+func (q *Queries) DeleteNote(ctx context.Context, id string) (bool, error) {
+	return q.db.DeleteByID(ctx, NoteTable, NoteKey(id))
+}
+
+// FindNote returns the row with the given key, or errors.ErrNotFound
+func (q *Queries) FindNote(ctx context.Context, id string) (*Note, error) {
+	obj, err := q.db.FindByID(ctx, NoteTable, NoteKey(id))
+	if err != nil {
+		return nil, err
+	}
+	return NoteFromObject(obj)
+}
+
+// FindNotes returns the rows matching query
+func (q *Queries) FindNotes(ctx context.Context, query storage.Query) ([]*Note, error) {
+	objs, err := q.db.Find(ctx, NoteTable, query)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*Note, len(objs))
+	for i, obj := range objs {
+		if rows[i], err = NoteFromObject(obj); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// StockEntryTable is the name of the stock_entries table
+const StockEntryTable = "stock_entries"
+
+// Columns of the stock_entries table
+const (
+	StockEntryColumnRegion = "region"
+	StockEntryColumnSKU    = "sku"
+	StockEntryColumnQty    = "qty"
+	StockEntryColumnBin    = "bin"
+)
+
+// StockEntryColumns are the typed columns of the stock_entries table, for building queries
+var StockEntryColumns = struct {
+	Region storage.Column[string]
+	SKU    storage.Column[int64]
+	Qty    storage.Column[int64]
+	Bin    storage.Column[string]
+}{
+	Region: StockEntryColumnRegion,
+	SKU:    StockEntryColumnSKU,
+	Qty:    StockEntryColumnQty,
+	Bin:    StockEntryColumnBin,
+}
+
+// StockEntry is a row of the stock_entries table
+type StockEntry struct {
+	Region string  `ddao:"region,pk"`
+	SKU    int64   `ddao:"sku,type=bigint,pk"`
+	Qty    int64   `ddao:"qty"`
+	Bin    *string `ddao:"bin"`
+}
+
+// TableName returns StockEntryTable
+func (StockEntry) TableName() string {
+	return StockEntryTable
+}
+
+// ToObject returns the object storing s
+func (s *StockEntry) ToObject() *object.Object {
+	obj := object.New()
+	obj.TableName = StockEntryTable
+	obj.Fields[StockEntryColumnRegion] = s.Region
+	obj.Fields[StockEntryColumnSKU] = s.SKU
+	obj.Fields[StockEntryColumnQty] = s.Qty
+	if s.Bin != nil {
+		obj.Fields[StockEntryColumnBin] = *s.Bin
+	} else {
+		obj.Fields[StockEntryColumnBin] = nil
+	}
+	obj.SetIDFromKey([]string{StockEntryColumnRegion, StockEntryColumnSKU})
+	return obj
+}
+
+// StockEntryFromObject returns the StockEntry stored in obj
+func StockEntryFromObject(obj *object.Object) (*StockEntry, error) {
+	s := &StockEntry{}
+	if value := obj.Fields[StockEntryColumnRegion]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("stock_entries.region: %w", err)
+		}
+		s.Region = v
+	}
+	if value := obj.Fields[StockEntryColumnSKU]; value != nil {
+		v, err := object.AsInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("stock_entries.sku: %w", err)
+		}
+		s.SKU = v
+	}
+	if value := obj.Fields[StockEntryColumnQty]; value != nil {
+		v, err := object.AsInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("stock_entries.qty: %w", err)
+		}
+		s.Qty = v
+	}
+	if value := obj.Fields[StockEntryColumnBin]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("stock_entries.bin: %w", err)
+		}
+		s.Bin = &v
+	}
+	return s, nil
+}
+
+// StockEntryKey returns the ID of the stock_entries row with the given key, as taken by
+// storage.Storage.FindByID
+func StockEntryKey(region string, sku int64) string {
+	return object.Key{{Column: StockEntryColumnRegion, Value: region}, {Column: StockEntryColumnSKU, Value: sku}}.String()
+}
+
+// InsertStockEntry creates s, failing with a DuplicateKeyError if its key already exists
+func (q *Queries) InsertStockEntry(ctx context.Context, s *StockEntry) error {
+	_, _, err := q.db.Insert(ctx, s.ToObject())
+	return err
+}
+
+// UpdateStockEntry overwrites the row with s's key, reporting whether there was one
+func (q *Queries) UpdateStockEntry(ctx context.Context, s *StockEntry) (bool, error) {
+	return q.db.Update(ctx, s.ToObject())
+}
+
+// UpsertStockEntry creates s, or overwrites the row with its key
+func (q *Queries) UpsertStockEntry(ctx context.Context, s *StockEntry) error {
+	_, _, err := q.db.Upsert(ctx, s.ToObject())
+	return err
+}
+
+// DeleteStockEntry deletes the row with the given key, reporting whether there was one
+func (q *Queries) DeleteStockEntry(ctx context.Context, region string, sku int64) (bool, error) {
+	return q.db.DeleteByID(ctx, StockEntryTable, StockEntryKey(region, sku))
+}
+
+// FindStockEntry returns the row with the given key, or errors.ErrNotFound
+func (q *Queries) FindStockEntry(ctx context.Context, region string, sku int64) (*StockEntry, error) {
+	obj, err := q.db.FindByID(ctx, StockEntryTable, StockEntryKey(region, sku))
+	if err != nil {
+		return nil, err
+	}
+	return StockEntryFromObject(obj)
+}
+
+// FindStockEntries returns the rows matching query
+func (q *Queries) FindStockEntries(ctx context.Context, query storage.Query) ([]*StockEntry, error) {
+	objs, err := q.db.Find(ctx, StockEntryTable, query)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*StockEntry, len(objs))
+	for i, obj := range objs {
+		if rows[i], err = StockEntryFromObject(obj); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// FindStockEntryByRegionAndBin returns the row with the given region and bin, or errors.ErrNotFound
+func (q *Queries) FindStockEntryByRegionAndBin(ctx context.Context, region string, bin string) (*StockEntry, error) {
+	rows, err := q.FindStockEntries(ctx, storage.And(StockEntryColumns.Region.Eq(region), StockEntryColumns.Bin.Eq(bin)))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return rows[0], nil
+}
+
+// UserTable is the name of the users table
+const UserTable = "users"
+
+// Columns of the users table
+const (
+	UserColumnID        = "id"
+	UserColumnEmail     = "email"
+	UserColumnName      = "name"
+	UserColumnAge       = "age"
+	UserColumnScore     = "score"
+	UserColumnActive    = "active"
+	UserColumnAvatar    = "avatar"
+	UserColumnSettings  = "settings"
+	UserColumnTeamID    = "team_id"
+	UserColumnCreatedAt = "created_at"
+	UserColumnDeletedAt = "deleted_at"
+)
+
+// UserColumns are the typed columns of the users table, for building queries
+var UserColumns = struct {
+	ID        storage.Column[string]
+	Email     storage.Column[string]
+	Name      storage.Column[string]
+	Age       storage.Column[int64]
+	Score     storage.Column[float64]
+	Active    storage.Column[bool]
+	Avatar    storage.Column[[]byte]
+	Settings  storage.Column[string]
+	TeamID    storage.Column[int64]
+	CreatedAt storage.TimeColumn
+	DeletedAt storage.TimeColumn
+}{
+	ID:        UserColumnID,
+	Email:     UserColumnEmail,
+	Name:      UserColumnName,
+	Age:       UserColumnAge,
+	Score:     UserColumnScore,
+	Active:    UserColumnActive,
+	Avatar:    UserColumnAvatar,
+	Settings:  UserColumnSettings,
+	TeamID:    UserColumnTeamID,
+	CreatedAt: UserColumnCreatedAt,
+	DeletedAt: UserColumnDeletedAt,
+}
+
+// User is a row of the users table
+//
+// Registered users
+type User struct {
+	ID    string `ddao:"id,type=varchar(36),pk"`
+	Email string `ddao:"email,unique"`
+	// Display name
+	Name      string          `ddao:"name"`
+	Age       *int64          `ddao:"age"`
+	Score     float64         `ddao:"score"`
+	Active    bool            `ddao:"active"`
+	Avatar    []byte          `ddao:"avatar,nullable"`
+	Settings  json.RawMessage `ddao:"settings,nullable"`
+	TeamID    int64           `ddao:"team_id,index"`
+	CreatedAt time.Time       `ddao:"created_at"`
+	DeletedAt *time.Time      `ddao:"deleted_at"`
+}
+
+// TableName returns UserTable
+func (User) TableName() string {
+	return UserTable
+}
+
+// ToObject returns the object storing u
+func (u *User) ToObject() *object.Object {
+	obj := object.New()
+	obj.TableName = UserTable
+	obj.Fields[UserColumnID] = u.ID
+	obj.Fields[UserColumnEmail] = u.Email
+	obj.Fields[UserColumnName] = u.Name
+	if u.Age != nil {
+		obj.Fields[UserColumnAge] = *u.Age
+	} else {
+		obj.Fields[UserColumnAge] = nil
+	}
+	obj.Fields[UserColumnScore] = u.Score
+	obj.Fields[UserColumnActive] = u.Active
+	if u.Avatar != nil {
+		obj.Fields[UserColumnAvatar] = u.Avatar
+	} else {
+		obj.Fields[UserColumnAvatar] = nil
+	}
+	if u.Settings != nil {
+		obj.Fields[UserColumnSettings] = u.Settings
+	} else {
+		obj.Fields[UserColumnSettings] = nil
+	}
+	obj.Fields[UserColumnTeamID] = u.TeamID
+	obj.Fields[UserColumnCreatedAt] = u.CreatedAt.Format(time.RFC3339Nano)
+	if u.DeletedAt != nil {
+		obj.Fields[UserColumnDeletedAt] = u.DeletedAt.Format(time.RFC3339Nano)
+	} else {
+		obj.Fields[UserColumnDeletedAt] = nil
+	}
+	obj.SetIDFromKey([]string{UserColumnID})
+	return obj
+}
+
+// UserFromObject returns the User stored in obj
+func UserFromObject(obj *object.Object) (*User, error) {
+	u := &User{}
+	if value := obj.Fields[UserColumnID]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.id: %w", err)
+		}
+		u.ID = v
+	}
+	if value := obj.Fields[UserColumnEmail]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.email: %w", err)
+		}
+		u.Email = v
+	}
+	if value := obj.Fields[UserColumnName]; value != nil {
+		v, err := object.AsString(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.name: %w", err)
+		}
+		u.Name = v
+	}
+	if value := obj.Fields[UserColumnAge]; value != nil {
+		v, err := object.AsInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.age: %w", err)
+		}
+		u.Age = &v
+	}
+	if value := obj.Fields[UserColumnScore]; value != nil {
+		v, err := object.AsFloat64(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.score: %w", err)
+		}
+		u.Score = v
+	}
+	if value := obj.Fields[UserColumnActive]; value != nil {
+		v, err := object.AsBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.active: %w", err)
+		}
+		u.Active = v
+	}
+	if value := obj.Fields[UserColumnAvatar]; value != nil {
+		v, err := object.AsBytes(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.avatar: %w", err)
+		}
+		u.Avatar = v
+	}
+	if value := obj.Fields[UserColumnSettings]; value != nil {
+		v, err := object.AsJSON(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.settings: %w", err)
+		}
+		u.Settings = v
+	}
+	if value := obj.Fields[UserColumnTeamID]; value != nil {
+		v, err := object.AsInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.team_id: %w", err)
+		}
+		u.TeamID = v
+	}
+	if value := obj.Fields[UserColumnCreatedAt]; value != nil {
+		v, err := object.AsTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.created_at: %w", err)
+		}
+		u.CreatedAt = v
+	}
+	if value := obj.Fields[UserColumnDeletedAt]; value != nil {
+		v, err := object.AsTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("users.deleted_at: %w", err)
+		}
+		u.DeletedAt = &v
+	}
+	return u, nil
+}
+
+// UserKey returns the ID of the users row with the given key, as taken by
+// storage.Storage.FindByID
+func UserKey(id string) string {
+	return object.Key{{Column: UserColumnID, Value: id}}.String()
+}
+
+// InsertUser creates u, failing with a DuplicateKeyError if its key already exists
+func (q *Queries) InsertUser(ctx context.Context, u *User) error {
+	_, _, err := q.db.Insert(ctx, u.ToObject())
+	return err
+}
+
+// UpdateUser overwrites the row with u's key, reporting whether there was one
+func (q *Queries) UpdateUser(ctx context.Context, u *User) (bool, error) {
+	return q.db.Update(ctx, u.ToObject())
+}
+
+// UpsertUser creates u, or overwrites the row with its key
+func (q *Queries) UpsertUser(ctx context.Context, u *User) error {
+	_, _, err := q.db.Upsert(ctx, u.ToObject())
+	return err
+}
+
+// DeleteUser deletes the row with the given key, reporting whether there was one
+func (q *Queries) DeleteUser(ctx context.Context, id string) (bool, error) {
+	return q.db.DeleteByID(ctx, UserTable, UserKey(id))
+}
+
+// FindUser returns the row with the given key, or errors.ErrNotFound
+func (q *Queries) FindUser(ctx context.Context, id string) (*User, error) {
+	obj, err := q.db.FindByID(ctx, UserTable, UserKey(id))
+	if err != nil {
+		return nil, err
+	}
+	return UserFromObject(obj)
+}
+
+// FindUsers returns the rows matching query
+func (q *Queries) FindUsers(ctx context.Context, query storage.Query) ([]*User, error) {
+	objs, err := q.db.Find(ctx, UserTable, query)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*User, len(objs))
+	for i, obj := range objs {
+		if rows[i], err = UserFromObject(obj); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// FindUserByEmail returns the row with the given email, or errors.ErrNotFound
+func (q *Queries) FindUserByEmail(ctx context.Context, email string) (*User, error) {
+	rows, err := q.FindUsers(ctx, UserColumns.Email.Eq(email))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return rows[0], nil
+}
+
+// FindUsersByTeamID returns the rows with the given team_id
+func (q *Queries) FindUsersByTeamID(ctx context.Context, teamID int64) ([]*User, error) {
+	return q.FindUsers(ctx, UserColumns.TeamID.Eq(teamID))
+}
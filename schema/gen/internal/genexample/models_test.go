@@ -0,0 +1,172 @@
+package genexample
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	sqliteStorage "github.com/jadedragon942/ddao/storage/sqlite"
+)
+
+func loadSchema(t *testing.T) *schema.Schema {
+	t.Helper()
+	sch, err := schema.LoadFile("schema.json")
+	if err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+	return sch
+}
+
+func newQueries(t *testing.T) (storage.Storage, *Queries) {
+	t.Helper()
+	ctx := context.Background()
+	sch := loadSchema(t)
+	store := sqliteStorage.New()
+	if err := store.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { store.ResetConnection(ctx) })
+	if err := store.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	return store, New(store)
+}
+
+func TestGeneratedQueries(t *testing.T) {
+	ctx := context.Background()
+	_, q := newQueries(t)
+
+	age := int64(36)
+	user := &User{
+		ID:        "u1",
+		Email:     "ada@example.com",
+		Name:      "Ada",
+		Age:       &age,
+		Score:     9.5,
+		Active:    true,
+		Avatar:    []byte{1, 2, 3},
+		Settings:  json.RawMessage(`{"theme":"dark"}`),
+		TeamID:    7,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := q.InsertUser(ctx, user); err != nil {
+		t.Fatalf("InsertUser failed: %v", err)
+	}
+	if err := q.InsertUser(ctx, user); !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected a conflict inserting u1 again, got %v", err)
+	}
+
+	got, err := q.FindUser(ctx, "u1")
+	if err != nil {
+		t.Fatalf("FindUser failed: %v", err)
+	}
+	if !got.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("expected created_at %v, got %v", user.CreatedAt, got.CreatedAt)
+	}
+	got.CreatedAt = user.CreatedAt
+	if !reflect.DeepEqual(got, user) {
+		t.Errorf("expected %+v, got %+v", user, got)
+	}
+
+	byEmail, err := q.FindUserByEmail(ctx, "ada@example.com")
+	if err != nil || byEmail.ID != "u1" {
+		t.Errorf("expected FindUserByEmail to find u1, got %+v (%v)", byEmail, err)
+	}
+	if _, err := q.FindUserByEmail(ctx, "bob@example.com"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found for an unknown email, got %v", err)
+	}
+
+	deleted := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := q.UpsertUser(ctx, &User{ID: "u2", Email: "bob@example.com", TeamID: 7, DeletedAt: &deleted}); err != nil {
+		t.Fatalf("UpsertUser failed: %v", err)
+	}
+	team, err := q.FindUsersByTeamID(ctx, 7)
+	if err != nil || len(team) != 2 {
+		t.Errorf("expected two users in team 7, got %d (%v)", len(team), err)
+	}
+	gone, err := q.FindUsers(ctx, storage.And(UserColumns.TeamID.Eq(7), UserColumns.DeletedAt.Before(time.Now())))
+	if err != nil || len(gone) != 1 || gone[0].ID != "u2" || gone[0].Age != nil || gone[0].Avatar != nil {
+		t.Errorf("expected to find only u2, got %+v (%v)", gone, err)
+	}
+
+	user.Age = nil
+	if found, err := q.UpdateUser(ctx, user); err != nil || !found {
+		t.Fatalf("UpdateUser failed: %v (found %v)", err, found)
+	}
+	if got, err := q.FindUser(ctx, "u1"); err != nil || got.Age != nil {
+		t.Errorf("expected u1 to have no age, got %+v (%v)", got, err)
+	}
+
+	if found, err := q.DeleteUser(ctx, "u1"); err != nil || !found {
+		t.Fatalf("DeleteUser failed: %v (found %v)", err, found)
+	}
+	if _, err := q.FindUser(ctx, "u1"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestGeneratedCompositeKey(t *testing.T) {
+	ctx := context.Background()
+	store, q := newQueries(t)
+
+	bin := "A1"
+	entry := &StockEntry{Region: "eu west", SKU: 42, Qty: 3, Bin: &bin}
+	if err := q.InsertStockEntry(ctx, entry); err != nil {
+		t.Fatalf("InsertStockEntry failed: %v", err)
+	}
+	if key := StockEntryKey("eu west", 42); key != "eu%20west/42" {
+		t.Errorf("expected key eu%%20west/42, got %q", key)
+	}
+
+	tx, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	entry.Qty = 5
+	if _, err := q.WithTx(tx).UpdateStockEntry(ctx, entry); err != nil {
+		t.Fatalf("UpdateStockEntry failed: %v", err)
+	}
+	tx.Rollback()
+
+	got, err := q.FindStockEntryByRegionAndBin(ctx, "eu west", "A1")
+	if err != nil {
+		t.Fatalf("FindStockEntryByRegionAndBin failed: %v", err)
+	}
+	if got.Qty != 3 {
+		t.Errorf("expected the rolled back quantity 3, got %d", got.Qty)
+	}
+	if got, err := q.FindStockEntry(ctx, "eu west", 42); err != nil || *got.Bin != "A1" {
+		t.Errorf("expected FindStockEntry to find the entry, got %+v (%v)", got, err)
+	}
+}
+
+func TestGeneratedStructsMatchSchema(t *testing.T) {
+	sch := loadSchema(t)
+	for _, derive := range []func() (*schema.TableSchema, error){
+		schema.FromStruct[User], schema.FromStruct[StockEntry], schema.FromStruct[Note],
+	} {
+		table, err := derive()
+		if err != nil {
+			t.Fatalf("FromStruct failed: %v", err)
+		}
+		expected := sch.Tables[table.TableName]
+		if expected == nil {
+			t.Fatalf("unexpected table %s", table.TableName)
+		}
+		if !reflect.DeepEqual(table.PrimaryKeyColumns(), expected.PrimaryKeyColumns()) {
+			t.Errorf("%s: expected key %v, got %v", table.TableName, expected.PrimaryKeyColumns(), table.PrimaryKeyColumns())
+		}
+		for name, want := range expected.Fields {
+			got := table.Fields[name]
+			if got.DataType != want.DataType || got.Nullable != want.Nullable || got.Unique != want.Unique || got.Index != want.Index {
+				t.Errorf("%s.%s: expected %+v, got %+v", table.TableName, name, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// Dialect renders plan changes as the DDL of one database
+type Dialect interface {
+	// Name is the dialect's name, as accepted by DialectFor
+	Name() string
+	// ColumnType returns the type field is created with in tbl
+	ColumnType(tbl schema.TableSchema, field schema.ColumnData) string
+	// SameType reports whether a column the database reports with type
+	// current already has the type field would be created with
+	SameType(tbl schema.TableSchema, field schema.ColumnData, current string) bool
+	// Render returns the statements applying change
+	Render(change Change) ([]string, error)
+}
+
+// The dialects of the SQL backends. Their types and table definitions match
+// what the backend's CreateTables creates.
+var (
+	SQLite    Dialect = &sqlDialect{name: "sqlite", ddl: dialect.SQLite, idType: "TEXT"}
+	Postgres  Dialect = &sqlDialect{name: "postgres", ddl: dialect.Postgres, idType: "TEXT", types: postgresTypes, defaultType: "TEXT"}
+	Yugabyte  Dialect = &sqlDialect{name: "yugabyte", ddl: dialect.Yugabyte, idType: "TEXT", types: postgresTypes, defaultType: "TEXT"}
+	Cockroach Dialect = &sqlDialect{name: "cockroach", ddl: dialect.Cockroach, idType: "STRING", types: cockroachTypes, defaultType: "STRING"}
+	TiDB      Dialect = &sqlDialect{name: "tidb", ddl: dialect.TiDB, idType: "VARCHAR(255)", types: tidbTypes, defaultType: "VARCHAR(255)",
+		textType: "TEXT", keyTextType: "VARCHAR(255)", aliases: map[string]string{"boolean": "tinyint"}}
+	SQLServer Dialect = &sqlDialect{name: "sqlserver", ddl: dialect.SQLServer, idType: "NVARCHAR(255)", types: sqlServerTypes, defaultType: "NVARCHAR(MAX)",
+		textType: "NVARCHAR(MAX)", keyTextType: "NVARCHAR(255)"}
+	Oracle Dialect = &sqlDialect{name: "oracle", ddl: dialect.Oracle, idType: "VARCHAR2(255)", types: oracleTypes, defaultType: "CLOB",
+		textType: "CLOB", keyTextType: "VARCHAR2(255)"}
+)
+
+// DialectFor returns the dialect called name
+func DialectFor(name string) (Dialect, error) {
+	for _, dialect := range []Dialect{SQLite, Postgres, Yugabyte, Cockroach, TiDB, SQLServer, Oracle} {
+		if dialect.Name() == name {
+			return dialect, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown dialect %q", name)
+}
+
+var (
+	postgresTypes = map[string]string{
+		"TEXT": "TEXT", "VARCHAR": "TEXT", "CHAR": "TEXT",
+		"INTEGER": "INTEGER", "INT": "INTEGER",
+		"REAL": "REAL", "FLOAT": "REAL",
+		"BLOB": "BYTEA", "BOOLEAN": "BOOLEAN", "JSON": "JSONB",
+		"DATETIME": "TIMESTAMP", "TIMESTAMP": "TIMESTAMP",
+		"DATE": "DATE", "TIME": "TIME", "UUID": "UUID",
+	}
+	cockroachTypes = map[string]string{
+		"TEXT": "STRING", "VARCHAR": "STRING", "CHAR": "STRING",
+		"INTEGER": "INT8", "INT": "INT8",
+		"REAL": "FLOAT8", "FLOAT": "FLOAT8",
+		"BLOB": "BYTES", "BOOLEAN": "BOOL", "JSON": "JSONB",
+		"DATETIME": "TIMESTAMPTZ", "TIMESTAMP": "TIMESTAMPTZ",
+		"DATE": "DATE", "TIME": "TIME", "UUID": "UUID",
+	}
+	tidbTypes = map[string]string{
+		"TEXT": "TEXT", "VARCHAR": "VARCHAR(255)", "CHAR": "VARCHAR(255)",
+		"INTEGER": "BIGINT", "INT": "BIGINT",
+		"REAL": "DOUBLE", "FLOAT": "DOUBLE",
+		"BLOB": "BLOB", "BOOLEAN": "BOOLEAN", "JSON": "JSON",
+		"DATETIME": "TIMESTAMP", "TIMESTAMP": "TIMESTAMP",
+		"DATE": "DATE", "TIME": "TIME", "UUID": "VARCHAR(36)",
+	}
+	sqlServerTypes = map[string]string{
+		"TEXT": "NVARCHAR(MAX)", "VARCHAR": "NVARCHAR(MAX)", "CHAR": "NVARCHAR(MAX)",
+		"INTEGER": "BIGINT", "INT": "BIGINT",
+		"REAL": "FLOAT", "FLOAT": "FLOAT",
+		"BLOB": "VARBINARY(MAX)", "BOOLEAN": "BIT", "JSON": "NVARCHAR(MAX)",
+		"DATETIME": "DATETIME2", "TIMESTAMP": "DATETIME2",
+		"DATE": "DATE", "TIME": "TIME", "UUID": "UNIQUEIDENTIFIER",
+	}
+	oracleTypes = map[string]string{
+		"TEXT": "CLOB", "VARCHAR": "CLOB", "CHAR": "CLOB",
+		"INTEGER": "NUMBER(19)", "INT": "NUMBER(19)",
+		"REAL": "BINARY_DOUBLE", "FLOAT": "BINARY_DOUBLE",
+		"BLOB": "BLOB", "BOOLEAN": "NUMBER(1)", "JSON": "CLOB",
+		"DATETIME": "TIMESTAMP", "TIMESTAMP": "TIMESTAMP",
+		"DATE": "DATE", "TIME": "TIMESTAMP", "UUID": "VARCHAR2(36)",
+	}
+)
+
+// sqlDialect renders the DDL the SQL backends share, switching on name where
+// their syntax differs
+type sqlDialect struct {
+	name string
+	// ddl quotes names and renders defaults as the backend's CreateTables does
+	ddl dialect.Dialect
+	// idType is the type of the implicit id column
+	idType string
+	// types maps upper case schema types to column types; nil keeps them as written
+	types       map[string]string
+	defaultType string
+	// textType is the unbounded text type, which cannot be keyed or indexed
+	// and is replaced by keyTextType on key, unique and indexed columns
+	textType    string
+	keyTextType string
+	// aliases maps reported types to the names the dialect creates them with
+	aliases map[string]string
+}
+
+func (d sqlDialect) Name() string {
+	return d.name
+}
+
+func (d sqlDialect) ColumnType(tbl schema.TableSchema, field schema.ColumnData) string {
+	if d.types == nil {
+		return field.DataType
+	}
+	dataType, ok := d.types[strings.ToUpper(field.DataType)]
+	if !ok {
+		dataType = d.defaultType
+	}
+	if d.textType != "" && dataType == d.textType && tbl.IsIndexed(field.Name) {
+		return d.keyTextType
+	}
+	return dataType
+}
+
+var typeSize = regexp.MustCompile(`\s*\(.*\)$`)
+
+// baseType lower cases a type and strips its size, e.g. VARCHAR(255) to varchar
+func (d sqlDialect) baseType(dataType string) string {
+	base := strings.ToLower(typeSize.ReplaceAllString(strings.TrimSpace(dataType), ""))
+	if alias, ok := d.aliases[base]; ok {
+		return alias
+	}
+	return base
+}
+
+func (d sqlDialect) SameType(tbl schema.TableSchema, field schema.ColumnData, current string) bool {
+	return d.baseType(d.ColumnType(tbl, field)) == d.baseType(current)
+}
+
+// ident renders an identifier the way the backend's CreateTables does
+func (d sqlDialect) ident(name string) string {
+	return d.ddl.QuoteIdent(name)
+}
+
+func (d sqlDialect) idents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.ident(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// defaultValue renders a column default as a typed literal, or an
+// expression as it is
+func (d sqlDialect) defaultValue(field schema.ColumnData) string {
+	return d.ddl.Literal(field.Default)
+}
+
+// columnDefinition renders field as in CREATE TABLE or ADD COLUMN, with a
+// UNIQUE constraint when unique is set
+func (d sqlDialect) columnDefinition(tbl schema.TableSchema, field schema.ColumnData, unique bool) string {
+	definition := d.ident(field.Name) + " " + d.ColumnType(tbl, field)
+	if !field.Nullable {
+		definition += " NOT NULL"
+	} else if d.name != "oracle" {
+		definition += " NULL"
+	}
+	if field.Default != nil {
+		definition += " DEFAULT " + d.defaultValue(field)
+	}
+	if unique && field.Unique && d.name != "oracle" {
+		definition += " UNIQUE"
+	}
+	return definition
+}
+
+func (d sqlDialect) Render(change Change) ([]string, error) {
+	table := d.ident(change.Table)
+	switch change.Kind {
+	case CreateTable:
+		return d.createTable(change.TableSchema), nil
+
+	case AddColumn:
+		definition := d.columnDefinition(change.TableSchema, change.Column, true)
+		switch d.name {
+		case "sqlserver":
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD %s", table, definition)}, nil
+		case "oracle":
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD (%s)", table, definition)}, nil
+		default:
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition)}, nil
+		}
+
+	case AlterColumn:
+		return d.alterColumn(change)
+
+	case DropColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, d.ident(change.Column.Name))}, nil
+
+	case CreateIndex:
+		unique := ""
+		if change.Index.Unique {
+			unique = "UNIQUE "
+		}
+		return []string{fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, d.ident(change.Index.Name), table, d.idents(change.Index.Columns))}, nil
+
+	case DropIndex:
+		switch d.name {
+		case "tidb", "sqlserver":
+			return []string{fmt.Sprintf("DROP INDEX %s ON %s", d.ident(change.Index.Name), table)}, nil
+		case "cockroach":
+			return []string{fmt.Sprintf("DROP INDEX %s@%s", table, d.ident(change.Index.Name))}, nil
+		default:
+			return []string{fmt.Sprintf("DROP INDEX %s", d.ident(change.Index.Name))}, nil
+		}
+
+	case DropTable:
+		return []string{fmt.Sprintf("DROP TABLE %s", table)}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported change %v", d.name, change.Kind)
+}
+
+// createTable renders the CREATE TABLE statement of tbl, followed on Oracle
+// by the unique constraints it adds separately
+func (d sqlDialect) createTable(tbl schema.TableSchema) []string {
+	declared := tbl.HasDeclaredPrimaryKey()
+	keyColumns := tbl.PrimaryKeyColumns()
+
+	var definitions []string
+	if !declared {
+		definitions = append(definitions, d.ident("id")+" "+d.idType+" PRIMARY KEY")
+	}
+	for _, name := range columns(tbl) {
+		field := tbl.Fields[name]
+		definition := d.columnDefinition(tbl, field, true)
+		// SQLite only allows AUTOINCREMENT on a single-column INTEGER PRIMARY KEY
+		if d.name == "sqlite" && declared && len(keyColumns) == 1 && name == keyColumns[0] {
+			definition += " PRIMARY KEY"
+			if field.AutoIncrement {
+				definition += " AUTOINCREMENT"
+			}
+		}
+		definitions = append(definitions, definition)
+	}
+	if declared && (d.name != "sqlite" || len(keyColumns) > 1) {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", d.idents(keyColumns)))
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", d.ident(tbl.TableName), strings.Join(definitions, ", "))}
+	if d.name == "oracle" {
+		for _, name := range columns(tbl) {
+			if field := tbl.Fields[name]; field.Unique && !field.PrimaryKey {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)",
+					d.ident(tbl.TableName), d.ident(dialect.UniqueConstraint(tbl.TableName, name)), d.ident(name)))
+			}
+		}
+	}
+	return statements
+}
+
+// alterColumn renders the parts of an AlterColumn change that differ
+func (d sqlDialect) alterColumn(change Change) ([]string, error) {
+	table := d.ident(change.Table)
+	column := d.ident(change.Column.Name)
+	field := change.Column
+
+	var statements []string
+	switch d.name {
+	case "sqlite":
+		return nil, fmt.Errorf("sqlite cannot alter column %s.%s in place", change.Table, change.Column.Name)
+
+	case "tidb":
+		// MODIFY restates the whole column
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, d.columnDefinition(change.TableSchema, field, false)))
+
+	case "sqlserver":
+		if change.TypeChanged() || change.NullabilityChanged() {
+			null := " NULL"
+			if !field.Nullable {
+				null = " NOT NULL"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s", table, column, d.ColumnType(change.TableSchema, field), null))
+		}
+		if change.DefaultChanged() {
+			// Defaults are constraints with generated names, found through sys.default_constraints
+			object := dialect.QuoteString(table)
+			statements = append(statements, fmt.Sprintf("DECLARE @name sysname; "+
+				"SELECT @name = name FROM sys.default_constraints WHERE parent_object_id = OBJECT_ID(%s) "+
+				"AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId'); "+
+				"IF @name IS NOT NULL EXEC(%s + QUOTENAME(@name))",
+				object, object, dialect.QuoteString(field.Name), dialect.QuoteString("ALTER TABLE "+table+" DROP CONSTRAINT ")))
+			if field.Default != nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD DEFAULT %s FOR %s", table, d.defaultValue(field), column))
+			}
+		}
+
+	case "oracle":
+		// Oracle rejects MODIFY to a nullability the column already has, so each part is its own statement
+		if change.TypeChanged() {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY (%s %s)", table, column, d.ColumnType(change.TableSchema, field)))
+		}
+		if change.NullabilityChanged() {
+			null := "NULL"
+			if !field.Nullable {
+				null = "NOT NULL"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY (%s %s)", table, column, null))
+		}
+		if change.DefaultChanged() {
+			value := "NULL"
+			if field.Default != nil {
+				value = d.defaultValue(field)
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY (%s DEFAULT %s)", table, column, value))
+		}
+
+	default:
+		if change.TypeChanged() {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", table, column, d.ColumnType(change.TableSchema, field)))
+		}
+		if change.NullabilityChanged() {
+			action := "DROP NOT NULL"
+			if !field.Nullable {
+				action = "SET NOT NULL"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, action))
+		}
+		if change.DefaultChanged() {
+			action := "DROP DEFAULT"
+			if field.Default != nil {
+				action = "SET DEFAULT " + d.defaultValue(field)
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, action))
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,113 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderAll(t *testing.T, dialect Dialect, changes ...Change) []string {
+	t.Helper()
+	statements, err := Render(&Plan{Changes: changes}, dialect)
+	require.NoError(t, err)
+	return statements
+}
+
+func TestDialectFor(t *testing.T) {
+	for _, name := range []string{"sqlite", "postgres", "yugabyte", "cockroach", "tidb", "sqlserver", "oracle"} {
+		dialect, err := DialectFor(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, dialect.Name())
+	}
+	_, err := DialectFor("dbase")
+	assert.Error(t, err)
+}
+
+func TestRenderCreateTable(t *testing.T) {
+	notes := schema.NewTableSchema("notes")
+	notes.AddField(schema.ColumnData{Name: "title", DataType: "text", Unique: true})
+	notes.AddField(schema.ColumnData{Name: "views", DataType: "integer", Nullable: true, Default: 0})
+	create := Change{Kind: CreateTable, Table: "notes", TableSchema: *notes}
+
+	assert.Equal(t, []string{`CREATE TABLE "notes" ("id" TEXT PRIMARY KEY, "title" text NOT NULL UNIQUE, "views" integer NULL DEFAULT 0)`},
+		renderAll(t, SQLite, create))
+	assert.Equal(t, []string{`CREATE TABLE "notes" ("id" TEXT PRIMARY KEY, "title" TEXT NOT NULL UNIQUE, "views" INTEGER NULL DEFAULT 0)`},
+		renderAll(t, Postgres, create))
+	assert.Equal(t, []string{"CREATE TABLE `notes` (`id` VARCHAR(255) PRIMARY KEY, `title` VARCHAR(255) NOT NULL UNIQUE, `views` BIGINT NULL DEFAULT 0)"},
+		renderAll(t, TiDB, create))
+	assert.Equal(t, []string{"CREATE TABLE [notes] ([id] NVARCHAR(255) PRIMARY KEY, [title] NVARCHAR(255) NOT NULL UNIQUE, [views] BIGINT NULL DEFAULT 0)"},
+		renderAll(t, SQLServer, create))
+	assert.Equal(t, []string{
+		`CREATE TABLE "NOTES" ("ID" VARCHAR2(255) PRIMARY KEY, "TITLE" VARCHAR2(255) NOT NULL, "VIEWS" NUMBER(19) DEFAULT 0)`,
+		`ALTER TABLE "NOTES" ADD CONSTRAINT "UK_NOTES_TITLE" UNIQUE ("TITLE")`,
+	}, renderAll(t, Oracle, create))
+
+	stock := schema.NewTableSchema("stock")
+	stock.AddField(schema.ColumnData{Name: "region", DataType: "text", PrimaryKey: true})
+	stock.AddField(schema.ColumnData{Name: "sku", DataType: "integer", PrimaryKey: true})
+	assert.Equal(t, []string{`CREATE TABLE "stock" ("region" text NOT NULL, "sku" integer NOT NULL, PRIMARY KEY ("region", "sku"))`},
+		renderAll(t, SQLite, Change{Kind: CreateTable, Table: "stock", TableSchema: *stock}))
+	assert.Equal(t, []string{`CREATE TABLE "stock" ("region" STRING NOT NULL, "sku" INT8 NOT NULL, PRIMARY KEY ("region", "sku"))`},
+		renderAll(t, Cockroach, Change{Kind: CreateTable, Table: "stock", TableSchema: *stock}))
+}
+
+func TestRenderColumnChanges(t *testing.T) {
+	tbl := *peopleTable()
+	add := Change{Kind: AddColumn, Table: "people", TableSchema: tbl, Column: schema.ColumnData{Name: "email", DataType: "text", Nullable: true}}
+	alter := Change{Kind: AlterColumn, Table: "people", TableSchema: tbl,
+		Column: schema.ColumnData{Name: "age", DataType: "integer", Default: 1},
+		From:   schema.ColumnData{Name: "age", DataType: "text", Nullable: true}}
+	drop := Change{Kind: DropColumn, Table: "people", TableSchema: tbl, Column: schema.ColumnData{Name: "legacy"}}
+
+	assert.Equal(t, []string{
+		`ALTER TABLE "people" ADD COLUMN "email" TEXT NULL`,
+		`ALTER TABLE "people" ALTER COLUMN "age" TYPE INTEGER`,
+		`ALTER TABLE "people" ALTER COLUMN "age" SET NOT NULL`,
+		`ALTER TABLE "people" ALTER COLUMN "age" SET DEFAULT 1`,
+		`ALTER TABLE "people" DROP COLUMN "legacy"`,
+	}, renderAll(t, Postgres, add, alter, drop))
+
+	assert.Equal(t, []string{
+		"ALTER TABLE `people` ADD COLUMN `email` TEXT NULL",
+		"ALTER TABLE `people` MODIFY COLUMN `age` BIGINT NOT NULL DEFAULT 1",
+		"ALTER TABLE `people` DROP COLUMN `legacy`",
+	}, renderAll(t, TiDB, add, alter, drop))
+
+	statements := renderAll(t, SQLServer, add, alter, drop)
+	require.Len(t, statements, 5)
+	assert.Equal(t, "ALTER TABLE [people] ADD [email] NVARCHAR(MAX) NULL", statements[0])
+	assert.Equal(t, "ALTER TABLE [people] ALTER COLUMN [age] BIGINT NOT NULL", statements[1])
+	assert.Contains(t, statements[2], "sys.default_constraints")
+	assert.Contains(t, statements[2], "COLUMNPROPERTY(OBJECT_ID('[people]'), 'age', 'ColumnId')")
+	assert.Equal(t, "ALTER TABLE [people] ADD DEFAULT 1 FOR [age]", statements[3])
+	assert.Equal(t, "ALTER TABLE [people] DROP COLUMN [legacy]", statements[4])
+
+	assert.Equal(t, []string{
+		`ALTER TABLE "PEOPLE" ADD ("EMAIL" CLOB)`,
+		`ALTER TABLE "PEOPLE" MODIFY ("AGE" NUMBER(19))`,
+		`ALTER TABLE "PEOPLE" MODIFY ("AGE" NOT NULL)`,
+		`ALTER TABLE "PEOPLE" MODIFY ("AGE" DEFAULT 1)`,
+		`ALTER TABLE "PEOPLE" DROP COLUMN "LEGACY"`,
+	}, renderAll(t, Oracle, add, alter, drop))
+
+	_, err := SQLite.Render(alter)
+	assert.Error(t, err)
+}
+
+func TestRenderIndexes(t *testing.T) {
+	index := schema.Index{Name: "uq_people_team_handle", Columns: []string{"team", "handle"}, Unique: true}
+	create := Change{Kind: CreateIndex, Table: "people", Index: index}
+	drop := Change{Kind: DropIndex, Table: "people", Index: index}
+
+	for dialect, want := range map[Dialect][]string{
+		SQLite:    {`CREATE UNIQUE INDEX "uq_people_team_handle" ON "people" ("team", "handle")`, `DROP INDEX "uq_people_team_handle"`},
+		Cockroach: {`CREATE UNIQUE INDEX "uq_people_team_handle" ON "people" ("team", "handle")`, `DROP INDEX "people"@"uq_people_team_handle"`},
+		TiDB:      {"CREATE UNIQUE INDEX `uq_people_team_handle` ON `people` (`team`, `handle`)", "DROP INDEX `uq_people_team_handle` ON `people`"},
+		SQLServer: {"CREATE UNIQUE INDEX [uq_people_team_handle] ON [people] ([team], [handle])", "DROP INDEX [uq_people_team_handle] ON [people]"},
+		Oracle:    {`CREATE UNIQUE INDEX "UQ_PEOPLE_TEAM_HANDLE" ON "PEOPLE" ("TEAM", "HANDLE")`, `DROP INDEX "UQ_PEOPLE_TEAM_HANDLE"`},
+	} {
+		assert.Equal(t, want, renderAll(t, dialect, create, drop), dialect.Name())
+	}
+}
@@ -0,0 +1,92 @@
+// Package migrate evolves a database's tables towards a desired schema.
+//
+// Diff compares the desired schema with the one introspected from the live
+// database and returns a Plan of ordered changes: tables, columns and indexes
+// to create, alter or drop. A Dialect renders each change as DDL for one
+// database, and Apply runs the statements:
+//
+//	db, _ := sql.Open("sqlite3", "app.db")
+//	plan, err := migrate.Migrate(ctx, db, infoschema.NewSQLiteAdapter(db), desired, migrate.Options{
+//		Dialect: migrate.SQLite,
+//	})
+//
+// Use Storage.CreateTables to create a fresh database and Migrate afterwards,
+// as CREATE TABLE IF NOT EXISTS never changes a table that already exists.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// Introspector reads the schema of a live database, as infoschema.Parser
+// and infoschema.SQLiteAdapter do
+type Introspector interface {
+	ParseSchema(databaseName string) (*schema.Schema, error)
+}
+
+// Render returns the statements applying plan in dialect, in order
+func Render(plan *Plan, dialect Dialect) ([]string, error) {
+	var statements []string
+	for _, change := range plan.Changes {
+		rendered, err := dialect.Render(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", change, err)
+		}
+		statements = append(statements, rendered...)
+	}
+	return statements, nil
+}
+
+// Apply renders plan in dialect and runs the statements on db in one
+// transaction. Databases that commit DDL implicitly (TiDB, Oracle) keep the
+// statements run before a failure.
+func Apply(ctx context.Context, db *sql.DB, dialect Dialect, plan *Plan) error {
+	statements, err := Render(plan, dialect)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	for _, statement := range statements {
+		storage.DebugLog(statement)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply %q: %w", statement, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// Migrate introspects db, diffs it against desired and applies the plan,
+// which it returns. opts.Dialect is required.
+func Migrate(ctx context.Context, db *sql.DB, introspector Introspector, desired *schema.Schema, opts Options) (*Plan, error) {
+	if opts.Dialect == nil {
+		return nil, errors.New("migrate: no dialect given")
+	}
+
+	current, err := introspector.ParseSchema(desired.DatabaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current schema: %w", err)
+	}
+
+	plan := Diff(current, desired, opts)
+	if err := Apply(ctx, db, opts.Dialect, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
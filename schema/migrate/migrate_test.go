@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/schema/parser/infoschema"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE people (id TEXT PRIMARY KEY, name text NOT NULL, legacy text NULL)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO people (id, name) VALUES ('1', 'Ann')")
+	require.NoError(t, err)
+
+	people := schema.NewTableSchema("people")
+	people.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	people.AddField(schema.ColumnData{Name: "email", DataType: "text", Nullable: true, Index: true})
+	posts := schema.NewTableSchema("posts")
+	posts.AddField(schema.ColumnData{Name: "author", DataType: "text"})
+	posts.AddField(schema.ColumnData{Name: "title", DataType: "text"})
+	posts.AddIndex("", true, "author", "title")
+	desired := schemaOf(people, posts)
+
+	opts := Options{Dialect: SQLite, AllowDrops: true}
+	adapter := infoschema.NewSQLiteAdapter(db)
+	plan, err := Migrate(ctx, db, adapter, desired, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create table posts",
+		"add column people.email",
+		"drop column people.legacy",
+		"create index idx_people_email on people (email)",
+		"create unique index uq_posts_author_title on posts (author, title)",
+	}, kinds(plan))
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM people WHERE id = '1'").Scan(&name))
+	assert.Equal(t, "Ann", name)
+
+	// Migrating again finds nothing left to do
+	plan, err = Migrate(ctx, db, adapter, desired, opts)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+}
@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// ChangeKind identifies what a Change does
+type ChangeKind int
+
+const (
+	CreateTable ChangeKind = iota
+	AddColumn
+	AlterColumn
+	DropColumn
+	CreateIndex
+	DropIndex
+	DropTable
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case CreateTable:
+		return "create table"
+	case AddColumn:
+		return "add column"
+	case AlterColumn:
+		return "alter column"
+	case DropColumn:
+		return "drop column"
+	case CreateIndex:
+		return "create index"
+	case DropIndex:
+		return "drop index"
+	case DropTable:
+		return "drop table"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is one step of a Plan
+type Change struct {
+	Kind  ChangeKind
+	Table string
+	// TableSchema is the desired definition of the table; for DropTable and
+	// the changes dropping from it, the current one
+	TableSchema schema.TableSchema
+	// Column is the desired column for AddColumn and AlterColumn, and the
+	// dropped column for DropColumn
+	Column schema.ColumnData
+	// From is the current column for AlterColumn
+	From schema.ColumnData
+	// Index is the index created or dropped
+	Index schema.Index
+}
+
+// TypeChanged, NullabilityChanged and DefaultChanged report which parts of
+// an AlterColumn change differ; a Dialect renders only those
+func (c Change) TypeChanged() bool {
+	return c.Column.DataType != c.From.DataType
+}
+
+func (c Change) NullabilityChanged() bool {
+	return c.Column.Nullable != c.From.Nullable
+}
+
+func (c Change) DefaultChanged() bool {
+	return !sameDefault(c.Column.Default, c.From.Default)
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case CreateTable, DropTable:
+		return fmt.Sprintf("%s %s", c.Kind, c.Table)
+	case AddColumn, DropColumn:
+		return fmt.Sprintf("%s %s.%s", c.Kind, c.Table, c.Column.Name)
+	case AlterColumn:
+		var parts []string
+		if c.TypeChanged() {
+			parts = append(parts, fmt.Sprintf("type %s -> %s", c.From.DataType, c.Column.DataType))
+		}
+		if c.NullabilityChanged() {
+			parts = append(parts, fmt.Sprintf("nullable %t -> %t", c.From.Nullable, c.Column.Nullable))
+		}
+		if c.DefaultChanged() {
+			parts = append(parts, fmt.Sprintf("default %v -> %v", c.From.Default, c.Column.Default))
+		}
+		return fmt.Sprintf("%s %s.%s (%s)", c.Kind, c.Table, c.Column.Name, strings.Join(parts, ", "))
+	default:
+		kind := c.Kind.String()
+		if c.Index.Unique {
+			kind = strings.Replace(kind, "index", "unique index", 1)
+		}
+		return fmt.Sprintf("%s %s on %s (%s)", kind, c.Index.Name, c.Table, strings.Join(c.Index.Columns, ", "))
+	}
+}
+
+// Plan is an ordered list of changes turning one schema into another
+type Plan struct {
+	Changes []Change
+}
+
+// Empty reports whether the plan has nothing to do
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String lists the plan's changes, one per line
+func (p *Plan) String() string {
+	lines := make([]string, len(p.Changes))
+	for i, change := range p.Changes {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Options control Diff
+type Options struct {
+	// AllowDrops includes changes dropping the tables, columns and indexes
+	// missing from the desired schema. By default they are left in place.
+	AllowDrops bool
+	// Dialect compares column types the way the database reports them.
+	// Without one, DataTypes are compared as written, ignoring case.
+	Dialect Dialect
+}
+
+// Diff plans the changes turning current, usually introspected from a live
+// database by infoschema.Parser or infoschema.SQLiteAdapter, into desired.
+//
+// Changes are ordered so each can be applied after the ones before it: index
+// drops, new tables, added, altered and dropped columns, new indexes, and
+// finally dropped tables. Tables are visited in name order. Primary keys are
+// compared only to skip the implicit id column; changing a key is not planned.
+func Diff(current, desired *schema.Schema, opts Options) *Plan {
+	var dropIndexes, createTables, addColumns, alterColumns, dropColumns, createIndexes, dropTables []Change
+
+	for _, name := range tableNames(desired) {
+		want, _ := desired.GetTable(name)
+		have, ok := current.GetTable(name)
+		if !ok {
+			createTables = append(createTables, Change{Kind: CreateTable, Table: name, TableSchema: want})
+			for _, index := range want.SecondaryIndexes() {
+				createIndexes = append(createIndexes, Change{Kind: CreateIndex, Table: name, TableSchema: want, Index: index})
+			}
+			continue
+		}
+
+		for _, column := range columns(want) {
+			field := want.Fields[column]
+			currentField, ok := have.Fields[column]
+			if !ok {
+				addColumns = append(addColumns, Change{Kind: AddColumn, Table: name, TableSchema: want, Column: field})
+				continue
+			}
+			if !sameType(opts.Dialect, want, field, currentField.DataType) || field.Nullable != currentField.Nullable || !sameDefault(field.Default, currentField.Default) {
+				from := currentField
+				if sameType(opts.Dialect, want, field, currentField.DataType) {
+					from.DataType = field.DataType // Only report the parts that differ
+				}
+				alterColumns = append(alterColumns, Change{Kind: AlterColumn, Table: name, TableSchema: want, Column: field, From: from})
+			}
+		}
+		if opts.AllowDrops {
+			wanted := columns(want)
+			for _, column := range columns(have) {
+				if !slices.Contains(wanted, column) && !(column == "id" && !want.HasDeclaredPrimaryKey()) {
+					dropColumns = append(dropColumns, Change{Kind: DropColumn, Table: name, TableSchema: have, Column: have.Fields[column]})
+				}
+			}
+		}
+
+		wantIndexes := want.SecondaryIndexes()
+		for _, index := range wantIndexes {
+			i := slices.IndexFunc(have.IndexDefs, func(existing schema.Index) bool { return existing.Name == index.Name })
+			if i >= 0 && sameIndex(have.IndexDefs[i], index) {
+				continue
+			}
+			if i >= 0 {
+				dropIndexes = append(dropIndexes, Change{Kind: DropIndex, Table: name, TableSchema: have, Index: have.IndexDefs[i]})
+			}
+			createIndexes = append(createIndexes, Change{Kind: CreateIndex, Table: name, TableSchema: want, Index: index})
+		}
+		if opts.AllowDrops {
+			for _, index := range have.IndexDefs {
+				wanted := slices.ContainsFunc(wantIndexes, func(w schema.Index) bool { return w.Name == index.Name })
+				if !wanted && !uniqueConstraint(want, index) {
+					dropIndexes = append(dropIndexes, Change{Kind: DropIndex, Table: name, TableSchema: have, Index: index})
+				}
+			}
+		}
+	}
+
+	if opts.AllowDrops {
+		for _, name := range tableNames(current) {
+			if _, ok := desired.GetTable(name); !ok {
+				have, _ := current.GetTable(name)
+				dropTables = append(dropTables, Change{Kind: DropTable, Table: name, TableSchema: have})
+			}
+		}
+	}
+
+	plan := &Plan{}
+	for _, changes := range [][]Change{dropIndexes, createTables, addColumns, alterColumns, dropColumns, createIndexes, dropTables} {
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan
+}
+
+// tableNames returns the names of sch's tables in sorted order
+func tableNames(sch *schema.Schema) []string {
+	sch.RLock()
+	defer sch.RUnlock()
+	names := make([]string, 0, len(sch.Tables))
+	for name := range sch.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// columns returns tbl's columns in field order, without the implicit id
+// column of a table that declares no primary key
+func columns(tbl schema.TableSchema) []string {
+	declared := tbl.HasDeclaredPrimaryKey()
+	names := make([]string, 0, len(tbl.FieldOrder))
+	for _, name := range tbl.FieldOrder {
+		if !declared && name == "id" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func sameType(dialect Dialect, tbl schema.TableSchema, field schema.ColumnData, current string) bool {
+	if dialect == nil {
+		return strings.EqualFold(field.DataType, current)
+	}
+	return dialect.SameType(tbl, field, current)
+}
+
+// sameDefault compares a desired default with one read back from a database,
+// which may report string defaults with their SQL quotes
+func sameDefault(want, have any) bool {
+	if want == nil || have == nil {
+		return want == nil && have == nil
+	}
+	haveText := fmt.Sprint(have)
+	if len(haveText) >= 2 && haveText[0] == '\'' && haveText[len(haveText)-1] == '\'' {
+		haveText = strings.ReplaceAll(haveText[1:len(haveText)-1], "''", "'")
+	}
+	return fmt.Sprint(want) == haveText
+}
+
+func sameIndex(a, b schema.Index) bool {
+	return a.Unique == b.Unique && slices.Equal(a.Columns, b.Columns)
+}
+
+// uniqueConstraint reports whether index is the one a database creates for
+// a column tbl declares Unique, which is kept even when dropping is allowed
+func uniqueConstraint(tbl schema.TableSchema, index schema.Index) bool {
+	return index.Unique && len(index.Columns) == 1 && tbl.Fields[index.Columns[0]].Unique
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func peopleTable(fields ...schema.ColumnData) *schema.TableSchema {
+	tbl := schema.NewTableSchema("people")
+	tbl.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	for _, field := range fields {
+		tbl.AddField(field)
+	}
+	return tbl
+}
+
+func schemaOf(tables ...*schema.TableSchema) *schema.Schema {
+	sch := schema.New()
+	for _, tbl := range tables {
+		sch.AddTable(tbl)
+	}
+	return sch
+}
+
+func kinds(plan *Plan) []string {
+	var out []string
+	for _, change := range plan.Changes {
+		out = append(out, change.String())
+	}
+	return out
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	current := schemaOf(peopleTable(schema.ColumnData{Name: "name", DataType: "text"}))
+	desired := schemaOf(peopleTable(schema.ColumnData{Name: "name", DataType: "TEXT"}))
+
+	plan := Diff(current, desired, Options{})
+	assert.True(t, plan.Empty(), plan.String())
+}
+
+func TestDiffOrdersChanges(t *testing.T) {
+	have := peopleTable(
+		schema.ColumnData{Name: "name", DataType: "text"},
+		schema.ColumnData{Name: "age", DataType: "text", Nullable: true},
+		schema.ColumnData{Name: "legacy", DataType: "text"},
+		schema.ColumnData{Name: "team", DataType: "text"},
+	)
+	have.AddIndex("idx_people_legacy", false, "legacy")
+	have.AddIndex("idx_people_team", false, "team", "name")
+
+	want := peopleTable(
+		schema.ColumnData{Name: "name", DataType: "text", Default: "anon"},
+		schema.ColumnData{Name: "age", DataType: "integer"},
+		schema.ColumnData{Name: "team", DataType: "text"},
+		schema.ColumnData{Name: "email", DataType: "text", Index: true},
+	)
+	want.AddIndex("idx_people_team", false, "team")
+
+	posts := schema.NewTableSchema("posts")
+	posts.AddField(schema.ColumnData{Name: "title", DataType: "text", Index: true})
+
+	plan := Diff(schemaOf(have, schema.NewTableSchema("old")), schemaOf(want, posts), Options{AllowDrops: true})
+	assert.Equal(t, []string{
+		"drop index idx_people_team on people (team, name)",
+		"drop index idx_people_legacy on people (legacy)",
+		"create table posts",
+		"add column people.email",
+		"alter column people.name (default <nil> -> anon)",
+		"alter column people.age (type text -> integer, nullable true -> false)",
+		"drop column people.legacy",
+		"create index idx_people_team on people (team)",
+		"create index idx_people_email on people (email)",
+		"create index idx_posts_title on posts (title)",
+		"drop table old",
+	}, kinds(plan))
+}
+
+func TestDiffKeepsWithoutAllowDrops(t *testing.T) {
+	have := peopleTable(schema.ColumnData{Name: "legacy", DataType: "text"})
+	have.AddIndex("idx_people_legacy", false, "legacy")
+
+	plan := Diff(schemaOf(have, schema.NewTableSchema("old")), schemaOf(peopleTable()), Options{})
+	assert.True(t, plan.Empty(), plan.String())
+}
+
+func TestDiffImplicitID(t *testing.T) {
+	// A table without a declared key reads back with its implicit id column
+	have := schema.NewTableSchema("notes")
+	have.AddField(schema.ColumnData{Name: "id", DataType: "TEXT", PrimaryKey: true})
+	have.AddField(schema.ColumnData{Name: "body", DataType: "text"})
+	have.AddIndex("sqlite_email", true, "email")
+
+	want := schema.NewTableSchema("notes")
+	want.AddField(schema.ColumnData{Name: "body", DataType: "text"})
+	want.AddField(schema.ColumnData{Name: "email", DataType: "text", Unique: true})
+
+	plan := Diff(schemaOf(have), schemaOf(want), Options{AllowDrops: true})
+	assert.Equal(t, []string{"add column notes.email"}, kinds(plan))
+}
+
+func TestDiffDialectTypes(t *testing.T) {
+	have := peopleTable(
+		schema.ColumnData{Name: "name", DataType: "varchar"},
+		schema.ColumnData{Name: "age", DataType: "bigint"},
+		schema.ColumnData{Name: "active", DataType: "tinyint"},
+	)
+	have.Fields["id"] = schema.ColumnData{Name: "id", DataType: "varchar", PrimaryKey: true}
+	want := peopleTable(
+		schema.ColumnData{Name: "name", DataType: "varchar"},
+		schema.ColumnData{Name: "age", DataType: "integer"},
+		schema.ColumnData{Name: "active", DataType: "boolean"},
+	)
+
+	assert.True(t, Diff(schemaOf(have), schemaOf(want), Options{Dialect: TiDB}).Empty())
+	assert.Len(t, Diff(schemaOf(have), schemaOf(want), Options{}).Changes, 3)
+}
+
+func TestSameDefault(t *testing.T) {
+	assert.True(t, sameDefault(nil, nil))
+	assert.True(t, sameDefault("x", "'x'"))
+	assert.True(t, sameDefault("it's", "'it''s'"))
+	assert.True(t, sameDefault(5, "5"))
+	assert.False(t, sameDefault("x", nil))
+	assert.False(t, sameDefault(nil, "x"))
+	assert.False(t, sameDefault("x", "'y'"))
+}
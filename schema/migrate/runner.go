@@ -0,0 +1,583 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+const (
+	// HistoryTableName is the table recording applied migrations, one row per version
+	HistoryTableName = "ddao_migrations"
+	// LockTableName is the table holding the runner's lock object while it migrates
+	LockTableName = "ddao_migrations_lock"
+
+	lockID = "lock"
+)
+
+var (
+	// ErrLocked is returned when another runner kept the lock past LockTimeout
+	ErrLocked = errors.New("migrations are locked by another runner")
+	// ErrChecksumMismatch is returned by Up when an applied migration no longer
+	// matches the checksum recorded for it
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrNoDown is returned when rolling back a migration without a Down function
+	ErrNoDown = errors.New("migration has no down function")
+	// ErrUnknownVersion is returned when rolling back an applied version that is not registered
+	ErrUnknownVersion = errors.New("applied migration is not registered")
+)
+
+// MigrationFunc runs one direction of a Migration. Writes made through tx are
+// committed together with the history record; DDL made through store, such
+// as CreateTables or AlterTable, is not part of the transaction.
+type MigrationFunc func(ctx context.Context, store storage.Storage, tx storage.Tx) error
+
+// Migration is one hand-written, versioned change
+type Migration struct {
+	// Version orders the migrations; it must be positive and unique
+	Version int64
+	Name    string
+	Up      MigrationFunc
+	// Down reverts Up; Down and Redo fail with ErrNoDown without one
+	Down MigrationFunc
+	// Source is optional text covered by the checksum along with Version and
+	// Name, such as the SQL the migration runs. Changing it after the
+	// migration is applied makes Up fail with ErrChecksumMismatch.
+	Source string
+}
+
+// Checksum identifies the migration's version, name and source
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%s", m.Version, m.Name, m.Source)))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus reports one migration, registered or found in the history table
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+	// AppliedAt is when the migration was applied; zero if it is not
+	AppliedAt time.Time
+	// Modified reports an applied migration whose checksum changed since
+	Modified bool
+	// Missing reports an applied migration that is no longer registered
+	Missing bool
+}
+
+// HistoryTables returns the tables the runner keeps its history and lock in
+func HistoryTables() []*schema.TableSchema {
+	history := schema.NewTableSchema(HistoryTableName)
+	history.AddField(schema.ColumnData{Name: "version", DataType: "integer", PrimaryKey: true})
+	history.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	history.AddField(schema.ColumnData{Name: "checksum", DataType: "text"})
+	history.AddField(schema.ColumnData{Name: "applied_at", DataType: "text"})
+
+	lock := schema.NewTableSchema(LockTableName)
+	lock.AddField(schema.ColumnData{Name: "owner", DataType: "text"})
+	lock.AddField(schema.ColumnData{Name: "expires_at", DataType: "integer"})
+	return []*schema.TableSchema{history, lock}
+}
+
+// Runner applies versioned migrations to a Storage, recording each applied
+// version in the ddao_migrations table. Only storage calls are used, so it
+// works on every backend; on S3 and Scylla the history rows and the lock are
+// plain objects.
+//
+// Each operation takes a lock first, an object in ddao_migrations_lock
+// created with Insert, so replicas booting at once migrate one at a time.
+// A lock left behind by a crashed runner is broken once it is older than
+// LockTTL. The lock is renewed before every migration, and the run fails if
+// another runner took it over in the meantime.
+//
+// The runner adds its tables to the storage's current schema with
+// CreateTables, keeping the tables already known to it. CreateTables replaces
+// the storage's schema, so Up and Down functions calling it keep the
+// runner's tables as well.
+type Runner struct {
+	store      storage.Storage
+	migrations []Migration
+
+	// Owner identifies this runner in the lock object; defaults to the host name and process ID
+	Owner string
+	// LockTTL is how long the lock is held before other runners may break it; defaults to 15 minutes
+	LockTTL time.Duration
+	// LockTimeout is how long to wait for another runner's lock; defaults to 5 minutes
+	LockTimeout time.Duration
+	// PollInterval is how often a waiting runner retries the lock; defaults to 1 second
+	PollInterval time.Duration
+}
+
+// NewRunner returns a Runner applying migrations to store in version order
+func NewRunner(store storage.Storage, migrations ...Migration) (*Runner, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q: version must be positive", m.Name)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d: missing up function", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration %d registered twice", m.Version)
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return &Runner{
+		store:        store,
+		migrations:   sorted,
+		Owner:        fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		LockTTL:      15 * time.Minute,
+		LockTimeout:  5 * time.Minute,
+		PollInterval: time.Second,
+	}, nil
+}
+
+// Up applies the pending migrations in version order, each in its own
+// transaction, and returns the ones applied. It stops at the first failure.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := r.locked(ctx, func(history map[int64]*object.Object) error {
+		for _, m := range r.migrations {
+			record, ok := history[m.Version]
+			if !ok {
+				continue
+			}
+			if checksum, _ := record.GetString("checksum"); checksum != m.Checksum() {
+				return fmt.Errorf("%w: migration %d (%s)", ErrChecksumMismatch, m.Version, m.Name)
+			}
+		}
+
+		for _, m := range r.migrations {
+			if _, ok := history[m.Version]; ok {
+				continue
+			}
+			if err := r.up(ctx, m); err != nil {
+				return err
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the n most recently applied migrations, newest first, and
+// returns the ones rolled back
+func (r *Runner) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := r.locked(ctx, func(history map[int64]*object.Object) error {
+		for _, version := range appliedVersions(history, n) {
+			m, err := r.migration(version)
+			if err != nil {
+				return err
+			}
+			if err := r.down(ctx, m); err != nil {
+				return err
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again,
+// returning it; nil if nothing is applied
+func (r *Runner) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := r.locked(ctx, func(history map[int64]*object.Object) error {
+		versions := appliedVersions(history, 1)
+		if len(versions) == 0 {
+			return nil
+		}
+		m, err := r.migration(versions[0])
+		if err != nil {
+			return err
+		}
+		if err := r.down(ctx, m); err != nil {
+			return err
+		}
+		if err := r.up(ctx, m); err != nil {
+			return err
+		}
+		redone = &m
+		return nil
+	})
+	return redone, err
+}
+
+// Status reports every registered migration, and every applied one that is
+// no longer registered, in version order
+func (r *Runner) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := r.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	history, err := r.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range r.migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if record, ok := history[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = appliedAt(record)
+			checksum, _ := record.GetString("checksum")
+			status.Modified = checksum != m.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range history {
+		if _, err := r.migration(version); err == nil {
+			continue
+		}
+		name, _ := record.GetString("name")
+		statuses = append(statuses, MigrationStatus{Version: version, Name: name, Applied: true, AppliedAt: appliedAt(record), Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// up runs m.Up and records m in the same transaction
+func (r *Runner) up(ctx context.Context, m Migration) error {
+	return r.inTx(ctx, m, "up", m.Up, func(tx storage.Tx) error {
+		record := object.New()
+		record.TableName = HistoryTableName
+		record.ID = strconv.FormatInt(m.Version, 10)
+		record.Fields["version"] = m.Version
+		record.Fields["name"] = m.Name
+		record.Fields["checksum"] = m.Checksum()
+		record.Fields["applied_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+		_, _, err := tx.Insert(ctx, record)
+		return err
+	})
+}
+
+// down runs m.Down and removes m's record in the same transaction
+func (r *Runner) down(ctx context.Context, m Migration) error {
+	if m.Down == nil {
+		return fmt.Errorf("%w: migration %d (%s)", ErrNoDown, m.Version, m.Name)
+	}
+	return r.inTx(ctx, m, "down", m.Down, func(tx storage.Tx) error {
+		_, err := tx.DeleteByID(ctx, HistoryTableName, strconv.FormatInt(m.Version, 10))
+		return err
+	})
+}
+
+func (r *Runner) inTx(ctx context.Context, m Migration, direction string, fn MigrationFunc, record func(tx storage.Tx) error) error {
+	if err := r.renew(ctx); err != nil {
+		return err
+	}
+	tx, err := r.store.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	storage.DebugLog(fmt.Sprintf("migration %d (%s) %s", m.Version, m.Name, direction))
+	if err := fn(ctx, &runnerStorage{Storage: r.store}, tx); err != nil {
+		return fmt.Errorf("migration %d (%s) %s failed: %w", m.Version, m.Name, direction, err)
+	}
+	if err := record(tx); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// locked runs fn holding the lock, with the history read after taking it
+func (r *Runner) locked(ctx context.Context, fn func(history map[int64]*object.Object) error) error {
+	if err := r.ensureTables(ctx); err != nil {
+		return err
+	}
+	if err := r.lock(ctx); err != nil {
+		return err
+	}
+	defer r.unlock(context.WithoutCancel(ctx))
+
+	history, err := r.history(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(history)
+}
+
+// lock creates the lock object, waiting up to LockTimeout while another
+// runner holds it and breaking it once it has expired
+func (r *Runner) lock(ctx context.Context) error {
+	deadline := time.Now().Add(r.LockTimeout)
+	for {
+		lock := object.New()
+		lock.TableName = LockTableName
+		lock.ID = lockID
+		lock.Fields["owner"] = r.Owner
+		lock.Fields["expires_at"] = time.Now().Add(r.LockTTL).UnixMilli()
+
+		_, _, err := r.store.Insert(ctx, lock)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ddaoerrors.ErrConflict) {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+
+		held, err := r.store.FindByID(ctx, LockTableName, lockID)
+		if err != nil && !errors.Is(err, ddaoerrors.ErrNotFound) {
+			return fmt.Errorf("failed to read migration lock: %w", err)
+		}
+		if held == nil {
+			continue // Released in the meantime
+		}
+		owner, _ := held.GetString("owner")
+		expiresAt, _ := held.GetInt64("expires_at")
+		if time.Now().UnixMilli() > expiresAt {
+			broken, err := r.breakLock(ctx, owner, expiresAt)
+			if err != nil {
+				return err
+			}
+			if broken {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (held by %s)", ErrLocked, owner)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.PollInterval):
+		}
+	}
+}
+
+// breakLock takes over the expired lock of owner, reporting false if another
+// runner broke it first or owner renewed or released it. The takeover inserts
+// a claim object named after the lock it replaces in the same transaction, so
+// of the runners breaking one lock only the first to insert the claim commits,
+// whatever isolation the backend gives. Claims are kept, as deleting them
+// would let a runner that read the lock before it was broken break it again.
+func (r *Runner) breakLock(ctx context.Context, owner string, expiresAt int64) (bool, error) {
+	tx, err := r.store.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to break migration lock: %w", err)
+	}
+	defer tx.Rollback()
+
+	claim := object.New()
+	claim.TableName = LockTableName
+	claim.ID = fmt.Sprintf("broken-%s-%d", owner, expiresAt)
+	claim.Fields["owner"] = r.Owner
+	claim.Fields["expires_at"] = expiresAt
+	if _, _, err := tx.Insert(ctx, claim); err != nil {
+		if errors.Is(err, ddaoerrors.ErrConflict) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to break migration lock: %w", err)
+	}
+
+	held, err := tx.FindByID(ctx, LockTableName, lockID)
+	if err != nil && !errors.Is(err, ddaoerrors.ErrNotFound) {
+		return false, fmt.Errorf("failed to read migration lock: %w", err)
+	}
+	if held == nil {
+		return false, nil
+	}
+	if heldOwner, _ := held.GetString("owner"); heldOwner != owner {
+		return false, nil
+	}
+	if heldExpiresAt, _ := held.GetInt64("expires_at"); heldExpiresAt != expiresAt {
+		return false, nil
+	}
+
+	lock := object.New()
+	lock.TableName = LockTableName
+	lock.ID = lockID
+	lock.Fields["owner"] = r.Owner
+	lock.Fields["expires_at"] = time.Now().Add(r.LockTTL).UnixMilli()
+	if _, err := tx.Update(ctx, lock); err != nil {
+		return false, fmt.Errorf("failed to break migration lock: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		// A concurrent takeover, renewal or release won; the lock is read again
+		storage.DebugLog("failed to break migration lock", err)
+		return false, nil
+	}
+	storage.DebugLog("broke expired migration lock", owner)
+	return true, nil
+}
+
+// renew extends the lock by LockTTL, failing if another runner took it over
+func (r *Runner) renew(ctx context.Context) error {
+	tx, err := r.store.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to renew migration lock: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.checkOwner(ctx, tx); err != nil {
+		return err
+	}
+	lock := object.New()
+	lock.TableName = LockTableName
+	lock.ID = lockID
+	lock.Fields["owner"] = r.Owner
+	lock.Fields["expires_at"] = time.Now().Add(r.LockTTL).UnixMilli()
+	if _, err := tx.Update(ctx, lock); err != nil {
+		return fmt.Errorf("failed to renew migration lock: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to renew migration lock: %w", err)
+	}
+	return nil
+}
+
+// unlock deletes the lock object unless another runner took it over
+func (r *Runner) unlock(ctx context.Context) {
+	tx, err := r.store.BeginTx(ctx, nil)
+	if err != nil {
+		storage.DebugLog("failed to release migration lock", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := r.checkOwner(ctx, tx); err != nil {
+		storage.DebugLog("failed to release migration lock", err)
+		return
+	}
+	if _, err := tx.DeleteByID(ctx, LockTableName, lockID); err != nil {
+		storage.DebugLog("failed to release migration lock", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		storage.DebugLog("failed to release migration lock", err)
+	}
+}
+
+// checkOwner fails with ErrLocked unless the lock object is r's
+func (r *Runner) checkOwner(ctx context.Context, tx storage.Tx) error {
+	held, err := tx.FindByID(ctx, LockTableName, lockID)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return fmt.Errorf("%w (lock was released)", ErrLocked)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read migration lock: %w", err)
+	}
+	if owner, _ := held.GetString("owner"); owner != r.Owner {
+		return fmt.Errorf("%w (taken over by %s)", ErrLocked, owner)
+	}
+	return nil
+}
+
+// ensureTables creates the history and lock tables, keeping the tables the
+// storage already knows about
+func (r *Runner) ensureTables(ctx context.Context) error {
+	var current *schema.Schema
+	if holder, ok := r.store.(interface{ GetSchema() *schema.Schema }); ok {
+		current = holder.GetSchema()
+	}
+	if err := r.store.CreateTables(ctx, withHistoryTables(current)); err != nil {
+		return fmt.Errorf("failed to create migration tables: %w", err)
+	}
+	return nil
+}
+
+// history reads the applied migrations' records by version
+func (r *Runner) history(ctx context.Context) (map[int64]*object.Object, error) {
+	history := map[int64]*object.Object{}
+	opts := storage.ListOptions{}
+	for {
+		records, cursor, err := r.store.List(ctx, HistoryTableName, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration history: %w", err)
+		}
+		for _, record := range records {
+			version, ok := record.GetInt64("version")
+			if !ok {
+				version, _ = strconv.ParseInt(record.ID, 10, 64)
+			}
+			history[version] = record
+		}
+		if cursor == "" {
+			return history, nil
+		}
+		opts.Cursor = cursor
+	}
+}
+
+func (r *Runner) migration(version int64) (Migration, error) {
+	i := sort.Search(len(r.migrations), func(i int) bool { return r.migrations[i].Version >= version })
+	if i == len(r.migrations) || r.migrations[i].Version != version {
+		return Migration{}, fmt.Errorf("%w: version %d", ErrUnknownVersion, version)
+	}
+	return r.migrations[i], nil
+}
+
+// appliedVersions returns up to n applied versions, newest first
+func appliedVersions(history map[int64]*object.Object, n int) []int64 {
+	versions := make([]int64, 0, len(history))
+	for version := range history {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if n < len(versions) {
+		versions = versions[:max(n, 0)]
+	}
+	return versions
+}
+
+func appliedAt(record *object.Object) time.Time {
+	text, _ := record.GetString("applied_at")
+	at, _ := time.Parse(time.RFC3339Nano, text)
+	return at
+}
+
+// withHistoryTables returns a schema with sch's tables and the runner's
+// tables, leaving sch itself unchanged
+func withHistoryTables(sch *schema.Schema) *schema.Schema {
+	merged := schema.New()
+	if sch != nil {
+		sch.RLock()
+		merged.DatabaseName = sch.DatabaseName
+		merged.FieldOrder = slices.Clone(sch.FieldOrder)
+		if sch.Tables != nil {
+			merged.Tables = maps.Clone(sch.Tables)
+		}
+		sch.RUnlock()
+	}
+	for _, table := range HistoryTables() {
+		merged.AddTable(table)
+	}
+	return merged
+}
+
+// runnerStorage is the Storage handed to migrations: its CreateTables keeps
+// the runner's tables in the storage's schema
+type runnerStorage struct {
+	storage.Storage
+}
+
+func (s *runnerStorage) CreateTables(ctx context.Context, sch *schema.Schema) error {
+	return s.Storage.CreateTables(ctx, withHistoryTables(sch))
+}
@@ -0,0 +1,295 @@
+package migrate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/jadedragon942/ddao/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRunnerStorage(t *testing.T) storage.Storage {
+	t.Helper()
+	store := sqlite.New()
+	require.NoError(t, store.Connect(context.Background(), filepath.Join(t.TempDir(), "app.db")))
+	t.Cleanup(func() { store.ResetConnection(context.Background()) })
+	return store
+}
+
+func runnerMigrations() []Migration {
+	users := schema.NewTableSchema("users")
+	users.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "create users",
+			Up: func(ctx context.Context, store storage.Storage, tx storage.Tx) error {
+				return store.CreateTables(ctx, schemaOf(users))
+			},
+			Down: func(ctx context.Context, store storage.Storage, tx storage.Tx) error {
+				return nil
+			},
+		},
+		{
+			Version: 2,
+			Name:    "seed admin",
+			Up: func(ctx context.Context, store storage.Storage, tx storage.Tx) error {
+				admin := object.New()
+				admin.TableName = "users"
+				admin.ID = "admin"
+				admin.Fields["name"] = "Admin"
+				_, _, err := tx.Insert(ctx, admin)
+				return err
+			},
+			Down: func(ctx context.Context, store storage.Storage, tx storage.Tx) error {
+				_, err := tx.DeleteByID(ctx, "users", "admin")
+				return err
+			},
+		},
+	}
+}
+
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, len(migrations))
+	for i, m := range migrations {
+		result[i] = m.Version
+	}
+	return result
+}
+
+func TestRunnerUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	runner, err := NewRunner(store, runnerMigrations()...)
+	require.NoError(t, err)
+
+	statuses, err := runner.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Applied)
+
+	applied, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions(applied))
+
+	admin, err := store.FindByID(ctx, "users", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "Admin", admin.Fields["name"])
+
+	applied, err = runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err = runner.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, status.Name)
+		assert.False(t, status.AppliedAt.IsZero(), status.Name)
+		assert.False(t, status.Modified, status.Name)
+	}
+
+	reverted, err := runner.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(reverted))
+	_, err = store.FindByID(ctx, "users", "admin")
+	assert.ErrorIs(t, err, ddaoerrors.ErrNotFound)
+
+	redone, err := runner.Redo(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, redone)
+	assert.Equal(t, int64(1), redone.Version)
+
+	applied, err = runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(applied))
+
+	// The lock is released after every operation
+	_, err = store.FindByID(ctx, LockTableName, lockID)
+	assert.ErrorIs(t, err, ddaoerrors.ErrNotFound)
+}
+
+func TestRunnerFailedMigrationIsNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	migrations := runnerMigrations()
+	migrations[1].Up = func(ctx context.Context, store storage.Storage, tx storage.Tx) error {
+		return assert.AnError
+	}
+	runner, err := NewRunner(store, migrations...)
+	require.NoError(t, err)
+
+	applied, err := runner.Up(ctx)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, []int64{1}, versions(applied))
+
+	statuses, err := runner.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+}
+
+func TestRunnerChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	runner, err := NewRunner(store, runnerMigrations()...)
+	require.NoError(t, err)
+	_, err = runner.Up(ctx)
+	require.NoError(t, err)
+
+	migrations := runnerMigrations()
+	migrations[0].Source = "CREATE TABLE users (id TEXT PRIMARY KEY, name TEXT, email TEXT)"
+	migrations = append(migrations[:1], Migration{Version: 3, Name: "later", Up: migrations[1].Up})
+	runner, err = NewRunner(store, migrations...)
+	require.NoError(t, err)
+
+	_, err = runner.Up(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	statuses, err := runner.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Modified)
+	assert.True(t, statuses[1].Missing)
+	assert.False(t, statuses[2].Applied)
+
+	_, err = runner.Down(ctx, 1)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestRunnerLock(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	runner, err := NewRunner(store, runnerMigrations()...)
+	require.NoError(t, err)
+	runner.LockTimeout = 50 * time.Millisecond
+	runner.PollInterval = 10 * time.Millisecond
+	_, err = runner.Status(ctx)
+	require.NoError(t, err)
+
+	held := object.New()
+	held.TableName = LockTableName
+	held.ID = lockID
+	held.Fields["owner"] = "other"
+	held.Fields["expires_at"] = time.Now().Add(time.Hour).UnixMilli()
+	_, _, err = store.Insert(ctx, held)
+	require.NoError(t, err)
+
+	_, err = runner.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	// An expired lock is broken
+	held.Fields["expires_at"] = time.Now().Add(-time.Minute).UnixMilli()
+	_, err = store.Update(ctx, held)
+	require.NoError(t, err)
+
+	applied, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+}
+
+func TestRunnerLockBreakIsClaimed(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	first, err := NewRunner(store, runnerMigrations()...)
+	require.NoError(t, err)
+	first.Owner = "first"
+	second, err := NewRunner(store, runnerMigrations()...)
+	require.NoError(t, err)
+	second.Owner = "second"
+	second.LockTimeout = 50 * time.Millisecond
+	second.PollInterval = 10 * time.Millisecond
+	_, err = first.Status(ctx)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Minute).UnixMilli()
+	held := object.New()
+	held.TableName = LockTableName
+	held.ID = lockID
+	held.Fields["owner"] = "crashed"
+	held.Fields["expires_at"] = expiresAt
+	_, _, err = store.Insert(ctx, held)
+	require.NoError(t, err)
+
+	// Only one of the runners that saw the expired lock takes it over
+	broken, err := first.breakLock(ctx, "crashed", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, broken)
+	broken, err = second.breakLock(ctx, "crashed", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, broken)
+
+	lock, err := store.FindByID(ctx, LockTableName, lockID)
+	require.NoError(t, err)
+	owner, _ := lock.GetString("owner")
+	assert.Equal(t, "first", owner)
+
+	_, err = second.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+func TestRunnerLockTakenOver(t *testing.T) {
+	ctx := context.Background()
+	store := newRunnerStorage(t)
+	migrations := runnerMigrations()
+	up := migrations[0].Up
+	migrations[0].Up = func(ctx context.Context, s storage.Storage, tx storage.Tx) error {
+		// Another runner breaks the lock while this migration runs
+		lock := object.New()
+		lock.TableName = LockTableName
+		lock.ID = lockID
+		lock.Fields["owner"] = "other"
+		lock.Fields["expires_at"] = time.Now().Add(time.Hour).UnixMilli()
+		if _, err := s.Update(ctx, lock); err != nil {
+			return err
+		}
+		return up(ctx, s, tx)
+	}
+	runner, err := NewRunner(store, migrations...)
+	require.NoError(t, err)
+
+	applied, err := runner.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Equal(t, []int64{1}, versions(applied))
+
+	// The lock now held by the other runner is left in place
+	lock, err := store.FindByID(ctx, LockTableName, lockID)
+	require.NoError(t, err)
+	owner, _ := lock.GetString("owner")
+	assert.Equal(t, "other", owner)
+}
+
+func TestNewRunnerValidates(t *testing.T) {
+	up := func(ctx context.Context, store storage.Storage, tx storage.Tx) error { return nil }
+
+	_, err := NewRunner(nil, Migration{Version: 0, Name: "zero", Up: up})
+	assert.Error(t, err)
+	_, err = NewRunner(nil, Migration{Version: 1, Name: "no up"})
+	assert.Error(t, err)
+	_, err = NewRunner(nil, Migration{Version: 1, Name: "a", Up: up}, Migration{Version: 1, Name: "b", Up: up})
+	assert.Error(t, err)
+}
+
+func TestWithHistoryTablesLeavesSchemaUnchanged(t *testing.T) {
+	sch := schema.New()
+	sch.FieldOrder = make([]string, 1, 4)
+	sch.FieldOrder[0] = "users"
+	sch.AddTable(schema.NewTableSchema("users"))
+
+	merged := withHistoryTables(sch)
+	merged.FieldOrder = append(merged.FieldOrder, "extra")
+	merged.AddTable(schema.NewTableSchema("extra"))
+
+	assert.Equal(t, []string{"users"}, sch.FieldOrder)
+	assert.Equal(t, "", sch.FieldOrder[:2][1])
+	assert.Len(t, sch.Tables, 1)
+	assert.Contains(t, merged.Tables, HistoryTableName)
+	assert.Contains(t, merged.Tables, LockTableName)
+}
@@ -0,0 +1,49 @@
+// Package ddl parses SQL DDL into a ddao schema, for databases described by
+// .sql files rather than a live connection. It produces the same
+// *schema.Schema as the infoschema package.
+//
+// The parser understands the common MySQL, PostgreSQL and SQLite forms of:
+//   - CREATE TABLE, with column types, NULL and NOT NULL, defaults, comments,
+//     inline and table-level PRIMARY KEY, UNIQUE and FOREIGN KEY constraints,
+//     and MySQL KEY and INDEX definitions
+//   - CREATE [UNIQUE] INDEX
+//   - ALTER TABLE: ADD, DROP, ALTER, MODIFY, CHANGE and RENAME of columns,
+//     constraints and the table
+//   - COMMENT ON TABLE and COMMENT ON COLUMN
+//   - DROP TABLE, DROP INDEX, RENAME TABLE and USE
+//
+// Other statements, such as INSERT, SET or CREATE FUNCTION, are skipped, so
+// the output of mysqldump, pg_dump and the sqlite3 .schema command can be
+// parsed as it is.
+//
+// Types are kept as written, lower cased, e.g. "varchar(255)" or "timestamp
+// with time zone", except PostgreSQL's serial types, which become integer
+// types with AutoIncrement set, as do AUTO_INCREMENT, AUTOINCREMENT, identity
+// columns and nextval() defaults. Literal defaults become Go values: strings,
+// int64 or float64 numbers and booleans. Other defaults, such as
+// CURRENT_TIMESTAMP or now(), are kept as their SQL text in a schema.Expr.
+//
+// Single-column UNIQUE constraints without a name set the column's Unique
+// flag; named and multi-column ones, like indexes, are added with AddIndex.
+// Indexes on expressions and partial indexes are skipped, as are CHECK
+// constraints. Foreign keys keep their referential actions; one declared
+// without the referenced columns references the primary key of its table.
+//
+// Usage:
+//
+//	sch, err := ddl.ParseFile("schema.sql")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	// Statements may also be spread over several sources
+//	p := ddl.NewParser()
+//	for _, migration := range migrations {
+//		if err := p.Parse(migration); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//	sch := p.Schema()
+//
+// Errors are *SyntaxError values carrying the line they occurred on.
+package ddl
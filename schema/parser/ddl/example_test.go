@@ -0,0 +1,39 @@
+package ddl_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jadedragon942/ddao/schema/parser/ddl"
+)
+
+func ExampleParse() {
+	sch, err := ddl.Parse(`
+		CREATE TABLE users (
+			id serial PRIMARY KEY,
+			email varchar(255) NOT NULL UNIQUE,
+			created_at timestamp DEFAULT CURRENT_TIMESTAMP
+		);
+		ALTER TABLE users ADD COLUMN active boolean NOT NULL DEFAULT true;
+		CREATE INDEX idx_users_created_at ON users (created_at);
+	`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users := sch.Tables["users"]
+	for _, name := range users.FieldOrder {
+		field := users.Fields[name]
+		fmt.Printf("%s %s nullable=%v default=%v\n", name, field.DataType, field.Nullable, field.Default)
+	}
+	fmt.Println("primary key:", users.PrimaryKey, "auto-increment:", users.AutoIncrementFields)
+	fmt.Println("indexes:", users.Indexes)
+
+	// Output:
+	// id integer nullable=false default=<nil>
+	// email varchar(255) nullable=false default=<nil>
+	// created_at timestamp nullable=true default=CURRENT_TIMESTAMP
+	// active boolean nullable=false default=true
+	// primary key: id auto-increment: [id]
+	// indexes: [idx_users_created_at]
+}
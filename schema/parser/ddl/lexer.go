@@ -0,0 +1,314 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // bare identifier or keyword
+	tokQuoted           // quoted identifier: "x", `x` or [x]
+	tokString           // string literal, unescaped
+	tokNumber
+	tokPunct // operators and punctuation, "::" included
+)
+
+type token struct {
+	kind tokenKind
+	// text is the token's value: a word as written, an identifier or string
+	// without its quotes, or the punctuation itself
+	text string
+	// quote is the opening quote of a quoted identifier or string, and for
+	// prefixed strings such as X'00' the prefix
+	quote string
+	// start and end are the token's byte offsets in the source
+	start, end int
+	line       int
+}
+
+// is reports whether t is the bare keyword kw, case insensitively
+func (t token) is(kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+// isPunct reports whether t is the punctuation p
+func (t token) isPunct(p string) bool {
+	return t.kind == tokPunct && t.text == p
+}
+
+// name reports whether t can name a table, column or index
+func (t token) name() bool {
+	return t.kind == tokWord || t.kind == tokQuoted
+}
+
+// lexer splits SQL source into tokens, skipping whitespace and comments
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	// backslashEscapes makes backslashes escape characters in plain strings,
+	// as MySQL does. PostgreSQL dumps turn it off with
+	// SET standard_conforming_strings = on.
+	backslashEscapes bool
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, backslashEscapes: true}
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: l.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) peekByte(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+		}
+		l.pos++
+	}
+}
+
+// skipSpace skips whitespace and --, # and /* */ comments
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			l.advance(1)
+		case c == '-' && l.peekByte(1) == '-', c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '/' && l.peekByte(1) == '*':
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return l.errorf("unterminated comment")
+			}
+			l.advance(end + 4)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipCopyData skips the rows following COPY ... FROM stdin in a PostgreSQL
+// dump, which end with a line holding \.
+func (l *lexer) skipCopyData() {
+	for l.pos < len(l.src) {
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		line := l.src[l.pos:]
+		if end >= 0 {
+			line = line[:end]
+		}
+		l.advance(len(line) + 1)
+		if strings.TrimRight(line, "\r") == `\.` {
+			return
+		}
+	}
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// next returns the next token, tokEOF at the end of the source
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+	tok := token{start: l.pos, line: l.line}
+	if l.pos >= len(l.src) {
+		tok.kind, tok.end = tokEOF, l.pos
+		return tok, nil
+	}
+
+	c := l.src[l.pos]
+	r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	var err error
+	switch {
+	case c == '\'':
+		tok.kind, tok.quote = tokString, "'"
+		tok.text, err = l.quoted('\'', l.backslashEscapes)
+
+	case (c == 'E' || c == 'e') && l.peekByte(1) == '\'':
+		l.advance(1)
+		tok.kind, tok.quote = tokString, "'"
+		tok.text, err = l.quoted('\'', true)
+
+	case (c == 'N' || c == 'n') && l.peekByte(1) == '\'':
+		l.advance(1)
+		tok.kind, tok.quote = tokString, "'"
+		tok.text, err = l.quoted('\'', l.backslashEscapes)
+
+	case strings.ContainsRune("XxBb", rune(c)) && l.peekByte(1) == '\'':
+		l.advance(1)
+		tok.kind, tok.quote = tokString, strings.ToUpper(string(c))
+		tok.text, err = l.quoted('\'', false)
+
+	case c == '"':
+		tok.kind, tok.quote = tokQuoted, `"`
+		tok.text, err = l.quoted('"', false)
+
+	case c == '`':
+		tok.kind, tok.quote = tokQuoted, "`"
+		tok.text, err = l.quoted('`', false)
+
+	case c == '[' && l.pos+1 < len(l.src) && isWordStart(rune(l.src[l.pos+1])):
+		end := strings.IndexByte(l.src[l.pos:], ']')
+		if end < 0 {
+			return token{}, l.errorf("unterminated identifier")
+		}
+		tok.kind, tok.quote = tokQuoted, "["
+		tok.text = l.src[l.pos+1 : l.pos+end]
+		l.advance(end + 1)
+
+	case c == '$' && l.dollarTag() != "":
+		tag := l.dollarTag()
+		l.advance(len(tag))
+		end := strings.Index(l.src[l.pos:], tag)
+		if end < 0 {
+			return token{}, l.errorf("unterminated %s string", tag)
+		}
+		tok.kind, tok.quote = tokString, tag
+		tok.text = l.src[l.pos : l.pos+end]
+		l.advance(end + len(tag))
+
+	case isDigit(c) || (c == '.' && isDigit(l.peekByte(1))):
+		tok.kind = tokNumber
+		l.number()
+		tok.text = l.src[tok.start:l.pos]
+
+	case isWordStart(r):
+		tok.kind = tokWord
+		l.advance(size)
+		for l.pos < len(l.src) {
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			if !isWordPart(r) {
+				break
+			}
+			l.advance(size)
+		}
+		tok.text = l.src[tok.start:l.pos]
+
+	case c == ':' && l.peekByte(1) == ':':
+		tok.kind, tok.text = tokPunct, "::"
+		l.advance(2)
+
+	default:
+		tok.kind, tok.text = tokPunct, string(r)
+		l.advance(size)
+	}
+	if err != nil {
+		return token{}, err
+	}
+	tok.end = l.pos
+	return tok, nil
+}
+
+// quoted reads a string or identifier closed by quote, in which a doubled
+// quote stands for itself
+func (l *lexer) quoted(quote byte, backslashes bool) (string, error) {
+	line := l.line
+	l.advance(1)
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == quote && l.peekByte(1) == quote:
+			b.WriteByte(quote)
+			l.advance(2)
+		case c == quote:
+			l.advance(1)
+			return b.String(), nil
+		case c == '\\' && backslashes && l.pos+1 < len(l.src):
+			b.WriteString(unescape(l.src[l.pos+1]))
+			l.advance(2)
+		default:
+			b.WriteByte(c)
+			l.advance(1)
+		}
+	}
+	return "", &SyntaxError{Line: line, Msg: fmt.Sprintf("unterminated %c", quote)}
+}
+
+// unescape returns the character a backslash escape stands for
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case '0':
+		return "\x00"
+	case 'b':
+		return "\b"
+	case 'Z':
+		return "\x1a"
+	}
+	return string(c)
+}
+
+// dollarTag returns the $tag$ opening a PostgreSQL dollar-quoted string at
+// the current position, or "" if there is none
+func (l *lexer) dollarTag() string {
+	for i := l.pos + 1; i < len(l.src); i++ {
+		c := l.src[i]
+		if c == '$' {
+			return l.src[l.pos : i+1]
+		}
+		if !(c == '_' || isDigit(c) || unicode.IsLetter(rune(c))) || (i == l.pos+1 && isDigit(c)) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// number reads an integer, decimal or hexadecimal number
+func (l *lexer) number() {
+	if l.src[l.pos] == '0' && (l.peekByte(1) == 'x' || l.peekByte(1) == 'X') {
+		l.advance(2)
+		for l.pos < len(l.src) && strings.IndexByte("0123456789abcdefABCDEF", l.src[l.pos]) >= 0 {
+			l.advance(1)
+		}
+		return
+	}
+	for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.advance(1)
+	}
+	if c := l.peekByte(0); c == 'e' || c == 'E' {
+		sign := 0
+		if s := l.peekByte(1); s == '+' || s == '-' {
+			sign = 1
+		}
+		if isDigit(l.peekByte(1 + sign)) {
+			l.advance(1 + sign)
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.advance(1)
+			}
+		}
+	}
+}
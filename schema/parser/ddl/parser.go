@@ -0,0 +1,1462 @@
+package ddl
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// SyntaxError reports a statement the parser cannot understand
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parser builds a schema from DDL statements, which may be spread over
+// several sources parsed in order
+type Parser struct {
+	sch *schema.Schema
+
+	// State of the source being parsed
+	lx    *lexer
+	tok   token   // the current token
+	ahead []token // tokens lexed past tok by peek
+	err   error   // the first lexing error, after which tok is tokEOF
+}
+
+// NewParser returns a parser with an empty schema
+func NewParser() *Parser {
+	return &Parser{sch: schema.New()}
+}
+
+// Parse parses the DDL in src into a new schema
+func Parse(src string) (*schema.Schema, error) {
+	p := NewParser()
+	if err := p.Parse(src); err != nil {
+		return nil, err
+	}
+	return p.Schema(), nil
+}
+
+// ParseFile parses the DDL files at paths, in order, into a new schema
+func ParseFile(paths ...string) (*schema.Schema, error) {
+	p := NewParser()
+	for _, path := range paths {
+		if err := p.ParseFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return p.Schema(), nil
+}
+
+// Schema returns the schema built from the statements parsed so far
+func (p *Parser) Schema() *schema.Schema {
+	return p.sch
+}
+
+// ParseFile parses the DDL file at path into the parser's schema
+func (p *Parser) ParseFile(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := p.Parse(string(src)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Parse parses the statements of src into the parser's schema. Statements
+// before a failing one have been applied.
+func (p *Parser) Parse(src string) error {
+	p.lx, p.ahead, p.err = newLexer(src), nil, nil
+	p.next()
+	for p.tok.kind != tokEOF {
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	return p.err
+}
+
+// next moves to the next token
+func (p *Parser) next() {
+	if len(p.ahead) > 0 {
+		p.tok, p.ahead = p.ahead[0], p.ahead[1:]
+		return
+	}
+	p.tok = p.lex()
+}
+
+func (p *Parser) lex() token {
+	if p.err != nil {
+		return token{kind: tokEOF}
+	}
+	tok, err := p.lx.next()
+	if err != nil {
+		p.err = err
+		return token{kind: tokEOF}
+	}
+	return tok
+}
+
+// peek returns the token n tokens after the current one
+func (p *Parser) peek(n int) token {
+	for len(p.ahead) < n {
+		p.ahead = append(p.ahead, p.lex())
+	}
+	return p.ahead[n-1]
+}
+
+// accept moves past the keywords kws if they come next
+func (p *Parser) accept(kws ...string) bool {
+	if !p.tok.is(kws[0]) {
+		return false
+	}
+	for i, kw := range kws[1:] {
+		if !p.peek(i + 1).is(kw) {
+			return false
+		}
+	}
+	for range kws {
+		p.next()
+	}
+	return true
+}
+
+// acceptPunct moves past the punctuation punct if it comes next
+func (p *Parser) acceptPunct(punct string) bool {
+	if p.tok.isPunct(punct) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &SyntaxError{Line: p.tok.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// unexpected reports the current token, expecting what
+func (p *Parser) unexpected(what string) error {
+	var found string
+	switch p.tok.kind {
+	case tokEOF:
+		found = "end of input"
+	case tokString:
+		found = "string " + strconv.Quote(p.tok.text)
+	default:
+		found = strconv.Quote(p.tok.text)
+	}
+	return p.errorf("expected %s, found %s", what, found)
+}
+
+func (p *Parser) expect(kw string) error {
+	if !p.accept(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *Parser) expectPunct(punct string) error {
+	if !p.acceptPunct(punct) {
+		return p.unexpected(strconv.Quote(punct))
+	}
+	return nil
+}
+
+// name reads an identifier
+func (p *Parser) name() (string, error) {
+	if !p.tok.name() {
+		return "", p.unexpected("a name")
+	}
+	name := p.tok.text
+	p.next()
+	return name, nil
+}
+
+// nameParts reads a possibly qualified name, such as schema.table.column
+func (p *Parser) nameParts() ([]string, error) {
+	var parts []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, name)
+		if !p.tok.isPunct(".") {
+			return parts, nil
+		}
+		p.next()
+	}
+}
+
+// qualifiedName reads a possibly qualified name, returning its last part.
+// Tables are named without their database or schema.
+func (p *Parser) qualifiedName() (string, error) {
+	parts, err := p.nameParts()
+	if err != nil {
+		return "", err
+	}
+	return parts[len(parts)-1], nil
+}
+
+// atEnd reports whether the current statement has ended
+func (p *Parser) atEnd() bool {
+	return p.tok.kind == tokEOF || p.tok.isPunct(";")
+}
+
+// atItemEnd reports whether the current item of a comma separated list has
+// ended
+func (p *Parser) atItemEnd() bool {
+	return p.atEnd() || p.tok.isPunct(",") || p.tok.isPunct(")")
+}
+
+// skip moves past the current token, or the parenthesized group it opens
+func (p *Parser) skip() {
+	if !p.tok.isPunct("(") {
+		p.next()
+		return
+	}
+	depth := 0
+	for !(p.tok.kind == tokEOF || (depth == 0 && p.tok.isPunct(";"))) {
+		if p.tok.isPunct("(") {
+			depth++
+		} else if p.tok.isPunct(")") {
+			depth--
+		}
+		p.next()
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// skipItem moves to the end of the current list item
+func (p *Parser) skipItem() {
+	for !p.atItemEnd() {
+		p.skip()
+	}
+}
+
+// skipStatement moves past the end of the current statement
+func (p *Parser) skipStatement() {
+	for !p.atEnd() {
+		p.next()
+	}
+	p.next()
+}
+
+// endStatement moves past the end of the current statement, which must
+// have been read entirely
+func (p *Parser) endStatement() error {
+	if !p.atEnd() {
+		return p.unexpected(`";"`)
+	}
+	p.next()
+	return nil
+}
+
+func (p *Parser) statement() error {
+	switch {
+	case p.tok.isPunct(";"):
+		p.next()
+		return nil
+	case p.tok.is("CREATE"):
+		return p.create()
+	case p.tok.is("ALTER") && p.peek(1).is("TABLE"):
+		return p.alterTable()
+	case p.tok.is("DROP"):
+		return p.drop()
+	case p.tok.is("RENAME") && p.peek(1).is("TABLE"):
+		return p.renameTables()
+	case p.tok.is("COMMENT") && p.peek(1).is("ON"):
+		return p.comment()
+	case p.tok.is("USE") && p.peek(1).name():
+		p.next()
+		p.sch.SetDatabaseName(p.tok.text)
+		p.next()
+		return p.endStatement()
+	case p.tok.is("SET") && p.peek(1).is("standard_conforming_strings"):
+		// Later strings of a PostgreSQL dump may hold unescaped backslashes
+		p.next()
+		p.next()
+		if p.tok.isPunct("=") || p.tok.is("TO") {
+			p.next()
+		}
+		p.lx.backslashEscapes = !strings.EqualFold(p.tok.text, "on")
+		p.skipStatement()
+		return nil
+	case p.tok.is("COPY"):
+		// COPY ... FROM stdin is followed by rows up to a \. line
+		stdin := false
+		for !p.atEnd() {
+			stdin = p.tok.is("FROM") && p.peek(1).is("stdin")
+			p.next()
+			if stdin {
+				p.next()
+				break
+			}
+		}
+		if stdin && p.tok.isPunct(";") && len(p.ahead) == 0 {
+			p.lx.skipCopyData()
+		}
+		p.skipStatement()
+		return nil
+	}
+	p.skipStatement()
+	return nil
+}
+
+func (p *Parser) create() error {
+	p.next()
+	p.accept("OR", "REPLACE")
+	for p.tok.is("TEMPORARY") || p.tok.is("TEMP") || p.tok.is("UNLOGGED") || p.tok.is("GLOBAL") || p.tok.is("LOCAL") {
+		p.next()
+	}
+	switch {
+	case p.tok.is("TABLE"):
+		return p.createTable()
+	case p.tok.is("INDEX"), p.tok.is("UNIQUE") && p.peek(1).is("INDEX"):
+		return p.createIndex()
+	}
+	p.skipStatement()
+	return nil
+}
+
+func (p *Parser) createTable() error {
+	p.next()
+	ifNotExists := p.accept("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if _, exists := p.sch.Tables[name]; exists {
+		if ifNotExists {
+			p.skipStatement()
+			return nil
+		}
+		return p.errorf("table %s is created twice", name)
+	}
+
+	var tbl *schema.TableSchema
+	switch {
+	case p.tok.is("LIKE") || (p.tok.isPunct("(") && p.peek(1).is("LIKE")):
+		// CREATE TABLE t LIKE other, or (LIKE other ...)
+		parenthesized := p.acceptPunct("(")
+		p.next()
+		other, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		source, err := p.table(other)
+		if err != nil {
+			return err
+		}
+		tbl = source.Clone()
+		tbl.TableName = name
+		if parenthesized {
+			p.skipItem()
+			if err := p.expectPunct(")"); err != nil {
+				return err
+			}
+		}
+	case p.tok.isPunct("("):
+		tbl = schema.NewTableSchema(name)
+		if err := p.tableElements(tbl); err != nil {
+			return err
+		}
+	default:
+		return p.errorf("CREATE TABLE %s without column definitions is not supported", name)
+	}
+
+	// Table options: only MySQL's COMMENT is kept
+	for !p.atEnd() {
+		if p.accept("COMMENT") {
+			p.acceptPunct("=")
+			if p.tok.kind == tokString {
+				tbl.Comment = p.tok.text
+			}
+			continue
+		}
+		p.skip()
+	}
+	p.addTable(tbl)
+	return p.endStatement()
+}
+
+// tableElements reads the parenthesized column and constraint definitions
+// of tbl
+func (p *Parser) tableElements(tbl *schema.TableSchema) error {
+	p.next()
+	var keyColumns []string
+	// Foreign keys are added once the primary key they may reference is known
+	var fks []schema.ForeignKey
+	for {
+		if p.constraintStart() {
+			key, fk, err := p.constraint(tbl)
+			if err != nil {
+				return err
+			}
+			if key != nil {
+				keyColumns = key
+			}
+			if fk != nil {
+				fks = append(fks, *fk)
+			}
+		} else {
+			field, fk, err := p.column()
+			if err != nil {
+				return err
+			}
+			if _, exists := tbl.Fields[field.Name]; exists {
+				return p.errorf("column %s.%s is defined twice", tbl.TableName, field.Name)
+			}
+			tbl.AddField(field)
+			if fk != nil {
+				fks = append(fks, *fk)
+			}
+		}
+		if p.acceptPunct(")") {
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return err
+		}
+	}
+	if keyColumns != nil {
+		if err := p.setPrimaryKey(tbl, keyColumns); err != nil {
+			return err
+		}
+	}
+	for _, fk := range fks {
+		if err := p.addForeignKey(tbl, fk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPrimaryKey makes columns tbl's primary key, which are not nullable
+func (p *Parser) setPrimaryKey(tbl *schema.TableSchema, columns []string) error {
+	for _, column := range columns {
+		field, ok := tbl.Fields[column]
+		if !ok {
+			return p.errorf("primary key column %s.%s is not defined", tbl.TableName, column)
+		}
+		field.Nullable = false
+		tbl.Fields[column] = field
+	}
+	tbl.SetPrimaryKey(columns...)
+	return nil
+}
+
+// constraintStart reports whether a table constraint or, in MySQL, an index
+// definition starts at the current token
+func (p *Parser) constraintStart() bool {
+	switch {
+	case p.tok.is("CONSTRAINT"), p.tok.is("PRIMARY"), p.tok.is("UNIQUE"), p.tok.is("FOREIGN"), p.tok.is("CHECK"):
+		return true
+	case p.tok.is("FULLTEXT"), p.tok.is("SPATIAL"), p.tok.is("EXCLUDE"):
+		next := p.peek(1)
+		return next.is("KEY") || next.is("INDEX") || next.is("USING") || next.isPunct("(") || next.kind == tokQuoted
+	case p.tok.is("KEY"), p.tok.is("INDEX"):
+		// PostgreSQL allows columns named key and index: KEY idx (a) is an
+		// index but key varchar(10) a column
+		next := p.peek(1)
+		if next.isPunct("(") || next.kind == tokQuoted || next.is("USING") {
+			return true
+		}
+		return next.kind == tokWord && p.peek(2).isPunct("(") && p.peek(3).kind != tokNumber
+	}
+	return false
+}
+
+// constraint reads a table constraint of tbl, adding its indexes. It returns
+// the columns of a primary key or a foreign key, which the caller adds.
+func (p *Parser) constraint(tbl *schema.TableSchema) ([]string, *schema.ForeignKey, error) {
+	name := ""
+	if p.accept("CONSTRAINT") {
+		if !p.constraintStart() {
+			var err error
+			if name, err = p.name(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		columns, ok, err := p.indexColumns()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, p.errorf("primary key of %s on expressions is not supported", tbl.TableName)
+		}
+		p.skipItem()
+		return columns, nil, nil
+
+	case p.accept("FOREIGN", "KEY"):
+		if p.tok.name() {
+			p.next() // MySQL's name for the index backing the key
+		}
+		columns, err := p.nameList()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := p.expect("REFERENCES"); err != nil {
+			return nil, nil, err
+		}
+		fk, err := p.references()
+		if err != nil {
+			return nil, nil, err
+		}
+		fk.Name, fk.Columns = name, columns
+		p.skipItem()
+		return nil, &fk, nil
+
+	case p.tok.is("UNIQUE"), p.tok.is("KEY"), p.tok.is("INDEX"):
+		unique := p.accept("UNIQUE")
+		if !p.accept("KEY") {
+			p.accept("INDEX")
+		}
+		if name == "" && p.tok.name() && !p.tok.is("USING") {
+			name = p.tok.text
+			p.next()
+		}
+		columns, ok, err := p.indexColumns()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.skipItem()
+		if !ok {
+			return nil, nil, nil
+		}
+		if unique && name == "" && len(columns) == 1 {
+			field, exists := tbl.Fields[columns[0]]
+			if !exists {
+				return nil, nil, p.errorf("unique column %s.%s is not defined", tbl.TableName, columns[0])
+			}
+			field.Unique = true
+			tbl.Fields[columns[0]] = field
+			return nil, nil, nil
+		}
+		return nil, nil, p.addIndex(tbl, name, unique, columns)
+	}
+
+	// CHECK, EXCLUDE, FULLTEXT and SPATIAL
+	p.skipItem()
+	return nil, nil, nil
+}
+
+// nameList reads a parenthesized list of names
+func (p *Parser) nameList() ([]string, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if p.acceptPunct(")") {
+			return names, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// addForeignKey adds fk to tbl. A foreign key not naming the referenced
+// columns references the primary key of its table, which must be defined.
+func (p *Parser) addForeignKey(tbl *schema.TableSchema, fk schema.ForeignKey) error {
+	for _, column := range fk.Columns {
+		if _, ok := tbl.Fields[column]; !ok {
+			return p.errorf("foreign key column %s.%s is not defined", tbl.TableName, column)
+		}
+	}
+	if len(fk.RefColumns) == 0 {
+		ref := tbl
+		if fk.RefTable != tbl.TableName {
+			ref = p.sch.Tables[fk.RefTable]
+		}
+		if ref == nil {
+			return p.errorf("foreign key of %s references the primary key of %s, which is not defined", tbl.TableName, fk.RefTable)
+		}
+		fk.RefColumns = ref.PrimaryKeyColumns()
+	}
+	tbl.AddForeignKey(fk)
+	return nil
+}
+
+// indexColumns reads a parenthesized list of indexed columns. It reports
+// false if one of them is an expression.
+func (p *Parser) indexColumns() ([]string, bool, error) {
+	if p.accept("USING") {
+		p.next()
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, false, err
+	}
+	var columns []string
+	ok := true
+	for {
+		next := p.peek(1)
+		simple := next.isPunct(",") || next.isPunct(")") || next.is("ASC") || next.is("DESC") ||
+			next.is("COLLATE") || next.is("NULLS") ||
+			(next.isPunct("(") && p.peek(2).kind == tokNumber && p.peek(3).isPunct(")")) // MySQL prefix length
+		if p.tok.name() && simple {
+			columns = append(columns, p.tok.text)
+		} else {
+			ok = false
+		}
+		p.skipItem()
+		if p.acceptPunct(")") {
+			return columns, ok, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// addIndex adds an index on columns to tbl, unless it has one named name
+func (p *Parser) addIndex(tbl *schema.TableSchema, name string, unique bool, columns []string) error {
+	if name != "" && slices.ContainsFunc(tbl.IndexDefs, func(index schema.Index) bool { return index.Name == name }) {
+		return nil
+	}
+	for _, column := range columns {
+		if _, ok := tbl.Fields[column]; !ok {
+			return p.errorf("indexed column %s.%s is not defined", tbl.TableName, column)
+		}
+	}
+	tbl.AddIndex(name, unique, columns...)
+	return nil
+}
+
+// columnKeyword reports whether the current token starts a column
+// constraint or attribute rather than continuing the column's type
+func (p *Parser) columnKeyword() bool {
+	if p.tok.kind != tokWord {
+		return false
+	}
+	switch strings.ToUpper(p.tok.text) {
+	case "NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "KEY", "REFERENCES", "CHECK", "CONSTRAINT",
+		"COLLATE", "CHARSET", "AUTO_INCREMENT", "AUTOINCREMENT", "COMMENT", "GENERATED", "AS", "ON",
+		"INVISIBLE", "VISIBLE", "STORED", "VIRTUAL", "USING":
+		return true
+	case "CHARACTER":
+		return p.peek(1).is("SET")
+	}
+	return false
+}
+
+// serialTypes maps PostgreSQL's auto-incrementing types to their integer types
+var serialTypes = map[string]string{
+	"smallserial": "smallint", "serial2": "smallint",
+	"serial": "integer", "serial4": "integer",
+	"bigserial": "bigint", "serial8": "bigint",
+}
+
+// column reads a column definition, and the foreign key it declares with
+// REFERENCES, if any
+func (p *Parser) column() (schema.ColumnData, *schema.ForeignKey, error) {
+	name, err := p.name()
+	if err != nil {
+		return schema.ColumnData{}, nil, err
+	}
+	field := schema.ColumnData{Name: name, Nullable: true, DataType: p.dataType()}
+	if field.DataType == "" {
+		field.DataType = "blob" // SQLite columns without a type have BLOB affinity
+	}
+	if integer, ok := serialTypes[field.DataType]; ok {
+		field.DataType = integer
+		field.AutoIncrement = true
+		field.Nullable = false
+	}
+
+	var fk *schema.ForeignKey
+	constraint := ""
+	for !p.atItemEnd() {
+		switch {
+		case p.accept("NOT", "NULL"):
+			field.Nullable = false
+		case p.accept("NULL"):
+			field.Nullable = true
+		case p.accept("DEFAULT"):
+			value, expression, err := p.defaultValue()
+			if err != nil {
+				return schema.ColumnData{}, nil, err
+			}
+			setDefault(&field, value, expression)
+		case p.accept("PRIMARY", "KEY"), p.accept("KEY"):
+			field.PrimaryKey = true
+			field.Nullable = false
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			field.Unique = true
+		case p.accept("AUTO_INCREMENT"), p.accept("AUTOINCREMENT"):
+			field.AutoIncrement = true
+		case p.accept("GENERATED"):
+			// GENERATED {ALWAYS | BY DEFAULT} AS IDENTITY, or a generated column
+			for !p.atItemEnd() && !p.tok.is("AS") {
+				p.next()
+			}
+			p.accept("AS")
+			if p.accept("IDENTITY") {
+				field.AutoIncrement = true
+			}
+			if p.tok.isPunct("(") {
+				p.skip()
+			}
+		case p.accept("COMMENT"):
+			if p.tok.kind != tokString {
+				return schema.ColumnData{}, nil, p.unexpected("a comment string")
+			}
+			field.Comment = p.tok.text
+			p.next()
+		case p.accept("CONSTRAINT"):
+			constraint = p.tok.text
+			p.next()
+		case p.accept("REFERENCES"):
+			references, err := p.references()
+			if err != nil {
+				return schema.ColumnData{}, nil, err
+			}
+			references.Name, references.Columns = constraint, []string{name}
+			fk = &references
+		case p.accept("COLLATE"), p.accept("CHARSET"), p.accept("CHARACTER", "SET"):
+			p.next()
+		case p.accept("ON"):
+			// MySQL's ON UPDATE expression, or SQLite's ON CONFLICT clause
+			p.next()
+			if p.tok.kind == tokWord && p.peek(1).isPunct("(") {
+				p.next()
+			}
+			p.skip()
+		default:
+			// CHECK (...), AS (...) of generated columns, and attributes
+			p.skip()
+		}
+	}
+	return field, fk, nil
+}
+
+// setDefault sets field's default, an expression as a schema.Expr. A
+// nextval() default is how PostgreSQL dumps auto-incrementing columns.
+func setDefault(field *schema.ColumnData, value any, expression bool) {
+	text, ok := value.(string)
+	if !ok || !expression {
+		field.Default = value
+		return
+	}
+	if strings.HasPrefix(strings.ToLower(text), "nextval(") {
+		field.AutoIncrement = true
+		field.Default = nil
+		return
+	}
+	field.Default = schema.Expr(text)
+}
+
+// references reads the referenced table and columns and the actions of a
+// foreign key, following REFERENCES
+func (p *Parser) references() (schema.ForeignKey, error) {
+	var fk schema.ForeignKey
+	var err error
+	if fk.RefTable, err = p.qualifiedName(); err != nil {
+		return fk, err
+	}
+	if p.tok.isPunct("(") {
+		if fk.RefColumns, err = p.nameList(); err != nil {
+			return fk, err
+		}
+	}
+	for {
+		switch {
+		case p.accept("MATCH"), p.accept("INITIALLY"):
+			p.next()
+		case p.accept("ON"):
+			event := p.tok
+			p.next()
+			var action string
+			switch {
+			case p.accept("SET"):
+				action = "SET " + p.tok.text // NULL or DEFAULT
+				p.next()
+				if p.tok.isPunct("(") {
+					p.skip() // PostgreSQL's column list
+				}
+			case p.accept("NO"):
+				action = "NO " + p.tok.text // ACTION
+				p.next()
+			default:
+				action = p.tok.text // CASCADE or RESTRICT
+				p.next()
+			}
+			parsed, err := schema.ParseReferentialAction(action)
+			if err != nil {
+				return fk, p.errorf("%v", err)
+			}
+			if event.is("DELETE") {
+				fk.OnDelete = parsed
+			} else {
+				fk.OnUpdate = parsed
+			}
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"):
+		default:
+			return fk, nil
+		}
+	}
+}
+
+// dataType reads a column's type, such as "varchar(255)", "double
+// precision" or "numeric(10,2)[]"
+func (p *Parser) dataType() string {
+	var b strings.Builder
+	for {
+		switch {
+		case p.tok.kind == tokWord && !p.columnKeyword():
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.ToLower(p.tok.text))
+			p.next()
+		case p.tok.isPunct(".") && b.Len() > 0 && p.peek(1).name():
+			b.WriteByte('.')
+			p.next()
+			b.WriteString(strings.ToLower(p.tok.text))
+			p.next()
+		case (p.tok.isPunct("(") || p.tok.isPunct("[")) && b.Len() > 0:
+			b.WriteString(p.group())
+		default:
+			return b.String()
+		}
+	}
+}
+
+// group reads a parenthesized or bracketed group of tokens, rendering them
+// without spaces, such as "(10,2)" or "('a','b')"
+func (p *Parser) group() string {
+	var b strings.Builder
+	depth := 0
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.isPunct("(") || p.tok.isPunct("["):
+			depth++
+		case p.tok.isPunct(")") || p.tok.isPunct("]"):
+			depth--
+		}
+		if p.tok.kind == tokString {
+			b.WriteString("'" + strings.ReplaceAll(p.tok.text, "'", "''") + "'")
+		} else {
+			b.WriteString(p.tok.text)
+		}
+		p.next()
+		if depth == 0 {
+			break
+		}
+	}
+	return b.String()
+}
+
+// defaultEnd reports whether the current token ends a default expression
+func (p *Parser) defaultEnd() bool {
+	if p.tok.kind != tokWord {
+		return false
+	}
+	switch strings.ToUpper(p.tok.text) {
+	case "NOT", "NULL", "PRIMARY", "UNIQUE", "KEY", "CHECK", "REFERENCES", "CONSTRAINT", "COLLATE",
+		"COMMENT", "ON", "GENERATED", "AUTO_INCREMENT", "AUTOINCREMENT":
+		return true
+	}
+	return false
+}
+
+// defaultValue reads a default, returning literals as Go values and
+// expressions as their SQL text, with expression set
+func (p *Parser) defaultValue() (any, bool, error) {
+	var tokens []token
+	depth := 0
+	for !p.atEnd() {
+		if depth == 0 && (p.tok.isPunct(",") || p.tok.isPunct(")") || (len(tokens) > 0 && p.defaultEnd())) {
+			break
+		}
+		if p.tok.isPunct("(") {
+			depth++
+		} else if p.tok.isPunct(")") {
+			depth--
+		}
+		tokens = append(tokens, p.tok)
+		p.next()
+	}
+	if len(tokens) == 0 {
+		return nil, false, p.unexpected("a default value")
+	}
+	if value, ok := literal(tokens); ok {
+		return value, false, nil
+	}
+	return p.lx.src[tokens[0].start:tokens[len(tokens)-1].end], true, nil
+}
+
+// literal returns the value of tokens if they are a literal, possibly
+// parenthesized, signed or cast
+func literal(tokens []token) (any, bool) {
+	for len(tokens) > 2 && tokens[0].isPunct("(") && closes(tokens) {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	if len(tokens) > 1 && tokens[1].isPunct("::") {
+		tokens = tokens[:1]
+	}
+	sign := ""
+	if len(tokens) == 2 && (tokens[0].isPunct("-") || tokens[0].isPunct("+")) && tokens[1].kind == tokNumber {
+		sign, tokens = tokens[0].text, tokens[1:]
+	}
+	if len(tokens) != 1 {
+		return nil, false
+	}
+
+	tok := tokens[0]
+	switch {
+	case tok.kind == tokString && tok.quote == "'", tok.kind == tokQuoted && tok.quote == `"`:
+		// MySQL and SQLite accept double quoted strings
+		return tok.text, true
+	case tok.kind == tokNumber:
+		if n, err := strconv.ParseInt(sign+tok.text, 10, 64); err == nil {
+			return n, true
+		}
+		if f, err := strconv.ParseFloat(sign+tok.text, 64); err == nil {
+			return f, true
+		}
+	case tok.is("TRUE"):
+		return true, true
+	case tok.is("FALSE"):
+		return false, true
+	case tok.is("NULL"):
+		return nil, true
+	}
+	return nil, false
+}
+
+// closes reports whether the parenthesis opening tokens closes at their end
+func closes(tokens []token) bool {
+	depth := 0
+	for i, tok := range tokens {
+		if tok.isPunct("(") {
+			depth++
+		} else if tok.isPunct(")") {
+			depth--
+		}
+		if depth == 0 {
+			return i == len(tokens)-1
+		}
+	}
+	return false
+}
+
+func (p *Parser) createIndex() error {
+	unique := p.accept("UNIQUE")
+	p.next() // INDEX
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	name := ""
+	if !p.tok.is("ON") {
+		var err error
+		if name, err = p.qualifiedName(); err != nil {
+			return err
+		}
+	}
+	if p.accept("USING") {
+		p.next()
+	}
+	if err := p.expect("ON"); err != nil {
+		return err
+	}
+	p.accept("ONLY")
+	tableName, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	tbl, err := p.table(tableName)
+	if err != nil {
+		return err
+	}
+	columns, ok, err := p.indexColumns()
+	if err != nil {
+		return err
+	}
+	partial := false
+	for !p.atEnd() {
+		partial = partial || p.tok.is("WHERE")
+		p.skip()
+	}
+	if ok && !partial {
+		if err := p.addIndex(tbl, name, unique, columns); err != nil {
+			return err
+		}
+	}
+	return p.endStatement()
+}
+
+func (p *Parser) alterTable() error {
+	p.next()
+	p.next()
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	p.acceptPunct("*")
+	if _, err := p.table(name); err != nil {
+		return err
+	}
+	for {
+		if name, err = p.alterAction(name); err != nil {
+			return err
+		}
+		if !p.acceptPunct(",") {
+			return p.endStatement()
+		}
+	}
+}
+
+// alter applies ops to the table name, returning its name afterwards
+func (p *Parser) alter(name string, ops ...storage.AlterOp) (string, error) {
+	tbl, err := p.table(name)
+	if err != nil {
+		return "", err
+	}
+	altered, err := storage.AlterTableSchema(*tbl, ops)
+	if err != nil {
+		return "", p.errorf("%v", err)
+	}
+	p.replaceTable(name, altered)
+	return altered.TableName, nil
+}
+
+// alterAction reads and applies one action of ALTER TABLE name, returning
+// the table's name afterwards
+func (p *Parser) alterAction(name string) (string, error) {
+	tbl, err := p.table(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case p.accept("ADD"):
+		if p.constraintStart() {
+			key, fk, err := p.constraint(tbl)
+			if err == nil && key != nil {
+				err = p.setPrimaryKey(tbl, key)
+			}
+			if err == nil && fk != nil {
+				err = p.addForeignKey(tbl, *fk)
+			}
+			return name, err
+		}
+		p.accept("COLUMN")
+		p.accept("IF", "NOT", "EXISTS")
+		if p.acceptPunct("(") {
+			// MySQL's ADD (column, ...)
+			for {
+				if name, err = p.addColumn(name); err != nil {
+					return "", err
+				}
+				if p.acceptPunct(")") {
+					return name, nil
+				}
+				if err := p.expectPunct(","); err != nil {
+					return "", err
+				}
+			}
+		}
+		return p.addColumn(name)
+
+	case p.accept("DROP"):
+		switch {
+		case p.accept("PRIMARY", "KEY"):
+			tbl.SetPrimaryKey()
+		case p.accept("CONSTRAINT"), p.accept("INDEX"), p.accept("KEY"), p.accept("FOREIGN", "KEY"):
+			p.accept("IF", "EXISTS")
+			constraint, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			dropIndex(tbl, constraint)
+			dropForeignKey(tbl, constraint)
+		case p.tok.is("CHECK"):
+		default:
+			p.accept("COLUMN")
+			ifExists := p.accept("IF", "EXISTS")
+			column, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			if _, ok := tbl.Fields[column]; ok || !ifExists {
+				if name, err = p.alter(name, storage.DropColumn(column)); err != nil {
+					return "", err
+				}
+			}
+		}
+		p.skipItem()
+		return name, nil
+
+	case p.accept("ALTER"):
+		p.accept("COLUMN")
+		column, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		var ops []storage.AlterOp
+		switch {
+		case p.accept("SET", "NOT", "NULL"):
+			ops = append(ops, storage.SetNotNull(column))
+		case p.accept("DROP", "NOT", "NULL"):
+			ops = append(ops, storage.DropNotNull(column))
+		case p.accept("SET", "DEFAULT"):
+			value, expression, err := p.defaultValue()
+			if err != nil {
+				return "", err
+			}
+			field, ok := tbl.Fields[column]
+			if !ok {
+				return "", p.errorf("column %s.%s is not defined", name, column)
+			}
+			setDefault(&field, value, expression)
+			tbl.Fields[column] = field
+		case p.accept("DROP", "DEFAULT"):
+			ops = append(ops, storage.DropDefault(column))
+		case p.accept("SET", "DATA", "TYPE"), p.accept("TYPE"):
+			ops = append(ops, storage.ChangeType(column, p.dataType()))
+		case p.accept("ADD", "GENERATED"):
+			field, ok := tbl.Fields[column]
+			if !ok {
+				return "", p.errorf("column %s.%s is not defined", name, column)
+			}
+			field.AutoIncrement = true
+			tbl.Fields[column] = field
+		}
+		p.skipItem()
+		if len(ops) == 0 {
+			return name, nil
+		}
+		return p.alter(name, ops...)
+
+	case p.accept("MODIFY"):
+		p.accept("COLUMN")
+		return p.modifyColumn(name, "")
+
+	case p.accept("CHANGE"):
+		p.accept("COLUMN")
+		column, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		return p.modifyColumn(name, column)
+
+	case p.accept("RENAME"):
+		switch {
+		case p.accept("COLUMN"), p.tok.name() && p.peek(1).is("TO") && !p.tok.is("TO") && !p.tok.is("AS"):
+			column, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			if err := p.expect("TO"); err != nil {
+				return "", err
+			}
+			newName, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			return p.alter(name, storage.RenameColumn(column, newName))
+		case p.accept("INDEX"), p.accept("KEY"), p.accept("CONSTRAINT"):
+			index, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			if err := p.expect("TO"); err != nil {
+				return "", err
+			}
+			newName, err := p.name()
+			if err != nil {
+				return "", err
+			}
+			renameIndex(tbl, index, newName)
+			return name, nil
+		default:
+			if !p.accept("TO") {
+				p.accept("AS")
+			}
+			newName, err := p.qualifiedName()
+			if err != nil {
+				return "", err
+			}
+			return p.alter(name, storage.RenameTable(newName))
+		}
+	}
+
+	// OWNER TO, SET, ENABLE and other actions that do not change the schema
+	p.skipItem()
+	return name, nil
+}
+
+// addColumn reads a column definition and adds it to the table name
+func (p *Parser) addColumn(name string) (string, error) {
+	field, fk, err := p.column()
+	if err != nil {
+		return "", err
+	}
+	if !field.PrimaryKey {
+		if name, err = p.alter(name, storage.AddColumn(field)); err != nil {
+			return "", err
+		}
+		return name, p.addColumnForeignKey(name, fk)
+	}
+	// AlterTableSchema refuses new key columns, which live databases cannot add
+	// to existing rows but a schema being built up can
+	tbl, err := p.table(name)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := tbl.Fields[field.Name]; exists {
+		return "", p.errorf("column %s.%s already exists", name, field.Name)
+	}
+	field.PrimaryKey = false
+	tbl.AddField(field)
+	if err := p.setPrimaryKey(tbl, []string{field.Name}); err != nil {
+		return "", err
+	}
+	return name, p.addColumnForeignKey(name, fk)
+}
+
+// addColumnForeignKey adds the foreign key a column definition of the table
+// name declared, if any
+func (p *Parser) addColumnForeignKey(name string, fk *schema.ForeignKey) error {
+	if fk == nil {
+		return nil
+	}
+	return p.addForeignKey(p.sch.Tables[name], *fk)
+}
+
+// modifyColumn reads a column definition replacing column of the table
+// name, or the column of the same name if column is empty
+func (p *Parser) modifyColumn(name, column string) (string, error) {
+	field, fk, err := p.column()
+	if err != nil {
+		return "", err
+	}
+	if column != "" && column != field.Name {
+		if name, err = p.alter(name, storage.RenameColumn(column, field.Name)); err != nil {
+			return "", err
+		}
+	}
+	tbl, err := p.table(name)
+	if err != nil {
+		return "", err
+	}
+	old, ok := tbl.Fields[field.Name]
+	if !ok {
+		return "", p.errorf("column %s.%s is not defined", name, field.Name)
+	}
+	if old.PrimaryKey {
+		field.PrimaryKey = true
+		field.Nullable = false
+	}
+	tbl.Fields[field.Name] = field
+	if field.PrimaryKey && !old.PrimaryKey {
+		if err := p.setPrimaryKey(tbl, []string{field.Name}); err != nil {
+			return "", err
+		}
+	}
+	return name, p.addColumnForeignKey(name, fk)
+}
+
+// dropIndex removes the index named name from tbl, if it has one
+func dropIndex(tbl *schema.TableSchema, name string) {
+	isName := func(n string) bool { return n == name }
+	tbl.IndexDefs = slices.DeleteFunc(tbl.IndexDefs, func(index schema.Index) bool { return index.Name == name })
+	tbl.Indexes = slices.DeleteFunc(tbl.Indexes, isName)
+	tbl.UniqueKeys = slices.DeleteFunc(tbl.UniqueKeys, isName)
+}
+
+// dropForeignKey removes the foreign key named name from tbl, if it has one
+func dropForeignKey(tbl *schema.TableSchema, name string) {
+	tbl.ForeignKeys = slices.DeleteFunc(tbl.ForeignKeys, func(fk schema.ForeignKey) bool { return fk.Name == name })
+}
+
+// renameIndex renames tbl's index oldName, if it has one
+func renameIndex(tbl *schema.TableSchema, oldName, newName string) {
+	for i := range tbl.IndexDefs {
+		if tbl.IndexDefs[i].Name == oldName {
+			tbl.IndexDefs[i].Name = newName
+		}
+	}
+	for _, names := range [][]string{tbl.Indexes, tbl.UniqueKeys} {
+		for i, name := range names {
+			if name == oldName {
+				names[i] = newName
+			}
+		}
+	}
+}
+
+func (p *Parser) drop() error {
+	p.next()
+	switch {
+	case p.accept("TABLE"):
+		p.accept("IF", "EXISTS")
+		for {
+			name, err := p.qualifiedName()
+			if err != nil {
+				return err
+			}
+			p.dropTable(name)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+	case p.accept("INDEX"):
+		p.accept("CONCURRENTLY")
+		p.accept("IF", "EXISTS")
+		name, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if p.accept("ON") {
+			// MySQL names the table
+			tableName, err := p.qualifiedName()
+			if err != nil {
+				return err
+			}
+			if tbl, ok := p.sch.Tables[tableName]; ok {
+				dropIndex(tbl, name)
+			}
+		} else {
+			for _, tbl := range p.sch.Tables {
+				dropIndex(tbl, name)
+			}
+		}
+	}
+	p.skipStatement()
+	return nil
+}
+
+// renameTables reads MySQL's RENAME TABLE a TO b, c TO d
+func (p *Parser) renameTables() error {
+	p.next()
+	p.next()
+	for {
+		name, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("TO"); err != nil {
+			return err
+		}
+		newName, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if _, err := p.alter(name, storage.RenameTable(newName)); err != nil {
+			return err
+		}
+		if !p.acceptPunct(",") {
+			return p.endStatement()
+		}
+	}
+}
+
+// comment reads PostgreSQL's COMMENT ON TABLE and COMMENT ON COLUMN
+func (p *Parser) comment() error {
+	p.next()
+	p.next()
+	onColumn := p.accept("COLUMN")
+	if !onColumn && !p.accept("TABLE") {
+		p.skipStatement()
+		return nil
+	}
+	parts, err := p.nameParts()
+	if err != nil {
+		return err
+	}
+	if onColumn && len(parts) < 2 {
+		return p.errorf("column %s is not qualified by its table", parts[0])
+	}
+	if err := p.expect("IS"); err != nil {
+		return err
+	}
+	text := ""
+	if p.tok.kind == tokString {
+		text = p.tok.text
+	} else if !p.tok.is("NULL") {
+		return p.unexpected("a comment string")
+	}
+	p.next()
+
+	if !onColumn {
+		tbl, err := p.table(parts[len(parts)-1])
+		if err != nil {
+			return err
+		}
+		tbl.Comment = text
+		return p.endStatement()
+	}
+	tbl, err := p.table(parts[len(parts)-2])
+	if err != nil {
+		return err
+	}
+	column := parts[len(parts)-1]
+	field, ok := tbl.Fields[column]
+	if !ok {
+		return p.errorf("column %s.%s is not defined", tbl.TableName, column)
+	}
+	field.Comment = text
+	tbl.Fields[column] = field
+	return p.endStatement()
+}
+
+// table returns the table called name
+func (p *Parser) table(name string) (*schema.TableSchema, error) {
+	tbl, ok := p.sch.Tables[name]
+	if !ok {
+		return nil, p.errorf("table %s is not defined", name)
+	}
+	return tbl, nil
+}
+
+// addTable adds tbl to the schema, after the tables defined before it
+func (p *Parser) addTable(tbl *schema.TableSchema) {
+	p.sch.AddTable(tbl)
+	p.sch.FieldOrder = append(p.sch.FieldOrder, tbl.TableName)
+}
+
+// replaceTable replaces the table called name with tbl, keeping its place
+func (p *Parser) replaceTable(name string, tbl *schema.TableSchema) {
+	p.sch.ReplaceTable(name, tbl)
+	for i, table := range p.sch.FieldOrder {
+		if table == name {
+			p.sch.FieldOrder[i] = tbl.TableName
+		}
+	}
+}
+
+func (p *Parser) dropTable(name string) {
+	delete(p.sch.Tables, name)
+	p.sch.FieldOrder = slices.DeleteFunc(p.sch.FieldOrder, func(table string) bool { return table == name })
+}
@@ -0,0 +1,411 @@
+package ddl
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlDump is shaped like mysqldump output
+const mysqlDump = "-- MySQL dump 10.13\n" +
+	"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+	"USE `shop`;\n" +
+	"DROP TABLE IF EXISTS `customers`;\n" +
+	"CREATE TABLE `customers` (\n" +
+	"  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `email` varchar(255) CHARACTER SET utf8mb4 NOT NULL COMMENT 'Login \\'name\\'',\n" +
+	"  `status` enum('active','closed') NOT NULL DEFAULT 'active',\n" +
+	"  `balance` decimal(10,2) DEFAULT '0.00',\n" +
+	"  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+	"  `region` char(2) DEFAULT NULL,\n" +
+	"  `city` varchar(64) DEFAULT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  UNIQUE KEY `uq_email` (`email`),\n" +
+	"  KEY `idx_place` (`region`,`city`(10)),\n" +
+	"  FULLTEXT KEY `ft_email` (`email`)\n" +
+	") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COMMENT='Paying customers';\n" +
+	"LOCK TABLES `customers` WRITE;\n" +
+	"INSERT INTO `customers` VALUES (1,'a@example.com;','active','1.00',NOW(),NULL,NULL);\n" +
+	"UNLOCK TABLES;\n" +
+	"CREATE TABLE `orders` (\n" +
+	"  `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,\n" +
+	"  `customer_id` int(11) unsigned NOT NULL,\n" +
+	"  `total` double NOT NULL DEFAULT -1.5,\n" +
+	"  CONSTRAINT `fk_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE\n" +
+	");\n"
+
+func TestParseMySQL(t *testing.T) {
+	sch, err := Parse(mysqlDump)
+	require.NoError(t, err)
+
+	assert.Equal(t, "shop", sch.DatabaseName)
+	assert.Equal(t, []string{"customers", "orders"}, sch.TableNames())
+
+	customers := sch.Tables["customers"]
+	assert.Equal(t, "Paying customers", customers.Comment)
+	assert.Equal(t, []string{"id", "email", "status", "balance", "created_at", "region", "city"}, customers.FieldOrder)
+	assert.Equal(t, "id", customers.PrimaryKey)
+	assert.Equal(t, []string{"id"}, customers.AutoIncrementFields)
+
+	id := customers.Fields["id"]
+	assert.Equal(t, "int(11) unsigned", id.DataType)
+	assert.True(t, id.PrimaryKey)
+	assert.False(t, id.Nullable)
+
+	email := customers.Fields["email"]
+	assert.Equal(t, "varchar(255)", email.DataType)
+	assert.Equal(t, "Login 'name'", email.Comment)
+	assert.False(t, email.Nullable)
+
+	assert.Equal(t, "enum('active','closed')", customers.Fields["status"].DataType)
+	assert.Equal(t, "active", customers.Fields["status"].Default)
+	assert.Equal(t, "decimal(10,2)", customers.Fields["balance"].DataType)
+	assert.Equal(t, "0.00", customers.Fields["balance"].Default)
+	assert.True(t, customers.Fields["balance"].Nullable)
+	assert.Equal(t, schema.Expr("CURRENT_TIMESTAMP"), customers.Fields["created_at"].Default)
+	assert.Nil(t, customers.Fields["region"].Default)
+
+	assert.Equal(t, []schema.Index{
+		{Name: "uq_email", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_place", Columns: []string{"region", "city"}},
+	}, customers.IndexDefs)
+
+	orders := sch.Tables["orders"]
+	assert.Equal(t, "id", orders.PrimaryKey)
+	assert.Equal(t, "bigint", orders.Fields["id"].DataType)
+	assert.Equal(t, -1.5, orders.Fields["total"].Default)
+	assert.Empty(t, orders.IndexDefs, "foreign keys are not indexes")
+	assert.Equal(t, []schema.ForeignKey{{
+		Name: "fk_customer", Columns: []string{"customer_id"},
+		RefTable: "customers", RefColumns: []string{"id"}, OnDelete: schema.Cascade,
+	}}, orders.ForeignKeys)
+}
+
+// pgDump is shaped like pg_dump output
+const pgDump = `--
+-- PostgreSQL database dump
+--
+SET statement_timeout = 0;
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.updated_at = now(); RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.authors (
+    id integer NOT NULL,
+    name character varying(100) NOT NULL,
+    bio text DEFAULT 'C:\path'::text,
+    tags text[] DEFAULT '{}'::text[],
+    rating numeric(3,1) DEFAULT (-1),
+    active boolean DEFAULT true NOT NULL,
+    updated_at timestamp with time zone DEFAULT now()
+);
+
+COMMENT ON TABLE public.authors IS 'People who write';
+COMMENT ON COLUMN public.authors.bio IS 'Short biography';
+
+CREATE SEQUENCE public.authors_id_seq AS integer START WITH 1 INCREMENT BY 1;
+ALTER SEQUENCE public.authors_id_seq OWNED BY public.authors.id;
+ALTER TABLE ONLY public.authors ALTER COLUMN id SET DEFAULT nextval('public.authors_id_seq'::regclass);
+
+CREATE TABLE public.books (
+    id bigserial,
+    author_id integer,
+    isbn text,
+    title text NOT NULL,
+    CONSTRAINT title_length CHECK ((length(title) > 0))
+);
+ALTER TABLE public.books OWNER TO app;
+
+COPY public.books (id, author_id, isbn, title) FROM stdin;
+1	1	978-0	A book; with a semicolon
+\.
+
+ALTER TABLE ONLY public.authors
+    ADD CONSTRAINT authors_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_isbn_key UNIQUE (isbn);
+CREATE INDEX books_author_id_idx ON public.books USING btree (author_id);
+CREATE INDEX books_lower_title_idx ON public.books USING btree (lower(title));
+CREATE UNIQUE INDEX books_title_idx ON public.books (title) WHERE (isbn IS NULL);
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.authors(id);
+`
+
+func TestParsePostgres(t *testing.T) {
+	sch, err := Parse(pgDump)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"authors", "books"}, sch.TableNames())
+
+	authors := sch.Tables["authors"]
+	assert.Equal(t, "People who write", authors.Comment)
+	assert.Equal(t, "id", authors.PrimaryKey)
+	assert.True(t, authors.Fields["id"].AutoIncrement, "nextval defaults auto-increment")
+	assert.Nil(t, authors.Fields["id"].Default)
+	assert.Equal(t, "character varying(100)", authors.Fields["name"].DataType)
+	assert.Equal(t, `C:\path`, authors.Fields["bio"].Default, "standard_conforming_strings is on")
+	assert.Equal(t, "Short biography", authors.Fields["bio"].Comment)
+	assert.Equal(t, "text[]", authors.Fields["tags"].DataType)
+	assert.Equal(t, "{}", authors.Fields["tags"].Default)
+	assert.Equal(t, int64(-1), authors.Fields["rating"].Default)
+	assert.Equal(t, true, authors.Fields["active"].Default)
+	assert.False(t, authors.Fields["active"].Nullable)
+	assert.Equal(t, "timestamp with time zone", authors.Fields["updated_at"].DataType)
+	assert.Equal(t, schema.Expr("now()"), authors.Fields["updated_at"].Default)
+
+	books := sch.Tables["books"]
+	assert.Equal(t, "id", books.PrimaryKey)
+	assert.Equal(t, "bigint", books.Fields["id"].DataType)
+	assert.True(t, books.Fields["id"].AutoIncrement)
+	assert.False(t, books.Fields["id"].Nullable)
+	assert.Equal(t, []schema.Index{
+		{Name: "books_isbn_key", Columns: []string{"isbn"}, Unique: true},
+		{Name: "books_author_id_idx", Columns: []string{"author_id"}},
+	}, books.IndexDefs, "expression and partial indexes are skipped")
+	assert.Equal(t, []schema.ForeignKey{{
+		Name: "books_author_id_fkey", Columns: []string{"author_id"},
+		RefTable: "authors", RefColumns: []string{"id"},
+	}}, books.ForeignKeys)
+	assert.NoError(t, sch.Validate())
+}
+
+func TestParseForeignKeys(t *testing.T) {
+	sch, err := Parse(`
+		CREATE TABLE teams (id integer PRIMARY KEY, name text);
+		CREATE TABLE people (
+			id integer,
+			team_id integer REFERENCES teams ON DELETE SET NULL,
+			manager_id integer CONSTRAINT fk_manager REFERENCES people,
+			region text,
+			code text,
+			PRIMARY KEY (id),
+			FOREIGN KEY (region, code) REFERENCES offices (region, code) ON UPDATE CASCADE ON DELETE NO ACTION
+		);
+		ALTER TABLE people ADD mentor_id integer REFERENCES people (id) ON DELETE RESTRICT;
+		ALTER TABLE people ADD CONSTRAINT fk_buddy FOREIGN KEY (mentor_id) REFERENCES people (id) ON DELETE SET DEFAULT;
+		ALTER TABLE people DROP FOREIGN KEY fk_buddy;
+		ALTER TABLE teams RENAME TO squads;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []schema.ForeignKey{
+		{Name: "fk_people_team_id", Columns: []string{"team_id"}, RefTable: "squads", RefColumns: []string{"id"}, OnDelete: schema.SetNull},
+		{Name: "fk_manager", Columns: []string{"manager_id"}, RefTable: "people", RefColumns: []string{"id"}},
+		{Name: "fk_people_region_code", Columns: []string{"region", "code"}, RefTable: "offices", RefColumns: []string{"region", "code"},
+			OnDelete: schema.NoAction, OnUpdate: schema.Cascade},
+		{Name: "fk_people_mentor_id", Columns: []string{"mentor_id"}, RefTable: "people", RefColumns: []string{"id"}, OnDelete: schema.Restrict},
+	}, sch.Tables["people"].ForeignKeys)
+
+	_, err = Parse("CREATE TABLE a (b_id int REFERENCES b);")
+	assert.EqualError(t, err, "line 1: foreign key of a references the primary key of b, which is not defined")
+}
+
+func TestParseSQLite(t *testing.T) {
+	sch, err := Parse(`
+		CREATE TABLE IF NOT EXISTS "events" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL DEFAULT "click" COLLATE NOCASE,
+			payload,
+			at DATETIME DEFAULT (datetime('now')),
+			[key] text UNIQUE ON CONFLICT REPLACE,
+			UNIQUE (kind, at)
+		);
+		CREATE TABLE IF NOT EXISTS events (ignored TEXT);
+		CREATE INDEX IF NOT EXISTS idx_events_kind ON events (kind COLLATE NOCASE DESC);
+	`)
+	require.NoError(t, err)
+
+	events := sch.Tables["events"]
+	assert.Equal(t, []string{"id", "kind", "payload", "at", "key"}, events.FieldOrder)
+	assert.Equal(t, "id", events.PrimaryKey)
+	assert.True(t, events.Fields["id"].AutoIncrement)
+	assert.Equal(t, "click", events.Fields["kind"].Default)
+	assert.Equal(t, "blob", events.Fields["payload"].DataType)
+	assert.Equal(t, schema.Expr("(datetime('now'))"), events.Fields["at"].Default)
+	assert.True(t, events.Fields["key"].Unique)
+	assert.Equal(t, []schema.Index{
+		{Name: "uq_events_kind_at", Columns: []string{"kind", "at"}, Unique: true},
+		{Name: "idx_events_kind", Columns: []string{"kind"}},
+	}, events.IndexDefs)
+}
+
+func TestParseCompositeKey(t *testing.T) {
+	sch, err := Parse(`
+		CREATE TABLE stock (region text, sku text, qty int, PRIMARY KEY (region, sku));
+		CREATE TABLE copy LIKE stock;
+		CREATE TABLE other (LIKE stock INCLUDING ALL);
+	`)
+	require.NoError(t, err)
+	for _, name := range []string{"stock", "copy", "other"} {
+		tbl := sch.Tables[name]
+		assert.Equal(t, name, tbl.TableName)
+		assert.Equal(t, []string{"region", "sku"}, tbl.PrimaryKeyColumns(), name)
+		assert.False(t, tbl.Fields["sku"].Nullable, name)
+		assert.True(t, tbl.Fields["qty"].Nullable, name)
+	}
+}
+
+func TestParseAlterTable(t *testing.T) {
+	sch, err := Parse(`
+		CREATE TABLE users (name varchar(50), email text, age int, legacy text);
+		ALTER TABLE users ADD COLUMN id bigint PRIMARY KEY, ADD nickname text DEFAULT 'none';
+		ALTER TABLE users ADD (score int NOT NULL, rank int);
+		ALTER TABLE users DROP COLUMN legacy, DROP IF EXISTS missing;
+		ALTER TABLE users ALTER COLUMN name SET NOT NULL;
+		ALTER TABLE users ALTER COLUMN age TYPE bigint USING age::bigint;
+		ALTER TABLE users ALTER COLUMN age SET DEFAULT 18;
+		ALTER TABLE users MODIFY email varchar(320) NOT NULL COMMENT 'Login';
+		ALTER TABLE users CHANGE rank position smallint;
+		ALTER TABLE users RENAME COLUMN nickname TO alias;
+		ALTER TABLE users RENAME score TO points;
+		ALTER TABLE users ADD UNIQUE KEY uq_alias (alias), ADD INDEX idx_points (points);
+		ALTER TABLE users RENAME INDEX idx_points TO idx_users_points;
+		ALTER TABLE users DROP INDEX uq_alias;
+		ALTER TABLE users RENAME TO members;
+		CREATE TABLE tmp (x int);
+		RENAME TABLE tmp TO scratch;
+		DROP TABLE scratch;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"members"}, sch.TableNames())
+
+	members := sch.Tables["members"]
+	assert.Equal(t, []string{"name", "email", "age", "id", "alias", "points", "position"}, members.FieldOrder)
+	assert.Equal(t, "id", members.PrimaryKey)
+	assert.False(t, members.Fields["id"].Nullable)
+	assert.False(t, members.Fields["name"].Nullable)
+	assert.Equal(t, "bigint", members.Fields["age"].DataType)
+	assert.Equal(t, int64(18), members.Fields["age"].Default)
+	assert.Equal(t, schema.ColumnData{Name: "email", DataType: "varchar(320)", Comment: "Login"}, members.Fields["email"])
+	assert.Equal(t, "smallint", members.Fields["position"].DataType)
+	assert.Equal(t, "none", members.Fields["alias"].Default)
+	assert.False(t, members.Fields["points"].Nullable)
+	assert.Equal(t, []schema.Index{{Name: "idx_users_points", Columns: []string{"points"}}}, members.IndexDefs)
+	assert.Equal(t, []string{"idx_users_points"}, members.Indexes)
+	assert.Empty(t, members.UniqueKeys)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"CREATE TABLE t (a int);\nCREATE TABLE t (b int);", 2, "table t is created twice"},
+		{"CREATE TABLE t (a int,\n  PRIMARY KEY (b));", 2, "primary key column t.b is not defined"},
+		{"CREATE INDEX i ON missing (a);", 1, "table missing is not defined"},
+		{"CREATE TABLE t (a int);\n\nCREATE INDEX i ON t (b);", 3, "indexed column t.b is not defined"},
+		{"CREATE TABLE t (a int);\nALTER TABLE t DROP COLUMN b;", 2, "cannot drop column b: field b not found in table t schema"},
+		{"CREATE TABLE t AS SELECT 1;", 1, "CREATE TABLE t without column definitions is not supported"},
+		{"CREATE TABLE t (a int", 1, `expected ",", found end of input`},
+		{"CREATE TABLE t (a int DEFAULT 'x);", 1, "unterminated '"},
+		{"CREATE TABLE t (a int);\n/* unterminated", 2, "unterminated comment"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.src)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), "%q: %v", test.src, err) {
+			assert.Equal(t, test.line, syntaxErr.Line, test.src)
+			assert.Equal(t, test.msg, syntaxErr.Msg, test.src)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "001_create.sql")
+	second := filepath.Join(dir, "002_alter.sql")
+	require.NoError(t, os.WriteFile(first, []byte("CREATE TABLE notes (id int PRIMARY KEY, body text);"), 0o644))
+	require.NoError(t, os.WriteFile(second, []byte("ALTER TABLE notes\n  ADD pinned boolean DEFAULT false;\nALTER TABLE nope ADD x int;"), 0o644))
+
+	_, err := ParseFile(first, second)
+	assert.EqualError(t, err, second+": line 3: table nope is not defined")
+
+	sch, err := ParseFile(first)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "body"}, sch.Tables["notes"].FieldOrder)
+}
+
+// TestRenderParsed checks a parsed schema renders to DDL SQLite accepts,
+// which parses back to the same schema
+func TestRenderParsed(t *testing.T) {
+	sch, err := Parse(`
+		CREATE TABLE accounts (
+			id integer NOT NULL AUTO_INCREMENT,
+			email varchar(255) NOT NULL,
+			plan text DEFAULT 'it''s free',
+			credits real DEFAULT 0.5,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_accounts_email (email)
+		);
+		CREATE TABLE stock (region text, sku text, bin text, PRIMARY KEY (region, sku));
+		CREATE INDEX idx_stock_bin ON stock (bin, sku);
+	`)
+	require.NoError(t, err)
+	ddl := dialect.SQLite.RenderDDL(sch)
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(ddl)
+	require.NoError(t, err, ddl)
+
+	reparsed, err := Parse(ddl)
+	require.NoError(t, err, ddl)
+	for _, name := range sch.TableNames() {
+		want, got := sch.Tables[name], reparsed.Tables[name]
+		assert.Equal(t, want.FieldOrder, got.FieldOrder, name)
+		assert.Equal(t, want.PrimaryKey, got.PrimaryKey, name)
+		assert.Equal(t, want.IndexDefs, got.IndexDefs, name)
+		for _, column := range want.FieldOrder {
+			assert.Equal(t, want.Fields[column].Nullable, got.Fields[column].Nullable, column)
+			assert.Equal(t, want.Fields[column].Default, got.Fields[column].Default, column)
+		}
+	}
+}
+
+// TestRenderParsedAutoIncrement checks an auto-increment key declared with a
+// MySQL type renders to an INTEGER key SQLite numbers itself
+func TestRenderParsedAutoIncrement(t *testing.T) {
+	sch, err := Parse("CREATE TABLE `users` (\n" +
+		"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+		"  `name` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB;\n")
+	require.NoError(t, err)
+	ddl := dialect.SQLite.RenderDDL(sch)
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(ddl)
+	require.NoError(t, err, ddl)
+
+	_, err = db.Exec(`INSERT INTO users (name) VALUES ('Amy'), ('Bob')`)
+	require.NoError(t, err)
+	var id int64
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE name = 'Bob'`).Scan(&id))
+	assert.Equal(t, int64(2), id)
+
+	reparsed, err := Parse(ddl)
+	require.NoError(t, err, ddl)
+	users := reparsed.Tables["users"]
+	assert.Equal(t, "id", users.PrimaryKey)
+	assert.Equal(t, "integer", users.Fields["id"].DataType)
+	assert.True(t, users.Fields["id"].AutoIncrement)
+}
@@ -101,6 +101,18 @@ func (p *Parser) parseTable(table InfoSchemaTable) (*schema.TableSchema, error)
 
 	p.processIndexes(tableSchema, indexes)
 
+	keyColumns, err := p.getForeignKeyColumns(table.TableSchema, table.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+
+	constraints, err := p.getReferentialConstraints(table.TableSchema, table.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get foreign key rules: %w", err)
+	}
+
+	p.processForeignKeys(tableSchema, keyColumns, constraints)
+
 	return tableSchema, nil
 }
 
@@ -186,6 +198,81 @@ func (p *Parser) getIndexes(schemaName, tableName string) ([]InfoSchemaIndex, er
 	return indexes, rows.Err()
 }
 
+// getForeignKeyColumns returns the columns of the table's foreign keys with
+// the columns they reference, ordered by constraint and position
+func (p *Parser) getForeignKeyColumns(schemaName, tableName string) ([]InfoSchemaKeyColumn, error) {
+	query := `
+		SELECT
+			constraint_catalog, constraint_schema, constraint_name,
+			table_catalog, table_schema, table_name, column_name,
+			ordinal_position, position_in_unique_constraint,
+			referenced_table_schema, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = ? AND table_name = ? AND referenced_table_name IS NOT NULL
+		ORDER BY constraint_name, ordinal_position`
+
+	rows, err := p.db.Query(query, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []InfoSchemaKeyColumn
+	for rows.Next() {
+		var column InfoSchemaKeyColumn
+		err := rows.Scan(
+			&column.ConstraintCatalog, &column.ConstraintSchema, &column.ConstraintName,
+			&column.TableCatalog, &column.TableSchema, &column.TableName, &column.ColumnName,
+			&column.OrdinalPosition, &column.PositionInUniqueConstraint,
+			&column.ReferencedTableSchema, &column.ReferencedTableName, &column.ReferencedColumnName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// getReferentialConstraints returns the update and delete rules of the
+// table's foreign keys
+func (p *Parser) getReferentialConstraints(schemaName, tableName string) ([]InfoSchemaReferentialConstraint, error) {
+	query := `
+		SELECT
+			constraint_catalog, constraint_schema, constraint_name,
+			unique_constraint_catalog, unique_constraint_schema,
+			COALESCE(unique_constraint_name, '') as unique_constraint_name,
+			match_option, update_rule, delete_rule,
+			table_name, referenced_table_name
+		FROM information_schema.referential_constraints
+		WHERE constraint_schema = ? AND table_name = ?`
+
+	rows, err := p.db.Query(query, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []InfoSchemaReferentialConstraint
+	for rows.Next() {
+		var constraint InfoSchemaReferentialConstraint
+		err := rows.Scan(
+			&constraint.ConstraintCatalog, &constraint.ConstraintSchema, &constraint.ConstraintName,
+			&constraint.UniqueConstraintCatalog, &constraint.UniqueConstraintSchema,
+			&constraint.UniqueConstraintName,
+			&constraint.MatchOption, &constraint.UpdateRule, &constraint.DeleteRule,
+			&constraint.TableName, &constraint.ReferencedTableName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	return constraints, rows.Err()
+}
+
 func (p *Parser) convertColumn(column InfoSchemaColumn) schema.ColumnData {
 	columnData := schema.ColumnData{
 		Name:     column.ColumnName,
@@ -208,26 +295,70 @@ func (p *Parser) convertColumn(column InfoSchemaColumn) schema.ColumnData {
 }
 
 func (p *Parser) processIndexes(tableSchema *schema.TableSchema, indexes []InfoSchemaIndex) {
+	var indexNames []string
 	indexMap := make(map[string][]string)
 	uniqueIndexes := make(map[string]bool)
 
+	// Rows are ordered by index name and position within the index
 	for _, index := range indexes {
 		if index.IndexName == "PRIMARY" {
 			continue
 		}
 
+		if _, ok := indexMap[index.IndexName]; !ok {
+			indexNames = append(indexNames, index.IndexName)
+		}
 		indexMap[index.IndexName] = append(indexMap[index.IndexName], index.ColumnName)
 		if index.NonUnique == 0 {
 			uniqueIndexes[index.IndexName] = true
 		}
 	}
 
-	for indexName := range indexMap {
-		tableSchema.Indexes = append(tableSchema.Indexes, indexName)
-		if uniqueIndexes[indexName] {
-			tableSchema.UniqueKeys = append(tableSchema.UniqueKeys, indexName)
+	for _, indexName := range indexNames {
+		tableSchema.AddIndex(indexName, uniqueIndexes[indexName], indexMap[indexName]...)
+	}
+}
+
+func (p *Parser) processForeignKeys(tableSchema *schema.TableSchema, keyColumns []InfoSchemaKeyColumn, constraints []InfoSchemaReferentialConstraint) {
+	rules := make(map[string]InfoSchemaReferentialConstraint)
+	for _, constraint := range constraints {
+		rules[constraint.ConstraintName] = constraint
+	}
+
+	// Rows are ordered by constraint name and position within the key
+	var fks []schema.ForeignKey
+	for _, column := range keyColumns {
+		if column.ReferencedTableName == nil || column.ReferencedColumnName == nil {
+			continue
+		}
+		if len(fks) == 0 || fks[len(fks)-1].Name != column.ConstraintName {
+			rule := rules[column.ConstraintName]
+			fks = append(fks, schema.ForeignKey{
+				Name:     column.ConstraintName,
+				RefTable: *column.ReferencedTableName,
+				OnDelete: referentialAction(rule.DeleteRule),
+				OnUpdate: referentialAction(rule.UpdateRule),
+			})
 		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, column.ColumnName)
+		fk.RefColumns = append(fk.RefColumns, *column.ReferencedColumnName)
+	}
+
+	for _, fk := range fks {
+		tableSchema.AddForeignKey(fk)
+	}
+}
+
+// referentialAction converts a rule as databases report it. NO ACTION, the
+// default, becomes the zero action, as a foreign key declared without a rule
+// has.
+func referentialAction(rule string) schema.ReferentialAction {
+	action, err := schema.ParseReferentialAction(rule)
+	if err != nil || action == schema.NoAction {
+		return ""
 	}
+	return action
 }
 
 func (p *Parser) ParseTableFromName(databaseName, tableName string) (*schema.TableSchema, error) {
@@ -220,4 +220,73 @@ func TestProcessIndexes(t *testing.T) {
 
 	assert.Contains(t, tableSchema.UniqueKeys, "idx_unique")
 	assert.NotContains(t, tableSchema.UniqueKeys, "idx_test")
+
+	assert.Equal(t, []schema.Index{
+		{Name: "idx_test", Columns: []string{"col1"}},
+		{Name: "idx_unique", Columns: []string{"col2"}, Unique: true},
+	}, tableSchema.IndexDefs)
+}
+
+func TestProcessIndexesComposite(t *testing.T) {
+	parser := &Parser{}
+	tableSchema := schema.NewTableSchema("test_table")
+
+	parser.processIndexes(tableSchema, []InfoSchemaIndex{
+		{IndexName: "idx_name", ColumnName: "last_name", SeqInIndex: 1, NonUnique: 1},
+		{IndexName: "idx_name", ColumnName: "first_name", SeqInIndex: 2, NonUnique: 1},
+		{IndexName: "uq_team_handle", ColumnName: "team", SeqInIndex: 1, NonUnique: 0},
+		{IndexName: "uq_team_handle", ColumnName: "handle", SeqInIndex: 2, NonUnique: 0},
+	})
+
+	assert.Equal(t, []schema.Index{
+		{Name: "idx_name", Columns: []string{"last_name", "first_name"}},
+		{Name: "uq_team_handle", Columns: []string{"team", "handle"}, Unique: true},
+	}, tableSchema.IndexDefs)
+	assert.Equal(t, []string{"uq_team_handle"}, tableSchema.UniqueKeys)
+}
+
+func TestParseForeignKeys(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE comments (
+			id INTEGER PRIMARY KEY,
+			post_id INTEGER REFERENCES posts ON DELETE CASCADE,
+			author_id INTEGER,
+			FOREIGN KEY (author_id) REFERENCES users(id) ON UPDATE SET NULL
+		)`)
+	require.NoError(t, err)
+
+	sch, err := NewSQLiteAdapter(db).ParseSchema("main")
+	require.NoError(t, err)
+
+	assert.Equal(t, []schema.ForeignKey{
+		{Name: "fk_posts_user_id", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+	}, sch.Tables["posts"].ForeignKeys)
+	assert.ElementsMatch(t, []schema.ForeignKey{
+		{Name: "fk_comments_post_id", Columns: []string{"post_id"}, RefTable: "posts", RefColumns: []string{"id"}, OnDelete: schema.Cascade},
+		{Name: "fk_comments_author_id", Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"}, OnUpdate: schema.SetNull},
+	}, sch.Tables["comments"].ForeignKeys)
+	assert.Empty(t, sch.Tables["users"].ForeignKeys)
+}
+
+func TestProcessForeignKeys(t *testing.T) {
+	parser := &Parser{}
+	tableSchema := schema.NewTableSchema("line_items")
+	ref := func(s string) *string { return &s }
+
+	parser.processForeignKeys(tableSchema, []InfoSchemaKeyColumn{
+		{ConstraintName: "fk_order", ColumnName: "order_id", OrdinalPosition: 1, ReferencedTableName: ref("orders"), ReferencedColumnName: ref("id")},
+		{ConstraintName: "fk_product", ColumnName: "vendor", OrdinalPosition: 1, ReferencedTableName: ref("products"), ReferencedColumnName: ref("vendor")},
+		{ConstraintName: "fk_product", ColumnName: "sku", OrdinalPosition: 2, ReferencedTableName: ref("products"), ReferencedColumnName: ref("sku")},
+	}, []InfoSchemaReferentialConstraint{
+		{ConstraintName: "fk_order", DeleteRule: "CASCADE", UpdateRule: "NO ACTION"},
+		{ConstraintName: "fk_product", DeleteRule: "RESTRICT", UpdateRule: "CASCADE"},
+	})
+
+	assert.Equal(t, []schema.ForeignKey{
+		{Name: "fk_order", Columns: []string{"order_id"}, RefTable: "orders", RefColumns: []string{"id"}, OnDelete: schema.Cascade},
+		{Name: "fk_product", Columns: []string{"vendor", "sku"}, RefTable: "products", RefColumns: []string{"vendor", "sku"}, OnDelete: schema.Restrict, OnUpdate: schema.Cascade},
+	}, tableSchema.ForeignKeys)
 }
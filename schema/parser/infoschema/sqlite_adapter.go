@@ -79,6 +79,15 @@ func (s *SQLiteAdapter) parseTable(tableName string) (*schema.TableSchema, error
 
 	s.updateColumnConstraints(tableSchema, indexes)
 
+	foreignKeys, err := s.getForeignKeys(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+
+	for _, fk := range foreignKeys {
+		tableSchema.AddForeignKey(fk)
+	}
+
 	return tableSchema, nil
 }
 
@@ -129,6 +138,83 @@ func (s *SQLiteAdapter) getColumns(tableName string) ([]SQLiteColumn, error) {
 	return columns, rows.Err()
 }
 
+// getForeignKeys returns the table's foreign keys. SQLite does not name
+// them, so they get AddForeignKey's default names.
+func (s *SQLiteAdapter) getForeignKeys(tableName string) ([]schema.ForeignKey, error) {
+	query := fmt.Sprintf("PRAGMA foreign_key_list(%s)", tableName)
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Rows are grouped by foreign key id and ordered by position within the key
+	var foreignKeys []schema.ForeignKey
+	lastID := -1
+	for rows.Next() {
+		var id, seq int
+		var refTable, from, onUpdate, onDelete, match string
+		var to sql.NullString
+
+		err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != lastID {
+			foreignKeys = append(foreignKeys, schema.ForeignKey{
+				RefTable: refTable,
+				OnDelete: referentialAction(onDelete),
+				OnUpdate: referentialAction(onUpdate),
+			})
+			lastID = id
+		}
+		fk := &foreignKeys[len(foreignKeys)-1]
+		fk.Columns = append(fk.Columns, from)
+		if to.Valid {
+			fk.RefColumns = append(fk.RefColumns, to.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A foreign key without referenced columns references the primary key
+	for i, fk := range foreignKeys {
+		if len(fk.RefColumns) > 0 {
+			continue
+		}
+		columns, err := s.getPrimaryKey(fk.RefTable)
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys[i].RefColumns = columns
+	}
+
+	return foreignKeys, nil
+}
+
+// getPrimaryKey returns the columns of the table's primary key in key order
+func (s *SQLiteAdapter) getPrimaryKey(tableName string) ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, rows.Err()
+}
+
 func (s *SQLiteAdapter) getIndexes(tableName string) ([]SQLiteIndex, error) {
 	query := fmt.Sprintf("PRAGMA index_list(%s)", tableName)
 
@@ -230,10 +316,7 @@ func (s *SQLiteAdapter) processIndexes(tableSchema *schema.TableSchema, indexes
 			continue
 		}
 
-		tableSchema.Indexes = append(tableSchema.Indexes, index.Name)
-		if index.Unique {
-			tableSchema.UniqueKeys = append(tableSchema.UniqueKeys, index.Name)
-		}
+		tableSchema.AddIndex(index.Name, index.Unique, index.Columns...)
 	}
 }
 
@@ -1,6 +1,10 @@
 package schema
 
-import "sync"
+import (
+	"slices"
+	"strings"
+	"sync"
+)
 
 type Schema struct {
 	DatabaseName string
@@ -13,9 +17,11 @@ type TableSchema struct {
 	TableName           string
 	Fields              map[string]ColumnData // Maps field names to their definitions
 	FieldOrder          []string              // Order of fields for iteration
-	PrimaryKey          string                // Name of the primary key field
+	PrimaryKey          string                // Name of the primary key field, comma separated for a composite key
 	Indexes             []string              // List of index names for this table
 	UniqueKeys          []string              // List of unique key names for this table
+	IndexDefs           []Index               // Named and multi-column indexes; see AddIndex
+	ForeignKeys         []ForeignKey          // References to other tables; see AddForeignKey
 	AutoIncrementFields []string              // List of fields that are auto-incremented
 	Comment             string                // Optional comment for the table
 }
@@ -24,7 +30,7 @@ type ColumnData struct {
 	Name          string
 	DataType      string
 	Nullable      bool
-	Default       any // Default value for the column, can be nil
+	Default       any // Default value for the column, can be nil; see Expr
 	Comment       string
 	Unique        bool
 	Index         bool
@@ -32,6 +38,19 @@ type ColumnData struct {
 	PrimaryKey    bool // Indicates if this column is a primary key
 }
 
+// Expr is a column default the database computes when a row is written,
+// such as CURRENT_TIMESTAMP, rather than a value. It is written into DDL as
+// it is, so it must come from the schema's author, never from user input;
+// any other default is rendered as an escaped literal.
+type Expr string
+
+// Index is a secondary index on one or more columns of a table
+type Index struct {
+	Name    string
+	Columns []string // Indexed columns, in index order
+	Unique  bool
+}
+
 func New() *Schema {
 	return &Schema{
 		Tables: make(map[string]*TableSchema),
@@ -57,7 +76,22 @@ func (s *Schema) GetTable(name string) (TableSchema, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	table, exists := s.Tables[name]
-	return *table, exists
+	if !exists || table == nil {
+		return TableSchema{}, false
+	}
+	return *table, true
+}
+
+// ReplaceTable replaces the table named oldName with table, which may have
+// been renamed. Foreign keys referencing a renamed table follow it.
+func (s *Schema) ReplaceTable(oldName string, table *TableSchema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Tables, oldName)
+	s.Tables[table.TableName] = table
+	if table.TableName != oldName {
+		s.renameReferences(oldName, table.TableName)
+	}
 }
 
 func NewTableSchema(name string) *TableSchema {
@@ -67,6 +101,25 @@ func NewTableSchema(name string) *TableSchema {
 	}
 }
 
+// Clone returns a copy of the table sharing no maps or slices with it
+func (ts TableSchema) Clone() *TableSchema {
+	clone := ts
+	clone.Fields = make(map[string]ColumnData, len(ts.Fields))
+	for name, field := range ts.Fields {
+		clone.Fields[name] = field
+	}
+	clone.FieldOrder = slices.Clone(ts.FieldOrder)
+	clone.Indexes = slices.Clone(ts.Indexes)
+	clone.UniqueKeys = slices.Clone(ts.UniqueKeys)
+	clone.AutoIncrementFields = slices.Clone(ts.AutoIncrementFields)
+	clone.IndexDefs = slices.Clone(ts.IndexDefs)
+	for i := range clone.IndexDefs {
+		clone.IndexDefs[i].Columns = slices.Clone(clone.IndexDefs[i].Columns)
+	}
+	clone.ForeignKeys = cloneForeignKeys(ts.ForeignKeys)
+	return &clone
+}
+
 func (ts *TableSchema) AddField(field ColumnData) {
 	if field.Name == "" {
 		return
@@ -74,13 +127,131 @@ func (ts *TableSchema) AddField(field ColumnData) {
 	ts.Fields[field.Name] = field
 	ts.FieldOrder = append(ts.FieldOrder, field.Name)
 	if field.PrimaryKey {
-		ts.PrimaryKey = field.Name
+		if ts.PrimaryKey == "" {
+			ts.PrimaryKey = field.Name
+		} else {
+			ts.PrimaryKey += "," + field.Name
+		}
 	}
 	if field.AutoIncrement {
 		ts.AutoIncrementFields = append(ts.AutoIncrementFields, field.Name)
 	}
 }
 
+// SetPrimaryKey makes columns, in order, the table's primary key
+func (ts *TableSchema) SetPrimaryKey(columns ...string) {
+	for name, field := range ts.Fields {
+		field.PrimaryKey = false
+		ts.Fields[name] = field
+	}
+	for _, column := range columns {
+		if field, ok := ts.Fields[column]; ok {
+			field.PrimaryKey = true
+			ts.Fields[column] = field
+		}
+	}
+	ts.PrimaryKey = strings.Join(columns, ",")
+}
+
+// PrimaryKeyColumns returns the columns of the table's primary key in key
+// order. Tables that do not declare one are keyed on an "id" text column.
+func (ts TableSchema) PrimaryKeyColumns() []string {
+	if ts.PrimaryKey != "" {
+		return strings.Split(ts.PrimaryKey, ",")
+	}
+	var columns []string
+	for _, name := range ts.FieldOrder {
+		if ts.Fields[name].PrimaryKey {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return []string{"id"}
+	}
+	return columns
+}
+
+// HasDeclaredPrimaryKey reports whether the table declares its primary key,
+// rather than relying on the implicit "id" text column
+func (ts TableSchema) HasDeclaredPrimaryKey() bool {
+	if ts.PrimaryKey != "" {
+		return true
+	}
+	for _, field := range ts.Fields {
+		if field.PrimaryKey {
+			return true
+		}
+	}
+	return false
+}
+
+// AddIndex declares an index on columns. An empty name defaults to
+// "idx_<table>_<columns>", or "uq_<table>_<columns>" for a unique index.
+func (ts *TableSchema) AddIndex(name string, unique bool, columns ...string) {
+	if len(columns) == 0 {
+		return
+	}
+	if name == "" {
+		prefix := "idx"
+		if unique {
+			prefix = "uq"
+		}
+		name = prefix + "_" + ts.TableName + "_" + strings.Join(columns, "_")
+	}
+	ts.IndexDefs = append(ts.IndexDefs, Index{Name: name, Columns: columns, Unique: unique})
+	ts.Indexes = append(ts.Indexes, name)
+	if unique {
+		ts.UniqueKeys = append(ts.UniqueKeys, name)
+	}
+}
+
+// SecondaryIndexes returns the indexes backends create for the table: those
+// declared with AddIndex, then one per column flagged Index. Flagged columns
+// that are part of the primary key, unique, or already indexed on their own
+// by a declared index are skipped, as the database indexes them anyway.
+func (ts TableSchema) SecondaryIndexes() []Index {
+	indexes := append([]Index(nil), ts.IndexDefs...)
+	keyColumns := ts.PrimaryKeyColumns()
+	for _, name := range ts.FieldOrder {
+		field := ts.Fields[name]
+		if !field.Index || field.Unique || field.PrimaryKey || (len(keyColumns) == 1 && keyColumns[0] == name) {
+			continue
+		}
+		covered := false
+		for _, index := range ts.IndexDefs {
+			if len(index.Columns) == 1 && index.Columns[0] == name {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			indexes = append(indexes, Index{Name: "idx_" + ts.TableName + "_" + name, Columns: []string{name}})
+		}
+	}
+	return indexes
+}
+
+// IsIndexed reports whether column is part of the primary key, unique, or
+// covered by one of the table's secondary indexes
+func (ts TableSchema) IsIndexed(column string) bool {
+	if field, ok := ts.Fields[column]; ok && (field.PrimaryKey || field.Unique || field.Index) {
+		return true
+	}
+	for _, key := range ts.PrimaryKeyColumns() {
+		if key == column {
+			return true
+		}
+	}
+	for _, index := range ts.IndexDefs {
+		for _, indexed := range index.Columns {
+			if indexed == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *Schema) Lock() {
 	s.mu.Lock()
 }
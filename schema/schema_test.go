@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -103,6 +104,49 @@ func TestAddFieldPrimaryKeyAndAutoIncrement(t *testing.T) {
 	}
 }
 
+func TestPrimaryKeyColumns(t *testing.T) {
+	ts := NewTableSchema("plain")
+	ts.AddField(ColumnData{Name: "id", DataType: "text"})
+	if got := ts.PrimaryKeyColumns(); len(got) != 1 || got[0] != "id" {
+		t.Errorf("expected implicit key [id], got %v", got)
+	}
+	if ts.HasDeclaredPrimaryKey() {
+		t.Error("expected no declared primary key")
+	}
+
+	ts = NewTableSchema("stock")
+	ts.AddField(ColumnData{Name: "region", DataType: "text", PrimaryKey: true})
+	ts.AddField(ColumnData{Name: "sku", DataType: "int", PrimaryKey: true})
+	ts.AddField(ColumnData{Name: "qty", DataType: "int"})
+	if ts.PrimaryKey != "region,sku" {
+		t.Errorf("expected PrimaryKey to be 'region,sku', got %q", ts.PrimaryKey)
+	}
+	if got := ts.PrimaryKeyColumns(); len(got) != 2 || got[0] != "region" || got[1] != "sku" {
+		t.Errorf("expected key [region sku], got %v", got)
+	}
+	if !ts.HasDeclaredPrimaryKey() {
+		t.Error("expected a declared primary key")
+	}
+}
+
+func TestSetPrimaryKey(t *testing.T) {
+	ts := NewTableSchema("stock")
+	ts.AddField(ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	ts.AddField(ColumnData{Name: "region", DataType: "text"})
+	ts.AddField(ColumnData{Name: "sku", DataType: "int"})
+
+	ts.SetPrimaryKey("sku", "region")
+	if got := ts.PrimaryKeyColumns(); len(got) != 2 || got[0] != "sku" || got[1] != "region" {
+		t.Errorf("expected key [sku region], got %v", got)
+	}
+	if ts.Fields["id"].PrimaryKey {
+		t.Error("expected id to no longer be flagged as a key column")
+	}
+	if !ts.Fields["sku"].PrimaryKey || !ts.Fields["region"].PrimaryKey {
+		t.Error("expected sku and region to be flagged as key columns")
+	}
+}
+
 func TestMultipleFieldsOrder(t *testing.T) {
 	ts := NewTableSchema("multi")
 	fields := []ColumnData{
@@ -175,3 +219,94 @@ func TestColumnDataFields(t *testing.T) {
 		t.Errorf("expected Comment to be 'user email', got %q", col.Comment)
 	}
 }
+
+func TestAddIndex(t *testing.T) {
+	ts := NewTableSchema("members")
+	ts.AddIndex("", false, "team", "role")
+	ts.AddIndex("members_by_handle", true, "handle")
+	ts.AddIndex("", true, "team", "handle")
+	ts.AddIndex("empty", false)
+
+	want := []Index{
+		{Name: "idx_members_team_role", Columns: []string{"team", "role"}},
+		{Name: "members_by_handle", Columns: []string{"handle"}, Unique: true},
+		{Name: "uq_members_team_handle", Columns: []string{"team", "handle"}, Unique: true},
+	}
+	if !reflect.DeepEqual(ts.IndexDefs, want) {
+		t.Errorf("expected IndexDefs %v, got %v", want, ts.IndexDefs)
+	}
+	if !reflect.DeepEqual(ts.Indexes, []string{"idx_members_team_role", "members_by_handle", "uq_members_team_handle"}) {
+		t.Errorf("unexpected Indexes %v", ts.Indexes)
+	}
+	if !reflect.DeepEqual(ts.UniqueKeys, []string{"members_by_handle", "uq_members_team_handle"}) {
+		t.Errorf("unexpected UniqueKeys %v", ts.UniqueKeys)
+	}
+}
+
+func TestSecondaryIndexes(t *testing.T) {
+	ts := NewTableSchema("members")
+	ts.AddField(ColumnData{Name: "id", DataType: "text", Index: true})
+	ts.AddField(ColumnData{Name: "email", DataType: "text", Index: true})
+	ts.AddField(ColumnData{Name: "handle", DataType: "text", Index: true, Unique: true})
+	ts.AddField(ColumnData{Name: "team", DataType: "text", Index: true})
+	ts.AddField(ColumnData{Name: "role", DataType: "text"})
+	ts.AddIndex("by_team", false, "team")
+	ts.AddIndex("", false, "team", "role")
+
+	want := []Index{
+		{Name: "by_team", Columns: []string{"team"}},
+		{Name: "idx_members_team_role", Columns: []string{"team", "role"}},
+		{Name: "idx_members_email", Columns: []string{"email"}},
+	}
+	if got := ts.SecondaryIndexes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIsIndexed(t *testing.T) {
+	ts := NewTableSchema("members")
+	ts.AddField(ColumnData{Name: "email", DataType: "text", Index: true})
+	ts.AddField(ColumnData{Name: "handle", DataType: "text", Unique: true})
+	ts.AddField(ColumnData{Name: "team", DataType: "text"})
+	ts.AddField(ColumnData{Name: "role", DataType: "text"})
+	ts.AddField(ColumnData{Name: "bio", DataType: "text"})
+	ts.AddIndex("", false, "team", "role")
+
+	for _, column := range []string{"id", "email", "handle", "team", "role"} {
+		if !ts.IsIndexed(column) {
+			t.Errorf("expected %s to be indexed", column)
+		}
+	}
+	if ts.IsIndexed("bio") {
+		t.Error("expected bio not to be indexed")
+	}
+}
+
+func TestCloneTable(t *testing.T) {
+	ts := NewTableSchema("members")
+	ts.AddField(ColumnData{Name: "team", DataType: "text"})
+	ts.AddField(ColumnData{Name: "role", DataType: "text"})
+	ts.AddIndex("", false, "team", "role")
+
+	clone := ts.Clone()
+	clone.AddField(ColumnData{Name: "bio", DataType: "text"})
+	clone.IndexDefs[0].Columns[0] = "squad"
+	clone.TableName = "people"
+
+	if _, ok := ts.Fields["bio"]; ok || len(ts.FieldOrder) != 2 {
+		t.Errorf("expected the original fields to be unchanged, got %v", ts.FieldOrder)
+	}
+	if ts.IndexDefs[0].Columns[0] != "team" {
+		t.Errorf("expected the original index to be unchanged, got %v", ts.IndexDefs[0].Columns)
+	}
+
+	sch := New()
+	sch.AddTable(ts)
+	sch.ReplaceTable("members", clone)
+	if _, ok := sch.GetTable("members"); ok {
+		t.Error("expected members to be replaced")
+	}
+	if got, ok := sch.GetTable("people"); !ok || len(got.FieldOrder) != 3 {
+		t.Errorf("expected people with 3 fields, got %v", got.FieldOrder)
+	}
+}
@@ -0,0 +1,378 @@
+package schema
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TagName is the struct tag FromStruct reads column definitions from, as in
+//
+//	Email string `ddao:"email,type=text,unique"`
+//
+// The first element names the column, defaulting to the field name in
+// snake_case. The options that follow are type=<data type>, overriding the
+// inferred type, references=<table>(<column>), declaring a foreign key to the
+// column, id if left out, and the flags nullable, unique, index, pk and
+// autoincrement. A tag of "-" skips the field.
+//
+// A field tagged with the relation flag is not a column, but holds the values
+// of the relation the first element names once it is loaded, as in
+//
+//	Author *User `ddao:"author,relation"`
+//	Posts  []Post `ddao:"posts,relation"`
+const TagName = "ddao"
+
+// TableNamer is implemented by structs naming the table FromStruct derives
+// from them. Structs that do not implement it are stored in a table named
+// after the type in snake_case.
+type TableNamer interface {
+	TableName() string
+}
+
+// StructColumn maps a struct field to the column storing it
+type StructColumn struct {
+	Column ColumnData
+	// Index is the field's index sequence, for reflect.Value.FieldByIndex
+	Index []int
+	// Type is the field's type
+	Type reflect.Type
+	// ForeignKey is the foreign key the references option declares, if any
+	ForeignKey *ForeignKey
+}
+
+// StructRelation maps a struct field tagged with the relation flag to the
+// relation whose values it holds
+type StructRelation struct {
+	Name string
+	// Index is the field's index sequence, for reflect.Value.FieldByIndex
+	Index []int
+	// Type is the field's type: a struct or pointer to one for a relation to
+	// one value, or a slice of them
+	Type reflect.Type
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+	// nullTypes are the database/sql types holding a nullable value
+	nullTypes = map[reflect.Type]string{
+		reflect.TypeOf(sql.NullString{}):  "text",
+		reflect.TypeOf(sql.NullInt64{}):   "integer",
+		reflect.TypeOf(sql.NullInt32{}):   "integer",
+		reflect.TypeOf(sql.NullInt16{}):   "integer",
+		reflect.TypeOf(sql.NullByte{}):    "integer",
+		reflect.TypeOf(sql.NullFloat64{}): "real",
+		reflect.TypeOf(sql.NullBool{}):    "boolean",
+		reflect.TypeOf(sql.NullTime{}):    "datetime",
+	}
+)
+
+// FromStruct derives a table from the exported fields of struct type T,
+// described by their ddao tags (see TagName). Fields of embedded structs are
+// flattened into the table, and are nullable when embedded by pointer.
+// Without a type option, a column's type is inferred from its field:
+// strings are text, integers integer, floats real, bools boolean, time.Time
+// datetime, []byte blob, and json.RawMessage, maps, slices and other structs
+// json. Pointer and sql.Null* fields are nullable. When no field is tagged pk,
+// the "id" column, if any, is the primary key.
+func FromStruct[T any]() (*TableSchema, error) {
+	return FromType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// MustFromStruct is like FromStruct but panics if the table cannot be derived
+func MustFromStruct[T any]() *TableSchema {
+	table, err := FromStruct[T]()
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// FromType is FromStruct for a struct type known at run time
+func FromType(t reflect.Type) (*TableSchema, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	columns, err := StructColumns(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no columns", t)
+	}
+
+	table := NewTableSchema(StructTableName(t))
+	declared := false
+	for _, column := range columns {
+		declared = declared || column.Column.PrimaryKey
+	}
+	for _, column := range columns {
+		field := column.Column
+		if !declared && field.Name == "id" {
+			field.PrimaryKey = true
+		}
+		if field.PrimaryKey && field.Nullable {
+			return nil, fmt.Errorf("%s: primary key column %s cannot be nullable", t, field.Name)
+		}
+		table.AddField(field)
+		if column.ForeignKey != nil {
+			table.AddForeignKey(*column.ForeignKey)
+		}
+	}
+	return table, nil
+}
+
+// StructTableName returns the name of the table storing struct type t: the
+// one its TableName method returns, or the type's name in snake_case
+func StructTableName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(reflect.TypeOf((*TableNamer)(nil)).Elem()) {
+		return reflect.Zero(t).Interface().(TableNamer).TableName()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*TableNamer)(nil)).Elem()) {
+		return reflect.New(t).Interface().(TableNamer).TableName()
+	}
+	return SnakeCase(t.Name())
+}
+
+// StructColumns returns the columns of struct type t in field order, with
+// the fields of embedded structs in place of the embedded field
+func StructColumns(t reflect.Type) ([]StructColumn, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+	columns, err := structColumns(t, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if seen[column.Column.Name] {
+			return nil, fmt.Errorf("%s: duplicate column %s", t, column.Column.Name)
+		}
+		seen[column.Column.Name] = true
+	}
+	return columns, nil
+}
+
+func structColumns(t reflect.Type, index []int, nullable bool) ([]StructColumn, error) {
+	var columns []StructColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		options := splitTag(tag)
+		name := options[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			embeddedByPointer := embedded.Kind() == reflect.Pointer
+			if embeddedByPointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != timeType {
+				if embeddedByPointer && !field.IsExported() {
+					return nil, fmt.Errorf("%s: cannot embed unexported %s by pointer", t, field.Type)
+				}
+				embeddedColumns, err := structColumns(embedded, fieldIndex, nullable || embeddedByPointer)
+				if err != nil {
+					return nil, err
+				}
+				columns = append(columns, embeddedColumns...)
+				continue
+			}
+		}
+		if !field.IsExported() || isRelation(options) {
+			continue
+		}
+
+		if name == "" {
+			name = SnakeCase(field.Name)
+		}
+		column := ColumnData{Name: name}
+		var fk *ForeignKey
+		for _, option := range options[1:] {
+			option = strings.TrimSpace(option)
+			switch {
+			case option == "":
+			case strings.HasPrefix(option, "type="):
+				column.DataType = strings.TrimPrefix(option, "type=")
+			case option == "nullable":
+				column.Nullable = true
+			case option == "unique":
+				column.Unique = true
+			case option == "index":
+				column.Index = true
+			case option == "pk":
+				column.PrimaryKey = true
+			case option == "autoincrement":
+				column.AutoIncrement = true
+			case strings.HasPrefix(option, "references="):
+				refTable, refColumn, ok := parseReference(strings.TrimPrefix(option, "references="))
+				if !ok {
+					return nil, fmt.Errorf("%s.%s: invalid references option %q; use references=<table>(<column>)", t, field.Name, option)
+				}
+				fk = &ForeignKey{Columns: []string{name}, RefTable: refTable, RefColumns: []string{refColumn}}
+			default:
+				return nil, fmt.Errorf("%s.%s: unknown %s tag option %q", t, field.Name, TagName, option)
+			}
+		}
+
+		dataType, inferredNullable, err := inferDataType(field.Type)
+		if column.DataType == "" {
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+			}
+			column.DataType = dataType
+		}
+		column.Nullable = column.Nullable || inferredNullable || nullable
+
+		columns = append(columns, StructColumn{Column: column, Index: fieldIndex, Type: field.Type, ForeignKey: fk})
+	}
+	return columns, nil
+}
+
+// StructRelations returns the fields of struct type t tagged with the
+// relation flag, in field order. Fields of embedded structs are not included.
+func StructRelations(t reflect.Type) ([]StructRelation, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+	var relations []StructRelation
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		options := splitTag(field.Tag.Get(TagName))
+		if !field.IsExported() || !isRelation(options) {
+			continue
+		}
+		if options[0] == "" {
+			return nil, fmt.Errorf("%s.%s: the relation flag needs a relation name", t, field.Name)
+		}
+		if len(options) > 2 {
+			return nil, fmt.Errorf("%s.%s: the relation flag takes no other options", t, field.Name)
+		}
+		elem := field.Type
+		if elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct || elem == timeType {
+			return nil, fmt.Errorf("%s.%s: relation %s must hold a struct, a pointer to one or a slice of them, not %s", t, field.Name, options[0], field.Type)
+		}
+		relations = append(relations, StructRelation{Name: options[0], Index: []int{i}, Type: field.Type})
+	}
+	return relations, nil
+}
+
+// isRelation reports whether a tag's options include the relation flag
+func isRelation(options []string) bool {
+	for _, option := range options[1:] {
+		if strings.TrimSpace(option) == "relation" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseReference parses the value of a references option, "table(column)"
+// or "table" for the table's id column
+func parseReference(value string) (table, column string, ok bool) {
+	table, column, found := strings.Cut(value, "(")
+	if !found {
+		return table, "id", table != ""
+	}
+	column, ok = strings.CutSuffix(column, ")")
+	return table, column, ok && table != "" && column != ""
+}
+
+// splitTag splits a struct tag at its commas, except those inside
+// parentheses, as in "price,type=decimal(10,2)"
+func splitTag(tag string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// inferDataType returns the column type storing values of t, and whether
+// the column is nullable
+func inferDataType(t reflect.Type) (string, bool, error) {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	if dataType, ok := nullTypes[t]; ok {
+		return dataType, true, nil
+	}
+
+	switch {
+	case t == timeType:
+		return "datetime", nullable, nil
+	case t == rawMessageType:
+		return "json", nullable, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return "blob", nullable, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "text", nullable, nil
+	case reflect.Bool:
+		return "boolean", nullable, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", nullable, nil
+	case reflect.Float32, reflect.Float64:
+		return "real", nullable, nil
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Interface:
+		return "json", nullable, nil
+	}
+	return "", false, fmt.Errorf("cannot infer a column type for %s; set one with type=", t)
+}
+
+// SnakeCase converts a Go identifier to snake_case, keeping initialisms
+// together: "AuthorID" becomes "author_id" and "HTTPServer" "http_server"
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+package schema
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type Audit struct {
+	EditedBy string `ddao:"edited_by,index"`
+}
+
+type structUser struct {
+	ID       string `ddao:"id"`
+	Email    string `ddao:",unique"`
+	Name     string `ddao:"full_name,type=varchar"`
+	Age      int
+	Score    float64 `ddao:",type=decimal(10,2)"`
+	Active   bool
+	Nickname *string
+	Avatar   []byte
+	Profile  json.RawMessage
+	Tags     []string
+	Settings map[string]any
+	Phone    sql.NullString
+	Password string `ddao:"-"`
+	internal string
+	Timestamps
+	*Audit
+}
+
+func (structUser) TableName() string { return "users" }
+
+func TestFromStruct(t *testing.T) {
+	table, err := FromStruct[structUser]()
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	if table.TableName != "users" {
+		t.Errorf("expected table name users, got %q", table.TableName)
+	}
+
+	expectedOrder := []string{"id", "email", "full_name", "age", "score", "active", "nickname", "avatar", "profile", "tags", "settings", "phone", "created_at", "updated_at", "edited_by"}
+	if !reflect.DeepEqual(table.FieldOrder, expectedOrder) {
+		t.Fatalf("expected fields %v, got %v", expectedOrder, table.FieldOrder)
+	}
+	if table.PrimaryKey != "id" || !table.Fields["id"].PrimaryKey {
+		t.Errorf("expected id to be the primary key, got %q", table.PrimaryKey)
+	}
+
+	tests := []struct {
+		name     string
+		dataType string
+		nullable bool
+	}{
+		{"id", "text", false},
+		{"email", "text", false},
+		{"full_name", "varchar", false},
+		{"age", "integer", false},
+		{"score", "decimal(10,2)", false},
+		{"active", "boolean", false},
+		{"nickname", "text", true},
+		{"avatar", "blob", false},
+		{"profile", "json", false},
+		{"tags", "json", false},
+		{"settings", "json", false},
+		{"phone", "text", true},
+		{"created_at", "datetime", false},
+		{"updated_at", "datetime", true},
+		{"edited_by", "text", true},
+	}
+	for _, tt := range tests {
+		field := table.Fields[tt.name]
+		if field.DataType != tt.dataType {
+			t.Errorf("%s: expected type %s, got %s", tt.name, tt.dataType, field.DataType)
+		}
+		if field.Nullable != tt.nullable {
+			t.Errorf("%s: expected nullable %v, got %v", tt.name, tt.nullable, field.Nullable)
+		}
+	}
+	if !table.Fields["email"].Unique {
+		t.Error("expected email to be unique")
+	}
+	if !table.Fields["edited_by"].Index {
+		t.Error("expected edited_by to be indexed")
+	}
+}
+
+func TestFromStructCompositeKey(t *testing.T) {
+	type OrderLine struct {
+		OrderID int64 `ddao:"order_id,pk"`
+		Line    int   `ddao:"line,pk"`
+		ID      string
+	}
+	table, err := FromStruct[OrderLine]()
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	if table.TableName != "order_line" {
+		t.Errorf("expected table name order_line, got %q", table.TableName)
+	}
+	if table.PrimaryKey != "order_id,line" {
+		t.Errorf("expected primary key order_id,line, got %q", table.PrimaryKey)
+	}
+	if table.Fields["id"].PrimaryKey {
+		t.Error("expected id not to be part of a declared primary key")
+	}
+}
+
+func TestFromStructRelations(t *testing.T) {
+	type Post struct {
+		ID       string
+		AuthorID string      `ddao:",index,references=users(id)"`
+		EditorID *string     `ddao:",references=users"`
+		Author   *structUser `ddao:"author,relation"`
+		Comments []Post      `ddao:"comments,relation"`
+	}
+	table, err := FromStruct[Post]()
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	if !reflect.DeepEqual(table.FieldOrder, []string{"id", "author_id", "editor_id"}) {
+		t.Errorf("expected relation fields not to be columns, got %v", table.FieldOrder)
+	}
+	want := []ForeignKey{
+		{Name: "fk_post_author_id", Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"}},
+		{Name: "fk_post_editor_id", Columns: []string{"editor_id"}, RefTable: "users", RefColumns: []string{"id"}},
+	}
+	if !reflect.DeepEqual(table.ForeignKeys, want) {
+		t.Errorf("expected foreign keys %+v, got %+v", want, table.ForeignKeys)
+	}
+
+	relations, err := StructRelations(reflect.TypeOf(Post{}))
+	if err != nil {
+		t.Fatalf("StructRelations failed: %v", err)
+	}
+	if len(relations) != 2 || relations[0].Name != "author" || relations[1].Name != "comments" {
+		t.Fatalf("unexpected relations %+v", relations)
+	}
+	if !reflect.DeepEqual(relations[1].Index, []int{4}) || relations[1].Type != reflect.TypeOf([]Post{}) {
+		t.Errorf("unexpected comments relation %+v", relations[1])
+	}
+
+	type badReference struct {
+		ID     string
+		UserID string `ddao:",references=users(id"`
+	}
+	type badRelation struct {
+		ID    string
+		Names []string `ddao:"names,relation"`
+	}
+	if _, err := FromStruct[badReference](); err == nil {
+		t.Error("expected an error for an invalid references option")
+	}
+	if _, err := StructRelations(reflect.TypeOf(badRelation{})); err == nil {
+		t.Error("expected an error for a relation not holding structs")
+	}
+}
+
+func TestFromStructErrors(t *testing.T) {
+	type unknownOption struct {
+		ID string `ddao:"id,primary"`
+	}
+	type duplicate struct {
+		Name  string
+		Other string `ddao:"name"`
+	}
+	type nullableKey struct {
+		ID *string `ddao:"id,pk"`
+	}
+	type uninferable struct {
+		ID      string
+		Handler func()
+	}
+
+	if _, err := FromStruct[unknownOption](); err == nil {
+		t.Error("expected an error for an unknown tag option")
+	}
+	if _, err := FromStruct[duplicate](); err == nil {
+		t.Error("expected an error for a duplicate column")
+	}
+	if _, err := FromStruct[nullableKey](); err == nil {
+		t.Error("expected an error for a nullable primary key")
+	}
+	if _, err := FromStruct[uninferable](); err == nil {
+		t.Error("expected an error for a field of uninferable type")
+	}
+	if _, err := FromStruct[string](); err == nil {
+		t.Error("expected an error for a non-struct type")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"AuthorID":   "author_id",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Line2Total": "line2_total",
+		"name":       "name",
+	}
+	for in, expected := range tests {
+		if got := SnakeCase(in); got != expected {
+			t.Errorf("SnakeCase(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
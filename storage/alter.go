@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// AlterKind identifies what an AlterOp does
+type AlterKind int
+
+const (
+	AlterAddColumn AlterKind = iota
+	AlterDropColumn
+	AlterRenameColumn
+	AlterRenameTable
+	AlterChangeType
+	AlterSetNotNull
+	AlterDropNotNull
+	AlterSetDefault
+	AlterDropDefault
+)
+
+func (k AlterKind) String() string {
+	switch k {
+	case AlterAddColumn:
+		return "add column"
+	case AlterDropColumn:
+		return "drop column"
+	case AlterRenameColumn:
+		return "rename column"
+	case AlterRenameTable:
+		return "rename table"
+	case AlterChangeType:
+		return "change type"
+	case AlterSetNotNull:
+		return "set not null"
+	case AlterDropNotNull:
+		return "drop not null"
+	case AlterSetDefault:
+		return "set default"
+	case AlterDropDefault:
+		return "drop default"
+	default:
+		return fmt.Sprintf("AlterKind(%d)", int(k))
+	}
+}
+
+// AlterOp is one change made by Storage.AlterTable. Build them with
+// AddColumn, DropColumn, RenameColumn, RenameTable, ChangeType, SetNotNull,
+// DropNotNull, SetDefault and DropDefault.
+type AlterOp struct {
+	Kind AlterKind
+	// Column names the column changed; the added column's name for AlterAddColumn
+	Column string
+	// Field is the column added by AlterAddColumn
+	Field schema.ColumnData
+	// NewName is the new name for AlterRenameColumn and AlterRenameTable
+	NewName string
+	// DataType is the new type for AlterChangeType, written as in schema.ColumnData
+	DataType string
+	// Default is the new default for AlterSetDefault
+	Default any
+}
+
+// AddColumn adds field to the table
+func AddColumn(field schema.ColumnData) AlterOp {
+	return AlterOp{Kind: AlterAddColumn, Column: field.Name, Field: field}
+}
+
+// DropColumn drops column and the indexes and foreign keys that include it
+func DropColumn(column string) AlterOp {
+	return AlterOp{Kind: AlterDropColumn, Column: column}
+}
+
+// RenameColumn renames column to newName
+func RenameColumn(column, newName string) AlterOp {
+	return AlterOp{Kind: AlterRenameColumn, Column: column, NewName: newName}
+}
+
+// RenameTable renames the table to newName
+func RenameTable(newName string) AlterOp {
+	return AlterOp{Kind: AlterRenameTable, NewName: newName}
+}
+
+// ChangeType changes column's type to dataType
+func ChangeType(column, dataType string) AlterOp {
+	return AlterOp{Kind: AlterChangeType, Column: column, DataType: dataType}
+}
+
+// SetNotNull makes column NOT NULL
+func SetNotNull(column string) AlterOp {
+	return AlterOp{Kind: AlterSetNotNull, Column: column}
+}
+
+// DropNotNull makes column nullable
+func DropNotNull(column string) AlterOp {
+	return AlterOp{Kind: AlterDropNotNull, Column: column}
+}
+
+// SetDefault sets column's default to value
+func SetDefault(column string, value any) AlterOp {
+	return AlterOp{Kind: AlterSetDefault, Column: column, Default: value}
+}
+
+// DropDefault removes column's default
+func DropDefault(column string) AlterOp {
+	return AlterOp{Kind: AlterDropDefault, Column: column}
+}
+
+func (op AlterOp) String() string {
+	switch op.Kind {
+	case AlterAddColumn:
+		return fmt.Sprintf("%s %s %s", op.Kind, op.Column, op.Field.DataType)
+	case AlterRenameColumn:
+		return fmt.Sprintf("%s %s to %s", op.Kind, op.Column, op.NewName)
+	case AlterRenameTable:
+		return fmt.Sprintf("%s to %s", op.Kind, op.NewName)
+	case AlterChangeType:
+		return fmt.Sprintf("%s of %s to %s", op.Kind, op.Column, op.DataType)
+	case AlterSetDefault:
+		return fmt.Sprintf("%s of %s to %v", op.Kind, op.Column, op.Default)
+	default:
+		return fmt.Sprintf("%s %s", op.Kind, op.Column)
+	}
+}
+
+// AlterTableSchema returns a copy of tbl with ops applied in order, failing
+// if one of them cannot apply: a missing or existing column, or a change to
+// a primary key column. Backends validate ops with it before altering the
+// database, and replace the table in their schema with the result afterwards.
+func AlterTableSchema(tbl schema.TableSchema, ops []AlterOp) (*schema.TableSchema, error) {
+	altered := tbl.Clone()
+	for _, op := range ops {
+		if err := alterTableSchema(altered, op); err != nil {
+			return nil, fmt.Errorf("cannot %s: %w", op, err)
+		}
+	}
+	return altered, nil
+}
+
+func alterTableSchema(tbl *schema.TableSchema, op AlterOp) error {
+	if op.Kind == AlterAddColumn {
+		if op.Column == "" {
+			return errors.New("column name is empty")
+		}
+		if _, exists := tbl.Fields[op.Column]; exists || (op.Column == "id" && !tbl.HasDeclaredPrimaryKey()) {
+			return fmt.Errorf("column %s.%s already exists", tbl.TableName, op.Column)
+		}
+		if op.Field.PrimaryKey {
+			return errors.New("primary key columns cannot be added")
+		}
+		tbl.AddField(op.Field)
+		return nil
+	}
+	if op.Kind == AlterRenameTable {
+		if op.NewName == "" {
+			return errors.New("table name is empty")
+		}
+		tbl.TableName = op.NewName
+		return nil
+	}
+
+	field, ok := tbl.Fields[op.Column]
+	if !ok {
+		return ddaoerrors.UnknownField(tbl.TableName, op.Column)
+	}
+	keyColumns := tbl.PrimaryKeyColumns()
+
+	switch op.Kind {
+	case AlterDropColumn:
+		if slices.Contains(keyColumns, op.Column) {
+			return errors.New("primary key columns cannot be dropped")
+		}
+		delete(tbl.Fields, op.Column)
+		tbl.FieldOrder = slices.DeleteFunc(tbl.FieldOrder, func(name string) bool { return name == op.Column })
+		tbl.AutoIncrementFields = slices.DeleteFunc(tbl.AutoIncrementFields, func(name string) bool { return name == op.Column })
+		var dropped []string
+		tbl.IndexDefs = slices.DeleteFunc(tbl.IndexDefs, func(index schema.Index) bool {
+			if slices.Contains(index.Columns, op.Column) {
+				dropped = append(dropped, index.Name)
+				return true
+			}
+			return false
+		})
+		tbl.Indexes = slices.DeleteFunc(tbl.Indexes, func(name string) bool { return slices.Contains(dropped, name) })
+		tbl.UniqueKeys = slices.DeleteFunc(tbl.UniqueKeys, func(name string) bool { return slices.Contains(dropped, name) })
+		tbl.ForeignKeys = slices.DeleteFunc(tbl.ForeignKeys, func(fk schema.ForeignKey) bool { return slices.Contains(fk.Columns, op.Column) })
+
+	case AlterRenameColumn:
+		if op.NewName == "" {
+			return errors.New("column name is empty")
+		}
+		if _, exists := tbl.Fields[op.NewName]; exists {
+			return fmt.Errorf("column %s.%s already exists", tbl.TableName, op.NewName)
+		}
+		rename := func(names []string) {
+			for i, name := range names {
+				if name == op.Column {
+					names[i] = op.NewName
+				}
+			}
+		}
+		delete(tbl.Fields, op.Column)
+		field.Name = op.NewName
+		tbl.Fields[op.NewName] = field
+		rename(tbl.FieldOrder)
+		rename(tbl.AutoIncrementFields)
+		for _, index := range tbl.IndexDefs {
+			rename(index.Columns)
+		}
+		for _, fk := range tbl.ForeignKeys {
+			rename(fk.Columns)
+		}
+		if tbl.PrimaryKey != "" {
+			rename(keyColumns)
+			tbl.PrimaryKey = strings.Join(keyColumns, ",")
+		}
+
+	case AlterChangeType:
+		if op.DataType == "" {
+			return errors.New("data type is empty")
+		}
+		field.DataType = op.DataType
+		tbl.Fields[op.Column] = field
+
+	case AlterSetNotNull, AlterDropNotNull:
+		if op.Kind == AlterDropNotNull && slices.Contains(keyColumns, op.Column) {
+			return errors.New("primary key columns cannot be nullable")
+		}
+		field.Nullable = op.Kind == AlterDropNotNull
+		tbl.Fields[op.Column] = field
+
+	case AlterSetDefault, AlterDropDefault:
+		if op.Kind == AlterSetDefault && op.Default == nil {
+			return errors.New("default is nil; use DropDefault")
+		}
+		field.Default = op.Default
+		tbl.Fields[op.Column] = field
+
+	default:
+		return fmt.Errorf("unknown alter operation %s", op.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func alterTestTable() *schema.TableSchema {
+	tbl := schema.NewTableSchema("people")
+	tbl.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	tbl.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	tbl.AddField(schema.ColumnData{Name: "team", DataType: "text", Nullable: true})
+	tbl.AddField(schema.ColumnData{Name: "role", DataType: "text", Nullable: true})
+	tbl.AddIndex("", false, "team", "role")
+	return tbl
+}
+
+func TestAlterTableSchema(t *testing.T) {
+	tbl := alterTestTable()
+
+	altered, err := AlterTableSchema(*tbl, []AlterOp{
+		AddColumn(schema.ColumnData{Name: "age", DataType: "integer", Nullable: true}),
+		RenameColumn("team", "squad"),
+		ChangeType("age", "real"),
+		SetNotNull("age"),
+		SetDefault("name", "anonymous"),
+		DropColumn("role"),
+		RenameTable("members"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "members", altered.TableName)
+	assert.Equal(t, []string{"id", "name", "squad", "age"}, altered.FieldOrder)
+	assert.Equal(t, schema.ColumnData{Name: "age", DataType: "real"}, altered.Fields["age"])
+	assert.Equal(t, "anonymous", altered.Fields["name"].Default)
+	assert.Equal(t, "squad", altered.Fields["squad"].Name)
+	assert.Empty(t, altered.IndexDefs, "the index on the dropped column goes with it")
+	assert.Empty(t, altered.Indexes)
+
+	// The original is left unchanged
+	assert.Equal(t, "people", tbl.TableName)
+	assert.Equal(t, []string{"id", "name", "team", "role"}, tbl.FieldOrder)
+	assert.Equal(t, []string{"team", "role"}, tbl.IndexDefs[0].Columns)
+}
+
+func TestAlterTableSchemaRenamesKeysAndIndexes(t *testing.T) {
+	tbl := alterTestTable()
+
+	altered, err := AlterTableSchema(*tbl, []AlterOp{RenameColumn("id", "person_id"), RenameColumn("role", "position")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"person_id"}, altered.PrimaryKeyColumns())
+	assert.Equal(t, []string{"team", "position"}, altered.IndexDefs[0].Columns)
+}
+
+func TestAlterTableSchemaForeignKeys(t *testing.T) {
+	tbl := alterTestTable()
+	tbl.AddForeignKey(schema.ForeignKey{Columns: []string{"team"}, RefTable: "teams", RefColumns: []string{"name"}})
+	tbl.AddForeignKey(schema.ForeignKey{Columns: []string{"team", "role"}, RefTable: "roles", RefColumns: []string{"team", "name"}})
+
+	altered, err := AlterTableSchema(*tbl, []AlterOp{RenameColumn("team", "squad"), DropColumn("role")})
+	require.NoError(t, err)
+	require.Len(t, altered.ForeignKeys, 1, "the foreign key on the dropped column goes with it")
+	assert.Equal(t, []string{"squad"}, altered.ForeignKeys[0].Columns)
+	assert.Equal(t, []string{"team"}, tbl.ForeignKeys[0].Columns, "the original is left unchanged")
+}
+
+func TestAlterTableSchemaErrors(t *testing.T) {
+	tbl := alterTestTable()
+
+	tests := []struct {
+		name string
+		op   AlterOp
+	}{
+		{"add existing", AddColumn(schema.ColumnData{Name: "name", DataType: "text"})},
+		{"add key", AddColumn(schema.ColumnData{Name: "code", DataType: "text", PrimaryKey: true})},
+		{"drop key", DropColumn("id")},
+		{"rename onto existing", RenameColumn("team", "role")},
+		{"nullable key", DropNotNull("id")},
+		{"nil default", SetDefault("name", nil)},
+		{"empty type", ChangeType("name", "")},
+		{"empty table name", RenameTable("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AlterTableSchema(*tbl, []AlterOp{tt.op})
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := AlterTableSchema(*tbl, []AlterOp{DropColumn("missing")})
+	assert.ErrorIs(t, err, ddaoerrors.ErrSchemaMismatch)
+
+	// The implicit id column cannot be added again
+	implicit := schema.NewTableSchema("notes")
+	implicit.AddField(schema.ColumnData{Name: "body", DataType: "text"})
+	_, err = AlterTableSchema(*implicit, []AlterOp{AddColumn(schema.ColumnData{Name: "id", DataType: "text"})})
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jadedragon942/ddao/object"
+)
+
+// DefaultBatchSize is the number of objects written per round-trip when BatchOptions.BatchSize is not set
+const DefaultBatchSize = 500
+
+// BatchOptions controls InsertMany, UpsertMany and DeleteMany
+type BatchOptions struct {
+	// BatchSize is the maximum number of objects sent to the database in one
+	// statement or batch; DefaultBatchSize when zero. Backends without batch
+	// statements (S3) use it as the number of concurrent requests instead.
+	// SQL backends may send fewer to stay within the database's parameter limit.
+	BatchSize int
+}
+
+// Size returns the effective batch size for opts
+func (opts BatchOptions) Size() int {
+	if opts.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return opts.BatchSize
+}
+
+// BatchItem is the outcome of writing or deleting a single object in a bulk operation
+type BatchItem struct {
+	TableName string
+	ID        string
+	// Err is nil if the object was written or deleted
+	Err error
+}
+
+// BatchResult reports the outcome of InsertMany, UpsertMany or DeleteMany for
+// each object, so one bad object does not hide whether the others were written.
+type BatchResult struct {
+	// Items has one entry per input object or ID, in input order
+	Items []BatchItem
+	// Deleted is the number of IDs DeleteMany found and deleted. Deleting an
+	// ID that does not exist is not an error, as with DeleteByID.
+	Deleted int64
+}
+
+// NewObjectsResult returns a BatchResult with a successful item for each of objs
+func NewObjectsResult(objs []*object.Object) *BatchResult {
+	items := make([]BatchItem, len(objs))
+	for i, obj := range objs {
+		items[i] = BatchItem{TableName: obj.TableName, ID: obj.ID}
+	}
+	return &BatchResult{Items: items}
+}
+
+// NewIDsResult returns a BatchResult with a successful item for each of ids in tblName
+func NewIDsResult(tblName string, ids []string) *BatchResult {
+	items := make([]BatchItem, len(ids))
+	for i, id := range ids {
+		items[i] = BatchItem{TableName: tblName, ID: id}
+	}
+	return &BatchResult{Items: items}
+}
+
+// Succeeded returns the number of items without an error
+func (r *BatchResult) Succeeded() int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the items that could not be written or deleted
+func (r *BatchResult) Failed() []BatchItem {
+	var failed []BatchItem
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of every failed item, naming the object each belongs
+// to, or returns nil if all of them succeeded
+func (r *BatchResult) Err() error {
+	var errs []error
+	for _, item := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s/%s: %w", item.TableName, item.ID, item.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// FailAll records err for every item from start onwards that has not already failed
+func (r *BatchResult) FailAll(start int, err error) {
+	for i := start; i < len(r.Items); i++ {
+		if r.Items[i].Err == nil {
+			r.Items[i].Err = err
+		}
+	}
+}
+
+// RunConcurrently calls fn for every index in [0, n), with at most limit calls in flight at once
+func RunConcurrently(n, limit int, fn func(i int)) {
+	if limit <= 0 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test")
+
+func TestBatchOptionsSize(t *testing.T) {
+	assert.Equal(t, DefaultBatchSize, BatchOptions{}.Size())
+	assert.Equal(t, DefaultBatchSize, BatchOptions{BatchSize: -1}.Size())
+	assert.Equal(t, 10, BatchOptions{BatchSize: 10}.Size())
+}
+
+func TestBatchResult(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	result := NewObjectsResult([]*object.Object{
+		{TableName: "people", ID: "a"},
+		{TableName: "people", ID: "b"},
+		{TableName: "people", ID: "c"},
+	})
+	require.Len(t, result.Items, 3)
+	assert.Equal(t, 3, result.Succeeded())
+	assert.Empty(t, result.Failed())
+	assert.NoError(t, result.Err())
+
+	result.Items[1].Err = errFailed
+	assert.Equal(t, 2, result.Succeeded())
+	require.Len(t, result.Failed(), 1)
+	assert.Equal(t, "b", result.Failed()[0].ID)
+	assert.ErrorIs(t, result.Err(), errFailed)
+	assert.Contains(t, result.Err().Error(), "people/b")
+
+	result.FailAll(1, errTest)
+	assert.Nil(t, result.Items[0].Err)
+	assert.Equal(t, errFailed, result.Items[1].Err, "FailAll should keep earlier failures")
+	assert.Equal(t, errTest, result.Items[2].Err)
+
+	ids := NewIDsResult("people", []string{"x", "y"})
+	assert.Equal(t, []BatchItem{{TableName: "people", ID: "x"}, {TableName: "people", ID: "y"}}, ids.Items)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	var calls, inFlight, peak atomic.Int64
+	seen := make([]bool, 20)
+
+	RunConcurrently(len(seen), 3, func(i int) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		seen[i] = true
+		calls.Add(1)
+		inFlight.Add(-1)
+	})
+
+	assert.Equal(t, int64(20), calls.Load())
+	assert.LessOrEqual(t, peak.Load(), int64(3))
+	for i, ok := range seen {
+		assert.True(t, ok, "index %d not visited", i)
+	}
+}
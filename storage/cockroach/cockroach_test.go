@@ -42,6 +42,23 @@ func TestCockroachDBCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestCockroachDBUpsert(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestCockroachDBLocal runs tests against a local CockroachDB instance
 // Use: docker run -d --name=roach --hostname=roach -p 26257:26257 -p 8080:8080 cockroachdb/cockroach:latest start-single-node --insecure
 func TestCockroachDBLocal(t *testing.T) {
@@ -60,4 +77,157 @@ func TestCockroachDBLocal(t *testing.T) {
 
 	storagetest.StorageTest(t, storage)
 	storagetest.CRUDTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestCockroachDBQuery(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestCockroachDBList(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestCockroachDBRows(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestCockroachDBErrors(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestCockroachDBBatch(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestCockroachDBKeys(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestCockroachDBReservedWords(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestCockroachDBIndexes(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestCockroachDBAlterTable(t *testing.T) {
+	connStr := os.Getenv("COCKROACH_TEST_URL")
+	if connStr == "" {
+		t.Skip("COCKROACH_TEST_URL not set, skipping CockroachDB alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to CockroachDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
@@ -0,0 +1,34 @@
+package cockroach
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// cockroachDialect is PostgreSQL's, but upserts with UPSERT INTO. CockroachDB
+// runs every transaction as SERIALIZABLE and asks clients to retry with
+// SQLSTATE 40001 ("restart transaction"), which IsRetryable recognises.
+type cockroachDialect struct {
+	common.Dialect
+}
+
+func newDialect() cockroachDialect {
+	return cockroachDialect{Dialect: common.PostgresDialect(dialect.Cockroach)}
+}
+
+// InsertQuery renders an INSERT of one or more rows, or an UPSERT that
+// overwrites an existing row with the same primary key
+func (cockroachDialect) InsertQuery(table string, columns []string, _ int, rows [][]string, upsert bool) string {
+	verb := "INSERT"
+	if upsert {
+		verb = "UPSERT"
+	}
+	return fmt.Sprintf("%s INTO %s (%s) VALUES %s",
+		verb,
+		table,
+		strings.Join(columns, ", "),
+		common.ValuesList(rows))
+}
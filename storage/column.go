@@ -0,0 +1,74 @@
+package storage
+
+import "time"
+
+// Column names a column whose values are of type T, so queries built from it
+// are checked at compile time. `ddao gen` emits one per column of a table:
+//
+//	users, err := q.FindUsers(ctx, storage.And(
+//		models.UserColumns.Age.Gte(18),
+//		models.UserColumns.Email.Like("%@example.com"),
+//	))
+type Column[T any] string
+
+// Name returns the column's name
+func (c Column[T]) Name() string { return string(c) }
+
+// Eq matches rows where the column equals value
+func (c Column[T]) Eq(value T) Query { return Eq(string(c), value) }
+
+// Ne matches rows where the column does not equal value
+func (c Column[T]) Ne(value T) Query { return Ne(string(c), value) }
+
+// Lt matches rows where the column is less than value
+func (c Column[T]) Lt(value T) Query { return Lt(string(c), value) }
+
+// Lte matches rows where the column is less than or equal to value
+func (c Column[T]) Lte(value T) Query { return Lte(string(c), value) }
+
+// Gt matches rows where the column is greater than value
+func (c Column[T]) Gt(value T) Query { return Gt(string(c), value) }
+
+// Gte matches rows where the column is greater than or equal to value
+func (c Column[T]) Gte(value T) Query { return Gte(string(c), value) }
+
+// In matches rows where the column equals any of values
+func (c Column[T]) In(values ...T) Query {
+	anys := make([]any, len(values))
+	for i, value := range values {
+		anys[i] = value
+	}
+	return In(string(c), anys...)
+}
+
+// Like matches rows where the column matches a SQL LIKE pattern
+func (c Column[T]) Like(pattern string) Query { return Like(string(c), pattern) }
+
+// IsNull matches rows where the column is NULL
+func (c Column[T]) IsNull() Query { return IsNull(string(c)) }
+
+// TimeColumn names a datetime column. Its values are compared in the RFC 3339
+// form datetimes are stored in.
+type TimeColumn string
+
+// Name returns the column's name
+func (c TimeColumn) Name() string { return string(c) }
+
+// Eq matches rows where the column equals t
+func (c TimeColumn) Eq(t time.Time) Query { return Eq(string(c), formatTime(t)) }
+
+// Ne matches rows where the column does not equal t
+func (c TimeColumn) Ne(t time.Time) Query { return Ne(string(c), formatTime(t)) }
+
+// Before matches rows where the column is before t
+func (c TimeColumn) Before(t time.Time) Query { return Lt(string(c), formatTime(t)) }
+
+// After matches rows where the column is after t
+func (c TimeColumn) After(t time.Time) Query { return Gt(string(c), formatTime(t)) }
+
+// IsNull matches rows where the column is NULL
+func (c TimeColumn) IsNull() Query { return IsNull(string(c)) }
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
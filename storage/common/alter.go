@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// AlterRenderer returns the statements performing op on a table, given the
+// table's definition before and after op
+type AlterRenderer func(before, after schema.TableSchema, op storage.AlterOp) ([]string, error)
+
+// AlterQueries validates ops against tbl and renders them in order. Indexes
+// on added columns are created with indexQuery. It returns the altered table
+// along with the statements.
+func AlterQueries(tbl schema.TableSchema, ops []storage.AlterOp, render AlterRenderer, indexQuery func(string, schema.Index) string) (*schema.TableSchema, []string, error) {
+	before := tbl.Clone()
+	var queries []string
+	for _, op := range ops {
+		after, err := storage.AlterTableSchema(*before, []storage.AlterOp{op})
+		if err != nil {
+			return nil, nil, err
+		}
+		rendered, err := render(*before, *after, op)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot %s: %w", op, err)
+		}
+		queries = append(queries, rendered...)
+
+		if op.Kind == storage.AlterAddColumn {
+			for _, index := range after.SecondaryIndexes() {
+				if slices.Contains(index.Columns, op.Column) {
+					queries = append(queries, indexQuery(after.TableName, index))
+				}
+			}
+		}
+		before = after
+	}
+	return before, queries, nil
+}
+
+// AlterTableWith performs ops on tableName with the statements render returns,
+// run in one transaction, then replaces the table in the schema with the
+// altered definition. Databases that commit DDL implicitly (TiDB, Oracle)
+// keep the statements run before a failure.
+func (b *BaseSQLStorage) AlterTableWith(ctx context.Context, tableName string, ops []storage.AlterOp, render AlterRenderer, indexQuery func(string, schema.Index) string) error {
+	if err := b.ValidateConnection(); err != nil {
+		return err
+	}
+	tbl, err := b.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	altered, queries, err := AlterQueries(tbl, ops, render, indexQuery)
+	if err != nil {
+		return err
+	}
+	if err := ExecAll(ctx, b.GetDB(), queries); err != nil {
+		return fmt.Errorf("failed to alter table %s: %w", tableName, err)
+	}
+
+	b.GetSchema().ReplaceTable(tableName, altered)
+	return nil
+}
+
+// ExecAll runs queries on db in one transaction
+func ExecAll(ctx context.Context, db *sql.DB, queries []string) error {
+	if len(queries) == 0 {
+		return nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		storage.DebugLog(query)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PostgresAlterRenderer renders AlterOps for PostgreSQL and the databases
+// sharing its ALTER TABLE syntax (YugabyteDB, CockroachDB), with d quoting
+// names and rendering column definitions, types and defaults
+func PostgresAlterRenderer(d dialect.Dialect) AlterRenderer {
+	return func(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+		table := d.QuoteIdent(before.TableName)
+		column := d.QuoteIdent(op.Column)
+		var query string
+		switch op.Kind {
+		case storage.AlterAddColumn:
+			query = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, d.ColumnDefinition(after, op.Field))
+		case storage.AlterDropColumn:
+			query = fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column)
+		case storage.AlterRenameColumn:
+			query = fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, column, d.QuoteIdent(op.NewName))
+		case storage.AlterRenameTable:
+			query = fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, d.QuoteIdent(op.NewName))
+		case storage.AlterChangeType:
+			dataType := d.ColumnType(after, after.Fields[op.Column])
+			query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s", table, column, dataType, column, dataType)
+		case storage.AlterSetNotNull:
+			query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, column)
+		case storage.AlterDropNotNull:
+			query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, column)
+		case storage.AlterSetDefault:
+			query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, column, d.Literal(op.Default))
+		case storage.AlterDropDefault:
+			query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, column)
+		}
+		return []string{query}, nil
+	}
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlterQueriesPostgres(t *testing.T) {
+	tbl := schema.NewTableSchema("people")
+	tbl.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	tbl.AddField(schema.ColumnData{Name: "age", DataType: "integer", Nullable: true})
+
+	altered, queries, err := AlterQueries(*tbl, []storage.AlterOp{
+		storage.AddColumn(schema.ColumnData{Name: "email", DataType: "text", Nullable: true, Index: true}),
+		storage.RenameColumn("age", "years"),
+		storage.ChangeType("years", "real"),
+		storage.SetNotNull("years"),
+		storage.DropNotNull("name"),
+		storage.SetDefault("name", "anonymous"),
+		storage.DropDefault("name"),
+		storage.DropColumn("years"),
+		storage.RenameTable("members"),
+	}, PostgresAlterRenderer(dialect.Postgres), dialect.Postgres.CreateIndex)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`ALTER TABLE "people" ADD COLUMN "email" TEXT NULL`,
+		`CREATE INDEX IF NOT EXISTS "idx_people_email" ON "people" ("email")`,
+		`ALTER TABLE "people" RENAME COLUMN "age" TO "years"`,
+		`ALTER TABLE "people" ALTER COLUMN "years" TYPE REAL USING "years"::REAL`,
+		`ALTER TABLE "people" ALTER COLUMN "years" SET NOT NULL`,
+		`ALTER TABLE "people" ALTER COLUMN "name" DROP NOT NULL`,
+		`ALTER TABLE "people" ALTER COLUMN "name" SET DEFAULT 'anonymous'`,
+		`ALTER TABLE "people" ALTER COLUMN "name" DROP DEFAULT`,
+		`ALTER TABLE "people" DROP COLUMN "years"`,
+		`ALTER TABLE "people" RENAME TO "members"`,
+	}, queries)
+	assert.Equal(t, "members", altered.TableName)
+	assert.Equal(t, []string{"name", "email"}, altered.FieldOrder)
+}
+
+func TestAlterQueriesInvalidOp(t *testing.T) {
+	tbl := schema.NewTableSchema("people")
+	tbl.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+
+	_, queries, err := AlterQueries(*tbl, []storage.AlterOp{
+		storage.DropNotNull("name"),
+		storage.DropColumn("missing"),
+	}, PostgresAlterRenderer(dialect.Postgres), dialect.Postgres.CreateIndex)
+	assert.Error(t, err)
+	assert.Nil(t, queries, "nothing is run when an op is invalid")
+}
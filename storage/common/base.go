@@ -3,30 +3,25 @@ package common
 import (
 	"context"
 	"database/sql"
-	"errors"
 
-	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 // BaseSQLStorage provides common functionality for SQL-based storage adapters
 type BaseSQLStorage struct {
-	DB             *sql.DB
-	Sch            *schema.Schema
-	TransactionMethods
+	DB  *sql.DB
+	Sch *schema.Schema
 }
 
 // NewBaseSQLStorage creates a new base SQL storage instance
 func NewBaseSQLStorage() *BaseSQLStorage {
-	base := &BaseSQLStorage{}
-	base.TransactionMethods.DB = base.DB
-	return base
+	return &BaseSQLStorage{}
 }
 
-// SetDB sets the database connection and updates transaction methods
+// SetDB sets the database connection
 func (b *BaseSQLStorage) SetDB(db *sql.DB) {
 	b.DB = db
-	b.TransactionMethods.DB = db
 }
 
 // SetSchema sets the schema
@@ -52,16 +47,6 @@ func (b *BaseSQLStorage) ResetConnection(ctx context.Context) error {
 	return nil
 }
 
-// FindByID is a common implementation that delegates to FindByKey
-func (b *BaseSQLStorage) FindByID(ctx context.Context, tblName, id string, findByKeyFunc func(context.Context, string, string, string) (*object.Object, error)) (*object.Object, error) {
-	return findByKeyFunc(ctx, tblName, "id", id)
-}
-
-// FindByIDTx is a common implementation that delegates to FindByKeyTx
-func (b *BaseSQLStorage) FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string, findByKeyTxFunc func(context.Context, *sql.Tx, string, string, string) (*object.Object, error)) (*object.Object, error) {
-	return findByKeyTxFunc(ctx, tx, tblName, "id", id)
-}
-
 // ValidateConnection validates the database connection
 func (b *BaseSQLStorage) ValidateConnection() error {
 	return ValidateConnection(b.DB)
@@ -80,7 +65,7 @@ func (b *BaseSQLStorage) GetTable(tableName string) (schema.TableSchema, error)
 
 	tbl, ok := b.Sch.GetTable(tableName)
 	if !ok {
-		return schema.TableSchema{}, errors.New("table " + tableName + " not found in schema")
+		return schema.TableSchema{}, ddaoerrors.UnknownTable(tableName)
 	}
 
 	return tbl, nil
@@ -0,0 +1,198 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// InsertBatch is a run of objects for one table with the same fields, so they
+// can be written by a single multi-row statement
+type InsertBatch struct {
+	Table schema.TableSchema
+	// Columns lists the primary key columns first, then the objects' other fields in sorted order
+	Columns []string
+	// Rows holds the column values of each object, with JSON fields already encoded
+	Rows    [][]any
+	Objects []*object.Object
+	// Items holds the index of each object in the BatchResult
+	Items []int
+}
+
+// Values flattens Rows into statement arguments
+func (b InsertBatch) Values() []any {
+	values := make([]any, 0, len(b.Rows)*len(b.Columns))
+	for _, row := range b.Rows {
+		values = append(values, row...)
+	}
+	return values
+}
+
+// Placeholders returns the placeholders of each row, numbered consecutively across rows
+func (b InsertBatch) Placeholders(placeholderFunc func(int) string) [][]string {
+	rows := make([][]string, len(b.Rows))
+	paramIndex := 1
+	for i := range rows {
+		rows[i] = make([]string, len(b.Columns))
+		for j := range rows[i] {
+			rows[i][j] = placeholderFunc(paramIndex)
+			paramIndex++
+		}
+	}
+	return rows
+}
+
+// ValuesList renders placeholder rows as the row list of a VALUES clause, e.g. "(?, ?), (?, ?)"
+func ValuesList(rows [][]string) string {
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		tuples[i] = "(" + strings.Join(row, ", ") + ")"
+	}
+	return strings.Join(tuples, ", ")
+}
+
+// SplitInsertBatches groups consecutive objs for the same table with the same
+// fields into InsertBatches of at most size objects, and of at most maxParams
+// values in total when maxParams is positive. Objects naming a table or field
+// missing from sch are failed in result rather than batched.
+func SplitInsertBatches(sch *schema.Schema, objs []*object.Object, size, maxParams int, result *storage.BatchResult) ([]InsertBatch, error) {
+	if err := ValidateSchema(sch); err != nil {
+		return nil, err
+	}
+
+	var batches []InsertBatch
+	var current *InsertBatch
+	for i, obj := range objs {
+		tbl, ok := sch.GetTable(obj.TableName)
+		if !ok {
+			result.Items[i].Err = ddaoerrors.UnknownTable(obj.TableName)
+			continue
+		}
+		columns, row, err := insertColumns(obj, tbl, true)
+		if err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+
+		limit := size
+		if maxParams > 0 {
+			limit = max(1, min(size, maxParams/len(columns)))
+		}
+		if current == nil || current.Table.TableName != tbl.TableName ||
+			!slices.Equal(current.Columns, columns) || len(current.Rows) >= limit {
+			batches = append(batches, InsertBatch{Table: tbl, Columns: columns})
+			current = &batches[len(batches)-1]
+		}
+		current.Rows = append(current.Rows, row)
+		current.Objects = append(current.Objects, obj)
+		current.Items = append(current.Items, i)
+	}
+	return batches, nil
+}
+
+// WriteBatches runs exec for each batch. A multi-row statement fails as a
+// whole, so when one does its objects are retried one at a time with single,
+// leaving the error on only the objects that caused it. If ctx is canceled the
+// objects not yet written are failed with its error, which is also returned.
+func WriteBatches(ctx context.Context, batches []InsertBatch, result *storage.BatchResult, exec func(context.Context, InsertBatch) error, single func(context.Context, *object.Object) error) error {
+	for _, b := range batches {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(b.Items[0], err)
+			return err
+		}
+
+		err := exec(ctx, b)
+		if err == nil {
+			continue
+		}
+		if len(b.Objects) == 1 {
+			result.Items[b.Items[0]].Err = err
+			continue
+		}
+		for i, obj := range b.Objects {
+			result.Items[b.Items[i]].Err = single(ctx, obj)
+		}
+	}
+	return nil
+}
+
+// CommonWriteMany implements InsertMany and UpsertMany for SQL databases.
+// exec writes a whole batch with one statement; single writes one object and
+// is used to find the culprits when a batch fails.
+func CommonWriteMany(ctx context.Context, sch *schema.Schema, objs []*object.Object, opts storage.BatchOptions, maxParams int, exec func(context.Context, InsertBatch) error, single func(context.Context, *object.Object) error) (*storage.BatchResult, error) {
+	result := storage.NewObjectsResult(objs)
+	batches, err := SplitInsertBatches(sch, objs, opts.Size(), maxParams, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, WriteBatches(ctx, batches, result, exec, single)
+}
+
+// CommonDeleteMany implements DeleteMany for SQL databases, deleting up to
+// opts.Size() IDs per statement. queryFunc builds the statement from the table
+// name and the WHERE clause matching the IDs' keys, and exec runs it,
+// returning the rows deleted. A failed statement fails every ID it covered.
+func CommonDeleteMany(ctx context.Context, sch *schema.Schema, tblName string, ids []string, opts storage.BatchOptions, wb WhereBuilder, queryFunc func(string, string) string, exec func(context.Context, string, []any) (int64, error)) (*storage.BatchResult, error) {
+	if err := ValidateSchema(sch); err != nil {
+		return nil, err
+	}
+	tbl, ok := sch.GetTable(tblName)
+	if !ok {
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+
+	result := storage.NewIDsResult(tblName, ids)
+	size := opts.Size()
+	for start := 0; start < len(ids); start += size {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(start, err)
+			return result, err
+		}
+
+		end := min(start+size, len(ids))
+		keys := make([]object.Key, 0, end-start)
+		items := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			key, err := storage.ParseID(tbl, ids[i])
+			if err != nil {
+				result.Items[i].Err = err
+				continue
+			}
+			keys = append(keys, key)
+			items = append(items, i)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		where, args := wb.KeysWhere(tbl, keys, 0)
+		query := queryFunc(tbl.TableName, where)
+		storage.DebugLog(query, args...)
+		n, err := exec(ctx, query, args)
+		if err != nil {
+			for _, i := range items {
+				result.Items[i].Err = err
+			}
+			continue
+		}
+		result.Deleted += n
+	}
+	return result, nil
+}
+
+// ExecDB returns an exec function for CommonDeleteMany that runs statements on db
+func ExecDB(db *sql.DB) func(context.Context, string, []any) (int64, error) {
+	return func(ctx context.Context, query string, args []any) (int64, error) {
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+}
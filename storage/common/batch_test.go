@@ -0,0 +1,165 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func person(id string, fields map[string]any) *object.Object {
+	return &object.Object{TableName: "people", ID: id, Fields: fields}
+}
+
+func TestSplitInsertBatches(t *testing.T) {
+	sch := schema.GetTestSchema()
+	objs := []*object.Object{
+		person("a", map[string]any{"name": "A", "metadata": map[string]any{"k": 1}}),
+		person("b", map[string]any{"metadata": nil, "name": "B"}),
+		person("c", map[string]any{"name": "C", "metadata": nil}),
+		person("d", map[string]any{"name": "D"}),
+		{TableName: "missing", ID: "e"},
+		person("f", map[string]any{"name": "F", "nonexistent": 1}),
+		person("g", map[string]any{"name": "G"}),
+	}
+	result := storage.NewObjectsResult(objs)
+
+	batches, err := SplitInsertBatches(sch, objs, 2, 0, result)
+	require.NoError(t, err)
+	require.Len(t, batches, 3)
+
+	assert.Equal(t, []string{"id", "metadata", "name"}, batches[0].Columns)
+	assert.Equal(t, []int{0, 1}, batches[0].Items)
+	assert.Equal(t, []any{"a", `{"k":1}`, "A", "b", "null", "B"}, batches[0].Values())
+
+	assert.Equal(t, []int{2}, batches[1].Items, "batch size should split objects with the same fields")
+	assert.Equal(t, []int{3, 6}, batches[2].Items, "objects that cannot be written should not split a batch")
+	assert.Equal(t, []string{"id", "name"}, batches[2].Columns)
+
+	assert.ErrorIs(t, result.Items[4].Err, ddaoerrors.ErrSchemaMismatch)
+	assert.ErrorIs(t, result.Items[5].Err, ddaoerrors.ErrSchemaMismatch)
+	assert.Equal(t, 5, result.Succeeded())
+
+	// maxParams caps the rows of a batch at maxParams / columns
+	many := []*object.Object{person("1", map[string]any{"name": "1"}), person("2", map[string]any{"name": "2"}), person("3", map[string]any{"name": "3"})}
+	batches, err = SplitInsertBatches(sch, many, 10, 5, storage.NewObjectsResult(many))
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0].Rows, 2)
+
+	_, err = SplitInsertBatches(nil, objs, 2, 0, result)
+	assert.Error(t, err)
+}
+
+func TestInsertBatchPlaceholders(t *testing.T) {
+	b := InsertBatch{Columns: []string{"id", "name"}, Rows: [][]any{{"a", "A"}, {"b", "B"}}}
+	rows := b.Placeholders(func(i int) string { return fmt.Sprintf("$%d", i) })
+	assert.Equal(t, [][]string{{"$1", "$2"}, {"$3", "$4"}}, rows)
+	assert.Equal(t, "($1, $2), ($3, $4)", ValuesList(rows))
+}
+
+func TestWriteBatches(t *testing.T) {
+	errDuplicate := errors.New("duplicate")
+	objs := []*object.Object{
+		person("a", map[string]any{"name": "A"}),
+		person("dup", map[string]any{"name": "Dup"}),
+		person("c", map[string]any{"name": "C"}),
+		person("d", map[string]any{"name": "D"}),
+	}
+	ctx := context.Background()
+
+	var execs, singles int
+	exec := func(ctx context.Context, b InsertBatch) error {
+		execs++
+		for _, obj := range b.Objects {
+			if obj.ID == "dup" {
+				return errDuplicate
+			}
+		}
+		return nil
+	}
+	single := func(ctx context.Context, obj *object.Object) error {
+		singles++
+		if obj.ID == "dup" {
+			return errDuplicate
+		}
+		return nil
+	}
+
+	result, err := CommonWriteMany(ctx, schema.GetTestSchema(), objs, storage.BatchOptions{BatchSize: 2}, 0, exec, single)
+	require.NoError(t, err)
+	assert.Equal(t, 2, execs)
+	assert.Equal(t, 2, singles, "only the failed batch should be retried one at a time")
+	assert.Nil(t, result.Items[0].Err)
+	assert.ErrorIs(t, result.Items[1].Err, errDuplicate)
+	assert.Equal(t, 3, result.Succeeded())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	result, err = CommonWriteMany(canceled, schema.GetTestSchema(), objs, storage.BatchOptions{}, 0, exec, single)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, result.Succeeded())
+}
+
+func TestCommonDeleteMany(t *testing.T) {
+	var queries []string
+	exec := func(ctx context.Context, query string, args []any) (int64, error) {
+		queries = append(queries, query)
+		if len(queries) == 2 {
+			return 0, errors.New("failed")
+		}
+		return int64(len(args)), nil
+	}
+	queryFunc := func(tableName, where string) string {
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, where)
+	}
+	wb := WhereBuilder{Placeholder: func(i int) string { return "?" }}
+
+	ids := []string{"a", "b", "c", "d", "e"}
+	result, err := CommonDeleteMany(context.Background(), schema.GetTestSchema(), "people", ids, storage.BatchOptions{BatchSize: 2}, wb, queryFunc, exec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE FROM people WHERE id IN (?, ?)",
+		"DELETE FROM people WHERE id IN (?, ?)",
+		"DELETE FROM people WHERE id IN (?)",
+	}, queries)
+	assert.Equal(t, int64(3), result.Deleted)
+	require.Len(t, result.Failed(), 2)
+	assert.Equal(t, "c", result.Failed()[0].ID)
+	assert.Equal(t, "d", result.Failed()[1].ID)
+}
+
+func TestCommonDeleteManyCompositeKey(t *testing.T) {
+	sch := schema.New()
+	tbl := schema.NewTableSchema("stock")
+	tbl.AddField(schema.ColumnData{Name: "region", DataType: "text", PrimaryKey: true})
+	tbl.AddField(schema.ColumnData{Name: "sku", DataType: "integer", PrimaryKey: true})
+	sch.AddTable(tbl)
+
+	var queries []string
+	var arguments [][]any
+	exec := func(ctx context.Context, query string, args []any) (int64, error) {
+		queries = append(queries, query)
+		arguments = append(arguments, args)
+		return int64(len(args) / 2), nil
+	}
+	queryFunc := func(tableName, where string) string {
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, where)
+	}
+	wb := WhereBuilder{Placeholder: func(i int) string { return fmt.Sprintf("$%d", i) }}
+
+	result, err := CommonDeleteMany(context.Background(), sch, "stock", []string{"eu/1", "us", "us/2"}, storage.BatchOptions{}, wb, queryFunc, exec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DELETE FROM stock WHERE (region = $1 AND sku = $2) OR (region = $3 AND sku = $4)"}, queries)
+	assert.Equal(t, []any{"eu", int64(1), "us", int64(2)}, arguments[0])
+	assert.Equal(t, int64(2), result.Deleted)
+	require.Len(t, result.Failed(), 1)
+	assert.Equal(t, "us", result.Failed()[0].ID)
+}
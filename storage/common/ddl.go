@@ -0,0 +1,22 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// CreateTable creates tbl and its secondary indexes with the statements d
+// renders, the same statements d.RenderDDL shows
+func CreateTable(ctx context.Context, db Execer, d dialect.Dialect, tbl schema.TableSchema) error {
+	for _, query := range d.CreateTable(tbl) {
+		storage.DebugLog(query)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", tbl.TableName, err)
+		}
+	}
+	return CreateIndexes(ctx, db, tbl, d.CreateIndex)
+}
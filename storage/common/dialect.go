@@ -0,0 +1,127 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// Dialect is what SQLStorage needs to know about a SQL database beyond its
+// DDL: how it spells bind parameters, upserts and row limits, whether an
+// INSERT can return the row it wrote, and what its errors mean. The embedded
+// dialect.Dialect maps types, quotes identifiers and renders CREATE
+// statements, so statements and DDL name tables and columns alike.
+type Dialect interface {
+	dialect.Dialect
+
+	// Placeholder returns the bind parameter for the n-th argument (1-based)
+	Placeholder(n int) string
+	// Column returns the expression a WHERE or ORDER BY clause uses for
+	// field, usually QuoteIdent(field.Name)
+	Column(field schema.ColumnData) string
+	// BindValue converts a value written to or compared with field before it is bound
+	BindValue(field schema.ColumnData, value any) any
+
+	// InsertQuery renders an INSERT into table of rows, each holding the
+	// placeholders of columns, or with upsert a statement that overwrites
+	// the rows with the same primary key instead of failing. table and
+	// columns are quoted, and the first keys columns are the primary key.
+	InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string
+	// Paginate orders query by orderBy and limits it to limit rows after skipping offset
+	Paginate(query, orderBy string, limit, offset int) string
+	// Returning returns the clause making an INSERT return columns of the
+	// row it wrote, or "" if the database has none
+	Returning(columns []string) string
+	// BatchLimits returns the most bind parameters and rows one statement
+	// may carry, 0 meaning no limit
+	BatchLimits() (maxParams, maxRows int)
+
+	// AlterQueries renders op; see AlterRenderer
+	AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error)
+
+	// TranslateError maps an error from a statement on tbl onto
+	// storage/errors, returning errors it does not recognise unchanged
+	TranslateError(err error, tbl schema.TableSchema) error
+	// IsRetryable reports whether err aborted a transaction that can be run again
+	IsRetryable(err error) bool
+}
+
+// OnConflictInsert renders Dialect.InsertQuery for databases with INSERT ...
+// ON CONFLICT (PostgreSQL, YugabyteDB, SQLite). An upsert overwrites the
+// other columns with the values proposed for insertion, or when there are
+// none leaves the existing row as it is.
+func OnConflictInsert(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), ValuesList(rows))
+	if !upsert {
+		return query
+	}
+	conflict := strings.Join(columns[:keys], ", ")
+	if len(columns) == keys {
+		return query + " ON CONFLICT (" + conflict + ") DO NOTHING"
+	}
+
+	updateClauses := make([]string, 0, len(columns)-keys)
+	for _, column := range columns[keys:] { // Skip key columns
+		updateClauses = append(updateClauses, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+	return query + " ON CONFLICT (" + conflict + ") DO UPDATE SET " + strings.Join(updateClauses, ", ")
+}
+
+// LimitOffset renders Dialect.Paginate with LIMIT and OFFSET
+func LimitOffset(query, orderBy string, limit, offset int) string {
+	query = fmt.Sprintf("%s ORDER BY %s LIMIT %d", query, orderBy, limit)
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return query
+}
+
+// OffsetFetch renders Dialect.Paginate with the standard OFFSET ... FETCH
+// NEXT, which SQL Server and Oracle (since 12c) use instead of LIMIT
+func OffsetFetch(query, orderBy string, limit, offset int) string {
+	return fmt.Sprintf("%s ORDER BY %s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, orderBy, offset, limit)
+}
+
+// PostgresDialect returns the Dialect of PostgreSQL and the databases sharing
+// its SQL and wire protocol, with d rendering their DDL
+func PostgresDialect(d dialect.Dialect) Dialect {
+	return postgresDialect{Dialect: d}
+}
+
+type postgresDialect struct {
+	dialect.Dialect
+}
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (d postgresDialect) Column(field schema.ColumnData) string { return d.QuoteIdent(field.Name) }
+
+func (postgresDialect) BindValue(_ schema.ColumnData, value any) any { return value }
+
+func (postgresDialect) InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	return OnConflictInsert(table, columns, keys, rows, upsert)
+}
+
+func (postgresDialect) Paginate(query, orderBy string, limit, offset int) string {
+	return LimitOffset(query, orderBy, limit, offset)
+}
+
+func (postgresDialect) Returning(columns []string) string {
+	return "RETURNING " + strings.Join(columns, ", ")
+}
+
+// BatchLimits allows the most bind parameters the PostgreSQL protocol can carry
+func (postgresDialect) BatchLimits() (int, int) { return 65535, 0 }
+
+func (d postgresDialect) AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+	return PostgresAlterRenderer(d.Dialect)(before, after, op)
+}
+
+func (postgresDialect) TranslateError(err error, tbl schema.TableSchema) error {
+	return TranslatePgError(err, tbl)
+}
+
+func (postgresDialect) IsRetryable(err error) bool { return IsSerializationFailure(err) }
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnConflictInsert(t *testing.T) {
+	rows := [][]string{{"$1", "$2", "$3"}, {"$4", "$5", "$6"}}
+	columns := []string{"region", "sku", "qty"}
+
+	assert.Equal(t, "INSERT INTO stock (region, sku, qty) VALUES ($1, $2, $3), ($4, $5, $6)",
+		OnConflictInsert("stock", columns, 2, rows, false))
+	assert.Equal(t, "INSERT INTO stock (region, sku, qty) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT (region, sku) DO UPDATE SET qty = EXCLUDED.qty",
+		OnConflictInsert("stock", columns, 2, rows, true))
+	assert.Equal(t, "INSERT INTO tags (id) VALUES ($1) ON CONFLICT (id) DO NOTHING",
+		OnConflictInsert("tags", []string{"id"}, 1, [][]string{{"$1"}}, true))
+}
+
+func TestPaginate(t *testing.T) {
+	query := "SELECT id FROM people"
+	assert.Equal(t, "SELECT id FROM people ORDER BY id LIMIT 10", LimitOffset(query, "id", 10, 0))
+	assert.Equal(t, "SELECT id FROM people ORDER BY id LIMIT 10 OFFSET 20", LimitOffset(query, "id", 10, 20))
+	assert.Equal(t, "SELECT id FROM people ORDER BY id OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY", OffsetFetch(query, "id", 10, 0))
+}
+
+func TestPostgresDialect(t *testing.T) {
+	d := PostgresDialect(dialect.Postgres)
+
+	assert.Equal(t, "postgres", d.Name())
+	assert.Equal(t, "$3", d.Placeholder(3))
+	assert.Equal(t, `"people"`, d.QuoteIdent("People"), "names fold to lower case as unquoted names do")
+	assert.Equal(t, `"name"`, d.Column(schema.ColumnData{Name: "name"}))
+	assert.Equal(t, "RETURNING id, name", d.Returning([]string{"id", "name"}))
+	assert.Equal(t, "INSERT INTO people (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+		d.InsertQuery("people", []string{"id", "name"}, 1, [][]string{{"$1", "$2"}}, true))
+
+	maxParams, maxRows := d.BatchLimits()
+	assert.Equal(t, 65535, maxParams)
+	assert.Zero(t, maxRows)
+}
@@ -0,0 +1,377 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// SQLStorage is the storage engine of the SQL backends. It implements every
+// storage.Storage operation but Connect with the statements its Dialect
+// renders, so a backend supplies Connect and a Dialect, and overrides only
+// what its driver does better, such as PostgreSQL's COPY.
+type SQLStorage struct {
+	*BaseSQLStorage
+	Dialect Dialect
+}
+
+var _ TxOperations = (*SQLStorage)(nil)
+
+// NewSQLStorage creates an engine running the statements d renders
+func NewSQLStorage(d Dialect) *SQLStorage {
+	return &SQLStorage{
+		BaseSQLStorage: NewBaseSQLStorage(),
+		Dialect:        d,
+	}
+}
+
+// Conn is implemented by both *sql.DB and *sql.Tx
+type Conn interface {
+	Queryer
+	Execer
+}
+
+// CreateTables creates the tables of sch and their indexes, in the order
+// RenderDDL lists them
+func (s *SQLStorage) CreateTables(ctx context.Context, sch *schema.Schema) error {
+	if err := s.ValidateConnection(); err != nil {
+		return err
+	}
+
+	for _, name := range sch.CreationOrder() {
+		if err := CreateTable(ctx, s.GetDB(), s.Dialect, *sch.Tables[name]); err != nil {
+			return err
+		}
+	}
+
+	s.SetSchema(sch)
+	return nil
+}
+
+// Insert creates obj, failing with a DuplicateKeyError if its ID already exists
+func (s *SQLStorage) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, false, err
+	}
+	return s.insert(ctx, s.GetDB(), obj, false)
+}
+
+// Upsert creates obj, or overwrites the row with the same primary key
+func (s *SQLStorage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, false, err
+	}
+	return s.insert(ctx, s.GetDB(), obj, true)
+}
+
+// InsertTx creates obj within a transaction, failing with a DuplicateKeyError if its ID already exists
+func (s *SQLStorage) InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return nil, false, err
+	}
+	return s.insert(ctx, tx, obj, false)
+}
+
+// UpsertTx creates obj within a transaction, or overwrites the row with the same primary key
+func (s *SQLStorage) UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return nil, false, err
+	}
+	return s.insert(ctx, tx, obj, true)
+}
+
+// insert writes obj with the dialect's INSERT, or its upsert. Where the
+// database supports RETURNING, the fields obj leaves unset are filled with
+// the values it stored, such as column defaults.
+func (s *SQLStorage) insert(ctx context.Context, db Conn, obj *object.Object, upsert bool) ([]byte, bool, error) {
+	tbl, err := s.GetTable(obj.TableName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	columns, placeholders, values, err := PrepareInsertData(obj, tbl, s.Dialect.Placeholder)
+	if err != nil {
+		return nil, false, err
+	}
+	s.bindValues(tbl, columns, values)
+	query := s.InsertQuery(tbl, columns, [][]string{placeholders}, upsert)
+
+	scanner := NewFieldScanner(tbl)
+	if returning := s.Dialect.Returning(s.quoteAll(scanner.GetColumns())); returning != "" {
+		err = s.insertReturning(ctx, db, tbl, obj, query+" "+returning, values, scanner)
+	} else {
+		storage.DebugLog(query, values...)
+		_, err = db.ExecContext(ctx, query, values...)
+	}
+	if err != nil {
+		return nil, false, s.Dialect.TranslateError(err, tbl)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// insertReturning runs query, an INSERT returning the row it wrote, and
+// copies the row's non-NULL values to the fields obj has no value for
+func (s *SQLStorage) insertReturning(ctx context.Context, db Queryer, tbl schema.TableSchema, obj *object.Object, query string, values []any, scanner *FieldScanner) error {
+	storage.DebugLog(query, values...)
+	rows, err := db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return err
+	}
+	stored := NewSQLRows(rows, scanner, tbl.TableName)
+	defer stored.Close()
+
+	// An upsert leaving an existing row as it is returns nothing
+	if stored.Next() {
+		if obj.Fields == nil {
+			obj.Fields = make(map[string]any)
+		}
+		for name, value := range stored.Object().Fields {
+			if _, ok := obj.Fields[name]; !ok && value != nil {
+				obj.Fields[name] = value
+			}
+		}
+	}
+	return stored.Err()
+}
+
+// Update overwrites the fields obj sets in the row with its primary key,
+// reporting whether there was such a row
+func (s *SQLStorage) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return false, err
+	}
+	return s.update(ctx, s.GetDB(), obj)
+}
+
+// UpdateTx overwrites the fields obj sets in the row with its primary key within a transaction
+func (s *SQLStorage) UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return false, err
+	}
+	return s.update(ctx, tx, obj)
+}
+
+func (s *SQLStorage) update(ctx context.Context, db Execer, obj *object.Object) (bool, error) {
+	tbl, err := s.GetTable(obj.TableName)
+	if err != nil {
+		return false, err
+	}
+
+	setClauses, where, values, err := PrepareUpdateData(obj, tbl, s.whereBuilder())
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", s.Dialect.QuoteIdent(tbl.TableName), strings.Join(setClauses, ", "), where)
+	storage.DebugLog(query, values...)
+
+	res, err := db.ExecContext(ctx, query, values...)
+	if err != nil {
+		return false, s.Dialect.TranslateError(err, tbl)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (s *SQLStorage) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, err
+	}
+	return CommonFindByID(ctx, s.GetDB(), s.GetSchema(), tblName, id, s.whereBuilder(), s.selectWhere)
+}
+
+func (s *SQLStorage) FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return nil, err
+	}
+	return CommonFindByID(ctx, tx, s.GetSchema(), tblName, id, s.whereBuilder(), s.selectWhere)
+}
+
+// FindByKey returns the first object whose key field equals value
+func (s *SQLStorage) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, err
+	}
+	return s.findByKey(ctx, s.GetDB(), tblName, key, value)
+}
+
+// FindByKeyTx returns the first object whose key field equals value within a transaction
+func (s *SQLStorage) FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return nil, err
+	}
+	return s.findByKey(ctx, tx, tblName, key, value)
+}
+
+// findByKey runs FindByKey as a query on key, so key must name a field of the table
+func (s *SQLStorage) findByKey(ctx context.Context, db Queryer, tblName, key, value string) (*object.Object, error) {
+	if err := ValidateFindByKeyParams(tblName, key, value); err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(ctx, db, s.GetSchema(), tblName, storage.Eq(key, value), s.whereBuilder(), s.selectWhere)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return rows.Object(), nil
+}
+
+func (s *SQLStorage) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	return CommonFind(ctx, s.GetDB(), s.GetSchema(), tblName, q, s.whereBuilder(), s.selectWhere)
+}
+
+func (s *SQLStorage) FindTx(ctx context.Context, tx *sql.Tx, tblName string, q storage.Query) ([]*object.Object, error) {
+	return CommonFindTx(ctx, tx, s.GetSchema(), tblName, q, s.whereBuilder(), s.selectWhere)
+}
+
+func (s *SQLStorage) FindRows(ctx context.Context, tblName string, q storage.Query) (storage.Rows, error) {
+	return CommonFindRows(ctx, s.GetDB(), s.GetSchema(), tblName, q, s.whereBuilder(), s.selectWhere)
+}
+
+func (s *SQLStorage) List(ctx context.Context, tblName string, opts storage.ListOptions) ([]*object.Object, string, error) {
+	return CommonList(ctx, s.GetDB(), s.GetSchema(), tblName, opts, s.whereBuilder(), s.selectPage)
+}
+
+func (s *SQLStorage) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	return CommonDeleteByID(ctx, s.GetDB(), s.GetSchema(), tblName, id, s.whereBuilder(), s.deleteQuery)
+}
+
+func (s *SQLStorage) DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error) {
+	return CommonDeleteByIDTx(ctx, tx, s.GetSchema(), tblName, id, s.whereBuilder(), s.deleteQuery)
+}
+
+// InsertMany creates objs with multi-row INSERT statements, failing the
+// objects whose ID already exists with a DuplicateKeyError
+func (s *SQLStorage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.writeMany(ctx, objs, opts, false)
+}
+
+// UpsertMany creates or overwrites objs with the dialect's multi-row upserts
+func (s *SQLStorage) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.writeMany(ctx, objs, opts, true)
+}
+
+func (s *SQLStorage) writeMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions, upsert bool) (*storage.BatchResult, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, err
+	}
+
+	maxParams, maxRows := s.Dialect.BatchLimits()
+	if maxRows > 0 {
+		opts.BatchSize = min(opts.Size(), maxRows)
+	}
+	return CommonWriteMany(ctx, s.GetSchema(), objs, opts, maxParams, func(ctx context.Context, b InsertBatch) error {
+		return s.ExecBatch(ctx, s.GetDB(), b, upsert)
+	}, func(ctx context.Context, obj *object.Object) error {
+		_, _, err := s.insert(ctx, s.GetDB(), obj, upsert)
+		return err
+	})
+}
+
+// ExecBatch writes b on db with a single multi-row INSERT, or with upsert a
+// multi-row upsert
+func (s *SQLStorage) ExecBatch(ctx context.Context, db Execer, b InsertBatch, upsert bool) error {
+	query := s.InsertQuery(b.Table, b.Columns, b.Placeholders(s.Dialect.Placeholder), upsert)
+	values := b.Values()
+	s.bindValues(b.Table, b.Columns, values)
+
+	storage.DebugLog(query, values...)
+	if _, err := db.ExecContext(ctx, query, values...); err != nil {
+		return s.Dialect.TranslateError(err, b.Table)
+	}
+	return nil
+}
+
+// DeleteMany deletes ids from tblName with DELETE ... WHERE id IN (...) statements,
+// or for a composite primary key with the keys' conditions joined by OR
+func (s *SQLStorage) DeleteMany(ctx context.Context, tblName string, ids []string, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, err
+	}
+
+	if _, maxRows := s.Dialect.BatchLimits(); maxRows > 0 {
+		opts.BatchSize = min(opts.Size(), maxRows)
+	}
+	return CommonDeleteMany(ctx, s.GetSchema(), tblName, ids, opts, s.whereBuilder(), s.deleteQuery, ExecDB(s.GetDB()))
+}
+
+// AlterTable applies ops to tableName with the statements the dialect
+// renders, run in one transaction
+func (s *SQLStorage) AlterTable(ctx context.Context, tableName string, ops []storage.AlterOp) error {
+	return s.AlterTableWith(ctx, tableName, ops, s.Dialect.AlterQueries, s.Dialect.CreateIndex)
+}
+
+// BeginTx starts a database transaction running the engine's statements
+func (s *SQLStorage) BeginTx(ctx context.Context, opts *storage.TxOptions) (storage.Tx, error) {
+	return BeginSQLTx(ctx, s.GetDB(), s, opts)
+}
+
+// IsRetryable reports whether err aborted a transaction that should be run again
+func (s *SQLStorage) IsRetryable(err error) bool {
+	return s.Dialect.IsRetryable(err)
+}
+
+// InsertQuery renders the dialect's INSERT, or upsert, of rows of columns into tbl
+func (s *SQLStorage) InsertQuery(tbl schema.TableSchema, columns []string, rows [][]string, upsert bool) string {
+	return s.Dialect.InsertQuery(s.Dialect.QuoteIdent(tbl.TableName), s.quoteAll(columns), len(tbl.PrimaryKeyColumns()), rows, upsert)
+}
+
+// bindValues converts values, a row or rows of columns of tbl, with the dialect's BindValue
+func (s *SQLStorage) bindValues(tbl schema.TableSchema, columns []string, values []any) {
+	for i, value := range values {
+		values[i] = s.Dialect.BindValue(columnField(tbl, columns[i%len(columns)]), value)
+	}
+}
+
+func (s *SQLStorage) whereBuilder() WhereBuilder {
+	return WhereBuilder{
+		Placeholder: s.Dialect.Placeholder,
+		Ident:       s.Dialect.QuoteIdent,
+		Column:      s.Dialect.Column,
+		Value:       s.Dialect.BindValue,
+	}
+}
+
+func (s *SQLStorage) selectWhere(columns []string, tableName, where string) string {
+	return SelectWhere(s.quoteAll(columns), s.Dialect.QuoteIdent(tableName), where)
+}
+
+func (s *SQLStorage) selectPage(columns []string, tableName, where, orderBy string, limit int) string {
+	return s.Dialect.Paginate(s.selectWhere(columns, tableName, where), orderBy, limit, 0)
+}
+
+func (s *SQLStorage) deleteQuery(tableName, where string) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", s.Dialect.QuoteIdent(tableName), where)
+}
+
+func (s *SQLStorage) quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = s.Dialect.QuoteIdent(name)
+	}
+	return quoted
+}
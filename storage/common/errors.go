@@ -0,0 +1,62 @@
+package common
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// pgKeyDetail extracts the columns from a unique violation detail such as
+// "Key (email)=(a@example.com) already exists."
+var pgKeyDetail = regexp.MustCompile(`^Key \((.+?)\)=`)
+
+// TranslatePgError maps errors from PostgreSQL-compatible databases (PostgreSQL,
+// CockroachDB, YugabyteDB) written to tbl onto storage/errors. Errors it does
+// not recognise are returned unchanged.
+func TranslatePgError(err error, tbl schema.TableSchema) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		column := pgErr.ColumnName
+		if m := pgKeyDetail.FindStringSubmatch(pgErr.Detail); column == "" && m != nil {
+			columns := strings.Split(m[1], ", ")
+			for i, name := range columns {
+				columns[i] = strings.Trim(name, `"`)
+			}
+			column = ddaoerrors.KeyColumns(columns)
+		}
+		if column == "" {
+			column = pgErr.ConstraintName
+		}
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: column, Err: err}
+	case "42P01", "42703": // undefined_table, undefined_column
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Field: pgErr.ColumnName, Err: err}
+	}
+	return err
+}
+
+// PrimaryKeyColumn returns the name of tbl's primary key column, or the
+// comma separated names of a composite key's columns
+func PrimaryKeyColumn(tbl schema.TableSchema) string {
+	return ddaoerrors.KeyColumns(tbl.PrimaryKeyColumns())
+}
+
+// UniqueIndexColumns returns the columns of tbl's unique index named name,
+// compared case insensitively as databases folding names report them, for
+// databases naming the violated index rather than its columns
+func UniqueIndexColumns(tbl schema.TableSchema, name string) (string, bool) {
+	for _, index := range tbl.IndexDefs {
+		if index.Unique && strings.EqualFold(index.Name, name) {
+			return ddaoerrors.KeyColumns(index.Columns), true
+		}
+	}
+	return "", false
+}
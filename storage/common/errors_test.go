@@ -0,0 +1,49 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslatePgError(t *testing.T) {
+	tbl := schema.NewTableSchema("people")
+
+	var dup *ddaoerrors.DuplicateKeyError
+	err := TranslatePgError(&pgconn.PgError{
+		Code:           "23505",
+		Detail:         "Key (email)=(a@example.com) already exists.",
+		ConstraintName: "people_email_key",
+	}, *tbl)
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "people", dup.Table)
+	assert.Equal(t, "email", dup.Column)
+	assert.ErrorIs(t, err, ddaoerrors.ErrConflict)
+
+	// Composite keys list their columns as every backend does
+	err = TranslatePgError(&pgconn.PgError{
+		Code:   "23505",
+		Detail: `Key (team, "order")=(red, 1) already exists.`,
+	}, *tbl)
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "team,order", dup.Column)
+
+	err = TranslatePgError(&pgconn.PgError{Code: "23505", ConstraintName: "people_pkey"}, *tbl)
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "people_pkey", dup.Column)
+
+	err = TranslatePgError(&pgconn.PgError{Code: "42P01"}, *tbl)
+	assert.ErrorIs(t, err, ddaoerrors.ErrSchemaMismatch)
+
+	var pgErr *pgconn.PgError
+	assert.ErrorAs(t, err, &pgErr, "driver error should stay reachable")
+
+	other := errors.New("boom")
+	assert.Equal(t, other, TranslatePgError(other, *tbl))
+	assert.Nil(t, TranslatePgError(nil, *tbl))
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// CreateIndexes creates tbl's secondary indexes with the statements queryFunc
+// builds, each of which must be a no-op when the index already exists
+func CreateIndexes(ctx context.Context, db Execer, tbl schema.TableSchema, queryFunc func(string, schema.Index) string) error {
+	for _, index := range tbl.SecondaryIndexes() {
+		query := queryFunc(tbl.TableName, index)
+		storage.DebugLog(query)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create index %s on %s: %w", index.Name, tbl.TableName, err)
+		}
+	}
+	return nil
+}
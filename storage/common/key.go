@@ -0,0 +1,146 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// KeyWhere renders the WHERE clause matching key, numbering bind parameters from offset+1
+func (wb WhereBuilder) KeyWhere(tbl schema.TableSchema, key object.Key, offset int) (string, []any) {
+	parts := make([]string, 0, len(key))
+	args := make([]any, 0, len(key))
+	for _, part := range key {
+		field := columnField(tbl, part.Column)
+		args = append(args, wb.value(field, part.Value))
+		parts = append(parts, wb.column(field)+" = "+wb.Placeholder(offset+len(args)))
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// IDWhere renders the WHERE clause matching the object id identifies in tbl,
+// numbering bind parameters from offset+1
+func (wb WhereBuilder) IDWhere(tbl schema.TableSchema, id string, offset int) (string, []any, error) {
+	key, err := storage.ParseID(tbl, id)
+	if err != nil {
+		return "", nil, err
+	}
+	where, args := wb.KeyWhere(tbl, key, offset)
+	return where, args, nil
+}
+
+// KeysWhere renders the WHERE clause matching any of keys: an IN list for a
+// single-column key, otherwise the keys' clauses joined with OR
+func (wb WhereBuilder) KeysWhere(tbl schema.TableSchema, keys []object.Key, offset int) (string, []any) {
+	args := make([]any, 0, len(keys))
+	if len(tbl.PrimaryKeyColumns()) == 1 {
+		field := columnField(tbl, tbl.PrimaryKeyColumns()[0])
+		placeholders := make([]string, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, wb.value(field, key[0].Value))
+			placeholders = append(placeholders, wb.Placeholder(offset+len(args)))
+		}
+		return wb.column(field) + " IN (" + strings.Join(placeholders, ", ") + ")", args
+	}
+
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		where, keyArgs := wb.KeyWhere(tbl, key, offset+len(args))
+		clauses = append(clauses, "("+where+")")
+		args = append(args, keyArgs...)
+	}
+	return strings.Join(clauses, " OR "), args
+}
+
+// KeyColumns returns the names of tbl's primary key columns as rendered by wb
+func (wb WhereBuilder) KeyColumns(tbl schema.TableSchema) []string {
+	columns := tbl.PrimaryKeyColumns()
+	rendered := make([]string, len(columns))
+	for i, column := range columns {
+		rendered[i] = wb.column(columnField(tbl, column))
+	}
+	return rendered
+}
+
+func (wb WhereBuilder) column(field schema.ColumnData) string {
+	if wb.Column != nil {
+		return wb.Column(field)
+	}
+	return wb.ident(field.Name)
+}
+
+func (wb WhereBuilder) ident(name string) string {
+	if wb.Ident != nil {
+		return wb.Ident(name)
+	}
+	return name
+}
+
+func (wb WhereBuilder) value(field schema.ColumnData, value any) any {
+	if wb.Value != nil {
+		return wb.Value(field, value)
+	}
+	return value
+}
+
+// columnField returns the definition of a column. The implicit id column may
+// be missing from the schema, and is never flagged as the primary key there.
+func columnField(tbl schema.TableSchema, name string) schema.ColumnData {
+	field, ok := tbl.Fields[name]
+	if !ok {
+		field = schema.ColumnData{Name: name, DataType: "text"}
+	}
+	if name == "id" && !tbl.HasDeclaredPrimaryKey() {
+		field.PrimaryKey = true
+	}
+	return field
+}
+
+// isKeyColumn reports whether name is one of the key columns, ignoring case
+// as the databases do
+func isKeyColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonFindByID implements FindByID for SQL databases, on either a *sql.DB
+// or a *sql.Tx, matching every column of the table's primary key
+func CommonFindByID(ctx context.Context, db Queryer, sch *schema.Schema, tblName, id string, wb WhereBuilder, queryFunc func([]string, string, string) string) (*object.Object, error) {
+	if err := ValidateSchema(sch); err != nil {
+		return nil, err
+	}
+	tbl, ok := sch.GetTable(tblName)
+	if !ok {
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+
+	where, args, err := wb.IDWhere(tbl, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldScanner := NewFieldScanner(tbl)
+	query := queryFunc(fieldScanner.GetColumns(), tbl.TableName, where)
+	storage.DebugLog(query, args...)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	results, err := storage.Collect(NewSQLRows(rows, fieldScanner, tbl.TableName))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return results[0], nil
+}
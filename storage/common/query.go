@@ -0,0 +1,273 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// Queryer is implemented by both *sql.DB and *sql.Tx
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Execer is implemented by both *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// WhereBuilder renders a storage.Query into a SQL WHERE clause for a specific dialect
+type WhereBuilder struct {
+	// Placeholder returns the bind parameter for the n-th argument (1-based)
+	Placeholder func(int) string
+	// Ident quotes a table or column name; defaults to leaving it bare
+	Ident func(name string) string
+	// Column returns the SQL expression used to reference a column; defaults to its quoted name
+	Column func(field schema.ColumnData) string
+	// Value converts a value before it is bound; defaults to the identity
+	Value func(field schema.ColumnData, value any) any
+}
+
+// Build renders q against tbl, numbering bind parameters from offset+1.
+// The zero Query renders to an empty clause.
+func (wb WhereBuilder) Build(q storage.Query, tbl schema.TableSchema, offset int) (string, []any, error) {
+	if err := q.Validate(tbl); err != nil {
+		return "", nil, err
+	}
+	if q.IsZero() {
+		return "", nil, nil
+	}
+
+	args := make([]any, 0)
+	clause := wb.build(q, tbl, offset, &args)
+	return clause, args, nil
+}
+
+func (wb WhereBuilder) build(q storage.Query, tbl schema.TableSchema, offset int, args *[]any) string {
+	switch q.Op {
+	case storage.OpAnd, storage.OpOr:
+		if len(q.Children) == 0 {
+			if q.Op == storage.OpAnd {
+				return "1 = 1"
+			}
+			return "1 = 0"
+		}
+		parts := make([]string, 0, len(q.Children))
+		for _, child := range q.Children {
+			parts = append(parts, wb.build(child, tbl, offset, args))
+		}
+		joiner := " AND "
+		if q.Op == storage.OpOr {
+			joiner = " OR "
+		}
+		return "(" + strings.Join(parts, joiner) + ")"
+	case storage.OpNot:
+		return "NOT (" + wb.build(q.Children[0], tbl, offset, args) + ")"
+	}
+
+	field := tbl.Fields[q.Field]
+	column := wb.column(field)
+
+	bind := func(value any) string {
+		*args = append(*args, wb.value(field, value))
+		return wb.Placeholder(offset + len(*args))
+	}
+
+	switch q.Op {
+	case storage.OpEq:
+		return fmt.Sprintf("%s = %s", column, bind(q.Value))
+	case storage.OpNe:
+		return fmt.Sprintf("%s <> %s", column, bind(q.Value))
+	case storage.OpLt:
+		return fmt.Sprintf("%s < %s", column, bind(q.Value))
+	case storage.OpLte:
+		return fmt.Sprintf("%s <= %s", column, bind(q.Value))
+	case storage.OpGt:
+		return fmt.Sprintf("%s > %s", column, bind(q.Value))
+	case storage.OpGte:
+		return fmt.Sprintf("%s >= %s", column, bind(q.Value))
+	case storage.OpLike:
+		return fmt.Sprintf("%s LIKE %s", column, bind(q.Value))
+	case storage.OpIsNull:
+		return fmt.Sprintf("%s IS NULL", column)
+	case storage.OpIn:
+		if len(q.Values) == 0 {
+			return "1 = 0"
+		}
+		placeholders := make([]string, 0, len(q.Values))
+		for _, value := range q.Values {
+			placeholders = append(placeholders, bind(value))
+		}
+		return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+	}
+
+	// Validate rejects unknown operators, so this is unreachable
+	return "1 = 0"
+}
+
+// CommonFind implements Find for SQL databases
+func CommonFind(ctx context.Context, db *sql.DB, sch *schema.Schema, tblName string, q storage.Query, wb WhereBuilder, queryFunc func([]string, string, string) string) ([]*object.Object, error) {
+	rows, err := CommonFindRows(ctx, db, sch, tblName, q, wb, queryFunc)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Collect(rows)
+}
+
+// CommonFindTx implements Find for SQL databases with transactions
+func CommonFindTx(ctx context.Context, tx *sql.Tx, sch *schema.Schema, tblName string, q storage.Query, wb WhereBuilder, queryFunc func([]string, string, string) string) ([]*object.Object, error) {
+	if err := ValidateTransaction(tx); err != nil {
+		return nil, err
+	}
+	rows, err := queryRows(ctx, tx, sch, tblName, q, wb, queryFunc)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Collect(rows)
+}
+
+// CommonFindRows implements FindRows for SQL databases, streaming rows as they are read
+func CommonFindRows(ctx context.Context, db *sql.DB, sch *schema.Schema, tblName string, q storage.Query, wb WhereBuilder, queryFunc func([]string, string, string) string) (storage.Rows, error) {
+	if err := ValidateConnection(db); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, db, sch, tblName, q, wb, queryFunc)
+}
+
+func queryRows(ctx context.Context, db Queryer, sch *schema.Schema, tblName string, q storage.Query, wb WhereBuilder, queryFunc func([]string, string, string) string) (*SQLRows, error) {
+	if err := ValidateSchema(sch); err != nil {
+		return nil, err
+	}
+	if tblName == "" {
+		return nil, fmt.Errorf("table name must not be empty")
+	}
+
+	tbl, ok := sch.GetTable(tblName)
+	if !ok {
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+
+	where, args, err := wb.Build(q, tbl, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldScanner := NewFieldScanner(tbl)
+	query := queryFunc(fieldScanner.GetColumns(), tbl.TableName, where)
+	storage.DebugLog(query, args...)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSQLRows(rows, fieldScanner, tbl.TableName), nil
+}
+
+// SelectWhere builds a SELECT statement with an optional WHERE clause
+func SelectWhere(columns []string, tableName, where string) string {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), tableName)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	return query
+}
+
+// CommonList implements List for SQL databases using keyset pagination on (OrderBy, primary key).
+// pageFunc renders the SELECT with the dialect's row-limiting syntax.
+func CommonList(ctx context.Context, db *sql.DB, sch *schema.Schema, tblName string, opts storage.ListOptions, wb WhereBuilder, pageFunc func([]string, string, string, string, int) string) ([]*object.Object, string, error) {
+	if err := ValidateConnection(db); err != nil {
+		return nil, "", err
+	}
+	if err := ValidateSchema(sch); err != nil {
+		return nil, "", err
+	}
+	if tblName == "" {
+		return nil, "", fmt.Errorf("table name must not be empty")
+	}
+
+	tbl, ok := sch.GetTable(tblName)
+	if !ok {
+		return nil, "", ddaoerrors.UnknownTable(tblName)
+	}
+	if err := opts.Validate(tbl); err != nil {
+		return nil, "", err
+	}
+
+	var q storage.Query
+	if opts.Cursor != "" {
+		cursor, err := storage.DecodeCursor(opts, tbl)
+		if err != nil {
+			return nil, "", err
+		}
+		if q, err = cursor.After(opts, tbl); err != nil {
+			return nil, "", err
+		}
+	}
+
+	where, args, err := wb.Build(q, tbl, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	column, desc := opts.Order()
+	orderBy := wb.orderBy(tbl, column, desc)
+	limit := opts.PageSize()
+
+	fieldScanner := NewFieldScanner(tbl)
+	// Fetch one extra row to learn whether another page follows
+	query := pageFunc(fieldScanner.GetColumns(), tbl.TableName, where, orderBy, limit+1)
+	storage.DebugLog(query, args...)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := storage.Collect(NewSQLRows(rows, fieldScanner, tbl.TableName))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(results) <= limit {
+		return results, "", nil
+	}
+
+	results = results[:limit]
+	last := results[limit-1]
+	var value any
+	if column != "id" {
+		value = last.Fields[column]
+	}
+	return results, storage.NewCursor(opts, value, last.ID).Encode(), nil
+}
+
+// orderBy renders the ORDER BY list for a column with the primary key as the
+// tie breaker; "id" orders by the primary key alone
+func (wb WhereBuilder) orderBy(tbl schema.TableSchema, column string, desc bool) string {
+	columns := tbl.PrimaryKeyColumns()
+	if column != "id" {
+		columns = append([]string{column}, columns...)
+	}
+
+	parts := make([]string, 0, len(columns))
+	for _, name := range columns {
+		expr := wb.column(columnField(tbl, name))
+		if desc {
+			expr += " DESC"
+		}
+		parts = append(parts, expr)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SelectPage builds a SELECT statement returning at most limit rows using LIMIT
+func SelectPage(columns []string, tableName, where, orderBy string, limit int) string {
+	return fmt.Sprintf("%s ORDER BY %s LIMIT %d", SelectWhere(columns, tableName, where), orderBy, limit)
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhereBuilderBuild(t *testing.T) {
+	tbl := *schema.GetTestSchema().Tables["people"]
+	wb := WhereBuilder{
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	}
+
+	tests := []struct {
+		name     string
+		query    storage.Query
+		offset   int
+		expected string
+		args     []any
+	}{
+		{"zero", storage.Query{}, 0, "", nil},
+		{"eq", storage.Eq("name", "Alice"), 0, "name = $1", []any{"Alice"}},
+		{"offset", storage.Ne("name", "Alice"), 2, "name <> $3", []any{"Alice"}},
+		{"in", storage.In("id", "a", "b"), 0, "id IN ($1, $2)", []any{"a", "b"}},
+		{"in empty", storage.In("id"), 0, "1 = 0", []any{}},
+		{"is null", storage.IsNull("metadata"), 0, "metadata IS NULL", []any{}},
+		{
+			"nested",
+			storage.And(storage.Like("name", "A%"), storage.Or(storage.Gt("id", "m"), storage.Not(storage.Lte("id", "c")))),
+			0,
+			"(name LIKE $1 AND (id > $2 OR NOT (id <= $3)))",
+			[]any{"A%", "m", "c"},
+		},
+		{"and empty", storage.And(), 0, "1 = 1", []any{}},
+		{"or empty", storage.Or(), 0, "1 = 0", []any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args, err := wb.Build(tt.query, tbl, tt.offset)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, clause)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	_, _, err := wb.Build(storage.Eq("missing", 1), tbl, 0)
+	assert.Error(t, err)
+}
+
+func TestWhereBuilderColumnAndValue(t *testing.T) {
+	tbl := *schema.GetTestSchema().Tables["people"]
+	wb := WhereBuilder{
+		Placeholder: func(int) string { return "?" },
+		Column:      func(field schema.ColumnData) string { return "[" + field.Name + "]" },
+		Value:       func(field schema.ColumnData, value any) any { return fmt.Sprint(value) + "!" },
+	}
+
+	clause, args, err := wb.Build(storage.Eq("name", "x"), tbl, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "[name] = ?", clause)
+	assert.Equal(t, []any{"x!"}, args)
+}
+
+func TestSelectWhere(t *testing.T) {
+	assert.Equal(t, "SELECT id, name FROM people", SelectWhere([]string{"id", "name"}, "people", ""))
+	assert.Equal(t, "SELECT id FROM people WHERE id = ?", SelectWhere([]string{"id"}, "people", "id = ?"))
+}
+
+func TestSelectPage(t *testing.T) {
+	tbl := *schema.GetTestSchema().Tables["people"]
+	wb := WhereBuilder{
+		Placeholder: func(int) string { return "?" },
+		Column:      func(field schema.ColumnData) string { return "[" + field.Name + "]" },
+	}
+
+	assert.Equal(t, "[id]", wb.orderBy(tbl, "id", false))
+	assert.Equal(t, "[name] DESC, [id] DESC", wb.orderBy(tbl, "name", true))
+	assert.Equal(t,
+		"SELECT id, name FROM people WHERE id > ? ORDER BY id LIMIT 11",
+		SelectPage([]string{"id", "name"}, "people", "id > ?", "id", 11),
+	)
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"database/sql"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// SQLRows streams objects from *sql.Rows, reusing one FieldScanner for every row
+type SQLRows struct {
+	rows      *sql.Rows
+	scanner   *FieldScanner
+	tableName string
+	current   *object.Object
+	err       error
+}
+
+var _ storage.Rows = (*SQLRows)(nil)
+
+// NewSQLRows wraps rows whose columns match the scanner's column order
+func NewSQLRows(rows *sql.Rows, scanner *FieldScanner, tableName string) *SQLRows {
+	return &SQLRows{
+		rows:      rows,
+		scanner:   scanner,
+		tableName: tableName,
+	}
+}
+
+// Next scans the next row into a fresh object
+func (r *SQLRows) Next() bool {
+	r.current = nil
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+
+	if err := r.rows.Scan(r.scanner.ColumnPointers...); err != nil {
+		r.err = err
+		r.rows.Close()
+		return false
+	}
+
+	r.current = r.scanner.ScanToObject(r.tableName)
+	return true
+}
+
+// Object returns the current object
+func (r *SQLRows) Object() *object.Object {
+	return r.current
+}
+
+// Err returns the first scan or iteration error
+func (r *SQLRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close closes the underlying *sql.Rows
+func (r *SQLRows) Close() error {
+	return r.rows.Close()
+}
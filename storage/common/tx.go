@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+)
+
+// TxOperations is implemented by SQL backends that run their operations on an explicit *sql.Tx
+type TxOperations interface {
+	InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error)
+	UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error)
+	UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error)
+	FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error)
+	FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error)
+	FindTx(ctx context.Context, tx *sql.Tx, tblName string, q storage.Query) ([]*object.Object, error)
+	DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error)
+}
+
+// SQLTx adapts a *sql.Tx and a backend's TxOperations to storage.Tx
+type SQLTx struct {
+	tx  *sql.Tx
+	ops TxOperations
+}
+
+var _ storage.Tx = (*SQLTx)(nil)
+
+// NewSQLTx wraps tx so that every operation runs through ops
+func NewSQLTx(tx *sql.Tx, ops TxOperations) *SQLTx {
+	return &SQLTx{tx: tx, ops: ops}
+}
+
+// BeginSQLTx starts a database transaction with opts and wraps it for ops
+func BeginSQLTx(ctx context.Context, db *sql.DB, ops TxOperations, opts *storage.TxOptions) (storage.Tx, error) {
+	if err := ValidateConnection(db); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, opts.SQLOptions())
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLTx(tx, ops), nil
+}
+
+// SQLTx returns the underlying database transaction
+func (t *SQLTx) SQLTx() *sql.Tx {
+	return t.tx
+}
+
+func (t *SQLTx) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return t.ops.InsertTx(ctx, t.tx, obj)
+}
+
+func (t *SQLTx) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	return t.ops.UpdateTx(ctx, t.tx, obj)
+}
+
+func (t *SQLTx) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return t.ops.UpsertTx(ctx, t.tx, obj)
+}
+
+func (t *SQLTx) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	return t.ops.FindByIDTx(ctx, t.tx, tblName, id)
+}
+
+func (t *SQLTx) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	return t.ops.FindByKeyTx(ctx, t.tx, tblName, key, value)
+}
+
+func (t *SQLTx) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	return t.ops.FindTx(ctx, t.tx, tblName, q)
+}
+
+func (t *SQLTx) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	return t.ops.DeleteByIDTx(ctx, t.tx, tblName, id)
+}
+
+// Commit commits the database transaction
+func (t *SQLTx) Commit() error {
+	return txError(t.tx.Commit())
+}
+
+// Rollback aborts the database transaction
+func (t *SQLTx) Rollback() error {
+	return txError(t.tx.Rollback())
+}
+
+func txError(err error) error {
+	if errors.Is(err, sql.ErrTxDone) {
+		return storage.ErrTxDone
+	}
+	return err
+}
+
+// SQLState returns the five character SQLSTATE code carried by err, or "" if
+// the driver did not report one
+func SQLState(err error) string {
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
+
+// IsSerializationFailure reports whether err is a serialization failure
+// (SQLSTATE 40001) or a detected deadlock (40P01), after which the whole
+// transaction can be retried
+func IsSerializationFailure(err error) bool {
+	switch SQLState(err) {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
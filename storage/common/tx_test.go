@@ -0,0 +1,21 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, IsSerializationFailure(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, IsSerializationFailure(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"})))
+	assert.False(t, IsSerializationFailure(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsSerializationFailure(errors.New("restart transaction")))
+	assert.False(t, IsSerializationFailure(nil))
+
+	assert.Equal(t, "40001", SQLState(&pgconn.PgError{Code: "40001"}))
+	assert.Equal(t, "", SQLState(errors.New("boom")))
+}
@@ -1,25 +1,22 @@
 package common
 
 import (
-	"database/sql"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
 )
 
-// SQLStorage represents common functionality for SQL-based storage adapters
-type SQLStorage struct {
-	DB  *sql.DB
-	Sch *schema.Schema
-}
-
 // FieldScanner handles common field scanning logic for different data types
 type FieldScanner struct {
 	ColumnPointers []any
 	FieldTypes     []string
 	FieldNames     []string
 	Fields         map[string]schema.ColumnData
+	// KeyColumns are the table's primary key columns, from which scanned objects get their ID
+	KeyColumns []string
 }
 
 // NewFieldScanner creates a new field scanner for the given table schema
@@ -50,13 +47,18 @@ func NewFieldScanner(tbl schema.TableSchema) *FieldScanner {
 				columnPointers = append(columnPointers, columnPointer)
 			}
 		case "REAL", "FLOAT":
-			columnPointer := new(float64)
-			columnPointers = append(columnPointers, columnPointer)
+			if field.Nullable {
+				columnPointer := new(*float64)
+				columnPointers = append(columnPointers, columnPointer)
+			} else {
+				columnPointer := new(float64)
+				columnPointers = append(columnPointers, columnPointer)
+			}
 		case "BLOB":
 			columnPointer := new([]byte)
 			columnPointers = append(columnPointers, columnPointer)
 		case "BOOLEAN":
-			columnPointer := new(bool)
+			columnPointer := new(BoolValue)
 			columnPointers = append(columnPointers, columnPointer)
 		case "JSON":
 			if field.Nullable {
@@ -86,6 +88,7 @@ func NewFieldScanner(tbl schema.TableSchema) *FieldScanner {
 		FieldTypes:     fieldTypes,
 		FieldNames:     fieldNames,
 		Fields:         tbl.Fields,
+		KeyColumns:     tbl.PrimaryKeyColumns(),
 	}
 }
 
@@ -120,11 +123,24 @@ func (fs *FieldScanner) ScanToObject(tableName string) *object.Object {
 				obj.Fields[field.Name] = *fs.ColumnPointers[i].(*int64)
 			}
 		case "REAL", "FLOAT":
-			obj.Fields[field.Name] = *fs.ColumnPointers[i].(*float64)
+			if field.Nullable {
+				if ptr, ok := fs.ColumnPointers[i].(**float64); ok && ptr != nil && *ptr != nil {
+					obj.Fields[field.Name] = **ptr
+				} else {
+					obj.Fields[field.Name] = nil
+				}
+			} else {
+				obj.Fields[field.Name] = *fs.ColumnPointers[i].(*float64)
+			}
 		case "BLOB":
 			obj.Fields[field.Name] = *fs.ColumnPointers[i].(*[]byte)
 		case "BOOLEAN":
-			obj.Fields[field.Name] = *fs.ColumnPointers[i].(*bool)
+			ptr := fs.ColumnPointers[i].(*BoolValue)
+			if !ptr.Valid && field.Nullable {
+				obj.Fields[field.Name] = nil
+			} else {
+				obj.Fields[field.Name] = ptr.Bool
+			}
 		case "JSON":
 			if field.Nullable {
 				if ptr, ok := fs.ColumnPointers[i].(**string); ok && ptr != nil && *ptr != nil {
@@ -150,10 +166,8 @@ func (fs *FieldScanner) ScanToObject(tableName string) *object.Object {
 		}
 	}
 
-	// Set the ID field from the object fields
-	if idValue, exists := obj.Fields["id"]; exists && idValue != nil {
-		obj.ID = idValue.(string)
-	}
+	// Set the ID from the primary key fields
+	obj.SetIDFromKey(fs.KeyColumns)
 
 	return obj
 }
@@ -166,4 +180,44 @@ func (fs *FieldScanner) GetColumns() []string {
 		columns = append(columns, field.Name)
 	}
 	return columns
-}
\ No newline at end of file
+}
+
+// BoolValue scans boolean columns, accepting native booleans as well as the
+// 0/1 numbers used by databases without a boolean type (e.g. Oracle NUMBER(1))
+type BoolValue struct {
+	Bool  bool
+	Valid bool
+}
+
+// Scan implements sql.Scanner
+func (b *BoolValue) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		b.Bool, b.Valid = false, false
+		return nil
+	case bool:
+		b.Bool, b.Valid = v, true
+		return nil
+	case int64:
+		b.Bool, b.Valid = v != 0, true
+		return nil
+	case []byte:
+		return b.parse(string(v))
+	case string:
+		return b.parse(v)
+	default:
+		return b.parse(fmt.Sprint(v))
+	}
+}
+
+func (b *BoolValue) parse(s string) error {
+	if parsed, err := strconv.ParseBool(s); err == nil {
+		b.Bool, b.Valid = parsed, true
+		return nil
+	}
+	if parsed, err := strconv.ParseFloat(s, 64); err == nil {
+		b.Bool, b.Valid = parsed != 0, true
+		return nil
+	}
+	return fmt.Errorf("cannot scan %q into a boolean", s)
+}
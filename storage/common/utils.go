@@ -6,17 +6,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
 	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 // ValidateConnection checks if the database connection is valid
 func ValidateConnection(db *sql.DB) error {
 	if db == nil {
-		return errors.New("not connected")
+		return ddaoerrors.ErrNotConnected
 	}
 	return nil
 }
@@ -45,165 +47,137 @@ func ValidateTransaction(tx *sql.Tx) error {
 	return nil
 }
 
-// PrepareInsertData prepares columns, placeholders, and values for INSERT operations
+// PrepareInsertData prepares columns, placeholders, and values for INSERT
+// operations. The table's primary key columns come first.
 func PrepareInsertData(obj *object.Object, tbl schema.TableSchema, placeholderFunc func(int) string) ([]string, []string, []any, error) {
-	columns := make([]string, 0, len(obj.Fields)+1)
-	placeholders := make([]string, 0, len(obj.Fields)+1)
-	values := make([]any, 0, len(obj.Fields)+1)
-
-	// Add ID field first
-	columns = append(columns, "id")
-	placeholders = append(placeholders, placeholderFunc(1))
-	values = append(values, obj.ID)
-
-	paramIndex := 2
-	for name, field := range obj.Fields {
-		if strings.ToLower(name) == "id" {
-			continue // Skip ID field, already handled
-		}
-
-		columns = append(columns, name)
-		placeholders = append(placeholders, placeholderFunc(paramIndex))
-
-		schField, ok := tbl.Fields[name]
-		if !ok {
-			return nil, nil, nil, fmt.Errorf("field %s not found in table %s schema", name, tbl.TableName)
-		}
-
-		if strings.ToLower(schField.DataType) == "json" {
-			jsonData, err := json.Marshal(field)
-			if err != nil {
-				return nil, nil, nil, fmt.Errorf("failed to marshal JSON field %s: %w", name, err)
-			}
-			values = append(values, string(jsonData))
-		} else {
-			values = append(values, field)
-		}
-		paramIndex++
+	columns, values, err := insertColumns(obj, tbl, false)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return columns, placeholders, values, nil
-}
-
-// PrepareUpdateData prepares SET clauses and values for UPDATE operations
-func PrepareUpdateData(obj *object.Object, placeholderFunc func(int) string) ([]string, []any) {
-	setClauses := make([]string, 0, len(obj.Fields)-1) // Exclude ID field
-	values := make([]any, 0, len(obj.Fields))
-	paramIndex := 1
-
-	for name, value := range obj.Fields {
-		if strings.ToLower(name) == "id" {
-			continue // Skip ID field
-		}
-		setClauses = append(setClauses, fmt.Sprintf("%s = %s", name, placeholderFunc(paramIndex)))
-		values = append(values, value)
-		paramIndex++
+	placeholders := make([]string, len(columns))
+	for i := range placeholders {
+		placeholders[i] = placeholderFunc(i + 1)
 	}
-
-	// Add ID at the end for WHERE clause
-	values = append(values, obj.ID)
-
-	return setClauses, values
+	return columns, placeholders, values, nil
 }
 
-// CommonFindByKey implements common FindByKey logic for SQL databases
-func CommonFindByKey(ctx context.Context, db *sql.DB, sch *schema.Schema, tblName, key, value string, queryFunc func([]string, string, string) string) (*object.Object, error) {
-	if err := ValidateConnection(db); err != nil {
-		return nil, err
+// insertColumns returns the columns and values to INSERT for obj: its primary
+// key columns first, then its other fields, in sorted order when sorted is set
+func insertColumns(obj *object.Object, tbl schema.TableSchema, sorted bool) ([]string, []any, error) {
+	keyColumns := tbl.PrimaryKeyColumns()
+	key, err := obj.Key(keyColumns)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := ValidateFindByKeyParams(tblName, key, value); err != nil {
-		return nil, err
+	names := make([]string, 0, len(obj.Fields))
+	for name := range obj.Fields {
+		if !isKeyColumn(keyColumns, name) {
+			names = append(names, name) // Key columns are already handled
+		}
 	}
-
-	tbl, ok := sch.GetTable(tblName)
-	if !ok {
-		return nil, fmt.Errorf("table %s not found in schema", tblName)
+	if sorted {
+		slices.Sort(names)
 	}
 
-	fieldScanner := NewFieldScanner(tbl)
-	columns := fieldScanner.GetColumns()
-
-	query := queryFunc(columns, tbl.TableName, tbl.Fields[key].Name)
-	storage.DebugLog(query, value)
+	columns := append(make([]string, 0, len(key)+len(names)), keyColumns...)
+	values := append(make([]any, 0, len(key)+len(names)), key.Values()...)
+	for _, name := range names {
+		schField, ok := tbl.Fields[name]
+		if !ok {
+			return nil, nil, ddaoerrors.UnknownField(tbl.TableName, name)
+		}
 
-	row := db.QueryRowContext(ctx, query, value)
-	if err := row.Scan(fieldScanner.ColumnPointers...); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+		value, err := columnValue(schField, obj.Fields[name])
+		if err != nil {
+			return nil, nil, err
 		}
-		return nil, err
+		columns = append(columns, name)
+		values = append(values, value)
 	}
-
-	return fieldScanner.ScanToObject(tbl.TableName), nil
+	return columns, values, nil
 }
 
-// CommonFindByKeyTx implements common FindByKey logic for SQL databases with transactions
-func CommonFindByKeyTx(ctx context.Context, tx *sql.Tx, sch *schema.Schema, tblName, key, value string, queryFunc func([]string, string, string) string) (*object.Object, error) {
-	if err := ValidateTransaction(tx); err != nil {
-		return nil, err
+// columnValue returns the value written to field's column: JSON fields are
+// encoded, other values are written as they are
+func columnValue(field schema.ColumnData, value any) (any, error) {
+	if strings.ToLower(field.DataType) != "json" {
+		return value, nil
 	}
-
-	if err := ValidateFindByKeyParams(tblName, key, value); err != nil {
-		return nil, err
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON field %s: %w", field.Name, err)
 	}
+	return string(jsonData), nil
+}
 
-	tbl, ok := sch.GetTable(tblName)
-	if !ok {
-		return nil, fmt.Errorf("table %s not found in schema", tblName)
+// PrepareUpdateData prepares SET clauses, the WHERE clause matching obj's
+// primary key, and their values for UPDATE operations
+func PrepareUpdateData(obj *object.Object, tbl schema.TableSchema, wb WhereBuilder) ([]string, string, []any, error) {
+	keyColumns := tbl.PrimaryKeyColumns()
+	key, err := obj.Key(keyColumns)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
-	fieldScanner := NewFieldScanner(tbl)
-	columns := fieldScanner.GetColumns()
-
-	query := queryFunc(columns, tbl.TableName, tbl.Fields[key].Name)
-	storage.DebugLog(query, value)
-
-	row := tx.QueryRowContext(ctx, query, value)
-	if err := row.Scan(fieldScanner.ColumnPointers...); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+	setClauses := make([]string, 0, len(obj.Fields))
+	values := make([]any, 0, len(obj.Fields)+len(key))
+	for name, value := range obj.Fields {
+		if isKeyColumn(keyColumns, name) {
+			continue // Key columns identify the row
 		}
-		return nil, err
+		schField, ok := tbl.Fields[name]
+		if !ok {
+			schField = schema.ColumnData{Name: name}
+		} else if value, err = columnValue(schField, value); err != nil {
+			return nil, "", nil, err
+		}
+		setClauses = append(setClauses, fmt.Sprintf("%s = %s", wb.ident(name), wb.Placeholder(len(values)+1)))
+		values = append(values, wb.value(schField, value))
 	}
 
-	return fieldScanner.ScanToObject(tbl.TableName), nil
+	// Key values go at the end for the WHERE clause
+	where, args := wb.KeyWhere(tbl, key, len(values))
+	return setClauses, where, append(values, args...), nil
 }
 
-// CommonDeleteByID implements common DeleteByID logic for SQL databases
-func CommonDeleteByID(ctx context.Context, db *sql.DB, tblName, id string, queryFunc func(string) string, args ...any) (bool, error) {
+// CommonDeleteByID implements common DeleteByID logic for SQL databases.
+// queryFunc builds the statement from the table name and the WHERE clause
+// matching the table's primary key.
+func CommonDeleteByID(ctx context.Context, db *sql.DB, sch *schema.Schema, tblName, id string, wb WhereBuilder, queryFunc func(string, string) string) (bool, error) {
 	if err := ValidateConnection(db); err != nil {
 		return false, err
 	}
+	return deleteByID(ctx, db, sch, tblName, id, wb, queryFunc)
+}
 
-	query := queryFunc(tblName)
-	allArgs := append([]any{id}, args...)
-	storage.DebugLog(query, allArgs...)
-
-	res, err := db.ExecContext(ctx, query, allArgs...)
-	if err != nil {
+// CommonDeleteByIDTx implements common DeleteByID logic for SQL databases with transactions
+func CommonDeleteByIDTx(ctx context.Context, tx *sql.Tx, sch *schema.Schema, tblName, id string, wb WhereBuilder, queryFunc func(string, string) string) (bool, error) {
+	if err := ValidateTransaction(tx); err != nil {
 		return false, err
 	}
+	return deleteByID(ctx, tx, sch, tblName, id, wb, queryFunc)
+}
 
-	n, err := res.RowsAffected()
-	if err != nil {
+func deleteByID(ctx context.Context, db Execer, sch *schema.Schema, tblName, id string, wb WhereBuilder, queryFunc func(string, string) string) (bool, error) {
+	if err := ValidateSchema(sch); err != nil {
 		return false, err
 	}
+	tbl, ok := sch.GetTable(tblName)
+	if !ok {
+		return false, ddaoerrors.UnknownTable(tblName)
+	}
 
-	return n > 0, nil
-}
-
-// CommonDeleteByIDTx implements common DeleteByID logic for SQL databases with transactions
-func CommonDeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string, queryFunc func(string) string, args ...any) (bool, error) {
-	if err := ValidateTransaction(tx); err != nil {
+	where, args, err := wb.IDWhere(tbl, id, 0)
+	if err != nil {
 		return false, err
 	}
 
-	query := queryFunc(tblName)
-	allArgs := append([]any{id}, args...)
-	storage.DebugLog(query, allArgs...)
+	query := queryFunc(tbl.TableName, where)
+	storage.DebugLog(query, args...)
 
-	res, err := tx.ExecContext(ctx, query, allArgs...)
+	res, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return false, err
 	}
@@ -215,32 +189,3 @@ func CommonDeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string, que
 
 	return n > 0, nil
 }
-
-// TransactionMethods provides common transaction method implementations
-type TransactionMethods struct {
-	DB *sql.DB
-}
-
-// BeginTx starts a new transaction
-func (tm *TransactionMethods) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	if err := ValidateConnection(tm.DB); err != nil {
-		return nil, err
-	}
-	return tm.DB.BeginTx(ctx, nil)
-}
-
-// CommitTx commits a transaction
-func (tm *TransactionMethods) CommitTx(tx *sql.Tx) error {
-	if err := ValidateTransaction(tx); err != nil {
-		return err
-	}
-	return tx.Commit()
-}
-
-// RollbackTx rolls back a transaction
-func (tm *TransactionMethods) RollbackTx(tx *sql.Tx) error {
-	if err := ValidateTransaction(tx); err != nil {
-		return err
-	}
-	return tx.Rollback()
-}
\ No newline at end of file
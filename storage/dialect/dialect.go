@@ -0,0 +1,278 @@
+// Package dialect renders the DDL each backend runs to create a schema:
+// CREATE TABLE statements with the database's column types, defaults,
+// comments and foreign keys, then CREATE INDEX statements. Backends run
+// exactly these statements in CreateTables, so RenderDDL shows what
+// CreateTables will do without connecting to a database.
+//
+// Foreign keys are declared in CREATE TABLE, and tables are created in the
+// schema's CreationOrder, so referenced tables exist first. A foreign key
+// closing a cycle of references therefore fails to be created, and has to
+// be added by a migration once both tables exist. Referential actions the
+// database lacks are left out, so its default applies. Scylla has no
+// foreign keys, and its dialect leaves them out.
+//
+// Every table, column, index and constraint name is quoted with the
+// dialect's QuoteIdent, so names that are reserved words, such as user or
+// order, work as any other. Defaults are rendered as typed literals, or
+// verbatim when they are a schema.Expr.
+package dialect
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// Dialect renders a database's DDL
+type Dialect interface {
+	// Name returns the name the dialect is registered under
+	Name() string
+	// MapDataType maps a schema data type to the database's column type
+	MapDataType(dataType string) string
+	// ColumnType returns the type of field as a column of table, which may
+	// differ from MapDataType for key and indexed columns
+	ColumnType(table schema.TableSchema, field schema.ColumnData) string
+	// ColumnDefinition renders field as a column of table, as written in
+	// CREATE TABLE and ALTER TABLE ... ADD, without primary key constraints
+	ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string
+	// QuoteIdent quotes name as a table, column, index or constraint name.
+	// Databases folding unquoted names to one case get name folded the
+	// same way, so it refers to the table or column the unquoted name did.
+	QuoteIdent(name string) string
+	// Literal renders a column default: nil as NULL, a schema.Expr as
+	// written, booleans and numbers as the database spells them, and
+	// anything else as an escaped string
+	Literal(value any) string
+	// CreateTable returns the CREATE TABLE statement for table, declaring its
+	// foreign keys, followed by any statements completing the new table, such
+	// as comments
+	CreateTable(table schema.TableSchema) []string
+	// CreateIndex returns the statement creating index on tableName, which
+	// does nothing if the index already exists
+	CreateIndex(tableName string, index schema.Index) string
+	// CreateIndexes returns the statements creating table's secondary indexes
+	CreateIndexes(table schema.TableSchema) []string
+	// RenderDDL returns the statements creating sch's tables and indexes, in
+	// the schema's CreationOrder, each terminated and on its own line
+	RenderDDL(sch *schema.Schema) string
+}
+
+var dialects = map[string]Dialect{}
+
+// Register makes d available to Get under its name and aliases
+func Register(d Dialect, aliases ...string) {
+	for _, name := range append([]string{d.Name()}, aliases...) {
+		dialects[strings.ToLower(name)] = d
+	}
+}
+
+// Get returns the dialect registered under name, case insensitively
+func Get(name string) (Dialect, bool) {
+	d, ok := dialects[strings.ToLower(name)]
+	return d, ok
+}
+
+// Names returns the names of the registered dialects, without aliases, sorted
+func Names() []string {
+	var names []string
+	for name, d := range dialects {
+		if name == d.Name() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(SQLite, "sqlite3")
+	Register(Postgres, "postgresql", "pgx")
+	Register(Cockroach, "cockroachdb", "crdb")
+	Register(Yugabyte, "yugabytedb")
+	Register(TiDB, "mysql")
+	Register(Oracle, "godror")
+	Register(SQLServer, "mssql")
+	Register(Scylla, "cassandra", "cql")
+}
+
+// render returns the statements of d creating sch
+func render(d Dialect, sch *schema.Schema) string {
+	var b strings.Builder
+	for _, name := range sch.CreationOrder() {
+		table, ok := sch.GetTable(name)
+		if !ok {
+			continue
+		}
+		for _, statement := range append(d.CreateTable(table), d.CreateIndexes(table)...) {
+			b.WriteString(statement)
+			if !strings.HasSuffix(statement, ";") {
+				b.WriteString(";") // PL/SQL blocks end with their own
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// columns returns table's columns in order, leaving out the implicit id
+// column of a table without a declared primary key, which dialects render
+// themselves
+func columns(table schema.TableSchema) []schema.ColumnData {
+	declared := table.HasDeclaredPrimaryKey()
+	var fields []schema.ColumnData
+	for _, name := range table.ColumnNames() {
+		if !declared && name == "id" {
+			continue
+		}
+		field := table.Fields[name]
+		field.Name = name
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// keyable reports whether column needs a type the database can index: it is
+// indexed, or part of a foreign key, which MySQL indexes and other databases
+// only allow on such types
+func keyable(table schema.TableSchema, column string) bool {
+	if table.IsIndexed(column) {
+		return true
+	}
+	for _, fk := range table.ForeignKeys {
+		if slices.Contains(fk.Columns, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteString renders s as a SQL string literal, doubling its quotes
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdent encloses name in open and close, doubling each close inside it
+func quoteIdent(name, open, close string) string {
+	return open + strings.ReplaceAll(name, close, close+close) + close
+}
+
+// foldASCII maps the ASCII letters of name to lower case, or upper case
+// with upper, leaving other characters as they are, the way PostgreSQL, CQL
+// and Oracle fold unquoted names
+func foldASCII(name string, upper bool) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case upper && 'a' <= c && c <= 'z':
+			b[i] = c - 'a' + 'A'
+		case !upper && 'A' <= c && c <= 'Z':
+			b[i] = c - 'A' + 'a'
+		}
+	}
+	return string(b)
+}
+
+// literal renders value as a SQL literal: nil as NULL, an expression as it
+// is, numbers as they are, booleans as trueValue or falseValue, and anything
+// else, including infinite and NaN floats, as a string quoted with quote
+func literal(value any, trueValue, falseValue string, quote func(string) string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case schema.Expr:
+		return string(v)
+	case bool:
+		if v {
+			return trueValue
+		}
+		return falseValue
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return literal(float64(v), trueValue, falseValue, quote)
+	case float64:
+		if !math.IsInf(v, 0) && !math.IsNaN(v) {
+			return fmt.Sprint(v)
+		}
+	}
+	return quote(fmt.Sprint(value))
+}
+
+// blockComment renders text as a /* */ comment
+func blockComment(text string) string {
+	return "/* " + strings.ReplaceAll(text, "*/", "* /") + " */"
+}
+
+// standardDefinition renders field as "name type [NOT] NULL [DEFAULT value]
+// [UNIQUE]", the column definition most databases share
+func standardDefinition(d Dialect, dataType string, field schema.ColumnData) string {
+	definition := d.QuoteIdent(field.Name) + " " + dataType
+	if field.Nullable {
+		definition += " NULL"
+	} else {
+		definition += " NOT NULL"
+	}
+	if field.Default != nil {
+		definition += " DEFAULT " + d.Literal(field.Default)
+	}
+	if field.Unique {
+		definition += " UNIQUE"
+	}
+	return definition
+}
+
+// StandardIndex builds a CREATE [UNIQUE] INDEX IF NOT EXISTS statement, for
+// the databases that support that form, naming the index, table and columns
+// with quote
+func StandardIndex(quote func(string) string, tableName string, index schema.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique, quote(index.Name), quote(tableName), quoteAll(quote, index.Columns))
+}
+
+// quoteAll quotes each of names with quote and joins them with commas
+func quoteAll(quote func(string) string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// createIndexes renders each of table's secondary indexes with d.CreateIndex
+func createIndexes(d Dialect, table schema.TableSchema) []string {
+	var statements []string
+	for _, index := range table.SecondaryIndexes() {
+		statements = append(statements, d.CreateIndex(table.TableName, index))
+	}
+	return statements
+}
+
+// allActions reports that a database supports every referential action
+func allActions(string, schema.ReferentialAction) bool { return true }
+
+// foreignKeys renders table's foreign keys as table constraints, naming
+// constraints, tables and columns with quote. Actions supports rejects for
+// an event, DELETE or UPDATE, are left out, so the database's default
+// applies.
+func foreignKeys(table schema.TableSchema, quote func(string) string, supports func(event string, action schema.ReferentialAction) bool) []string {
+	var constraints []string
+	for _, fk := range table.ForeignKeys {
+		constraint := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quote(fk.Name), quoteAll(quote, fk.Columns), quote(fk.RefTable), quoteAll(quote, fk.RefColumns))
+		if fk.OnDelete != "" && supports("DELETE", fk.OnDelete) {
+			constraint += " ON DELETE " + string(fk.OnDelete)
+		}
+		if fk.OnUpdate != "" && supports("UPDATE", fk.OnUpdate) {
+			constraint += " ON UPDATE " + string(fk.OnUpdate)
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
@@ -0,0 +1,258 @@
+package dialect
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchema returns a table keyed on the implicit id column, with a comment,
+// defaults needing escaping and an index, and a table with a composite key
+func testSchema() *schema.Schema {
+	sch := schema.New()
+
+	notes := schema.NewTableSchema("notes")
+	notes.Comment = "Users' notes"
+	notes.AddField(schema.ColumnData{Name: "title", DataType: "text", Default: "it's", Comment: "Shown in lists"})
+	notes.AddField(schema.ColumnData{Name: "email", DataType: "text", Unique: true})
+	notes.AddField(schema.ColumnData{Name: "pinned", DataType: "boolean", Default: true})
+	notes.AddField(schema.ColumnData{Name: "rank", DataType: "integer", Nullable: true, Index: true})
+	sch.AddTable(notes)
+
+	stock := schema.NewTableSchema("stock")
+	stock.AddField(schema.ColumnData{Name: "region", DataType: "text"})
+	stock.AddField(schema.ColumnData{Name: "sku", DataType: "text"})
+	stock.AddField(schema.ColumnData{Name: "bin", DataType: "text", Nullable: true})
+	stock.SetPrimaryKey("region", "sku")
+	stock.AddIndex("", true, "region", "bin")
+	sch.AddTable(stock)
+
+	return sch
+}
+
+func TestRenderDDL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{SQLite, []string{
+			`CREATE TABLE IF NOT EXISTS "notes" (/* Users' notes */ "id" TEXT PRIMARY KEY, "title" text NOT NULL DEFAULT 'it''s' /* Shown in lists */, "email" text NOT NULL UNIQUE, "pinned" boolean NOT NULL DEFAULT 1, "rank" integer NULL);`,
+			`CREATE INDEX IF NOT EXISTS "idx_notes_rank" ON "notes" ("rank");`,
+			`CREATE TABLE IF NOT EXISTS "stock" ("region" text NOT NULL, "sku" text NOT NULL, "bin" text NULL, PRIMARY KEY ("region", "sku"));`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS "uq_stock_region_bin" ON "stock" ("region", "bin");`,
+		}},
+		{Postgres, []string{
+			`CREATE TABLE IF NOT EXISTS "notes" ("id" TEXT PRIMARY KEY, "title" TEXT NOT NULL DEFAULT 'it''s', "email" TEXT NOT NULL UNIQUE, "pinned" BOOLEAN NOT NULL DEFAULT TRUE, "rank" INTEGER NULL);`,
+			`COMMENT ON TABLE "notes" IS 'Users'' notes';`,
+			`COMMENT ON COLUMN "notes"."title" IS 'Shown in lists';`,
+			`CREATE INDEX IF NOT EXISTS "idx_notes_rank" ON "notes" ("rank");`,
+			`CREATE TABLE IF NOT EXISTS "stock" ("region" TEXT NOT NULL, "sku" TEXT NOT NULL, "bin" TEXT NULL, PRIMARY KEY ("region", "sku"));`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS "uq_stock_region_bin" ON "stock" ("region", "bin");`,
+		}},
+		{Cockroach, []string{
+			`CREATE TABLE IF NOT EXISTS "notes" ("id" STRING PRIMARY KEY, "title" STRING NOT NULL DEFAULT 'it''s', "email" STRING NOT NULL UNIQUE, "pinned" BOOL NOT NULL DEFAULT TRUE, "rank" INT8 NULL);`,
+			`COMMENT ON TABLE "notes" IS 'Users'' notes';`,
+			`COMMENT ON COLUMN "notes"."title" IS 'Shown in lists';`,
+			`CREATE INDEX IF NOT EXISTS "idx_notes_rank" ON "notes" ("rank");`,
+			`CREATE TABLE IF NOT EXISTS "stock" ("region" STRING NOT NULL, "sku" STRING NOT NULL, "bin" STRING NULL, PRIMARY KEY ("region", "sku"));`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS "uq_stock_region_bin" ON "stock" ("region", "bin");`,
+		}},
+		{TiDB, []string{
+			"CREATE TABLE IF NOT EXISTS `notes` (`id` VARCHAR(255) PRIMARY KEY, `title` TEXT NOT NULL DEFAULT 'it''s' COMMENT 'Shown in lists', `email` VARCHAR(255) NOT NULL UNIQUE, `pinned` BOOLEAN NOT NULL DEFAULT TRUE, `rank` BIGINT NULL) COMMENT='Users'' notes';",
+			"CREATE INDEX IF NOT EXISTS `idx_notes_rank` ON `notes` (`rank`);",
+			"CREATE TABLE IF NOT EXISTS `stock` (`region` VARCHAR(255) NOT NULL, `sku` VARCHAR(255) NOT NULL, `bin` VARCHAR(255) NULL, PRIMARY KEY (`region`, `sku`));",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `uq_stock_region_bin` ON `stock` (`region`, `bin`);",
+		}},
+		{Oracle, []string{
+			`CREATE TABLE "NOTES" ("ID" VARCHAR2(255) PRIMARY KEY, "TITLE" CLOB DEFAULT 'it''s' NOT NULL, "EMAIL" VARCHAR2(255) NOT NULL, "PINNED" NUMBER(1) DEFAULT 1 NOT NULL, "RANK" NUMBER(19));`,
+			`ALTER TABLE "NOTES" ADD CONSTRAINT "UK_NOTES_EMAIL" UNIQUE ("EMAIL");`,
+			`COMMENT ON TABLE "NOTES" IS 'Users'' notes';`,
+			`COMMENT ON COLUMN "NOTES"."TITLE" IS 'Shown in lists';`,
+			`BEGIN EXECUTE IMMEDIATE 'CREATE INDEX "IDX_NOTES_RANK" ON "NOTES" ("RANK")'; EXCEPTION WHEN OTHERS THEN IF SQLCODE NOT IN (-955, -1408) THEN RAISE; END IF; END;`,
+			`CREATE TABLE "STOCK" ("REGION" VARCHAR2(255) NOT NULL, "SKU" VARCHAR2(255) NOT NULL, "BIN" VARCHAR2(255), PRIMARY KEY ("REGION", "SKU"));`,
+			`BEGIN EXECUTE IMMEDIATE 'CREATE UNIQUE INDEX "UQ_STOCK_REGION_BIN" ON "STOCK" ("REGION", "BIN")'; EXCEPTION WHEN OTHERS THEN IF SQLCODE NOT IN (-955, -1408) THEN RAISE; END IF; END;`,
+		}},
+		{SQLServer, []string{
+			"IF NOT EXISTS (SELECT * FROM sysobjects WHERE name='notes' AND xtype='U') CREATE TABLE [notes] ([id] NVARCHAR(255) PRIMARY KEY, [title] NVARCHAR(MAX) NOT NULL DEFAULT 'it''s', [email] NVARCHAR(255) NOT NULL UNIQUE, [pinned] BIT NOT NULL DEFAULT 1, [rank] BIGINT NULL);",
+			"IF NOT EXISTS (SELECT * FROM sys.fn_listextendedproperty('MS_Description', 'SCHEMA', 'dbo', 'TABLE', 'notes', NULL, NULL)) EXEC sp_addextendedproperty 'MS_Description', 'Users'' notes', 'SCHEMA', 'dbo', 'TABLE', 'notes', NULL, NULL;",
+			"IF NOT EXISTS (SELECT * FROM sys.fn_listextendedproperty('MS_Description', 'SCHEMA', 'dbo', 'TABLE', 'notes', 'COLUMN', 'title')) EXEC sp_addextendedproperty 'MS_Description', 'Shown in lists', 'SCHEMA', 'dbo', 'TABLE', 'notes', 'COLUMN', 'title';",
+			"IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name='idx_notes_rank' AND object_id=OBJECT_ID('[notes]')) CREATE INDEX [idx_notes_rank] ON [notes] ([rank]);",
+			"IF NOT EXISTS (SELECT * FROM sysobjects WHERE name='stock' AND xtype='U') CREATE TABLE [stock] ([region] NVARCHAR(255) NOT NULL, [sku] NVARCHAR(255) NOT NULL, [bin] NVARCHAR(255) NULL, PRIMARY KEY ([region], [sku]));",
+			"IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name='uq_stock_region_bin' AND object_id=OBJECT_ID('[stock]')) CREATE UNIQUE INDEX [uq_stock_region_bin] ON [stock] ([region], [bin]);",
+		}},
+		{ScyllaKeyspace("app"), []string{
+			`CREATE KEYSPACE IF NOT EXISTS "app" WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 3};`,
+			`CREATE TABLE IF NOT EXISTS "app"."notes" ("id" text PRIMARY KEY, "title" text, "email" text, "pinned" boolean, "rank" bigint) WITH comment = 'Users'' notes';`,
+			`CREATE INDEX IF NOT EXISTS "idx_notes_rank" ON "app"."notes" ("rank");`,
+			`CREATE TABLE IF NOT EXISTS "app"."stock" ("region" text, "sku" text, "bin" text, PRIMARY KEY ("region", "sku"));`,
+			`CREATE INDEX IF NOT EXISTS "uq_stock_region_bin_bin" ON "app"."stock" ("bin");`,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			ddl := test.dialect.RenderDDL(testSchema())
+			assert.Equal(t, test.want, strings.Split(strings.TrimSuffix(ddl, "\n"), "\n"))
+		})
+	}
+}
+
+func TestRenderDDLTableOrder(t *testing.T) {
+	sch := schema.New()
+	for _, name := range []string{"zebras", "apples", "mangos"} {
+		table := schema.NewTableSchema(name)
+		table.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+		sch.AddTable(table)
+	}
+
+	ddl := Postgres.RenderDDL(sch)
+	assert.Equal(t, ddl, Postgres.RenderDDL(sch), "rendering is deterministic")
+	assert.Less(t, strings.Index(ddl, "apples"), strings.Index(ddl, "mangos"))
+	assert.Less(t, strings.Index(ddl, "mangos"), strings.Index(ddl, "zebras"))
+
+	sch.FieldOrder = []string{"zebras"}
+	ddl = Postgres.RenderDDL(sch)
+	assert.Less(t, strings.Index(ddl, "zebras"), strings.Index(ddl, "apples"), "FieldOrder comes first")
+}
+
+// TestSQLiteDDLRuns checks the rendered DDL is accepted by SQLite and creates
+// working defaults
+func TestSQLiteDDLRuns(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(SQLite.RenderDDL(testSchema()))
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO notes (id, email) VALUES ('1', 'a@example.com')")
+	require.NoError(t, err)
+	var title string
+	var pinned bool
+	require.NoError(t, db.QueryRow("SELECT title, pinned FROM notes WHERE id = '1'").Scan(&title, &pinned))
+	assert.Equal(t, "it's", title)
+	assert.True(t, pinned)
+}
+
+// foreignKeySchema returns posts referencing users, added first so that
+// creation order differs from table order
+func foreignKeySchema() *schema.Schema {
+	sch := schema.New()
+
+	posts := schema.NewTableSchema("posts")
+	posts.AddField(schema.ColumnData{Name: "id", DataType: "integer", PrimaryKey: true})
+	posts.AddField(schema.ColumnData{Name: "author", DataType: "text", Nullable: true})
+	posts.AddForeignKey(schema.ForeignKey{Columns: []string{"author"}, RefTable: "users", RefColumns: []string{"handle"}, OnDelete: schema.SetNull, OnUpdate: schema.Restrict})
+	sch.AddTable(posts)
+
+	users := schema.NewTableSchema("users")
+	users.AddField(schema.ColumnData{Name: "handle", DataType: "text", PrimaryKey: true})
+	sch.AddTable(users)
+
+	sch.FieldOrder = []string{"posts", "users"}
+	return sch
+}
+
+func TestRenderForeignKeys(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{SQLite, `CONSTRAINT "fk_posts_author" FOREIGN KEY ("author") REFERENCES "users" ("handle") ON DELETE SET NULL ON UPDATE RESTRICT)`},
+		{Postgres, `CONSTRAINT "fk_posts_author" FOREIGN KEY ("author") REFERENCES "users" ("handle") ON DELETE SET NULL ON UPDATE RESTRICT)`},
+		{TiDB, "CONSTRAINT `fk_posts_author` FOREIGN KEY (`author`) REFERENCES `users` (`handle`) ON DELETE SET NULL ON UPDATE RESTRICT)"},
+		{Oracle, `CONSTRAINT "FK_POSTS_AUTHOR" FOREIGN KEY ("AUTHOR") REFERENCES "USERS" ("HANDLE") ON DELETE SET NULL)`},
+		{SQLServer, "CONSTRAINT [fk_posts_author] FOREIGN KEY ([author]) REFERENCES [users] ([handle]) ON DELETE SET NULL)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			ddl := test.dialect.RenderDDL(foreignKeySchema())
+			assert.Contains(t, ddl, test.want)
+			assert.NotContains(t, strings.SplitN(ddl, "\n", 2)[0], "FOREIGN KEY", "referenced tables are created first")
+		})
+	}
+
+	ddl := ScyllaKeyspace("app").RenderDDL(foreignKeySchema())
+	assert.NotContains(t, ddl, "FOREIGN KEY")
+}
+
+// TestSQLiteForeignKeysRun checks SQLite enforces the rendered foreign keys
+func TestSQLiteForeignKeysRun(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(SQLite.RenderDDL(foreignKeySchema()))
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO posts (id, author) VALUES (1, 'nobody')")
+	assert.Error(t, err, "the author must exist")
+
+	_, err = db.Exec("INSERT INTO users (handle) VALUES ('ada'); INSERT INTO posts (id, author) VALUES (1, 'ada')")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users WHERE handle = 'ada'")
+	require.NoError(t, err)
+	var author sql.NullString
+	require.NoError(t, db.QueryRow("SELECT author FROM posts WHERE id = 1").Scan(&author))
+	assert.False(t, author.Valid, "deleting the author sets it to NULL")
+}
+
+func TestLiteral(t *testing.T) {
+	assert.Equal(t, "42", Postgres.Literal(int64(42)))
+	assert.Equal(t, "1.5", Postgres.Literal(1.5))
+	assert.Equal(t, "FALSE", Postgres.Literal(false))
+	assert.Equal(t, "0", Oracle.Literal(false))
+	assert.Equal(t, "'O''Brien'", Postgres.Literal("O'Brien"))
+	assert.Equal(t, `'a\\b'`, TiDB.Literal(`a\b`), "MySQL treats backslashes as escapes")
+	assert.Equal(t, "[we]]ird]", Bracket("we]ird"))
+	assert.Equal(t, "NULL", SQLite.Literal(nil))
+	assert.Equal(t, "CURRENT_TIMESTAMP", Postgres.Literal(schema.Expr("CURRENT_TIMESTAMP")))
+	assert.Equal(t, "0.25", TiDB.Literal(float32(0.25)))
+	assert.Equal(t, "'NaN'", Postgres.Literal(math.NaN()), "non-finite floats have no numeric literal")
+}
+
+func TestQuoteIdent(t *testing.T) {
+	for _, c := range []struct {
+		d          Dialect
+		name, want string
+	}{
+		{Postgres, "User", `"user"`},
+		{Cockroach, `say "hi"`, `"say ""hi"""`},
+		{SQLite, "Order", `"Order"`},
+		{TiDB, "gr`oup", "`gr``oup`"},
+		{SQLServer, "user", "[user]"},
+		{Oracle, "group", `"GROUP"`},
+		{Scylla, "Token", `"token"`},
+	} {
+		assert.Equal(t, c.want, c.d.QuoteIdent(c.name), c.d.Name())
+	}
+
+	// COPY names what the DDL's quoted names created
+	for _, d := range []Dialect{Postgres, Cockroach, Yugabyte} {
+		assert.Equal(t, d.QuoteIdent("CreatedAt"), `"`+PostgresIdent("CreatedAt")+`"`, d.Name())
+	}
+}
+
+func TestGet(t *testing.T) {
+	for name, want := range map[string]Dialect{
+		"postgres": Postgres, "PGX": Postgres, "sqlite3": SQLite,
+		"mysql": TiDB, "mssql": SQLServer, "crdb": Cockroach, "cql": Scylla,
+	} {
+		d, ok := Get(name)
+		if assert.True(t, ok, name) {
+			assert.Equal(t, want.Name(), d.Name(), name)
+		}
+	}
+	_, ok := Get("dbase")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"cockroach", "oracle", "postgres", "scylla", "sqlite", "sqlserver", "tidb", "yugabyte"}, Names())
+}
@@ -0,0 +1,117 @@
+package dialect
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unquote reads the quoted token at the start of quoted, undoing doubled
+// close characters and with backslash MySQL's backslash escapes, and
+// reports whether the token spans all of quoted
+func unquote(quoted string, open, close byte, backslash bool) (string, bool) {
+	if len(quoted) < 2 || quoted[0] != open {
+		return "", false
+	}
+	var b strings.Builder
+	for i := 1; i < len(quoted); i++ {
+		switch c := quoted[i]; {
+		case backslash && c == '\\':
+			if i++; i == len(quoted) {
+				return "", false
+			}
+			b.WriteByte(quoted[i])
+		case c == close:
+			if i+1 < len(quoted) && quoted[i+1] == close {
+				b.WriteByte(close)
+				i++
+				continue
+			}
+			return b.String(), i == len(quoted)-1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+var identSeeds = []string{"user", "Order", "group", `say "hi"`, "we]ird", "tick`s", "x\"; DROP TABLE t; --", "ünï", ""}
+
+func FuzzQuoteIdent(f *testing.F) {
+	for _, seed := range identSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		for _, c := range []struct {
+			d           Dialect
+			open, close byte
+			folded      string
+		}{
+			{Postgres, '"', '"', foldASCII(name, false)},
+			{Cockroach, '"', '"', foldASCII(name, false)},
+			{Yugabyte, '"', '"', foldASCII(name, false)},
+			{Scylla, '"', '"', foldASCII(name, false)},
+			{Oracle, '"', '"', foldASCII(name, true)},
+			{SQLite, '"', '"', name},
+			{TiDB, '`', '`', name},
+			{SQLServer, '[', ']', name},
+		} {
+			got, whole := unquote(c.d.QuoteIdent(name), c.open, c.close, false)
+			assert.True(t, whole, "%s quoted %q as more than one token", c.d.Name(), name)
+			assert.Equal(t, c.folded, got, c.d.Name())
+		}
+	})
+}
+
+func FuzzLiteral(f *testing.F) {
+	for _, seed := range []string{"it's", `a\b`, `\'; DROP TABLE t; --`, "''", "\n", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		for _, d := range []Dialect{Postgres, Cockroach, Yugabyte, Scylla, Oracle, SQLite, TiDB, SQLServer} {
+			got, whole := unquote(d.Literal(value), '\'', '\'', d == TiDB)
+			assert.True(t, whole, "%s quoted %q as more than one token", d.Name(), value)
+			assert.Equal(t, value, got, d.Name())
+		}
+	})
+}
+
+// FuzzSQLiteDDL creates tables with fuzzed names and defaults in SQLite and
+// reads the defaults back
+func FuzzSQLiteDDL(f *testing.F) {
+	for _, seed := range identSeeds {
+		f.Add(seed, "it's")
+	}
+	f.Add("notes", `'); DROP TABLE notes; --`)
+	f.Fuzz(func(t *testing.T, name, value string) {
+		if strings.ContainsRune(name, 0) || strings.ContainsRune(value, 0) {
+			t.Skip("SQLite ends statements at NUL")
+		}
+		if name == "" || strings.EqualFold(name, "key") {
+			t.Skip("the column would clash with the key")
+		}
+		db, err := sql.Open("sqlite3", ":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		table := schema.NewTableSchema(name)
+		table.AddField(schema.ColumnData{Name: "key", DataType: "text"})
+		table.AddField(schema.ColumnData{Name: name, DataType: "text", Default: value})
+		table.SetPrimaryKey("key")
+		for _, statement := range SQLite.CreateTable(*table) {
+			_, err = db.Exec(statement)
+			require.NoError(t, err, statement)
+		}
+
+		quoted := SQLite.QuoteIdent(name)
+		_, err = db.Exec("INSERT INTO " + quoted + ` ("key") VALUES ('1')`)
+		require.NoError(t, err)
+		var got string
+		require.NoError(t, db.QueryRow("SELECT "+quoted+" FROM "+quoted).Scan(&got))
+		assert.Equal(t, value, got)
+	})
+}
@@ -0,0 +1,143 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// Oracle renders DDL for Oracle, whose unquoted names are upper case. Unique
+// columns get named constraints after the table is created, as Oracle
+// rejects inline UNIQUE on some column types.
+var Oracle Dialect = oracleDialect{}
+
+type oracleDialect struct{}
+
+func (oracleDialect) Name() string { return "oracle" }
+
+func (oracleDialect) MapDataType(dataType string) string {
+	switch strings.ToUpper(dataType) {
+	case "TEXT", "VARCHAR", "CHAR":
+		return "CLOB"
+	case "INTEGER", "INT":
+		return "NUMBER(19)"
+	case "REAL", "FLOAT":
+		return "BINARY_DOUBLE"
+	case "BLOB":
+		return "BLOB"
+	case "BOOLEAN":
+		return "NUMBER(1)" // Oracle doesn't have native boolean, use 0/1
+	case "JSON":
+		return "CLOB" // Oracle 12c+ has JSON support but we'll use CLOB for compatibility
+	case "DATETIME", "TIMESTAMP":
+		return "TIMESTAMP"
+	case "DATE":
+		return "DATE"
+	case "TIME":
+		return "TIMESTAMP"
+	case "UUID":
+		return "VARCHAR2(36)"
+	default:
+		return "CLOB"
+	}
+}
+
+// ColumnType returns the Oracle type of a column. LOBs cannot be keyed or
+// indexed, so text key, unique and indexed columns are stored as VARCHAR2
+// instead of CLOB.
+func (d oracleDialect) ColumnType(table schema.TableSchema, field schema.ColumnData) string {
+	dataType := d.MapDataType(field.DataType)
+	if dataType == "CLOB" && (field.PrimaryKey || field.Unique || field.Index || keyable(table, field.Name)) {
+		return "VARCHAR2(255)"
+	}
+	return dataType
+}
+
+// QuoteIdent quotes name in double quotes, upper case as Oracle folds
+// unquoted names
+func (oracleDialect) QuoteIdent(name string) string {
+	return quoteIdent(foldASCII(name, true), `"`, `"`)
+}
+
+func (oracleDialect) Literal(value any) string { return literal(value, "1", "0", QuoteString) }
+
+// ColumnDefinition renders field without its unique constraint, which
+// CreateTable adds separately
+func (d oracleDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	definition := fmt.Sprintf("%s %s", d.QuoteIdent(field.Name), d.ColumnType(table, field))
+	if field.Default != nil {
+		definition += " DEFAULT " + d.Literal(field.Default)
+	}
+	if !field.Nullable {
+		definition += " NOT NULL"
+	}
+	return definition
+}
+
+// CreateTable returns the CREATE TABLE statement, which fails if the table
+// exists, then the table's unique constraints and comments
+func (d oracleDialect) CreateTable(table schema.TableSchema) []string {
+	name := d.QuoteIdent(table.TableName)
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, d.QuoteIdent("id")+" VARCHAR2(255) PRIMARY KEY")
+	}
+	fields := columns(table)
+	for _, field := range fields {
+		definitions = append(definitions, d.ColumnDefinition(table, field))
+	}
+	if declared {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(d.QuoteIdent, table.PrimaryKeyColumns())))
+	}
+	definitions = append(definitions, foreignKeys(table, d.QuoteIdent, oracleActions)...)
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(definitions, ", "))}
+	for _, field := range fields {
+		if field.Unique && !field.PrimaryKey {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)",
+				name, d.QuoteIdent(UniqueConstraint(table.TableName, field.Name)), d.QuoteIdent(field.Name)))
+		}
+	}
+	if table.Comment != "" {
+		statements = append(statements, fmt.Sprintf("COMMENT ON TABLE %s IS %s", name, QuoteString(table.Comment)))
+	}
+	for _, field := range fields {
+		if field.Comment != "" {
+			statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", name, d.QuoteIdent(field.Name), QuoteString(field.Comment)))
+		}
+	}
+	return statements
+}
+
+// UniqueConstraint returns the name of the constraint Oracle tables get for
+// the unique column of tableName
+func UniqueConstraint(tableName, column string) string {
+	return "UK_" + tableName + "_" + column
+}
+
+// oracleActions reports whether Oracle supports action, which it only does
+// for ON DELETE CASCADE and ON DELETE SET NULL
+func oracleActions(event string, action schema.ReferentialAction) bool {
+	return event == "DELETE" && (action == schema.Cascade || action == schema.SetNull)
+}
+
+// CreateIndex builds a PL/SQL block creating index, which ignores the errors
+// raised when the index or an index on the same columns already exists
+func (d oracleDialect) CreateIndex(tableName string, index schema.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	create := fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, d.QuoteIdent(index.Name), d.QuoteIdent(tableName), quoteAll(d.QuoteIdent, index.Columns))
+	return fmt.Sprintf("BEGIN EXECUTE IMMEDIATE %s; EXCEPTION WHEN OTHERS THEN IF SQLCODE NOT IN (-955, -1408) THEN RAISE; END IF; END;", QuoteString(create))
+}
+
+func (d oracleDialect) CreateIndexes(table schema.TableSchema) []string {
+	return createIndexes(d, table)
+}
+
+func (d oracleDialect) RenderDDL(sch *schema.Schema) string { return render(d, sch) }
@@ -0,0 +1,132 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// postgresDialect renders DDL for PostgreSQL and the databases speaking its
+// dialect, which differ in their column types
+type postgresDialect struct {
+	name string
+	// types maps upper case schema data types to column types
+	types map[string]string
+	// text is the type of other data types and of the implicit id column
+	text string
+}
+
+// Postgres renders DDL for PostgreSQL
+var Postgres Dialect = postgresDialect{
+	name: "postgres",
+	types: map[string]string{
+		"TEXT": "TEXT", "VARCHAR": "TEXT", "CHAR": "TEXT",
+		"INTEGER": "INTEGER", "INT": "INTEGER",
+		"REAL": "REAL", "FLOAT": "REAL",
+		"BLOB":     "BYTEA",
+		"BOOLEAN":  "BOOLEAN",
+		"JSON":     "JSONB",
+		"DATETIME": "TIMESTAMP", "TIMESTAMP": "TIMESTAMP",
+		"DATE": "DATE",
+		"TIME": "TIME",
+		"UUID": "UUID",
+	},
+	text: "TEXT",
+}
+
+// Yugabyte renders DDL for YugabyteDB, which maps types as PostgreSQL does
+var Yugabyte Dialect = postgresDialect{
+	name:  "yugabyte",
+	types: Postgres.(postgresDialect).types,
+	text:  "TEXT",
+}
+
+// Cockroach renders DDL for CockroachDB
+var Cockroach Dialect = postgresDialect{
+	name: "cockroach",
+	types: map[string]string{
+		"TEXT": "STRING", "VARCHAR": "STRING", "CHAR": "STRING",
+		"INTEGER": "INT8", "INT": "INT8",
+		"REAL": "FLOAT8", "FLOAT": "FLOAT8",
+		"BLOB":     "BYTES",
+		"BOOLEAN":  "BOOL",
+		"JSON":     "JSONB",
+		"DATETIME": "TIMESTAMPTZ", "TIMESTAMP": "TIMESTAMPTZ",
+		"DATE": "DATE",
+		"TIME": "TIME",
+		"UUID": "UUID",
+	},
+	text: "STRING",
+}
+
+func (d postgresDialect) Name() string { return d.name }
+
+func (d postgresDialect) MapDataType(dataType string) string {
+	if mapped, ok := d.types[strings.ToUpper(dataType)]; ok {
+		return mapped
+	}
+	return d.text
+}
+
+func (d postgresDialect) ColumnType(_ schema.TableSchema, field schema.ColumnData) string {
+	return d.MapDataType(field.DataType)
+}
+
+// QuoteIdent quotes name in double quotes, lower case as PostgreSQL folds
+// unquoted names
+func (postgresDialect) QuoteIdent(name string) string {
+	return quoteIdent(PostgresIdent(name), `"`, `"`)
+}
+
+// PostgresIdent returns name as PostgreSQL stores the identifier QuoteIdent
+// renders, for APIs such as COPY that take identifiers unquoted
+func PostgresIdent(name string) string {
+	return foldASCII(name, false)
+}
+
+func (postgresDialect) Literal(value any) string { return literal(value, "TRUE", "FALSE", QuoteString) }
+
+func (d postgresDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	return standardDefinition(d, d.ColumnType(table, field), field)
+}
+
+func (d postgresDialect) CreateTable(table schema.TableSchema) []string {
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+	name := d.QuoteIdent(table.TableName)
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, d.QuoteIdent("id")+" "+d.text+" PRIMARY KEY")
+	}
+	fields := columns(table)
+	for _, field := range fields {
+		definitions = append(definitions, d.ColumnDefinition(table, field))
+	}
+	if declared {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(d.QuoteIdent, table.PrimaryKeyColumns())))
+	}
+	definitions = append(definitions, foreignKeys(table, d.QuoteIdent, allActions)...)
+
+	statements := []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", name, strings.Join(definitions, ", "))}
+	if table.Comment != "" {
+		statements = append(statements, fmt.Sprintf("COMMENT ON TABLE %s IS %s", name, QuoteString(table.Comment)))
+	}
+	for _, field := range fields {
+		if field.Comment != "" {
+			statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", name, d.QuoteIdent(field.Name), QuoteString(field.Comment)))
+		}
+	}
+	return statements
+}
+
+func (d postgresDialect) CreateIndex(tableName string, index schema.Index) string {
+	return StandardIndex(d.QuoteIdent, tableName, index)
+}
+
+func (d postgresDialect) CreateIndexes(table schema.TableSchema) []string {
+	return createIndexes(d, table)
+}
+
+func (d postgresDialect) RenderDDL(sch *schema.Schema) string { return render(d, sch) }
@@ -0,0 +1,147 @@
+package dialect
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// Scylla renders CQL for ScyllaDB and Cassandra with unqualified table names.
+// CQL has no foreign keys, so they are left out; the scylla backend does not
+// enforce them either.
+var Scylla = ScyllaKeyspace("")
+
+// ScyllaKeyspace renders CQL for tables in keyspace, starting RenderDDL with
+// the statement creating the keyspace
+func ScyllaKeyspace(keyspace string) Dialect {
+	return scyllaDialect{keyspace: keyspace}
+}
+
+type scyllaDialect struct {
+	keyspace string
+}
+
+// CreateKeyspace returns the statement creating keyspace if it does not exist
+func CreateKeyspace(keyspace string) string {
+	return fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 3}", QuoteCQL(keyspace))
+}
+
+// QuoteCQL quotes name in double quotes, lower case as CQL folds unquoted
+// names
+func QuoteCQL(name string) string {
+	return quoteIdent(foldASCII(name, false), `"`, `"`)
+}
+
+func (scyllaDialect) Name() string { return "scylla" }
+
+func (scyllaDialect) MapDataType(dataType string) string {
+	switch strings.ToUpper(dataType) {
+	case "TEXT", "VARCHAR", "CHAR":
+		return "text"
+	case "INTEGER", "INT":
+		return "bigint"
+	case "REAL", "FLOAT":
+		return "double"
+	case "BLOB":
+		return "blob"
+	case "BOOLEAN":
+		return "boolean"
+	case "JSON":
+		return "text" // ScyllaDB doesn't have native JSON type, store as text
+	case "DATETIME", "TIMESTAMP":
+		return "timestamp"
+	case "DATE":
+		return "date"
+	case "TIME":
+		return "time"
+	case "UUID":
+		return "uuid"
+	default:
+		return "text"
+	}
+}
+
+func (d scyllaDialect) ColumnType(_ schema.TableSchema, field schema.ColumnData) string {
+	return d.MapDataType(field.DataType)
+}
+
+func (scyllaDialect) QuoteIdent(name string) string { return QuoteCQL(name) }
+
+func (scyllaDialect) Literal(value any) string { return literal(value, "true", "false", QuoteString) }
+
+// ColumnDefinition renders field as "name type". CQL columns have no
+// nullability, defaults or constraints.
+func (d scyllaDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	return d.QuoteIdent(field.Name) + " " + d.ColumnType(table, field)
+}
+
+// qualify quotes tableName, prefixed with the dialect's keyspace, if any
+func (d scyllaDialect) qualify(tableName string) string {
+	if d.keyspace == "" {
+		return d.QuoteIdent(tableName)
+	}
+	return d.QuoteIdent(d.keyspace) + "." + d.QuoteIdent(tableName)
+}
+
+func (d scyllaDialect) CreateTable(table schema.TableSchema) []string {
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, d.QuoteIdent("id")+" text PRIMARY KEY")
+	}
+	for _, field := range columns(table) {
+		definitions = append(definitions, d.ColumnDefinition(table, field))
+	}
+	if declared {
+		// The first key column is the partition key, the rest are clustering columns
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(d.QuoteIdent, table.PrimaryKeyColumns())))
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.qualify(table.TableName), strings.Join(definitions, ", "))
+	if table.Comment != "" {
+		statement += " WITH comment = " + QuoteString(table.Comment)
+	}
+	return []string{statement}
+}
+
+// CreateIndex creates a secondary index on index's first column. CQL
+// indexes cover a single column, which CreateIndexes accounts for.
+func (d scyllaDialect) CreateIndex(tableName string, index schema.Index) string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", d.QuoteIdent(index.Name), d.qualify(tableName), d.QuoteIdent(index.Columns[0]))
+}
+
+// CreateIndexes creates a secondary index per indexed column of table. A
+// multi-column index becomes one index per column, named after the index and
+// the column, and uniqueness cannot be enforced. Key columns need no index.
+func (d scyllaDialect) CreateIndexes(table schema.TableSchema) []string {
+	keyColumns := table.PrimaryKeyColumns()
+	indexed := make(map[string]bool)
+	var statements []string
+	for _, index := range table.SecondaryIndexes() {
+		for _, column := range index.Columns {
+			if indexed[column] || slices.Contains(keyColumns, column) {
+				continue
+			}
+			indexed[column] = true
+
+			name := index.Name
+			if len(index.Columns) > 1 {
+				name += "_" + column
+			}
+			statements = append(statements, d.CreateIndex(table.TableName, schema.Index{Name: name, Columns: []string{column}}))
+		}
+	}
+	return statements
+}
+
+func (d scyllaDialect) RenderDDL(sch *schema.Schema) string {
+	ddl := render(d, sch)
+	if d.keyspace == "" {
+		return ddl
+	}
+	return CreateKeyspace(d.keyspace) + ";\n" + ddl
+}
@@ -0,0 +1,88 @@
+package dialect
+
+import (
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// SQLite renders DDL for SQLite, which keeps declared column types as they
+// are. Comments are kept as /* */ comments in the table's stored definition.
+// SQLite enforces foreign keys only on connections enabling them, with
+// PRAGMA foreign_keys = ON or the _foreign_keys=on DSN parameter.
+var SQLite Dialect = sqliteDialect{}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) MapDataType(dataType string) string { return dataType }
+
+func (sqliteDialect) ColumnType(_ schema.TableSchema, field schema.ColumnData) string {
+	return field.DataType
+}
+
+// QuoteIdent quotes name in double quotes. SQLite compares names case
+// insensitively whether they are quoted or not.
+func (sqliteDialect) QuoteIdent(name string) string { return quoteIdent(name, `"`, `"`) }
+
+func (sqliteDialect) Literal(value any) string { return literal(value, "1", "0", QuoteString) }
+
+func (d sqliteDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	definition := standardDefinition(d, d.ColumnType(table, field), field)
+	if field.Comment != "" {
+		definition += " " + blockComment(field.Comment)
+	}
+	return definition
+}
+
+func (d sqliteDialect) CreateTable(table schema.TableSchema) []string {
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+	keyColumns := table.PrimaryKeyColumns()
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, d.QuoteIdent("id")+" TEXT PRIMARY KEY")
+	}
+	for _, field := range columns(table) {
+		// SQLite only allows AUTOINCREMENT on a single-column INTEGER PRIMARY KEY,
+		// so such a key is INTEGER whatever type another database declared
+		key := declared && len(keyColumns) == 1 && field.Name == keyColumns[0]
+		columnType := d.ColumnType(table, field)
+		if key && field.AutoIncrement {
+			columnType = "INTEGER"
+		}
+		definition := standardDefinition(d, columnType, field)
+		if key {
+			definition += " PRIMARY KEY"
+			if field.AutoIncrement {
+				definition += " AUTOINCREMENT"
+			}
+		}
+		if field.Comment != "" {
+			definition += " " + blockComment(field.Comment)
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(keyColumns) > 1 {
+		definitions = append(definitions, "PRIMARY KEY ("+quoteAll(d.QuoteIdent, keyColumns)+")")
+	}
+	definitions = append(definitions, foreignKeys(table, d.QuoteIdent, allActions)...)
+
+	comment := ""
+	if table.Comment != "" {
+		comment = blockComment(table.Comment) + " "
+	}
+	return []string{"CREATE TABLE IF NOT EXISTS " + d.QuoteIdent(table.TableName) + " (" + comment + strings.Join(definitions, ", ") + ")"}
+}
+
+func (d sqliteDialect) CreateIndex(tableName string, index schema.Index) string {
+	return StandardIndex(d.QuoteIdent, tableName, index)
+}
+
+func (d sqliteDialect) CreateIndexes(table schema.TableSchema) []string {
+	return createIndexes(d, table)
+}
+
+func (d sqliteDialect) RenderDDL(sch *schema.Schema) string { return render(d, sch) }
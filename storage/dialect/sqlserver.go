@@ -0,0 +1,128 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// SQLServer renders DDL for SQL Server. Names are bracketed, and as SQL
+// Server has no IF NOT EXISTS for tables and indexes, each CREATE is guarded
+// by a catalog lookup. Comments are stored as MS_Description properties.
+var SQLServer Dialect = sqlServerDialect{}
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string { return "sqlserver" }
+
+func (sqlServerDialect) MapDataType(dataType string) string {
+	switch strings.ToUpper(dataType) {
+	case "TEXT", "VARCHAR", "CHAR":
+		return "NVARCHAR(MAX)"
+	case "INTEGER", "INT":
+		return "BIGINT"
+	case "REAL", "FLOAT":
+		return "FLOAT"
+	case "BLOB":
+		return "VARBINARY(MAX)"
+	case "BOOLEAN":
+		return "BIT"
+	case "JSON":
+		return "NVARCHAR(MAX)" // SQL Server 2016+ has JSON support but we'll use NVARCHAR for compatibility
+	case "DATETIME", "TIMESTAMP":
+		return "DATETIME2"
+	case "DATE":
+		return "DATE"
+	case "TIME":
+		return "TIME"
+	case "UUID":
+		return "UNIQUEIDENTIFIER"
+	default:
+		return "NVARCHAR(MAX)"
+	}
+}
+
+func (d sqlServerDialect) ColumnType(table schema.TableSchema, field schema.ColumnData) string {
+	dataType := d.MapDataType(field.DataType)
+	if dataType == "NVARCHAR(MAX)" && keyable(table, field.Name) {
+		dataType = "NVARCHAR(255)" // MAX columns cannot be part of an index key
+	}
+	return dataType
+}
+
+func (sqlServerDialect) QuoteIdent(name string) string { return Bracket(name) }
+
+func (sqlServerDialect) Literal(value any) string { return literal(value, "1", "0", QuoteString) }
+
+func (d sqlServerDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	return standardDefinition(d, d.ColumnType(table, field), field)
+}
+
+func (d sqlServerDialect) CreateTable(table schema.TableSchema) []string {
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, "[id] NVARCHAR(255) PRIMARY KEY")
+	}
+	fields := columns(table)
+	for _, field := range fields {
+		definitions = append(definitions, d.ColumnDefinition(table, field))
+	}
+	if declared {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(Bracket, table.PrimaryKeyColumns())))
+	}
+	definitions = append(definitions, foreignKeys(table, Bracket, sqlServerActions)...)
+
+	statements := []string{fmt.Sprintf("IF NOT EXISTS (SELECT * FROM sysobjects WHERE name=%s AND xtype='U') CREATE TABLE %s (%s)",
+		QuoteString(table.TableName), Bracket(table.TableName), strings.Join(definitions, ", "))}
+	if table.Comment != "" {
+		statements = append(statements, describe(table.TableName, "", table.Comment))
+	}
+	for _, field := range fields {
+		if field.Comment != "" {
+			statements = append(statements, describe(table.TableName, field.Name, field.Comment))
+		}
+	}
+	return statements
+}
+
+// sqlServerActions reports whether SQL Server supports action: all but
+// RESTRICT, which its default NO ACTION behaves like
+func sqlServerActions(_ string, action schema.ReferentialAction) bool {
+	return action != schema.Restrict
+}
+
+// describe adds an MS_Description property holding comment to tableName,
+// or to its column if column is set, unless the property already exists
+func describe(tableName, column, comment string) string {
+	level2Type, level2Name := "NULL", "NULL"
+	if column != "" {
+		level2Type, level2Name = "'COLUMN'", QuoteString(column)
+	}
+	return fmt.Sprintf("IF NOT EXISTS (SELECT * FROM sys.fn_listextendedproperty('MS_Description', 'SCHEMA', 'dbo', 'TABLE', %[1]s, %[2]s, %[3]s)) "+
+		"EXEC sp_addextendedproperty 'MS_Description', %[4]s, 'SCHEMA', 'dbo', 'TABLE', %[1]s, %[2]s, %[3]s",
+		QuoteString(tableName), level2Type, level2Name, QuoteString(comment))
+}
+
+// CreateIndex builds a CREATE INDEX statement guarded by a sys.indexes
+// lookup, as SQL Server has no CREATE INDEX IF NOT EXISTS
+func (sqlServerDialect) CreateIndex(tableName string, index schema.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name=%s AND object_id=OBJECT_ID(%s)) CREATE %sINDEX %s ON %s (%s)",
+		QuoteString(index.Name), QuoteString(Bracket(tableName)), unique, Bracket(index.Name), Bracket(tableName), quoteAll(Bracket, index.Columns))
+}
+
+func (d sqlServerDialect) CreateIndexes(table schema.TableSchema) []string {
+	return createIndexes(d, table)
+}
+
+func (d sqlServerDialect) RenderDDL(sch *schema.Schema) string { return render(d, sch) }
+
+// Bracket quotes name as a SQL Server identifier
+func Bracket(name string) string { return quoteIdent(name, "[", "]") }
@@ -0,0 +1,109 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// TiDB renders DDL for TiDB and MySQL. Comments are attached to the table
+// and its columns with COMMENT clauses.
+var TiDB Dialect = tidbDialect{}
+
+type tidbDialect struct{}
+
+func (tidbDialect) Name() string { return "tidb" }
+
+func (tidbDialect) MapDataType(dataType string) string {
+	switch strings.ToUpper(dataType) {
+	case "TEXT":
+		return "TEXT"
+	case "VARCHAR", "CHAR":
+		return "VARCHAR(255)"
+	case "INTEGER", "INT":
+		return "BIGINT"
+	case "REAL", "FLOAT":
+		return "DOUBLE"
+	case "BLOB":
+		return "BLOB"
+	case "BOOLEAN":
+		return "BOOLEAN"
+	case "JSON":
+		return "JSON"
+	case "DATETIME", "TIMESTAMP":
+		return "TIMESTAMP"
+	case "DATE":
+		return "DATE"
+	case "TIME":
+		return "TIME"
+	case "UUID":
+		return "VARCHAR(36)"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+func (d tidbDialect) ColumnType(table schema.TableSchema, field schema.ColumnData) string {
+	dataType := d.MapDataType(field.DataType)
+	if dataType == "TEXT" && keyable(table, field.Name) {
+		dataType = "VARCHAR(255)" // TEXT columns cannot be keyed or indexed without a prefix length
+	}
+	return dataType
+}
+
+// quoteMySQL renders s as a string literal, escaping backslashes too, as
+// MySQL treats them as escape characters inside strings
+func quoteMySQL(s string) string {
+	return QuoteString(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+// QuoteIdent quotes name in backticks
+func (tidbDialect) QuoteIdent(name string) string { return quoteIdent(name, "`", "`") }
+
+func (tidbDialect) Literal(value any) string { return literal(value, "TRUE", "FALSE", quoteMySQL) }
+
+func (d tidbDialect) ColumnDefinition(table schema.TableSchema, field schema.ColumnData) string {
+	definition := standardDefinition(d, d.ColumnType(table, field), field)
+	if field.Comment != "" {
+		definition += " COMMENT " + quoteMySQL(field.Comment)
+	}
+	return definition
+}
+
+func (d tidbDialect) CreateTable(table schema.TableSchema) []string {
+	// Tables that do not declare a primary key are keyed on an implicit id column
+	declared := table.HasDeclaredPrimaryKey()
+
+	definitions := make([]string, 0, len(table.Fields)+1)
+	if !declared {
+		definitions = append(definitions, d.QuoteIdent("id")+" VARCHAR(255) PRIMARY KEY")
+	}
+	for _, field := range columns(table) {
+		definitions = append(definitions, d.ColumnDefinition(table, field))
+	}
+	if declared {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(d.QuoteIdent, table.PrimaryKeyColumns())))
+	}
+	definitions = append(definitions, foreignKeys(table, d.QuoteIdent, mysqlActions)...)
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.QuoteIdent(table.TableName), strings.Join(definitions, ", "))
+	if table.Comment != "" {
+		query += " COMMENT=" + quoteMySQL(table.Comment)
+	}
+	return []string{query}
+}
+
+// mysqlActions reports whether InnoDB supports action; it parses SET DEFAULT
+// but rejects the tables using it
+func mysqlActions(_ string, action schema.ReferentialAction) bool {
+	return action != schema.SetDefault
+}
+
+func (d tidbDialect) CreateIndex(tableName string, index schema.Index) string {
+	return StandardIndex(d.QuoteIdent, tableName, index)
+}
+
+func (d tidbDialect) CreateIndexes(table schema.TableSchema) []string { return createIndexes(d, table) }
+
+func (d tidbDialect) RenderDDL(sch *schema.Schema) string { return render(d, sch) }
@@ -0,0 +1,134 @@
+// Package errors defines the errors every storage backend reports, so callers
+// can test for them with errors.Is and errors.As whatever the database. Backends
+// translate driver-specific codes (SQLSTATEs, MySQL error numbers, ORA- codes,
+// SQL Server error numbers, gocql errors) into these; the driver error remains
+// reachable through Unwrap.
+//
+//	obj, err := store.FindByID(ctx, "users", id)
+//	if errors.Is(err, ddaoerrors.ErrNotFound) {
+//		...
+//	}
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned by FindByID and FindByKey when no object matches
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched by writes that violate a uniqueness constraint; see DuplicateKeyError
+	ErrConflict = errors.New("conflict")
+	// ErrNotConnected is returned by operations attempted before Connect or after ResetConnection
+	ErrNotConnected = errors.New("not connected")
+	// ErrNotSupported is matched by operations a backend cannot perform; see NotSupportedError
+	ErrNotSupported = errors.New("operation not supported")
+	// ErrSchemaMismatch is matched by errors naming tables or fields missing from the schema; see SchemaMismatchError
+	ErrSchemaMismatch = errors.New("schema mismatch")
+)
+
+// DuplicateKeyError reports a write that violated a primary key or unique
+// constraint. It matches ErrConflict.
+type DuplicateKeyError struct {
+	// Table is the table written to, when known
+	Table string
+	// Column names the violated column, or the columns of a composite key
+	// as KeyColumns joins them. Databases that only report the constraint or
+	// index (SQL Server, Oracle) leave its name here when it is not one of
+	// the schema's.
+	Column string
+	// Err is the driver error
+	Err error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("duplicate key")
+	if e.Column != "" {
+		sb.WriteString(" on ")
+		if e.Table != "" {
+			sb.WriteString(e.Table + ".")
+		}
+		sb.WriteString(e.Column)
+	} else if e.Table != "" {
+		sb.WriteString(" in table " + e.Table)
+	}
+	if e.Err != nil {
+		sb.WriteString(": " + e.Err.Error())
+	}
+	return sb.String()
+}
+
+// KeyColumns returns the DuplicateKeyError.Column of a key over columns: their
+// names joined by commas, so a composite key reads the same from every backend
+func KeyColumns(columns []string) string {
+	return strings.Join(columns, ",")
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// NotSupportedError reports an operation a backend cannot perform. It matches ErrNotSupported.
+type NotSupportedError struct {
+	Backend   string
+	Operation string
+}
+
+// NotSupported returns a NotSupportedError for operation on backend
+func NotSupported(backend, operation string) error {
+	return &NotSupportedError{Backend: backend, Operation: operation}
+}
+
+func (e *NotSupportedError) Error() string {
+	return fmt.Sprintf("%s does not support %s", e.Backend, e.Operation)
+}
+
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrNotSupported
+}
+
+// SchemaMismatchError reports a reference to a table, or a field of a table,
+// that the schema does not define, or that the database rejected as missing
+// although the schema defines it. It matches ErrSchemaMismatch.
+type SchemaMismatchError struct {
+	Table string
+	// Field is empty when the table itself is missing
+	Field string
+	// Err is the driver error when the database reported the mismatch
+	Err error
+}
+
+// UnknownTable returns a SchemaMismatchError for a table missing from the schema
+func UnknownTable(table string) error {
+	return &SchemaMismatchError{Table: table}
+}
+
+// UnknownField returns a SchemaMismatchError for a field missing from table
+func UnknownField(table, field string) error {
+	return &SchemaMismatchError{Table: table, Field: field}
+}
+
+func (e *SchemaMismatchError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("schema mismatch on table %s: %v", e.Table, e.Err)
+	}
+	if e.Field == "" {
+		return fmt.Sprintf("table %s not found in schema", e.Table)
+	}
+	return fmt.Sprintf("field %s not found in table %s schema", e.Field, e.Table)
+}
+
+func (e *SchemaMismatchError) Is(target error) bool {
+	return target == ErrSchemaMismatch
+}
+
+func (e *SchemaMismatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDuplicateKeyError(t *testing.T) {
+	driverErr := errors.New("UNIQUE constraint failed: people.email")
+	err := fmt.Errorf("insert: %w", &DuplicateKeyError{Table: "people", Column: "email", Err: driverErr})
+
+	if !errors.Is(err, ErrConflict) {
+		t.Error("DuplicateKeyError should match ErrConflict")
+	}
+	if !errors.Is(err, driverErr) {
+		t.Error("DuplicateKeyError should unwrap to the driver error")
+	}
+
+	var dup *DuplicateKeyError
+	if !errors.As(err, &dup) || dup.Column != "email" {
+		t.Errorf("expected DuplicateKeyError on email, got %v", err)
+	}
+
+	tests := []struct {
+		err  *DuplicateKeyError
+		want string
+	}{
+		{&DuplicateKeyError{Table: "people", Column: "email", Err: driverErr}, "duplicate key on people.email: UNIQUE constraint failed: people.email"},
+		{&DuplicateKeyError{Column: "people_email_key"}, "duplicate key on people_email_key"},
+		{&DuplicateKeyError{Table: "people"}, "duplicate key in table people"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	err := NotSupported("S3", "ordered listing")
+	if !errors.Is(err, ErrNotSupported) {
+		t.Error("NotSupportedError should match ErrNotSupported")
+	}
+	if got, want := err.Error(), "S3 does not support ordered listing"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestSchemaMismatchError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{UnknownTable("people"), "table people not found in schema"},
+		{UnknownField("people", "age"), "field age not found in table people schema"},
+		{&SchemaMismatchError{Table: "people", Err: errors.New("no such table: people")}, "schema mismatch on table people: no such table: people"},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrSchemaMismatch) {
+			t.Errorf("%v should match ErrSchemaMismatch", tt.err)
+		}
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
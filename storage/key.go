@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+)
+
+// ParseID decodes id, as produced by object.Key.String, into tbl's primary
+// key. Parts for declared integer, float, boolean, time and binary key columns
+// are converted to the column's type; the implicit "id" column is text.
+func ParseID(tbl schema.TableSchema, id string) (object.Key, error) {
+	key, err := object.ParseKey(tbl.PrimaryKeyColumns(), id)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", tbl.TableName, err)
+	}
+	if !tbl.HasDeclaredPrimaryKey() {
+		return key, nil
+	}
+
+	for i, part := range key {
+		field, ok := tbl.Fields[part.Column]
+		if !ok {
+			continue
+		}
+		s := part.Value.(string)
+		switch dataType := typeName(field.DataType); {
+		case IsIntegerType(dataType):
+			key[i].Value, err = strconv.ParseInt(s, 10, 64)
+		case dataType == "REAL", dataType == "FLOAT", dataType == "DOUBLE":
+			key[i].Value, err = strconv.ParseFloat(s, 64)
+		case dataType == "BOOLEAN", dataType == "BOOL":
+			key[i].Value, err = strconv.ParseBool(s)
+		case isTimeType(dataType):
+			key[i].Value, err = time.Parse(time.RFC3339Nano, s)
+		case isBinaryType(dataType):
+			key[i].Value, err = base64.RawURLEncoding.DecodeString(s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q for table %s: column %s: %w", id, tbl.TableName, part.Column, err)
+		}
+	}
+	return key, nil
+}
+
+// IsIntegerType reports whether dataType is one of the integer types the
+// backends store as int64, in any case and with any display width, as in
+// bigint or INT(11)
+func IsIntegerType(dataType string) bool {
+	switch typeName(dataType) {
+	case "INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT",
+		"INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL", "SMALLSERIAL":
+		return true
+	}
+	return false
+}
+
+func isTimeType(dataType string) bool {
+	switch typeName(dataType) {
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ", "DATETIME2":
+		return true
+	}
+	return false
+}
+
+func isBinaryType(dataType string) bool {
+	switch typeName(dataType) {
+	case "BLOB", "BYTEA", "BYTES", "BINARY", "VARBINARY":
+		return true
+	}
+	return false
+}
+
+// typeName returns dataType upper-cased and without its display width
+func typeName(dataType string) string {
+	name := strings.ToUpper(strings.TrimSpace(dataType))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	return name
+}
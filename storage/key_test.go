@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDRoundTrip(t *testing.T) {
+	tbl := schema.NewTableSchema("readings")
+	tbl.AddField(schema.ColumnData{Name: "sensor", DataType: "int(11)", PrimaryKey: true})
+	tbl.AddField(schema.ColumnData{Name: "at", DataType: "timestamp", PrimaryKey: true})
+	tbl.AddField(schema.ColumnData{Name: "hash", DataType: "blob", PrimaryKey: true})
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	key := object.Key{
+		{Column: "sensor", Value: int64(7)},
+		{Column: "at", Value: at},
+		{Column: "hash", Value: []byte{0x00, 0xff, '/', '%'}},
+	}
+	parsed, err := ParseID(*tbl, key.String())
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseID(*tbl, "7/2024-03-01 12:30:00/AA")
+	assert.Error(t, err)
+	_, err = ParseID(*tbl, "7/2024-03-01T12:30:00Z/not+base64")
+	assert.Error(t, err)
+}
+
+func TestParseIDSingleColumn(t *testing.T) {
+	for _, dataType := range []string{"datetime", "TIMESTAMPTZ"} {
+		tbl := schema.NewTableSchema("events")
+		tbl.AddField(schema.ColumnData{Name: "at", DataType: dataType, PrimaryKey: true})
+
+		at := time.Date(2024, 3, 1, 12, 30, 0, 5, time.UTC)
+		parsed, err := ParseID(*tbl, object.Key{{Column: "at", Value: at}}.String())
+		require.NoError(t, err, dataType)
+		assert.True(t, at.Equal(parsed[0].Value.(time.Time)), dataType)
+	}
+
+	tbl := schema.NewTableSchema("files")
+	tbl.AddField(schema.ColumnData{Name: "digest", DataType: "bytea", PrimaryKey: true})
+	digest := []byte{0xfb, 0xff, 0x3e}
+	parsed, err := ParseID(*tbl, object.Key{{Column: "digest", Value: digest}}.String())
+	require.NoError(t, err)
+	assert.Equal(t, digest, parsed[0].Value)
+}
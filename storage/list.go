@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// DefaultListLimit is the page size used when ListOptions.Limit is not set
+const DefaultListLimit = 100
+
+// ListOptions controls a single page of Storage.List
+type ListOptions struct {
+	// Limit is the maximum number of objects returned; DefaultListLimit when zero
+	Limit int
+	// Cursor is the continuation token returned by the previous page; empty starts from the beginning
+	Cursor string
+	// OrderBy names the column to sort by, with a leading "-" for descending order.
+	// "id" stands for the table's primary key, whichever columns it has. Ties
+	// are broken by the primary key. Defaults to "id".
+	OrderBy string
+}
+
+// PageSize returns the effective page size for opts
+func (opts ListOptions) PageSize() int {
+	if opts.Limit <= 0 {
+		return DefaultListLimit
+	}
+	return opts.Limit
+}
+
+// Order returns the column to sort by and whether the order is descending
+func (opts ListOptions) Order() (string, bool) {
+	column := strings.TrimSpace(opts.OrderBy)
+	desc := strings.HasPrefix(column, "-")
+	column = strings.TrimPrefix(column, "-")
+	if column == "" {
+		column = "id"
+	}
+	return column, desc
+}
+
+// Validate checks that opts can be used to page through tbl
+func (opts ListOptions) Validate(tbl schema.TableSchema) error {
+	if opts.Limit < 0 {
+		return fmt.Errorf("list limit must not be negative, got %d", opts.Limit)
+	}
+
+	column, _ := opts.Order()
+	if column == "id" {
+		return nil
+	}
+
+	field, ok := tbl.Fields[column]
+	if !ok {
+		return ddaoerrors.UnknownField(tbl.TableName, column)
+	}
+	if field.Nullable {
+		// Keyset pagination cannot resume reliably across NULL values
+		return fmt.Errorf("cannot order by nullable field %s", column)
+	}
+	return nil
+}
+
+// Cursor is the decoded form of the opaque continuation token returned by List.
+// SQL backends resume after (Value, ID) in OrderBy order; backends with native
+// paging (ScyllaDB page state, S3 continuation tokens) carry it in State.
+type Cursor struct {
+	OrderBy string `json:"o,omitempty"`
+	Value   any    `json:"v,omitempty"`
+	ID      string `json:"id,omitempty"`
+	State   []byte `json:"s,omitempty"`
+}
+
+// Encode returns the opaque token form of c
+func (c Cursor) Encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// Cursor values come from scanned rows, which always marshal
+		panic(fmt.Sprintf("failed to encode cursor: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token returned by List for tbl. The cursor must have been
+// produced for the same OrderBy as opts, otherwise pages would overlap or skip rows.
+func DecodeCursor(opts ListOptions, tbl schema.TableSchema) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+	if err != nil {
+		return Cursor{}, errors.New("invalid list cursor")
+	}
+
+	var c Cursor
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil {
+		return Cursor{}, errors.New("invalid list cursor")
+	}
+
+	if c.OrderBy != normalizedOrder(opts) {
+		return Cursor{}, fmt.Errorf("list cursor was issued for order %q, not %q", c.OrderBy, normalizedOrder(opts))
+	}
+
+	// JSON loses the sort value's type; restore it from the column definition
+	if number, ok := c.Value.(json.Number); ok {
+		column, _ := opts.Order()
+		if IsIntegerType(tbl.Fields[column].DataType) {
+			if c.Value, err = number.Int64(); err != nil {
+				return Cursor{}, errors.New("invalid list cursor")
+			}
+		} else if c.Value, err = number.Float64(); err != nil {
+			return Cursor{}, errors.New("invalid list cursor")
+		}
+	}
+	return c, nil
+}
+
+// NewCursor returns a cursor that resumes listing after a row with the given sort value and id
+func NewCursor(opts ListOptions, value any, id string) Cursor {
+	return Cursor{OrderBy: normalizedOrder(opts), Value: value, ID: id}
+}
+
+// NewStateCursor returns a cursor that resumes listing from a backend paging state
+func NewStateCursor(opts ListOptions, state []byte) Cursor {
+	return Cursor{OrderBy: normalizedOrder(opts), State: state}
+}
+
+// After returns the query matching rows of tbl that sort strictly after c under opts
+func (c Cursor) After(opts ListOptions, tbl schema.TableSchema) (Query, error) {
+	column, desc := opts.Order()
+	cmp := Gt
+	if desc {
+		cmp = Lt
+	}
+
+	key, err := ParseID(tbl, c.ID)
+	if err != nil {
+		return Query{}, errors.New("invalid list cursor")
+	}
+	// Keys compare column by column: later columns only break ties in earlier ones
+	after := cmp(key[len(key)-1].Column, key[len(key)-1].Value)
+	for i := len(key) - 2; i >= 0; i-- {
+		after = Or(
+			cmp(key[i].Column, key[i].Value),
+			And(Eq(key[i].Column, key[i].Value), after),
+		)
+	}
+
+	if column == "id" {
+		return after, nil
+	}
+	return Or(
+		cmp(column, c.Value),
+		And(Eq(column, c.Value), after),
+	), nil
+}
+
+func normalizedOrder(opts ListOptions) string {
+	column, desc := opts.Order()
+	if desc {
+		return "-" + column
+	}
+	return column
+}
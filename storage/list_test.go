@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listTestTable() schema.TableSchema {
+	tbl := schema.NewTableSchema("events")
+	tbl.AddField(schema.ColumnData{Name: "id", DataType: "text"})
+	tbl.AddField(schema.ColumnData{Name: "seq", DataType: "integer"})
+	tbl.AddField(schema.ColumnData{Name: "score", DataType: "real"})
+	tbl.AddField(schema.ColumnData{Name: "note", DataType: "text", Nullable: true})
+	return *tbl
+}
+
+func TestListOptionsDefaults(t *testing.T) {
+	opts := ListOptions{}
+	assert.Equal(t, DefaultListLimit, opts.PageSize())
+
+	column, desc := opts.Order()
+	assert.Equal(t, "id", column)
+	assert.False(t, desc)
+
+	column, desc = ListOptions{OrderBy: "-seq"}.Order()
+	assert.Equal(t, "seq", column)
+	assert.True(t, desc)
+}
+
+func TestListOptionsValidate(t *testing.T) {
+	tbl := listTestTable()
+
+	assert.NoError(t, ListOptions{}.Validate(tbl))
+	assert.NoError(t, ListOptions{OrderBy: "-seq", Limit: 10}.Validate(tbl))
+	assert.Error(t, ListOptions{Limit: -1}.Validate(tbl))
+	assert.Error(t, ListOptions{OrderBy: "missing"}.Validate(tbl))
+	assert.Error(t, ListOptions{OrderBy: "note"}.Validate(tbl))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tbl := listTestTable()
+
+	opts := ListOptions{OrderBy: "seq"}
+	opts.Cursor = NewCursor(opts, int64(1<<60+1), "evt-7").Encode()
+
+	cursor, err := DecodeCursor(opts, tbl)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<60+1), cursor.Value)
+	assert.Equal(t, "evt-7", cursor.ID)
+
+	opts = ListOptions{OrderBy: "-score"}
+	opts.Cursor = NewCursor(opts, 2.5, "evt-8").Encode()
+
+	cursor, err = DecodeCursor(opts, tbl)
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, cursor.Value)
+
+	opts = ListOptions{}
+	opts.Cursor = NewStateCursor(opts, []byte{0, 1, 2}).Encode()
+
+	cursor, err = DecodeCursor(opts, tbl)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2}, cursor.State)
+}
+
+func TestCursorRoundTripIntegerTypes(t *testing.T) {
+	// Above 2^53 a float64 would round the key to its even neighbour
+	const big = int64(1<<53 + 1)
+	for _, dataType := range []string{"BIGINT", "bigint", "SMALLINT", "int8", "INT(11)", "bigserial"} {
+		tbl := schema.NewTableSchema("events")
+		tbl.AddField(schema.ColumnData{Name: "id", DataType: "text"})
+		tbl.AddField(schema.ColumnData{Name: "seq", DataType: dataType})
+
+		opts := ListOptions{OrderBy: "-seq"}
+		opts.Cursor = NewCursor(opts, big, "evt-9").Encode()
+
+		cursor, err := DecodeCursor(opts, *tbl)
+		require.NoError(t, err, dataType)
+		assert.Equal(t, big, cursor.Value, dataType)
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	tbl := listTestTable()
+
+	_, err := DecodeCursor(ListOptions{Cursor: "%%%"}, tbl)
+	assert.Error(t, err)
+
+	_, err = DecodeCursor(ListOptions{Cursor: "bm90IGpzb24"}, tbl)
+	assert.Error(t, err)
+
+	// A cursor cannot be replayed under a different ordering
+	opts := ListOptions{OrderBy: "seq"}
+	token := NewCursor(opts, int64(3), "evt-3").Encode()
+	_, err = DecodeCursor(ListOptions{OrderBy: "-seq", Cursor: token}, tbl)
+	assert.Error(t, err)
+}
+
+func TestCursorAfter(t *testing.T) {
+	tbl := listTestTable()
+	after := func(c Cursor, opts ListOptions) Query {
+		q, err := c.After(opts, tbl)
+		require.NoError(t, err)
+		return q
+	}
+
+	assert.Equal(t, Gt("id", "b"), after(Cursor{ID: "b"}, ListOptions{}))
+	assert.Equal(t, Lt("id", "b"), after(Cursor{ID: "b"}, ListOptions{OrderBy: "-id"}))
+	assert.Equal(t,
+		Or(Gt("seq", int64(4)), And(Eq("seq", int64(4)), Gt("id", "b"))),
+		after(Cursor{Value: int64(4), ID: "b"}, ListOptions{OrderBy: "seq"}),
+	)
+}
+
+func TestCursorAfterCompositeKey(t *testing.T) {
+	tbl := *schema.NewTableSchema("stock")
+	tbl.AddField(schema.ColumnData{Name: "region", DataType: "text", PrimaryKey: true})
+	tbl.AddField(schema.ColumnData{Name: "sku", DataType: "integer", PrimaryKey: true})
+
+	q, err := Cursor{ID: "eu/7"}.After(ListOptions{}, tbl)
+	require.NoError(t, err)
+	assert.Equal(t, Or(Gt("region", "eu"), And(Eq("region", "eu"), Gt("sku", int64(7)))), q)
+
+	_, err = Cursor{ID: "eu"}.After(ListOptions{}, tbl)
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// InsertMany inserts objs in one commit, failing the objects whose ID already
+// exists with a DuplicateKeyError. The other objects are inserted. opts is
+// ignored, as there are no round trips to bound.
+func (s *MemoryStorage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.putMany(ctx, objs, false)
+}
+
+// UpsertMany inserts or overwrites objs in one commit
+func (s *MemoryStorage) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.putMany(ctx, objs, true)
+}
+
+func (s *MemoryStorage) putMany(ctx context.Context, objs []*object.Object, upsert bool) (*storage.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewObjectsResult(objs)
+	tx := s.autocommit()
+	for i, obj := range objs {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(i, err)
+			break
+		}
+		_, _, result.Items[i].Err = tx.put(obj, upsert)
+	}
+	tx.apply()
+	return result, ctx.Err()
+}
+
+// DeleteMany deletes ids from tblName in one commit
+func (s *MemoryStorage) DeleteMany(ctx context.Context, tblName string, ids []string, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewIDsResult(tblName, ids)
+	tx := s.autocommit()
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(i, err)
+			break
+		}
+		deleted, err := tx.deleteByID(tblName, id)
+		if err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+		if deleted {
+			result.Deleted++
+		}
+	}
+	tx.apply()
+	return result, ctx.Err()
+}
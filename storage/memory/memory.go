@@ -0,0 +1,510 @@
+// Package memory is a storage backend keeping its tables in memory, for unit
+// tests and programs embedding their data. It needs no database, cgo or
+// network, and enforces the schema as a SQL database would: unknown tables
+// and fields, NULLs in columns that are not nullable, and repeated primary
+// or unique keys are rejected, values are converted to their columns'
+// types, and omitted columns take their defaults.
+//
+// Every row keeps the versions recent commits left, so a transaction reads
+// the tables as they were when it began, whatever commits after it. A
+// transaction writing a row that another committed since it began fails to
+// commit with ErrWriteConflict, writing nothing: first committer wins, as
+// in snapshot isolation.
+//
+// Connect takes "mem:", for tables that last as long as the connection, or
+// "mem:" followed by a file path, to load the tables from that file if it
+// exists and save them back to it on ResetConnection and Save:
+//
+//	store, err := ddao.Open(ctx, "mem:")
+//	store, err := ddao.Open(ctx, "mem:testdata/fixtures.json")
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// ErrWriteConflict is returned by Commit when another transaction committed
+// a change to a row the transaction writes after it began. Running the
+// transaction again succeeds unless it conflicts again; see IsRetryable.
+var ErrWriteConflict = errors.New("transaction conflicts with a concurrent commit")
+
+// MemoryStorage implements the DDAO storage interface in memory
+type MemoryStorage struct {
+	mu        sync.RWMutex
+	sch       *schema.Schema
+	tables    map[string]table
+	ts        uint64         // timestamp of the latest commit
+	conn      uint64         // counts Connect and ResetConnection calls, so transactions outlive no connection
+	snapshots map[uint64]int // open transactions, counted by the timestamp they read at
+	path      string         // file the tables are saved to, if any
+	connected bool
+}
+
+// table holds the versions of each row of a table, by ID
+type table map[string][]version
+
+// version is a row as the commit at ts left it, oldest first; obj is nil
+// once the row is deleted
+type version struct {
+	ts  uint64
+	obj *object.Object
+}
+
+func New() storage.Storage {
+	return &MemoryStorage{}
+}
+
+func init() {
+	storage.Register("mem", New)
+	storage.Register("memory", New)
+}
+
+// Connect starts with no tables, or with those saved in the file connStr
+// names after its scheme, if it exists
+// connStr format: "mem:" or "mem:path/to/file.json"
+func (s *MemoryStorage) Connect(ctx context.Context, connStr string) error {
+	path, ok := storage.TrimScheme(connStr, "mem", "memory")
+	if !ok && connStr != "" {
+		return fmt.Errorf("invalid connection string %q: expected mem:[path]", connStr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn++
+	s.sch = nil
+	s.tables = make(map[string]table)
+	s.ts = 0
+	s.snapshots = make(map[uint64]int)
+	s.path = path
+	if path != "" {
+		if err := s.loadFile(path); err != nil {
+			return err
+		}
+	}
+	s.connected = true
+	return nil
+}
+
+// GetSchema returns the schema given to CreateTables, or the one loaded by
+// Connect; nil when there is neither
+func (s *MemoryStorage) GetSchema() *schema.Schema {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sch
+}
+
+// CreateTables creates the schema's tables that do not exist yet, keeping
+// the rows of those that do. Foreign keys are stored with the schema but not
+// enforced. Defaults computed by an expression the backend cannot evaluate
+// are rejected.
+func (s *MemoryStorage) CreateTables(ctx context.Context, sch *schema.Schema) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ddaoerrors.ErrNotConnected
+	}
+	for _, name := range sch.TableNames() {
+		tbl, _ := sch.GetTable(name)
+		if err := checkDefaults(tbl); err != nil {
+			return err
+		}
+	}
+
+	s.sch = sch
+	for _, name := range sch.TableNames() {
+		if _, ok := s.tables[name]; !ok {
+			s.tables[name] = make(table)
+		}
+	}
+	return nil
+}
+
+// Insert creates obj, failing with a DuplicateKeyError if its ID already
+// exists. The fields obj leaves out are filled in with the values stored,
+// such as column defaults.
+func (s *MemoryStorage) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return s.put(obj, false)
+}
+
+// Upsert creates obj or overwrites the fields it sets in the existing object
+// with its ID. The fields obj leaves out are filled in with the values stored.
+func (s *MemoryStorage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return s.put(obj, true)
+}
+
+func (s *MemoryStorage) put(obj *object.Object, upsert bool) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, false, ddaoerrors.ErrNotConnected
+	}
+	tx := s.autocommit()
+	row, created, err := tx.put(obj, upsert)
+	if err != nil {
+		return nil, false, err
+	}
+	tx.apply()
+	fillIn(obj, row)
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal object: %w", err)
+	}
+	return data, created, nil
+}
+
+// Update overwrites the fields obj sets in the object with its ID, reporting
+// whether there was such an object
+func (s *MemoryStorage) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return false, ddaoerrors.ErrNotConnected
+	}
+	tx := s.autocommit()
+	updated, err := tx.update(obj)
+	if err != nil {
+		return false, err
+	}
+	tx.apply()
+	return updated, nil
+}
+
+func (s *MemoryStorage) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	return s.autocommit().findByID(tblName, id)
+}
+
+// FindByKey returns the first object, in primary key order, whose key field equals value
+func (s *MemoryStorage) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	return s.autocommit().findByKey(tblName, key, value)
+}
+
+// Find returns the objects of tblName matching q, in primary key order
+func (s *MemoryStorage) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	return s.autocommit().find(tblName, q)
+}
+
+// FindRows returns the objects of tblName matching q, in primary key order.
+// They are found when FindRows is called, so later writes do not affect them.
+func (s *MemoryStorage) FindRows(ctx context.Context, tblName string, q storage.Query) (storage.Rows, error) {
+	objs, err := s.Find(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+	return &memRows{objs: objs}, nil
+}
+
+// memRows iterates over objects already found
+type memRows struct {
+	objs    []*object.Object
+	current *object.Object
+}
+
+func (r *memRows) Next() bool {
+	if len(r.objs) == 0 {
+		r.current = nil
+		return false
+	}
+	r.current, r.objs = r.objs[0], r.objs[1:]
+	return true
+}
+
+func (r *memRows) Object() *object.Object {
+	return r.current
+}
+
+func (r *memRows) Err() error {
+	return nil
+}
+
+func (r *memRows) Close() error {
+	r.objs = nil
+	return nil
+}
+
+// List returns a page of objects from tblName sorted by opts.OrderBy, then
+// by primary key, resuming after the object the cursor was issued for
+func (s *MemoryStorage) List(ctx context.Context, tblName string, opts storage.ListOptions) ([]*object.Object, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return nil, "", ddaoerrors.ErrNotConnected
+	}
+	tbl, err := s.getTable(tblName)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := opts.Validate(tbl); err != nil {
+		return nil, "", err
+	}
+
+	var q storage.Query
+	if opts.Cursor != "" {
+		cursor, err := storage.DecodeCursor(opts, tbl)
+		if err != nil {
+			return nil, "", err
+		}
+		if q, err = cursor.After(opts, tbl); err != nil {
+			return nil, "", err
+		}
+	}
+
+	column, desc := opts.Order()
+	var objs []*object.Object
+	for _, obj := range s.autocommit().rows(tbl) {
+		if q.Match(obj) {
+			objs = append(objs, obj)
+		}
+	}
+	if column != "id" {
+		// Rows are in key order already, so a stable sort breaks ties by key
+		slices.SortStableFunc(objs, func(a, b *object.Object) int {
+			c, _ := storage.Compare(a.Fields[column], b.Fields[column])
+			return c
+		})
+	}
+	if desc {
+		slices.Reverse(objs)
+	}
+
+	limit := opts.PageSize()
+	if len(objs) <= limit {
+		return cloneObjects(objs), "", nil
+	}
+	objs = objs[:limit]
+	last := objs[limit-1]
+	var value any
+	if column != "id" {
+		value = last.Fields[column]
+	}
+	return cloneObjects(objs), storage.NewCursor(opts, value, last.ID).Encode(), nil
+}
+
+// DeleteByID removes the object with id, reporting whether there was one
+func (s *MemoryStorage) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return false, ddaoerrors.ErrNotConnected
+	}
+	tx := s.autocommit()
+	deleted, err := tx.deleteByID(tblName, id)
+	if err != nil {
+		return false, err
+	}
+	tx.apply()
+	return deleted, nil
+}
+
+// ResetConnection saves the tables to the file given to Connect, if any,
+// and then discards them
+func (s *MemoryStorage) ResetConnection(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil
+	}
+	var err error
+	if s.path != "" {
+		err = s.saveFile(s.path)
+	}
+	s.connected = false
+	s.conn++
+	s.sch = nil
+	s.tables = nil
+	s.snapshots = nil
+	return err
+}
+
+// IsRetryable reports whether err is a commit that lost to a concurrent one
+func (s *MemoryStorage) IsRetryable(err error) bool {
+	return errors.Is(err, ErrWriteConflict)
+}
+
+// AlterTable applies ops to tableName's definition in the schema and to
+// every version of its rows: renamed and dropped columns are renamed and
+// dropped, added columns take their default and changed types convert the
+// values. Transactions already open read the altered rows. As in SQL, adding
+// a column that is not nullable and has no default, or making a column not
+// nullable, fails while a row would hold NULL in it.
+func (s *MemoryStorage) AlterTable(ctx context.Context, tableName string, ops []storage.AlterOp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ddaoerrors.ErrNotConnected
+	}
+	tbl, err := s.getTable(tableName)
+	if err != nil {
+		return err
+	}
+	altered, err := storage.AlterTableSchema(tbl, ops)
+	if err != nil {
+		return err
+	}
+	if err := checkDefaults(*altered); err != nil {
+		return err
+	}
+	if altered.TableName != tableName {
+		if _, ok := s.sch.GetTable(altered.TableName); ok {
+			return fmt.Errorf("cannot rename table %s to %s: table already exists", tableName, altered.TableName)
+		}
+	}
+
+	// Rewrite every version before replacing any, so a failure changes nothing
+	rows := make(table, len(s.tables[tableName]))
+	for id, versions := range s.tables[tableName] {
+		rewritten := make([]version, len(versions))
+		for i, v := range versions {
+			rewritten[i] = v
+			if v.obj == nil {
+				continue
+			}
+			if rewritten[i].obj, err = alterRow(*altered, v.obj, ops); err != nil {
+				return err
+			}
+		}
+		if obj := rewritten[len(rewritten)-1].obj; obj != nil {
+			if err := checkNotNull(*altered, obj); err != nil {
+				return fmt.Errorf("cannot alter table %s: %w", tableName, err)
+			}
+		}
+		rows[id] = rewritten
+	}
+
+	delete(s.tables, tableName)
+	s.tables[altered.TableName] = rows
+	s.sch.ReplaceTable(tableName, altered)
+	return nil
+}
+
+// getTable returns tblName's definition from the schema
+func (s *MemoryStorage) getTable(tblName string) (schema.TableSchema, error) {
+	if s.sch == nil {
+		return schema.TableSchema{}, ddaoerrors.UnknownTable(tblName)
+	}
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return schema.TableSchema{}, ddaoerrors.UnknownTable(tblName)
+	}
+	return tbl, nil
+}
+
+// committed returns the row tblName/id as the commit at ts left it, nil if
+// it did not exist then
+func (s *MemoryStorage) committed(tblName, id string, ts uint64) *object.Object {
+	versions := s.tables[tblName][id]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].ts <= ts {
+			return versions[i].obj
+		}
+	}
+	return nil
+}
+
+// latest returns the timestamp of the last commit writing tblName/id
+func (s *MemoryStorage) latest(tblName, id string) uint64 {
+	versions := s.tables[tblName][id]
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1].ts
+}
+
+// commit writes objs, keyed by row, as the next version of each row. The
+// versions no open transaction can read any more are dropped.
+func (s *MemoryStorage) commit(writes map[rowKey]*object.Object) {
+	s.ts++
+	oldest := s.ts
+	for ts := range s.snapshots {
+		oldest = min(oldest, ts)
+	}
+
+	for key, obj := range writes {
+		rows := s.tables[key.table]
+		versions := append(rows[key.id], version{ts: s.ts, obj: obj})
+		// Keep the newest version the oldest snapshot reads, and those after it
+		keep := 0
+		for i, v := range versions {
+			if v.ts <= oldest {
+				keep = i
+			}
+		}
+		versions = versions[keep:]
+		if len(versions) == 1 && versions[0].obj == nil {
+			delete(rows, key.id)
+			continue
+		}
+		rows[key.id] = slices.Clip(versions)
+	}
+}
+
+// compareKeys orders two rows of tbl by their primary keys
+func compareKeys(tbl schema.TableSchema, a, b *object.Object) int {
+	for _, column := range tbl.PrimaryKeyColumns() {
+		if c, ok := storage.Compare(keyValue(a, column), keyValue(b, column)); ok && c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// keyValue returns the value of the key column of obj, which is its ID for
+// an implicit id column missing from Fields
+func keyValue(obj *object.Object, column string) any {
+	if value, ok := obj.Fields[column]; ok {
+		return value
+	}
+	return obj.ID
+}
+
+// checkIsolation rejects isolation levels snapshot isolation does not provide
+func checkIsolation(opts *storage.TxOptions) error {
+	if opts == nil {
+		return nil
+	}
+	switch opts.Isolation {
+	case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted,
+		sql.LevelWriteCommitted, sql.LevelRepeatableRead, sql.LevelSnapshot:
+		return nil
+	}
+	return ddaoerrors.NotSupported("memory", "isolation level "+strings.ToLower(opts.Isolation.String()))
+}
@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/jadedragon942/ddao/storagetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.StorageTest(t, storage)
+}
+
+func TestMemoryCRUD(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.CRUDTest(t, storage)
+}
+
+func TestMemoryUpsert(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
+func TestMemoryTransactions(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.TransactionTest(t, storage)
+}
+
+func TestMemoryQuery(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestMemoryList(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestMemoryRows(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestMemoryErrors(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestMemoryBatch(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestMemoryKeys(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestMemoryReservedWords(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestMemoryIndexes(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestMemoryAlterTable(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, "mem:")
+	if err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
+
+// tasksSchema returns a table with a unique column, a column that is not
+// nullable and columns with defaults
+func tasksSchema() *schema.Schema {
+	sch := schema.New()
+	tasks := schema.NewTableSchema("tasks")
+	tasks.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	tasks.AddField(schema.ColumnData{Name: "title", DataType: "text"})
+	tasks.AddField(schema.ColumnData{Name: "slug", DataType: "text", Nullable: true, Unique: true})
+	tasks.AddField(schema.ColumnData{Name: "status", DataType: "text", Default: "open"})
+	tasks.AddField(schema.ColumnData{Name: "priority", DataType: "integer", Nullable: true, Default: 3})
+	tasks.AddField(schema.ColumnData{Name: "created", DataType: "timestamp", Default: schema.Expr("CURRENT_TIMESTAMP")})
+	sch.AddTable(tasks)
+	return sch
+}
+
+func newTasksStorage(t *testing.T, connStr string) storage.Storage {
+	t.Helper()
+	store := New()
+	ctx := context.Background()
+	if err := store.Connect(ctx, connStr); err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	if err := store.CreateTables(ctx, tasksSchema()); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+	return store
+}
+
+func task(id, title string) *object.Object {
+	return &object.Object{TableName: "tasks", ID: id, Fields: map[string]any{"id": id, "title": title}}
+}
+
+func TestMemoryConstraints(t *testing.T) {
+	store := newTasksStorage(t, "mem:")
+	ctx := context.Background()
+	defer store.ResetConnection(ctx)
+
+	obj := task("t1", "Write docs")
+	obj.Fields["slug"] = "docs"
+	if _, _, err := store.Insert(ctx, obj); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if obj.Fields["status"] != "open" || obj.Fields["priority"] != int64(3) {
+		t.Errorf("expected the column defaults to be filled in, got %v", obj.Fields)
+	}
+	if created, ok := obj.Fields["created"].(time.Time); !ok || created.IsZero() {
+		t.Errorf("expected created to default to the current time, got %v", obj.Fields["created"])
+	}
+
+	// Values are converted to their columns' types
+	if _, err := store.Update(ctx, &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"priority": "5"}}); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	found, err := store.FindByID(ctx, "tasks", "t1")
+	if err != nil {
+		t.Fatalf("Failed to find task: %v", err)
+	}
+	if found.Fields["priority"] != int64(5) {
+		t.Errorf("expected priority 5 as an int64, got %T %v", found.Fields["priority"], found.Fields["priority"])
+	}
+	if _, err := store.Update(ctx, &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"priority": "high"}}); err == nil {
+		t.Error("expected a non-numeric priority to be rejected")
+	}
+
+	dup := task("t2", "Copy docs")
+	dup.Fields["slug"] = "docs"
+	_, _, err = store.Insert(ctx, dup)
+	var dupErr *ddaoerrors.DuplicateKeyError
+	if !errors.As(err, &dupErr) || dupErr.Column != "slug" {
+		t.Errorf("expected a DuplicateKeyError on slug, got %v", err)
+	}
+
+	// NULLs never collide, but columns that are not nullable reject them
+	if _, _, err := store.Insert(ctx, task("t2", "Review docs")); err != nil {
+		t.Fatalf("Failed to insert task without a slug: %v", err)
+	}
+	if _, _, err := store.Insert(ctx, task("t3", "Ship docs")); err != nil {
+		t.Fatalf("Failed to insert second task without a slug: %v", err)
+	}
+	if _, _, err := store.Insert(ctx, &object.Object{TableName: "tasks", ID: "t4", Fields: map[string]any{"id": "t4"}}); err == nil {
+		t.Error("expected a task without a title to be rejected")
+	}
+	if _, err := store.Update(ctx, &object.Object{TableName: "tasks", ID: "t2", Fields: map[string]any{"title": nil}}); err == nil {
+		t.Error("expected setting the title to NULL to be rejected")
+	}
+
+	// Objects found are copies
+	found.Fields["title"] = "Changed"
+	if again, _ := store.FindByID(ctx, "tasks", "t1"); again.Fields["title"] != "Write docs" {
+		t.Errorf("expected changing a found object to leave the store alone, got %v", again.Fields["title"])
+	}
+}
+
+func TestMemorySnapshotIsolation(t *testing.T) {
+	store := newTasksStorage(t, "mem:")
+	ctx := context.Background()
+	defer store.ResetConnection(ctx)
+
+	if _, _, err := store.Insert(ctx, task("t1", "Write docs")); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+
+	tx1, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	tx2, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+
+	// Commits after a transaction began are invisible to it
+	if _, _, err := store.Insert(ctx, task("t2", "Review docs")); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if _, err := tx1.FindByID(ctx, "tasks", "t2"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected the transaction not to see a later commit, got %v", err)
+	}
+
+	// The first of two transactions writing a row commits; the second conflicts
+	if _, err := tx1.Update(ctx, &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"status": "doing"}}); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	if _, err := tx2.Update(ctx, &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"status": "done"}}); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	if found, _ := store.FindByID(ctx, "tasks", "t1"); found.Fields["status"] != "open" {
+		t.Errorf("expected uncommitted writes to be invisible, got %v", found.Fields["status"])
+	}
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Failed to commit first transaction: %v", err)
+	}
+	err = tx2.Commit()
+	if !errors.Is(err, ErrWriteConflict) {
+		t.Fatalf("expected the second commit to conflict, got %v", err)
+	}
+	if !store.(storage.RetryClassifier).IsRetryable(err) {
+		t.Error("expected a write conflict to be retryable")
+	}
+	if found, _ := store.FindByID(ctx, "tasks", "t1"); found.Fields["status"] != "doing" {
+		t.Errorf("expected the first commit to win, got %v", found.Fields["status"])
+	}
+
+	// Transactions writing the same unique value conflict at commit
+	tx3, _ := store.BeginTx(ctx, nil)
+	tx4, _ := store.BeginTx(ctx, nil)
+	for i, tx := range []storage.Tx{tx3, tx4} {
+		obj := task([]string{"t3", "t4"}[i], "Ship docs")
+		obj.Fields["slug"] = "ship"
+		if _, _, err := tx.Insert(ctx, obj); err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+	if err := tx3.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if err := tx4.Commit(); !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected a repeated slug to conflict at commit, got %v", err)
+	}
+
+	if _, err := store.BeginTx(ctx, &storage.TxOptions{Isolation: sql.LevelSerializable}); !errors.Is(err, ddaoerrors.ErrNotSupported) {
+		t.Errorf("expected serializable isolation to be unsupported, got %v", err)
+	}
+}
+
+func TestMemoryTxAfterReset(t *testing.T) {
+	store := newTasksStorage(t, "mem:")
+	ctx := context.Background()
+
+	committed, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, _, err := committed.Insert(ctx, task("t1", "Write docs")); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	rolledBack, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+
+	// Transactions open when the connection is reset end with it
+	if err := store.ResetConnection(ctx); err != nil {
+		t.Fatalf("Failed to reset connection: %v", err)
+	}
+	if err := committed.Commit(); !errors.Is(err, ddaoerrors.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected committing after a reset, got %v", err)
+	}
+	if err := committed.Rollback(); !errors.Is(err, storage.ErrTxDone) {
+		t.Errorf("expected ErrTxDone rolling back a finished transaction, got %v", err)
+	}
+	if err := rolledBack.Rollback(); !errors.Is(err, ddaoerrors.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected rolling back after a reset, got %v", err)
+	}
+
+	// Nor do they outlive a reconnect
+	if err := store.Connect(ctx, "mem:"); err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	defer store.ResetConnection(ctx)
+	stale, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if err := store.ResetConnection(ctx); err != nil {
+		t.Fatalf("Failed to reset connection: %v", err)
+	}
+	if err := store.Connect(ctx, "mem:"); err != nil {
+		t.Fatalf("Failed to connect to memory storage: %v", err)
+	}
+	if err := stale.Commit(); !errors.Is(err, ddaoerrors.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected committing after a reconnect, got %v", err)
+	}
+}
+
+func TestMemorySave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store := newTasksStorage(t, "mem:"+path)
+	ctx := context.Background()
+
+	obj := task("t1", "Write docs")
+	obj.Fields["slug"] = "docs"
+	if _, _, err := store.Insert(ctx, obj); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if _, _, err := store.Insert(ctx, task("t2", "Review docs")); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if err := store.ResetConnection(ctx); err != nil {
+		t.Fatalf("Failed to save tables: %v", err)
+	}
+
+	reloaded := New()
+	if err := reloaded.Connect(ctx, "mem:"+path); err != nil {
+		t.Fatalf("Failed to load tables: %v", err)
+	}
+	defer reloaded.ResetConnection(ctx)
+
+	if reloaded.(*MemoryStorage).GetSchema() == nil {
+		t.Fatal("expected the schema to be loaded")
+	}
+	found, err := reloaded.FindByID(ctx, "tasks", "t1")
+	if err != nil {
+		t.Fatalf("Failed to find saved task: %v", err)
+	}
+	if found.Fields["slug"] != "docs" || found.Fields["priority"] != int64(3) {
+		t.Errorf("expected the saved fields, got %v", found.Fields)
+	}
+	if _, ok := found.Fields["created"].(time.Time); !ok {
+		t.Errorf("expected created to be loaded as a time, got %T", found.Fields["created"])
+	}
+	dup := task("t3", "Copy docs")
+	dup.Fields["slug"] = "docs"
+	if _, _, err := reloaded.Insert(ctx, dup); !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected the loaded schema to keep slug unique, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// snapshotFile is the format of the file the tables are saved to: the schema,
+// in the schema file format, and every row as the latest commit left it, by
+// table and in primary key order
+type snapshotFile struct {
+	Schema *schema.Schema           `json:"schema"`
+	Tables map[string][]snapshotRow `json:"tables"`
+}
+
+type snapshotRow struct {
+	ID     string         `json:"id"`
+	Fields map[string]any `json:"fields"`
+}
+
+// Save writes the tables, as the latest commit left them, to the file given
+// to Connect. It replaces the file atomically, so a crash leaves either the
+// previous snapshot or the new one.
+func (s *MemoryStorage) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.connected {
+		return ddaoerrors.ErrNotConnected
+	}
+	if s.path == "" {
+		return errors.New("no file to save to: connect with mem:<path>")
+	}
+	return s.saveFile(s.path)
+}
+
+func (s *MemoryStorage) saveFile(path string) error {
+	snapshot := snapshotFile{Schema: s.sch, Tables: make(map[string][]snapshotRow)}
+	if s.sch != nil {
+		for _, name := range s.sch.TableNames() {
+			tbl, _ := s.sch.GetTable(name)
+			objs := s.autocommit().rows(tbl)
+			rows := make([]snapshotRow, len(objs))
+			for i, obj := range objs {
+				rows[i] = snapshotRow{ID: obj.ID, Fields: obj.Fields}
+			}
+			snapshot.Tables[name] = rows
+		}
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tables: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save tables to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save tables to %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save tables to %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save tables to %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadFile replaces the schema and tables with those saved to path, leaving
+// them empty if there is no such file
+func (s *MemoryStorage) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load tables from %s: %w", path, err)
+	}
+
+	var snapshot snapshotFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to load tables from %s: %w", path, err)
+	}
+
+	s.sch = snapshot.Schema
+	if s.sch == nil {
+		return nil
+	}
+	for _, name := range s.sch.TableNames() {
+		s.tables[name] = make(table)
+	}
+	for name, rows := range snapshot.Tables {
+		tbl, err := s.getTable(name)
+		if err != nil {
+			return fmt.Errorf("failed to load tables from %s: %w", path, err)
+		}
+		for _, r := range rows {
+			row, err := newRow(tbl, &object.Object{TableName: name, ID: r.ID, Fields: decodeBytes(tbl, r.Fields)})
+			if err != nil {
+				return fmt.Errorf("failed to load tables from %s: %w", path, err)
+			}
+			s.tables[name][row.ID] = []version{{ts: s.ts, obj: row}}
+		}
+	}
+	return nil
+}
+
+// decodeBytes decodes the values of fields in binary columns of tbl, which
+// JSON holds in base64
+func decodeBytes(tbl schema.TableSchema, fields map[string]any) map[string]any {
+	for name, value := range fields {
+		text, ok := value.(string)
+		if !ok || kindOf(tbl.Fields[name].DataType) != kindBytes {
+			continue
+		}
+		if data, err := base64.StdEncoding.DecodeString(text); err == nil {
+			fields[name] = data
+		}
+	}
+	return fields
+}
@@ -0,0 +1,451 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// rowKey names a row across tables
+type rowKey struct {
+	table, id string
+}
+
+// memTx reads the tables as the commit at ts left them, with its own writes
+// layered on top. Commit applies the writes as one commit, unless another
+// transaction committed a write to one of the same rows since ts. Single
+// calls outside a transaction run as one committing at once, made by
+// autocommit. Every method but Commit and Rollback expects the caller to hold
+// s.mu.
+type memTx struct {
+	s        *MemoryStorage
+	conn     uint64 // the connection the transaction began on
+	ts       uint64
+	writes   map[rowKey]*object.Object // rows written, nil once deleted
+	readOnly bool
+	done     bool
+}
+
+// BeginTx starts a snapshot transaction. Every isolation level up to
+// sql.LevelSnapshot is accepted, as snapshot isolation provides them all.
+func (s *MemoryStorage) BeginTx(ctx context.Context, opts *storage.TxOptions) (storage.Tx, error) {
+	if err := checkIsolation(opts); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	tx := &memTx{s: s, conn: s.conn, ts: s.ts}
+	if opts != nil {
+		tx.readOnly = opts.ReadOnly
+	}
+	s.snapshots[tx.ts]++
+	return tx, nil
+}
+
+// autocommit returns a transaction reading the latest commit, for a single call
+func (s *MemoryStorage) autocommit() *memTx {
+	return &memTx{s: s, conn: s.conn, ts: s.ts}
+}
+
+// apply commits tx's writes; the caller holds s.mu for writing
+func (tx *memTx) apply() {
+	if len(tx.writes) > 0 {
+		tx.s.commit(tx.writes)
+	}
+}
+
+// finish ends tx, releasing the versions only it still reads
+func (tx *memTx) finish() {
+	tx.done = true
+	if tx.conn != tx.s.conn {
+		return
+	}
+	if tx.s.snapshots[tx.ts]--; tx.s.snapshots[tx.ts] <= 0 {
+		delete(tx.s.snapshots, tx.ts)
+	}
+}
+
+func (tx *memTx) check() error {
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	if !tx.s.connected || tx.conn != tx.s.conn {
+		return ddaoerrors.ErrNotConnected
+	}
+	return nil
+}
+
+func (tx *memTx) checkWrite() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if tx.readOnly {
+		return errors.New("cannot write in a read-only transaction")
+	}
+	return nil
+}
+
+// Insert writes obj, failing with a DuplicateKeyError if its ID exists as
+// seen by the transaction. The fields obj leaves out are filled in with the
+// values written, such as column defaults.
+func (tx *memTx) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return tx.putObject(obj, false)
+}
+
+// Upsert writes obj or overwrites the fields it sets in the existing object
+// with its ID. The fields obj leaves out are filled in with the values written.
+func (tx *memTx) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return tx.putObject(obj, true)
+}
+
+func (tx *memTx) putObject(obj *object.Object, upsert bool) ([]byte, bool, error) {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+
+	if err := tx.checkWrite(); err != nil {
+		return nil, false, err
+	}
+	row, created, err := tx.put(obj, upsert)
+	if err != nil {
+		return nil, false, err
+	}
+	fillIn(obj, row)
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal object: %w", err)
+	}
+	return data, created, nil
+}
+
+func (tx *memTx) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+	return tx.update(obj)
+}
+
+func (tx *memTx) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	tx.s.mu.RLock()
+	defer tx.s.mu.RUnlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.findByID(tblName, id)
+}
+
+func (tx *memTx) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	tx.s.mu.RLock()
+	defer tx.s.mu.RUnlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.findByKey(tblName, key, value)
+}
+
+func (tx *memTx) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	tx.s.mu.RLock()
+	defer tx.s.mu.RUnlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.find(tblName, q)
+}
+
+func (tx *memTx) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+	return tx.deleteByID(tblName, id)
+}
+
+// Commit applies the transaction's writes as one commit. It fails with
+// ErrWriteConflict, writing nothing, if another transaction committed a
+// write to one of the same rows after this one began, and with a
+// DuplicateKeyError if one did commit a row holding the same unique values.
+func (tx *memTx) Commit() error {
+	s := tx.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	err := tx.check()
+	tx.finish()
+	if err != nil {
+		return err
+	}
+
+	for key := range tx.writes {
+		if s.latest(key.table, key.id) > tx.ts {
+			return fmt.Errorf("cannot commit write to %s/%s: %w", key.table, key.id, ErrWriteConflict)
+		}
+	}
+
+	// Check the rows written against the latest commit, which may have
+	// altered their tables or written other rows with the same unique values
+	tx.ts = s.ts
+	keys := slices.SortedFunc(maps.Keys(tx.writes), func(a, b rowKey) int {
+		return cmp.Or(cmp.Compare(a.table, b.table), cmp.Compare(a.id, b.id))
+	})
+	for _, key := range keys {
+		row := tx.writes[key]
+		if row == nil {
+			continue
+		}
+		tbl, err := s.getTable(key.table)
+		if err != nil {
+			return err
+		}
+		if err := tx.checkRow(tbl, row); err != nil {
+			return err
+		}
+	}
+
+	tx.apply()
+	return nil
+}
+
+// Rollback discards the transaction's writes
+func (tx *memTx) Rollback() error {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	err := tx.check()
+	tx.finish()
+	tx.writes = nil
+	return err
+}
+
+// get returns the row tblName/id as tx sees it, nil if it does not exist
+func (tx *memTx) get(tblName, id string) *object.Object {
+	if obj, ok := tx.writes[rowKey{tblName, id}]; ok {
+		return obj
+	}
+	return tx.s.committed(tblName, id, tx.ts)
+}
+
+// each calls fn with every row of tblName tx sees, in no particular order,
+// until fn returns false
+func (tx *memTx) each(tblName string, fn func(obj *object.Object) bool) {
+	for id := range tx.s.tables[tblName] {
+		if _, ok := tx.writes[rowKey{tblName, id}]; ok {
+			continue
+		}
+		if obj := tx.s.committed(tblName, id, tx.ts); obj != nil && !fn(obj) {
+			return
+		}
+	}
+	for key, obj := range tx.writes {
+		if key.table == tblName && obj != nil && !fn(obj) {
+			return
+		}
+	}
+}
+
+// rows returns the rows of tbl tx sees, in primary key order. They are the
+// stored rows, which callers must clone before handing out.
+func (tx *memTx) rows(tbl schema.TableSchema) []*object.Object {
+	var objs []*object.Object
+	tx.each(tbl.TableName, func(obj *object.Object) bool {
+		objs = append(objs, obj)
+		return true
+	})
+	slices.SortFunc(objs, func(a, b *object.Object) int {
+		return compareKeys(tbl, a, b)
+	})
+	return objs
+}
+
+// put writes obj, merged into the existing row with its key if upsert is
+// set, and returns the row written and whether it is new
+func (tx *memTx) put(obj *object.Object, upsert bool) (*object.Object, bool, error) {
+	tbl, err := tx.s.getTable(obj.TableName)
+	if err != nil {
+		return nil, false, err
+	}
+	row, err := newRow(tbl, obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing := tx.get(tbl.TableName, row.ID)
+	if existing != nil {
+		if !upsert {
+			return nil, false, &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: ddaoerrors.KeyColumns(tbl.PrimaryKeyColumns())}
+		}
+		row = merge(existing, row)
+	} else if err := applyDefaults(tbl, row); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.write(tbl, row); err != nil {
+		return nil, false, err
+	}
+	return row, existing == nil, nil
+}
+
+// update merges obj into the existing row with its key, reporting whether
+// there was one
+func (tx *memTx) update(obj *object.Object) (bool, error) {
+	tbl, err := tx.s.getTable(obj.TableName)
+	if err != nil {
+		return false, err
+	}
+	row, err := newRow(tbl, obj)
+	if err != nil {
+		return false, err
+	}
+
+	existing := tx.get(tbl.TableName, row.ID)
+	if existing == nil {
+		return false, nil
+	}
+	return true, tx.write(tbl, merge(existing, row))
+}
+
+// write records row as written, once it satisfies tbl's constraints
+func (tx *memTx) write(tbl schema.TableSchema, row *object.Object) error {
+	if err := tx.checkRow(tbl, row); err != nil {
+		return err
+	}
+	if tx.writes == nil {
+		tx.writes = make(map[rowKey]*object.Object)
+	}
+	tx.writes[rowKey{tbl.TableName, row.ID}] = row
+	return nil
+}
+
+// checkRow fails if row has a field tbl does not define, holds NULL in a column
+// that is not nullable, or holds the values of another row in one of tbl's
+// unique columns or indexes. As in SQL, NULLs never collide.
+func (tx *memTx) checkRow(tbl schema.TableSchema, row *object.Object) error {
+	for name := range row.Fields {
+		if _, ok := column(tbl, name); !ok {
+			return ddaoerrors.UnknownField(tbl.TableName, name)
+		}
+	}
+	if err := checkNotNull(tbl, row); err != nil {
+		return err
+	}
+
+	for _, columns := range uniqueKeys(tbl) {
+		values := make([]any, len(columns))
+		for i, name := range columns {
+			values[i] = row.Fields[name]
+		}
+		if slices.Contains(values, nil) {
+			continue
+		}
+
+		duplicate := false
+		tx.each(tbl.TableName, func(other *object.Object) bool {
+			if other.ID == row.ID {
+				return true
+			}
+			for i, name := range columns {
+				if c, ok := storage.Compare(values[i], other.Fields[name]); !ok || c != 0 {
+					return true
+				}
+			}
+			duplicate = true
+			return false
+		})
+		if duplicate {
+			return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: ddaoerrors.KeyColumns(columns)}
+		}
+	}
+	return nil
+}
+
+// deleteByID deletes the row with id, reporting whether there was one
+func (tx *memTx) deleteByID(tblName, id string) (bool, error) {
+	tbl, err := tx.s.getTable(tblName)
+	if err != nil {
+		return false, err
+	}
+	if id, err = rowID(tbl, id); err != nil {
+		return false, err
+	}
+
+	if tx.get(tblName, id) == nil {
+		return false, nil
+	}
+	if tx.writes == nil {
+		tx.writes = make(map[rowKey]*object.Object)
+	}
+	tx.writes[rowKey{tblName, id}] = nil
+	return true, nil
+}
+
+func (tx *memTx) findByID(tblName, id string) (*object.Object, error) {
+	tbl, err := tx.s.getTable(tblName)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = rowID(tbl, id); err != nil {
+		return nil, err
+	}
+
+	obj := tx.get(tblName, id)
+	if obj == nil {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return cloneObject(obj), nil
+}
+
+func (tx *memTx) findByKey(tblName, key, value string) (*object.Object, error) {
+	objs, err := tx.find(tblName, storage.Eq(key, value))
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return objs[0], nil
+}
+
+func (tx *memTx) find(tblName string, q storage.Query) ([]*object.Object, error) {
+	tbl, err := tx.s.getTable(tblName)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Validate(tbl); err != nil {
+		return nil, err
+	}
+
+	var objs []*object.Object
+	for _, obj := range tx.rows(tbl) {
+		if q.Match(obj) {
+			objs = append(objs, cloneObject(obj))
+		}
+	}
+	return objs, nil
+}
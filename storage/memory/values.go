@@ -0,0 +1,425 @@
+package memory
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// kind is how a column's values are stored
+type kind int
+
+const (
+	kindOther kind = iota
+	kindText
+	kindInteger
+	kindFloat
+	kindBool
+	kindTime
+	kindBytes
+	kindJSON
+)
+
+// kindOf classifies a schema data type, ignoring case and any length or precision
+func kindOf(dataType string) kind {
+	name := strings.ToUpper(strings.TrimSpace(dataType))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	switch name {
+	case "TEXT", "VARCHAR", "CHAR", "NVARCHAR", "NCHAR", "STRING", "CLOB", "UUID", "XML", "TIME":
+		return kindText
+	case "INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT", "INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL":
+		return kindInteger
+	case "REAL", "FLOAT", "DOUBLE", "DOUBLE PRECISION", "FLOAT4", "FLOAT8":
+		return kindFloat
+	case "BOOLEAN", "BOOL":
+		return kindBool
+	case "DATETIME", "DATE", "TIMESTAMPTZ":
+		return kindTime
+	case "BLOB", "BYTEA", "BINARY", "VARBINARY", "BYTES":
+		return kindBytes
+	case "JSON", "JSONB":
+		return kindJSON
+	}
+	if strings.HasPrefix(name, "TIMESTAMP") {
+		return kindTime // Including TIMESTAMP WITH TIME ZONE
+	}
+	return kindOther
+}
+
+// convert returns value as stored in field's column: integers as int64,
+// other numbers as float64, times as time.Time, text as strings, JSON
+// decoded unless it is a string, and values of types the backend does not
+// know as they are. Pointers are followed, and nil stays nil.
+func convert(tblName string, field schema.ColumnData, value any) (any, error) {
+	value = plain(value)
+	if value == nil {
+		return nil, nil
+	}
+
+	var err error
+	switch kindOf(field.DataType) {
+	case kindText:
+		value, err = object.AsString(value)
+	case kindInteger:
+		value, err = object.AsInt64(value)
+	case kindFloat:
+		value, err = object.AsFloat64(value)
+	case kindBool:
+		value, err = object.AsBool(value)
+	case kindTime:
+		value, err = object.AsTime(value)
+	case kindBytes:
+		value, err = object.AsBytes(value)
+	case kindJSON:
+		value, err = jsonValue(value)
+	default:
+		value = cloneValue(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for column %s of table %s: %w", field.Name, tblName, err)
+	}
+	return value, nil
+}
+
+// plain follows pointers in value and widens numbers, booleans and strings
+// of any Go type to int64, float64, bool and string
+func plain(value any) any {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String:
+		return rv.String()
+	}
+	return rv.Interface()
+}
+
+// jsonValue returns the value stored in a json column: strings, and bytes
+// as a string, as they are, anything else decoded from its JSON encoding,
+// so later changes to the caller's maps and slices do not reach the store
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded == nil {
+		return string(data), nil // A value encoding to null, such as a nil slice, is not NULL
+	}
+	return decoded, nil
+}
+
+// cloneValue copies the bytes, maps and slices in value, the only mutable
+// values convert stores
+func cloneValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return bytes.Clone(v)
+	case map[string]any:
+		clone := make(map[string]any, len(v))
+		for key, elem := range v {
+			clone[key] = cloneValue(elem)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(v))
+		for i, elem := range v {
+			clone[i] = cloneValue(elem)
+		}
+		return clone
+	}
+	return value
+}
+
+// cloneObject returns a copy of a stored row the caller may change freely
+func cloneObject(obj *object.Object) *object.Object {
+	fields := make(map[string]any, len(obj.Fields))
+	for name, value := range obj.Fields {
+		fields[name] = cloneValue(value)
+	}
+	return &object.Object{ID: obj.ID, TableName: obj.TableName, Fields: fields}
+}
+
+func cloneObjects(objs []*object.Object) []*object.Object {
+	clones := make([]*object.Object, len(objs))
+	for i, obj := range objs {
+		clones[i] = cloneObject(obj)
+	}
+	return clones
+}
+
+// column returns the definition of a column of tbl. The implicit id column
+// of a table without a declared primary key may be missing from the schema.
+func column(tbl schema.TableSchema, name string) (schema.ColumnData, bool) {
+	field, ok := tbl.Fields[name]
+	if !ok && name == "id" && !tbl.HasDeclaredPrimaryKey() {
+		return schema.ColumnData{Name: "id", DataType: "text"}, true
+	}
+	return field, ok
+}
+
+// newRow returns obj as a row of tbl: a copy whose fields are converted to
+// their columns' types, whose key columns missing from Fields are taken from
+// the ID, and whose ID is rebuilt from the key. Fields tbl does not define
+// are rejected.
+func newRow(tbl schema.TableSchema, obj *object.Object) (*object.Object, error) {
+	fields := make(map[string]any, len(tbl.Fields))
+	for name, value := range obj.Fields {
+		field, ok := column(tbl, name)
+		if !ok {
+			return nil, ddaoerrors.UnknownField(tbl.TableName, name)
+		}
+		converted, err := convert(tbl.TableName, field, value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = converted
+	}
+
+	keyColumns := tbl.PrimaryKeyColumns()
+	key := make(object.Key, 0, len(keyColumns))
+	var parsed object.Key
+	for i, name := range keyColumns {
+		value := fields[name]
+		if value == nil {
+			if parsed == nil {
+				var err error
+				if parsed, err = storage.ParseID(tbl, obj.ID); err != nil {
+					return nil, err
+				}
+			}
+			field, _ := column(tbl, name)
+			converted, err := convert(tbl.TableName, field, parsed[i].Value)
+			if err != nil {
+				return nil, err
+			}
+			value = converted
+			if _, ok := tbl.Fields[name]; ok {
+				fields[name] = value
+			}
+		}
+		key = append(key, object.KeyPart{Column: name, Value: value})
+	}
+	return &object.Object{TableName: tbl.TableName, ID: key.String(), Fields: fields}, nil
+}
+
+// rowID returns id as newRow rebuilds it, so IDs spelling a key differently,
+// such as "007" for the integer 7, find the same row
+func rowID(tbl schema.TableSchema, id string) (string, error) {
+	key, err := storage.ParseID(tbl, id)
+	if err != nil {
+		return "", err
+	}
+	for i, part := range key {
+		field, _ := column(tbl, part.Column)
+		if key[i].Value, err = convert(tbl.TableName, field, part.Value); err != nil {
+			return "", err
+		}
+	}
+	return key.String(), nil
+}
+
+// merge returns existing with the fields row sets overwritten
+func merge(existing, row *object.Object) *object.Object {
+	fields := maps.Clone(existing.Fields)
+	maps.Copy(fields, row.Fields)
+	return &object.Object{TableName: row.TableName, ID: row.ID, Fields: fields}
+}
+
+// fillIn copies the values row holds for the fields obj leaves out, NULLs
+// aside, as SQL backends fill in an inserted object from the row written
+func fillIn(obj, row *object.Object) {
+	if obj.Fields == nil {
+		obj.Fields = make(map[string]any, len(row.Fields))
+	}
+	for name, value := range row.Fields {
+		if _, ok := obj.Fields[name]; !ok && value != nil {
+			obj.Fields[name] = cloneValue(value)
+		}
+	}
+}
+
+// applyDefaults sets the columns of tbl row leaves out to their defaults
+func applyDefaults(tbl schema.TableSchema, row *object.Object) error {
+	for _, name := range tbl.FieldOrder {
+		field := tbl.Fields[name]
+		if _, ok := row.Fields[name]; ok || field.Default == nil {
+			continue
+		}
+		value, err := defaultValue(tbl.TableName, field)
+		if err != nil {
+			return err
+		}
+		row.Fields[name] = value
+	}
+	return nil
+}
+
+// defaultValue returns field's default, computing it if it is a schema.Expr
+func defaultValue(tblName string, field schema.ColumnData) (any, error) {
+	value := field.Default
+	if expr, ok := value.(schema.Expr); ok {
+		var err error
+		if value, err = evalExpr(expr); err != nil {
+			return nil, fmt.Errorf("cannot compute the default of column %s of table %s: %w", field.Name, tblName, err)
+		}
+	}
+	return convert(tblName, field, value)
+}
+
+// checkDefaults checks that every default of tbl can be computed and stored
+func checkDefaults(tbl schema.TableSchema) error {
+	for _, name := range tbl.FieldOrder {
+		if field := tbl.Fields[name]; field.Default != nil {
+			if _, err := defaultValue(tbl.TableName, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// evalExpr computes a default expression: the current time or date, in the
+// spellings SQL databases use, or a random UUID
+func evalExpr(expr schema.Expr) (any, error) {
+	text := strings.TrimSpace(string(expr))
+	for strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+
+	switch strings.ToUpper(text) {
+	case "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP()", "NOW()", "LOCALTIMESTAMP", "SYSDATE",
+		"SYSTIMESTAMP", "GETDATE()", "GETUTCDATE()", "SYSDATETIME()", "DATETIME('NOW')":
+		return time.Now().UTC(), nil
+	case "CURRENT_DATE", "CURRENT_DATE()", "DATE('NOW')":
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	case "GEN_RANDOM_UUID()", "UUID_GENERATE_V4()", "UUID()", "NEWID()":
+		return newUUID(), nil
+	}
+	return nil, ddaoerrors.NotSupported("memory", "default "+string(expr))
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// checkNotNull fails if row holds NULL in a column of tbl that is not nullable
+func checkNotNull(tbl schema.TableSchema, row *object.Object) error {
+	for _, name := range tbl.FieldOrder {
+		if !tbl.Fields[name].Nullable && row.Fields[name] == nil {
+			return fmt.Errorf("column %s of table %s cannot be null", name, tbl.TableName)
+		}
+	}
+	return nil
+}
+
+// uniqueKeys returns the column sets of tbl no two rows may share values
+// in, besides the primary key: unique columns, then unique indexes
+func uniqueKeys(tbl schema.TableSchema) [][]string {
+	keyColumns := tbl.PrimaryKeyColumns()
+	var keys [][]string
+	for _, name := range tbl.FieldOrder {
+		if tbl.Fields[name].Unique && !slices.Equal(keyColumns, []string{name}) {
+			keys = append(keys, []string{name})
+		}
+	}
+	for _, index := range tbl.IndexDefs {
+		if index.Unique {
+			keys = append(keys, index.Columns)
+		}
+	}
+	return keys
+}
+
+// alterRow returns obj, a row of the table altered became with ops, with
+// ops applied to its fields: columns added with a default take it, dropped
+// columns are removed, renamed ones moved, and values are converted to the
+// types of their columns
+func alterRow(altered schema.TableSchema, obj *object.Object, ops []storage.AlterOp) (*object.Object, error) {
+	fields := maps.Clone(obj.Fields)
+	for _, op := range ops {
+		switch op.Kind {
+		case storage.AlterAddColumn:
+			if op.Field.Default != nil {
+				value, err := defaultValue(altered.TableName, op.Field)
+				if err != nil {
+					return nil, err
+				}
+				fields[op.Column] = value
+			}
+		case storage.AlterDropColumn:
+			delete(fields, op.Column)
+		case storage.AlterRenameColumn:
+			if value, ok := fields[op.Column]; ok {
+				delete(fields, op.Column)
+				fields[op.NewName] = value
+			}
+		}
+	}
+
+	for name, value := range fields {
+		field, ok := column(altered, name)
+		if !ok {
+			delete(fields, name)
+			continue
+		}
+		converted, err := convert(altered.TableName, field, value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = converted
+	}
+	return &object.Object{TableName: altered.TableName, ID: obj.ID, Fields: fields}, nil
+}
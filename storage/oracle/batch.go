@@ -0,0 +1,146 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// InsertMany creates objs with array-bound INSERT statements, failing the
+// objects whose ID already exists with a DuplicateKeyError
+func (s *OracleStorage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.writeMany(ctx, objs, opts, false)
+}
+
+// UpsertMany creates or overwrites objs with array-bound MERGE statements
+func (s *OracleStorage) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.writeMany(ctx, objs, opts, true)
+}
+
+// writeMany binds each column of a batch as an array, so Oracle runs the
+// single-row statement once per row in one round-trip
+func (s *OracleStorage) writeMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions, upsert bool) (*storage.BatchResult, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	// Array binds take one parameter per column whatever the batch size
+	return common.CommonWriteMany(ctx, s.GetSchema(), objs, opts, 0, func(ctx context.Context, b common.InsertBatch) error {
+		placeholders := make([]string, len(b.Columns))
+		for i := range placeholders {
+			placeholders[i] = fmt.Sprintf(":%d", i+1)
+		}
+
+		query := s.InsertQuery(b.Table, b.Columns, [][]string{placeholders}, upsert)
+		args, err := s.arrayBind(b)
+		if err != nil {
+			return err
+		}
+
+		storage.DebugLog(query, args...)
+		if _, err := s.GetDB().ExecContext(ctx, query, args...); err != nil {
+			return translateError(err, b.Table)
+		}
+		return nil
+	}, func(ctx context.Context, obj *object.Object) error {
+		if upsert {
+			_, _, err := s.Upsert(ctx, obj)
+			return err
+		}
+		_, _, err := s.Insert(ctx, obj)
+		return err
+	})
+}
+
+// errMixedTypes fails a batch whose column holds values godror cannot bind
+// as one array, so WriteBatches writes its objects one at a time instead
+var errMixedTypes = errors.New("batch column mixes value types")
+
+// arrayBind transposes the rows of b into one slice per column, converting
+// booleans to numbers as Insert does
+func (s *OracleStorage) arrayBind(b common.InsertBatch) ([]any, error) {
+	args := make([]any, len(b.Columns))
+	for j, name := range b.Columns {
+		column := make([]any, len(b.Rows))
+		for i, row := range b.Rows {
+			column[i] = s.Dialect.BindValue(b.Table.Fields[name], row[j])
+		}
+		array, err := typedArray(column)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		args[j] = array
+	}
+	return args, nil
+}
+
+// typedArray converts a column of values to a slice of their common type,
+// which godror needs to bind it as an array. NULLs are bound as invalid
+// sql.Null* values, and as empty strings and byte slices, which Oracle stores
+// as NULL. A column of NULLs only is bound as empty strings.
+func typedArray(values []any) (any, error) {
+	var first any
+	for _, v := range values {
+		if v != nil {
+			first = v
+			break
+		}
+	}
+
+	switch first.(type) {
+	case nil, string:
+		return nullable(values, func(v any) (string, bool) {
+			s, ok := v.(string)
+			return s, ok
+		})
+	case []byte:
+		return nullable(values, func(v any) ([]byte, bool) {
+			b, ok := v.([]byte)
+			return b, ok
+		})
+	case int, int64:
+		return nullable(values, func(v any) (sql.NullInt64, bool) {
+			switch n := v.(type) {
+			case int:
+				return sql.NullInt64{Int64: int64(n), Valid: true}, true
+			case int64:
+				return sql.NullInt64{Int64: n, Valid: true}, true
+			}
+			return sql.NullInt64{}, false
+		})
+	case float64:
+		return nullable(values, func(v any) (sql.NullFloat64, bool) {
+			f, ok := v.(float64)
+			return sql.NullFloat64{Float64: f, Valid: ok}, ok
+		})
+	case time.Time:
+		return nullable(values, func(v any) (sql.NullTime, bool) {
+			t, ok := v.(time.Time)
+			return sql.NullTime{Time: t, Valid: ok}, ok
+		})
+	}
+	return nil, errMixedTypes
+}
+
+// nullable converts values with convert, leaving the zero T for nil
+func nullable[T any](values []any, convert func(any) (T, bool)) (any, error) {
+	typed := make([]T, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		t, ok := convert(v)
+		if !ok {
+			return nil, errMixedTypes
+		}
+		typed[i] = t
+	}
+	return typed, nil
+}
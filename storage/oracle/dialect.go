@@ -0,0 +1,150 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// oracleDialect renders Oracle's statements. Names are quoted upper case,
+// as Oracle stores unquoted names.
+type oracleDialect struct {
+	dialect.Dialect
+}
+
+var _ common.Dialect = oracleDialect{}
+
+func (oracleDialect) Placeholder(n int) string { return fmt.Sprintf(":%d", n) }
+
+func (d oracleDialect) Column(field schema.ColumnData) string {
+	// CLOB columns cannot be compared directly, so compare their leading characters instead
+	if d.ColumnType(schema.TableSchema{}, field) == "CLOB" {
+		return fmt.Sprintf("DBMS_LOB.SUBSTR(%s, 4000, 1)", d.QuoteIdent(field.Name))
+	}
+	return d.QuoteIdent(field.Name)
+}
+
+// BindValue converts booleans to the numbers Oracle stores them as
+func (oracleDialect) BindValue(field schema.ColumnData, value any) any {
+	if boolVal, ok := value.(bool); ok && strings.ToLower(field.DataType) == "boolean" {
+		if boolVal {
+			return 1
+		}
+		return 0
+	}
+	return value
+}
+
+// InsertQuery renders an INSERT of a row, or with upsert a MERGE that
+// overwrites the other columns of an existing row with the same key. Only
+// rows[0] is rendered: Oracle writes batches by binding arrays to one row.
+func (oracleDialect) InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	placeholders := rows[0]
+	if !upsert {
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			table,
+			strings.Join(columns, ", "),
+			strings.Join(placeholders, ", "))
+	}
+
+	selectParts := make([]string, len(columns))
+	sourceColumns := make([]string, len(columns))
+	for i, col := range columns {
+		selectParts[i] = placeholders[i] + " AS " + col
+		sourceColumns[i] = "source." + col
+	}
+	matches := make([]string, keys)
+	for i, col := range columns[:keys] {
+		matches[i] = fmt.Sprintf("target.%s = source.%s", col, col)
+	}
+	updateClauses := make([]string, 0, len(columns)-keys)
+	for _, col := range columns[keys:] { // Skip key columns
+		updateClauses = append(updateClauses, fmt.Sprintf("%s = source.%s", col, col))
+	}
+
+	query := fmt.Sprintf("MERGE INTO %s target USING (SELECT %s FROM DUAL) source ON (%s)",
+		table,
+		strings.Join(selectParts, ", "),
+		strings.Join(matches, " AND "))
+	if len(updateClauses) > 0 {
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(updateClauses, ", ")
+	}
+	return query + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		strings.Join(columns, ", "),
+		strings.Join(sourceColumns, ", "))
+}
+
+// Paginate uses OFFSET ... FETCH, available since Oracle 12c
+func (oracleDialect) Paginate(query, orderBy string, limit, offset int) string {
+	return common.OffsetFetch(query, orderBy, limit, offset)
+}
+
+// Returning returns "": Oracle's RETURNING INTO needs output binds
+func (oracleDialect) Returning([]string) string { return "" }
+
+// BatchLimits leaves the parameters unlimited, as array binds take one per
+// column whatever the batch size, and allows the 1000 expressions Oracle
+// accepts in an IN list
+func (oracleDialect) BatchLimits() (int, int) { return 0, 1000 }
+
+// AlterQueries renders op. Oracle commits each DDL statement on its own, so a
+// failed AlterTable keeps the ops run before it.
+func (d oracleDialect) AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+	table := d.QuoteIdent(before.TableName)
+	column := d.QuoteIdent(op.Column)
+	switch op.Kind {
+	case storage.AlterAddColumn:
+		queries := []string{fmt.Sprintf("ALTER TABLE %s ADD (%s)", table, d.ColumnDefinition(after, op.Field))}
+		if op.Field.Unique {
+			queries = append(queries, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)",
+				table, d.QuoteIdent(dialect.UniqueConstraint(before.TableName, op.Field.Name)), d.QuoteIdent(op.Field.Name)))
+		}
+		return queries, nil
+	case storage.AlterDropColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s CASCADE CONSTRAINTS", table, column)}, nil
+	case storage.AlterRenameColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, column, d.QuoteIdent(op.NewName))}, nil
+	case storage.AlterRenameTable:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, d.QuoteIdent(op.NewName))}, nil
+	case storage.AlterChangeType:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY (%s %s)", table, column, d.ColumnType(after, after.Fields[op.Column]))}, nil
+	case storage.AlterSetNotNull, storage.AlterDropNotNull:
+		// Oracle rejects MODIFY to the nullability the column already has
+		if before.Fields[op.Column].Nullable == after.Fields[op.Column].Nullable {
+			return nil, nil
+		}
+		nullable := "NOT NULL"
+		if after.Fields[op.Column].Nullable {
+			nullable = "NULL"
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY (%s %s)", table, column, nullable)}, nil
+	case storage.AlterSetDefault:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY (%s DEFAULT %s)", table, column, d.Literal(op.Default))}, nil
+	case storage.AlterDropDefault:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY (%s DEFAULT NULL)", table, column)}, nil
+	}
+	return nil, nil
+}
+
+func (oracleDialect) TranslateError(err error, tbl schema.TableSchema) error {
+	return translateError(err, tbl)
+}
+
+// IsRetryable reports whether err is ORA-08177 (cannot serialize access) or
+// ORA-00060 (deadlock detected), after which the transaction should be run again
+func (oracleDialect) IsRetryable(err error) bool {
+	var oraErr interface{ Code() int }
+	if !errors.As(err, &oraErr) {
+		return false
+	}
+	switch oraErr.Code() {
+	case 8177, 60:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package oracle
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// constraintName extracts the constraint from "ORA-00001: unique constraint (SCHEMA.NAME) violated"
+var constraintName = regexp.MustCompile(`constraint \((?:[^.)]+\.)?([^)]+)\)`)
+
+// invalidIdentifier extracts the column from `ORA-00904: "NAME": invalid identifier`
+var invalidIdentifier = regexp.MustCompile(`"([^"]+)": invalid identifier`)
+
+// translateError maps Oracle errors from writes to tbl onto storage/errors.
+// Oracle names the violated constraint rather than the column, which
+// constraintColumns maps back to columns.
+func translateError(err error, tbl schema.TableSchema) error {
+	var oraErr interface {
+		Code() int
+		Message() string
+	}
+	if !errors.As(err, &oraErr) {
+		return err
+	}
+
+	switch oraErr.Code() {
+	case 1: // ORA-00001: unique constraint violated
+		var column string
+		if m := constraintName.FindStringSubmatch(oraErr.Message()); m != nil {
+			column = constraintColumns(tbl, m[1])
+		}
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: column, Err: err}
+	case 942: // ORA-00942: table or view does not exist
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Err: err}
+	case 904: // ORA-00904: invalid identifier
+		var field string
+		if m := invalidIdentifier.FindStringSubmatch(oraErr.Message()); m != nil {
+			field = m[1]
+		}
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Field: field, Err: err}
+	}
+	return err
+}
+
+// constraintColumns returns the columns of tbl's unique constraint or index
+// named name. CreateTables names every one but the primary key, which Oracle
+// names SYS_C followed by a number.
+func constraintColumns(tbl schema.TableSchema, name string) string {
+	for _, field := range tbl.Fields {
+		if field.Unique && strings.EqualFold(dialect.UniqueConstraint(tbl.TableName, field.Name), name) {
+			return field.Name
+		}
+	}
+	if columns, ok := common.UniqueIndexColumns(tbl, name); ok {
+		return columns
+	}
+	if strings.HasPrefix(name, "SYS_C") {
+		return common.PrimaryKeyColumn(tbl)
+	}
+	return name
+}
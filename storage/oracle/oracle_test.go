@@ -2,8 +2,12 @@ package oracle
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jadedragon942/ddao/storagetest"
 )
@@ -42,6 +46,23 @@ func TestOracleCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestOracleUpsert(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestOracleLocal runs tests against a local Oracle instance
 // Use: docker run -d -p 1521:1521 -e ORACLE_PASSWORD=OraclePassword123 gvenzl/oracle-xe:21-slim
 func TestOracleLocal(t *testing.T) {
@@ -94,4 +115,187 @@ func TestOracleLocalTransactions(t *testing.T) {
 	defer storage.ResetConnection(ctx)
 
 	storagetest.TransactionTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestOracleQuery(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestOracleList(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestOracleRows(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestOracleErrors(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestOracleBatch(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestOracleKeys(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestOracleReservedWords(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestOracleIndexes(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestOracleAlterTable(t *testing.T) {
+	connStr := os.Getenv("ORACLE_TEST_URL")
+	if connStr == "" {
+		t.Skip("ORACLE_TEST_URL not set, skipping Oracle alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to Oracle storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
+
+func TestOracleTypedArray(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		values []any
+		want   any
+	}{
+		{"strings", []any{"a", nil, "b"}, []string{"a", "", "b"}},
+		{"bytes", []any{nil, []byte("a")}, [][]byte{nil, []byte("a")}},
+		{"integers", []any{1, nil, int64(2)}, []sql.NullInt64{{Int64: 1, Valid: true}, {}, {Int64: 2, Valid: true}}},
+		{"floats", []any{nil, 1.5}, []sql.NullFloat64{{}, {Float64: 1.5, Valid: true}}},
+		{"times", []any{now, nil}, []sql.NullTime{{Time: now, Valid: true}, {}}},
+		{"nulls", []any{nil, nil}, []string{"", ""}},
+	}
+	for _, test := range tests {
+		got, err := typedArray(test.values)
+		if err != nil {
+			t.Errorf("%s: Failed to bind array: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.want, got)
+		}
+	}
+
+	if _, err := typedArray([]any{int64(1), "a"}); !errors.Is(err, errMixedTypes) {
+		t.Errorf("expected errMixedTypes for a mixed column, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// InsertMany creates objs with COPY FROM, failing the objects whose ID already
+// exists with a DuplicateKeyError. When a batch holds a value COPY cannot
+// encode in its binary format, such as a timestamp given as a string, the
+// batch is sent as a multi-row INSERT instead.
+func (s *PostgreSQLStorage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if err := s.ValidateConnection(); err != nil {
+		return nil, err
+	}
+
+	maxParams, _ := s.Dialect.BatchLimits()
+	return common.CommonWriteMany(ctx, s.GetSchema(), objs, opts, maxParams, func(ctx context.Context, b common.InsertBatch) error {
+		err := s.copyBatch(ctx, b)
+		if copyAborted(err) {
+			return s.ExecBatch(ctx, s.GetDB(), b, false)
+		}
+		return err
+	}, func(ctx context.Context, obj *object.Object) error {
+		_, _, err := s.Insert(ctx, obj)
+		return err
+	})
+}
+
+// copyBatch writes b with the COPY protocol, on a pgx connection borrowed from the pool
+func (s *PostgreSQLStorage) copyBatch(ctx context.Context, b common.InsertBatch) error {
+	conn, err := s.GetDB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// pgx quotes the names itself, so pass them as the DDL's quoted names store them
+	columns := make([]string, len(b.Columns))
+	for i, column := range b.Columns {
+		columns[i] = dialect.PostgresIdent(column)
+	}
+
+	storage.DebugLog(fmt.Sprintf("COPY %s (%s) FROM STDIN", b.Table.TableName, strings.Join(columns, ", ")), len(b.Rows))
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		_, err := pgxConn.CopyFrom(ctx, pgx.Identifier{dialect.PostgresIdent(b.Table.TableName)}, columns, pgx.CopyFromRows(b.Rows))
+		return err
+	})
+	if err != nil {
+		return common.TranslatePgError(err, b.Table)
+	}
+	return nil
+}
+
+// copyAborted reports whether err is PostgreSQL acknowledging a COPY the
+// client aborted, as pgx does when it cannot encode a value in the binary
+// format, rather than rejecting the rows
+func copyAborted(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014" && strings.HasPrefix(pgErr.Message, "COPY from stdin failed")
+}
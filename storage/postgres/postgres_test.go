@@ -2,9 +2,15 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/common"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 	"github.com/jadedragon942/ddao/storagetest"
 )
 
@@ -42,6 +48,23 @@ func TestPostgreSQLCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestPostgreSQLUpsert(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestPostgreSQLLocal runs tests against a local PostgreSQL instance
 // Use: docker run --name postgres-test -e POSTGRES_PASSWORD=testpass -e POSTGRES_DB=testdb -p 5432:5432 -d postgres:13
 func TestPostgreSQLLocal(t *testing.T) {
@@ -94,4 +117,180 @@ func TestPostgreSQLLocalTransactions(t *testing.T) {
 	defer storage.ResetConnection(ctx)
 
 	storagetest.TransactionTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestPostgreSQLQuery(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestPostgreSQLList(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestPostgreSQLRows(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestPostgreSQLErrors(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestPostgreSQLBatch(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestPostgreSQLKeys(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestPostgreSQLReservedWords(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestPostgreSQLIndexes(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestPostgreSQLAlterTable(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_TEST_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_TEST_URL not set, skipping PostgreSQL alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
+
+func TestCopyAborted(t *testing.T) {
+	// pgx aborts a COPY holding a value it cannot encode, and the server acknowledges it
+	aborted := &pgconn.PgError{Code: "57014", Message: "COPY from stdin failed: unable to encode \"2024-01-01\" into binary format for timestamptz (OID 1184)"}
+	if !copyAborted(fmt.Errorf("copy: %w", aborted)) {
+		t.Error("expected an aborted COPY to fall back to INSERT")
+	}
+
+	// Rows the server rejects are reported, not replayed as an INSERT
+	unique := common.TranslatePgError(&pgconn.PgError{Code: "23505", Detail: "Key (id)=(a) already exists."}, *schema.NewTableSchema("people"))
+	if copyAborted(unique) {
+		t.Error("expected a unique violation not to fall back to INSERT")
+	}
+	if !errors.Is(unique, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", unique)
+	}
+	if copyAborted(&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}) {
+		t.Error("expected a statement timeout not to fall back to INSERT")
+	}
+	if copyAborted(nil) {
+		t.Error("expected success not to fall back to INSERT")
+	}
+}
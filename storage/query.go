@@ -0,0 +1,388 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// Op identifies the kind of predicate a Query node represents
+type Op string
+
+const (
+	OpEq     Op = "eq"
+	OpNe     Op = "ne"
+	OpLt     Op = "lt"
+	OpLte    Op = "lte"
+	OpGt     Op = "gt"
+	OpGte    Op = "gte"
+	OpIn     Op = "in"
+	OpLike   Op = "like"
+	OpIsNull Op = "isnull"
+	OpAnd    Op = "and"
+	OpOr     Op = "or"
+	OpNot    Op = "not"
+)
+
+// Query is a composable predicate tree passed to Storage.Find.
+// Leaf nodes compare Field against Value (or Values for IN), while
+// AND, OR and NOT nodes combine their Children. The zero Query matches every row.
+type Query struct {
+	Op       Op
+	Field    string
+	Value    any
+	Values   []any
+	Children []Query
+}
+
+// Eq matches rows where field equals value
+func Eq(field string, value any) Query {
+	return Query{Op: OpEq, Field: field, Value: value}
+}
+
+// Ne matches rows where field does not equal value
+func Ne(field string, value any) Query {
+	return Query{Op: OpNe, Field: field, Value: value}
+}
+
+// Lt matches rows where field is less than value
+func Lt(field string, value any) Query {
+	return Query{Op: OpLt, Field: field, Value: value}
+}
+
+// Lte matches rows where field is less than or equal to value
+func Lte(field string, value any) Query {
+	return Query{Op: OpLte, Field: field, Value: value}
+}
+
+// Gt matches rows where field is greater than value
+func Gt(field string, value any) Query {
+	return Query{Op: OpGt, Field: field, Value: value}
+}
+
+// Gte matches rows where field is greater than or equal to value
+func Gte(field string, value any) Query {
+	return Query{Op: OpGte, Field: field, Value: value}
+}
+
+// In matches rows where field equals any of values
+func In(field string, values ...any) Query {
+	return Query{Op: OpIn, Field: field, Values: values}
+}
+
+// Like matches rows where field matches a SQL LIKE pattern (% and _ wildcards)
+func Like(field, pattern string) Query {
+	return Query{Op: OpLike, Field: field, Value: pattern}
+}
+
+// IsNull matches rows where field is NULL or missing
+func IsNull(field string) Query {
+	return Query{Op: OpIsNull, Field: field}
+}
+
+// And matches rows that satisfy every query
+func And(queries ...Query) Query {
+	return Query{Op: OpAnd, Children: queries}
+}
+
+// Or matches rows that satisfy at least one query
+func Or(queries ...Query) Query {
+	return Query{Op: OpOr, Children: queries}
+}
+
+// Not matches rows that do not satisfy query
+func Not(query Query) Query {
+	return Query{Op: OpNot, Children: []Query{query}}
+}
+
+// IsZero reports whether q is the empty query that matches everything
+func (q Query) IsZero() bool {
+	return q.Op == ""
+}
+
+// IsLeaf reports whether q compares a single field rather than combining other queries
+func (q Query) IsLeaf() bool {
+	switch q.Op {
+	case OpAnd, OpOr, OpNot, "":
+		return false
+	default:
+		return true
+	}
+}
+
+// Validate checks that q is well formed and only references fields defined in tbl
+func (q Query) Validate(tbl schema.TableSchema) error {
+	switch q.Op {
+	case "":
+		return nil
+	case OpAnd, OpOr:
+		for _, child := range q.Children {
+			if err := child.Validate(tbl); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OpNot:
+		if len(q.Children) != 1 {
+			return fmt.Errorf("not query must have exactly one child, got %d", len(q.Children))
+		}
+		return q.Children[0].Validate(tbl)
+	case OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpLike, OpIsNull:
+		if q.Field == "" {
+			return fmt.Errorf("%s query must name a field", q.Op)
+		}
+		if _, ok := tbl.Fields[q.Field]; !ok {
+			return ddaoerrors.UnknownField(tbl.TableName, q.Field)
+		}
+		if q.Op == OpLike {
+			if _, ok := q.Value.(string); !ok {
+				return fmt.Errorf("like query on field %s requires a string pattern", q.Field)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported query operator %q", q.Op)
+	}
+}
+
+// Match evaluates q against obj in memory. Backends without a query language
+// (or with a restricted one) use it to filter scanned objects.
+func (q Query) Match(obj *object.Object) bool {
+	switch q.Op {
+	case "":
+		return true
+	case OpAnd:
+		for _, child := range q.Children {
+			if !child.Match(obj) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		for _, child := range q.Children {
+			if child.Match(obj) {
+				return true
+			}
+		}
+		return false
+	case OpNot:
+		if len(q.Children) != 1 {
+			return false
+		}
+		return !q.Children[0].Match(obj)
+	}
+
+	value, exists := fieldValue(obj, q.Field)
+	if q.Op == OpIsNull {
+		return !exists || isNil(value)
+	}
+	if !exists || isNil(value) {
+		// Comparisons against NULL are never true, mirroring SQL semantics
+		return false
+	}
+
+	switch q.Op {
+	case OpEq:
+		c, ok := Compare(value, q.Value)
+		return ok && c == 0
+	case OpNe:
+		c, ok := Compare(value, q.Value)
+		return ok && c != 0
+	case OpLt:
+		c, ok := Compare(value, q.Value)
+		return ok && c < 0
+	case OpLte:
+		c, ok := Compare(value, q.Value)
+		return ok && c <= 0
+	case OpGt:
+		c, ok := Compare(value, q.Value)
+		return ok && c > 0
+	case OpGte:
+		c, ok := Compare(value, q.Value)
+		return ok && c >= 0
+	case OpIn:
+		for _, candidate := range q.Values {
+			if c, ok := Compare(value, candidate); ok && c == 0 {
+				return true
+			}
+		}
+		return false
+	case OpLike:
+		pattern, ok := q.Value.(string)
+		if !ok {
+			return false
+		}
+		return likeToRegexp(pattern).MatchString(fmt.Sprint(deref(value)))
+	default:
+		return false
+	}
+}
+
+// fieldValue looks up a field on obj, falling back to obj.ID for the id column
+func fieldValue(obj *object.Object, name string) (any, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	if value, ok := obj.Fields[name]; ok {
+		return deref(value), true
+	}
+	if name == "id" {
+		return obj.ID, true
+	}
+	return nil, false
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// deref unwraps pointers so *string and string compare the same way
+func deref(value any) any {
+	for value != nil {
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Ptr {
+			return value
+		}
+		if rv.IsNil() {
+			return nil
+		}
+		value = rv.Elem().Interface()
+	}
+	return value
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Compare orders a against b as queries do: numbers by value whatever their
+// types, times chronologically and other values by their text. It returns
+// false when the values are not comparable, as when either is nil.
+func Compare(a, b any) (int, bool) {
+	a, b = deref(a), deref(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum && !bNum {
+		if s, ok := b.(string); ok {
+			bf, bNum = parseFloat(s)
+		}
+	} else if bNum && !aNum {
+		if s, ok := a.(string); ok {
+			af, aNum = parseFloat(s)
+		}
+	}
+	if aNum && bNum {
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	switch av := a.(type) {
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			break
+		}
+		switch {
+		case av == bv:
+			return 0, true
+		case !av:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv), true
+		}
+		if s, ok := b.(string); ok {
+			if bv, err := time.Parse(time.RFC3339, s); err == nil {
+				return av.Compare(bv), true
+			}
+		}
+	case []byte:
+		if bv, ok := b.([]byte); ok {
+			return bytes.Compare(av, bv), true
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)), true
+}
+
+func parseFloat(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
+// likeToRegexp converts a SQL LIKE pattern into an anchored regular expression
+func likeToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
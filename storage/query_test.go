@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func testObject() *object.Object {
+	name := "Alice"
+	return &object.Object{
+		TableName: "people",
+		ID:        "user1",
+		Fields: map[string]any{
+			"name":     &name,
+			"age":      int64(30),
+			"active":   true,
+			"created":  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			"metadata": nil,
+		},
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	obj := testObject()
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected bool
+	}{
+		{"zero", Query{}, true},
+		{"eq pointer", Eq("name", "Alice"), true},
+		{"eq mismatch", Eq("name", "Bob"), false},
+		{"eq id fallback", Eq("id", "user1"), true},
+		{"ne", Ne("name", "Bob"), true},
+		{"lt numeric", Lt("age", 31), true},
+		{"lte numeric string", Lte("age", "30"), true},
+		{"gt", Gt("age", 30), false},
+		{"gte", Gte("age", 30.0), true},
+		{"in", In("age", 1, 30), true},
+		{"in empty", In("age"), false},
+		{"like", Like("name", "A%e"), true},
+		{"like single", Like("name", "Alic_"), true},
+		{"like escaped", Like("name", "A.*"), false},
+		{"is null", IsNull("metadata"), true},
+		{"is null missing", IsNull("missing"), true},
+		{"is null present", IsNull("name"), false},
+		{"null comparison", Eq("metadata", nil), false},
+		{"bool", Eq("active", true), true},
+		{"time", Gt("created", "2023-12-31T00:00:00Z"), true},
+		{"and", And(Eq("name", "Alice"), Gt("age", 18)), true},
+		{"and empty", And(), true},
+		{"or", Or(Eq("name", "Bob"), Eq("age", 30)), true},
+		{"or empty", Or(), false},
+		{"not", Not(Eq("name", "Alice")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.query.Match(obj))
+		})
+	}
+}
+
+func TestQueryValidate(t *testing.T) {
+	tbl := schema.GetTestSchema().Tables["people"]
+
+	assert.NoError(t, Query{}.Validate(*tbl))
+	assert.NoError(t, And(Eq("name", "x"), Or(IsNull("metadata"), In("id", "a"))).Validate(*tbl))
+	assert.Error(t, Eq("missing", "x").Validate(*tbl))
+	assert.Error(t, Not(Eq("missing", "x")).Validate(*tbl))
+	assert.Error(t, Query{Op: OpNot}.Validate(*tbl))
+	assert.Error(t, Query{Op: OpLike, Field: "name", Value: 1}.Validate(*tbl))
+	assert.Error(t, Query{Op: "between", Field: "name"}.Validate(*tbl))
+	assert.Error(t, Query{Op: OpEq}.Validate(*tbl))
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var backends = map[string]func() Storage{}
+
+// Register makes a backend available to Get, and so to ddao.Open, under the
+// URL scheme scheme. Backend packages register themselves in init, so a
+// program only imports the backends it uses, as it would database/sql
+// drivers:
+//
+//	import _ "github.com/jadedragon942/ddao/storage/postgres"
+//
+// Register panics if newStorage is nil or scheme is already registered.
+func Register(scheme string, newStorage func() Storage) {
+	if newStorage == nil {
+		panic("storage: Register constructor is nil")
+	}
+	scheme = strings.ToLower(scheme)
+	if _, dup := backends[scheme]; dup {
+		panic(fmt.Sprintf("storage: Register called twice for scheme %q", scheme))
+	}
+	backends[scheme] = newStorage
+}
+
+// Get returns the constructor registered under scheme, case insensitively
+func Get(scheme string) (func() Storage, bool) {
+	newStorage, ok := backends[strings.ToLower(scheme)]
+	return newStorage, ok
+}
+
+// Schemes returns the registered schemes, sorted
+func Schemes() []string {
+	schemes := make([]string, 0, len(backends))
+	for scheme := range backends {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// TrimScheme returns connStr without its scheme and any // after it, and
+// true, if its scheme is one of schemes, case insensitively. Backends use it
+// to accept the URL ddao.Open passes them alongside their driver's own
+// connection strings.
+func TrimScheme(connStr string, schemes ...string) (string, bool) {
+	scheme, rest, ok := strings.Cut(connStr, ":")
+	if !ok {
+		return connStr, false
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(scheme, s) {
+			return strings.TrimPrefix(rest, "//"), true
+		}
+	}
+	return connStr, false
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() { delete(backends, "fake") })
+
+	Register("Fake", func() Storage { return nil })
+	_, ok := Get("FAKE")
+	assert.True(t, ok)
+	assert.Contains(t, Schemes(), "fake")
+	_, ok = Get("missing")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { Register("fake", func() Storage { return nil }) }, "a scheme is registered once")
+	assert.Panics(t, func() { Register("nil", nil) })
+}
+
+func TestTrimScheme(t *testing.T) {
+	for connStr, want := range map[string]string{
+		"sqlite:app.db":            "app.db",
+		"SQLite:///tmp/app.db":     "/tmp/app.db",
+		"sqlite::memory:":          ":memory:",
+		"sqlite3://app.db?mode=ro": "app.db?mode=ro",
+	} {
+		got, ok := TrimScheme(connStr, "sqlite", "sqlite3")
+		assert.True(t, ok, connStr)
+		assert.Equal(t, want, got, connStr)
+	}
+	for _, connStr := range []string{"app.db", ":memory:", "file:app.db"} {
+		got, ok := TrimScheme(connStr, "sqlite", "sqlite3")
+		assert.False(t, ok, connStr)
+		assert.Equal(t, connStr, got)
+	}
+}
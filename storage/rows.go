@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"iter"
+
+	"github.com/jadedragon942/ddao/object"
+)
+
+// Rows is a forward-only cursor over the results of Storage.FindRows.
+// Objects are produced one at a time as the backend streams them, so large
+// result sets never need to be held in memory. Callers must Close the Rows.
+//
+//	rows, err := store.FindRows(ctx, "people", storage.Query{})
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		obj := rows.Object()
+//		...
+//	}
+//	return rows.Err()
+type Rows interface {
+	// Next advances to the next object, returning false when the results are
+	// exhausted or an error occurred
+	Next() bool
+	// Object returns the object Next advanced to
+	Object() *object.Object
+	// Err returns the error, if any, that stopped iteration
+	Err() error
+	// Close releases the underlying cursor; it is safe to call more than once
+	Close() error
+}
+
+// All adapts rows to a range-over-func iterator. Iteration stops after the
+// first error, which is yielded with a nil object, and rows is closed when
+// the loop ends.
+//
+//	for obj, err := range storage.All(rows) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func All(rows Rows) iter.Seq2[*object.Object, error] {
+	return func(yield func(*object.Object, error) bool) {
+		defer rows.Close()
+
+		for rows.Next() {
+			if !yield(rows.Object(), nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		if err := rows.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Collect drains rows into a slice and closes it
+func Collect(rows Rows) ([]*object.Object, error) {
+	results := make([]*object.Object, 0)
+	for obj, err := range All(rows) {
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, obj)
+	}
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceRows is a Rows over a fixed slice that fails with err once exhausted
+type sliceRows struct {
+	objs   []*object.Object
+	pos    int
+	err    error
+	closed int
+}
+
+func (r *sliceRows) Next() bool {
+	if r.closed > 0 || r.pos >= len(r.objs) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *sliceRows) Object() *object.Object { return r.objs[r.pos-1] }
+func (r *sliceRows) Err() error             { return r.err }
+func (r *sliceRows) Close() error           { r.closed++; return nil }
+
+func testObjects(ids ...string) []*object.Object {
+	objs := make([]*object.Object, 0, len(ids))
+	for _, id := range ids {
+		objs = append(objs, &object.Object{ID: id})
+	}
+	return objs
+}
+
+func TestAll(t *testing.T) {
+	rows := &sliceRows{objs: testObjects("a", "b", "c")}
+
+	var ids []string
+	for obj, err := range All(rows) {
+		assert.NoError(t, err)
+		ids = append(ids, obj.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.NotZero(t, rows.closed)
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	rows := &sliceRows{objs: testObjects("a", "b", "c")}
+
+	for obj := range All(rows) {
+		assert.Equal(t, "a", obj.ID)
+		break
+	}
+	assert.Equal(t, 1, rows.pos)
+	assert.NotZero(t, rows.closed)
+}
+
+func TestAllYieldsError(t *testing.T) {
+	failure := errors.New("connection lost")
+	rows := &sliceRows{objs: testObjects("a"), err: failure}
+
+	var errs []error
+	for obj, err := range All(rows) {
+		if err != nil {
+			assert.Nil(t, obj)
+			errs = append(errs, err)
+		}
+	}
+	assert.Equal(t, []error{failure}, errs)
+
+	_, err := Collect(&sliceRows{objs: testObjects("a"), err: failure})
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestCollect(t *testing.T) {
+	objs, err := Collect(&sliceRows{})
+	assert.NoError(t, err)
+	assert.NotNil(t, objs)
+	assert.Empty(t, objs)
+
+	objs, err = Collect(&sliceRows{objs: testObjects("a", "b")})
+	assert.NoError(t, err)
+	assert.Len(t, objs, 2)
+}
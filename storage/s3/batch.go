@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// InsertMany uploads objs in parallel through the upload manager, opts.Size()
+// at a time, failing the objects whose ID already exists with a DuplicateKeyError
+func (s *S3Storage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.putMany(ctx, objs, opts, false)
+}
+
+// UpsertMany uploads objs in parallel through the upload manager, opts.Size()
+// at a time, overwriting existing objects
+func (s *S3Storage) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	return s.putMany(ctx, objs, opts, true)
+}
+
+func (s *S3Storage) putMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions, upsert bool) (*storage.BatchResult, error) {
+	if s.client == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewObjectsResult(objs)
+	storage.RunConcurrently(len(objs), opts.Size(), func(i int) {
+		if err := ctx.Err(); err != nil {
+			result.Items[i].Err = err
+			return
+		}
+		_, _, result.Items[i].Err = s.put(ctx, objs[i], upsert)
+	})
+	return result, ctx.Err()
+}
+
+// DeleteMany deletes ids from tblName in parallel, opts.Size() at a time
+func (s *S3Storage) DeleteMany(ctx context.Context, tblName string, ids []string, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if s.client == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewIDsResult(tblName, ids)
+	var deleted atomic.Int64
+	storage.RunConcurrently(len(ids), opts.Size(), func(i int) {
+		if err := ctx.Err(); err != nil {
+			result.Items[i].Err = err
+			return
+		}
+		ok, err := s.DeleteByID(ctx, tblName, ids[i])
+		if err != nil {
+			result.Items[i].Err = err
+			return
+		}
+		if ok {
+			deleted.Add(1)
+		}
+	})
+	result.Deleted = deleted.Load()
+	return result, ctx.Err()
+}
@@ -0,0 +1,204 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// Indexes are kept as empty entry objects under
+// tables/<table>/indexes/<index>/<value>/.../<id>, one path segment per
+// indexed column, so the objects with given values are found by listing a
+// prefix. Entries are written after the object itself and are not atomic
+// with it, so readers check the object they point to before trusting them.
+// Objects missing a value for an indexed column have no entry in that index.
+
+// indexEntry is the S3 key of one index entry
+type indexEntry struct {
+	key   string
+	index schema.Index
+}
+
+// indexes returns the indexes maintained for tblName: its secondary indexes,
+// plus a unique index per unique field outside the primary key
+func (s *S3Storage) indexes(tblName string) []schema.Index {
+	if s.sch == nil {
+		return nil
+	}
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return nil
+	}
+
+	indexes := tbl.SecondaryIndexes()
+	keyColumns := tbl.PrimaryKeyColumns()
+	for _, name := range tbl.FieldOrder {
+		if tbl.Fields[name].Unique && !slices.Contains(keyColumns, name) {
+			indexes = append(indexes, schema.Index{Name: "uq_" + tblName + "_" + name, Columns: []string{name}, Unique: true})
+		}
+	}
+	return indexes
+}
+
+// indexPrefix returns the S3 key prefix of index's entries starting with values
+func (s *S3Storage) indexPrefix(tblName string, index schema.Index, values []string) string {
+	return s.prefix + "tables/" + tblName + "/indexes/" + index.Name + "/" + strings.Join(values, "/") + "/"
+}
+
+// indexValues returns obj's path-escaped values for index's columns, or
+// false if any of them is missing
+func indexValues(obj *object.Object, index schema.Index) ([]string, bool) {
+	values := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		value, ok := obj.Fields[column]
+		if !ok || value == nil {
+			return nil, false
+		}
+		values[i] = url.PathEscape(fmt.Sprint(value))
+	}
+	return values, true
+}
+
+// indexEntries returns the index entries obj should have; none for a nil obj
+func (s *S3Storage) indexEntries(obj *object.Object) []indexEntry {
+	if obj == nil {
+		return nil
+	}
+	var entries []indexEntry
+	for _, index := range s.indexes(obj.TableName) {
+		if values, ok := indexValues(obj, index); ok {
+			entries = append(entries, indexEntry{
+				key:   s.indexPrefix(obj.TableName, index, values) + url.PathEscape(obj.ID),
+				index: index,
+			})
+		}
+	}
+	return entries
+}
+
+// entryID returns the ID of the object an index entry points to
+func entryID(key string) string {
+	id := key[strings.LastIndex(key, "/")+1:]
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		return unescaped
+	}
+	return id
+}
+
+// indexed lists the entries under prefix and returns the objects they point
+// to for which match holds, stopping once limit are found. Entries whose
+// object is gone or no longer matches are skipped.
+func (s *S3Storage) indexed(ctx context.Context, tblName, prefix string, limit int, match func(*object.Object) bool) ([]*object.Object, error) {
+	storage.DebugLog("ListObjectsV2 (index)", prefix)
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var results []*object.Object
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list index entries: %w", err)
+		}
+		for _, item := range page.Contents {
+			obj, err := s.readObject(ctx, s.getObjectKey(tblName, entryID(*item.Key)))
+			if err != nil {
+				return nil, err
+			}
+			if obj != nil && match(obj) {
+				results = append(results, obj)
+				if len(results) == limit {
+					return results, nil
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// checkUnique fails with a DuplicateKeyError if another object holds obj's
+// values in one of its table's unique indexes
+func (s *S3Storage) checkUnique(ctx context.Context, obj *object.Object) error {
+	for _, entry := range s.indexEntries(obj) {
+		if !entry.index.Unique {
+			continue
+		}
+		values, _ := indexValues(obj, entry.index)
+		others, err := s.indexed(ctx, obj.TableName, s.indexPrefix(obj.TableName, entry.index, values), 1, func(other *object.Object) bool {
+			otherValues, ok := indexValues(other, entry.index)
+			return other.ID != obj.ID && ok && slices.Equal(otherValues, values)
+		})
+		if err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			return &ddaoerrors.DuplicateKeyError{Table: obj.TableName, Column: ddaoerrors.KeyColumns(entry.index.Columns)}
+		}
+	}
+	return nil
+}
+
+// updateIndexes moves the index entries of an object from its old state to
+// its new one. old is nil for a new object, and obj is nil for a deleted one.
+func (s *S3Storage) updateIndexes(ctx context.Context, old, obj *object.Object) error {
+	oldEntries := s.indexEntries(old)
+	newEntries := s.indexEntries(obj)
+	contains := func(entries []indexEntry, key string) bool {
+		return slices.ContainsFunc(entries, func(entry indexEntry) bool { return entry.key == key })
+	}
+
+	for _, entry := range newEntries {
+		if contains(oldEntries, entry.key) {
+			continue
+		}
+		storage.DebugLog("PutObject (index entry)", entry.key)
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(entry.key),
+			Body:   bytes.NewReader(nil),
+			Metadata: map[string]string{
+				"ddao-type":  "index-entry",
+				"ddao-index": entry.index.Name,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write index entry %s: %w", entry.key, err)
+		}
+	}
+
+	for _, entry := range oldEntries {
+		if contains(newEntries, entry.key) {
+			continue
+		}
+		storage.DebugLog("DeleteObject (index entry)", entry.key)
+		_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(entry.key),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete index entry %s: %w", entry.key, err)
+		}
+	}
+	return nil
+}
+
+// keyIndex returns an index of tblName whose leading column is key
+func (s *S3Storage) keyIndex(tblName, key string) (schema.Index, bool) {
+	for _, index := range s.indexes(tblName) {
+		if index.Columns[0] == key {
+			return index, true
+		}
+	}
+	return schema.Index{}, false
+}
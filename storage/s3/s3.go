@@ -3,13 +3,13 @@ package s3
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,17 +21,18 @@ import (
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
 	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 // S3Storage implements the DDAO storage interface using Amazon S3
 type S3Storage struct {
-	client    *s3.Client
-	uploader  *manager.Uploader
-	bucket    string
-	prefix    string
-	region    string
-	sch       *schema.Schema
-	verbose   bool
+	client   *s3.Client
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+	region   string
+	sch      *schema.Schema
+	verbose  bool
 }
 
 // S3Object represents a stored object in S3
@@ -43,17 +44,14 @@ type S3Object struct {
 	UpdatedAt time.Time              `json:"updated_at,omitempty"`
 }
 
-// S3Transaction represents a transaction context for S3 operations
-type S3Transaction struct {
-	ID        string
-	CreatedAt time.Time
-	Objects   map[string]*S3Object // key format: "table/id"
-}
-
 func New() storage.Storage {
 	return &S3Storage{}
 }
 
+func init() {
+	storage.Register("s3", New)
+}
+
 // Connect establishes a connection to S3
 // connStr format: "s3://bucket/prefix?region=us-east-1&endpoint=http://localhost:9000"
 // Examples:
@@ -116,30 +114,89 @@ func (s *S3Storage) Connect(ctx context.Context, connStr string) error {
 		log.Printf("Connected to S3 bucket: %s, prefix: %s, region: %s", s.bucket, s.prefix, s.region)
 	}
 
-	return nil
+	return s.downloadSchema(ctx)
+}
+
+// GetSchema returns the schema given to CreateTables, or the one stored by an
+// earlier connection and reloaded by Connect; nil when there is neither
+func (s *S3Storage) GetSchema() *schema.Schema {
+	return s.sch
 }
 
 // CreateTables creates the necessary "table" structure in S3
-// For S3, this means creating metadata files for each table
+// For S3, this means creating metadata files for each table. Foreign keys
+// are stored with the schema but not enforced.
 func (s *S3Storage) CreateTables(ctx context.Context, schema *schema.Schema) error {
 	if s.client == nil {
-		return errors.New("not connected to S3")
+		return ddaoerrors.ErrNotConnected
 	}
 
 	s.sch = schema
 
-	// Create a metadata file for the schema
-	schemaData, err := json.MarshalIndent(schema, "", "  ")
+	if err := s.uploadSchema(ctx); err != nil {
+		return err
+	}
+
+	// Create metadata files for each table
+	for _, table := range schema.Tables {
+		if err := s.uploadTableMetadata(ctx, *table); err != nil {
+			return err
+		}
+
+		if s.verbose {
+			log.Printf("Created table metadata for: %s", table.TableName)
+		}
+	}
+
+	if s.verbose {
+		log.Printf("Created %d tables in S3", len(schema.Tables))
+	}
+
+	return nil
+}
+
+// downloadSchema reloads the schema from _schema.json, if a previous
+// CreateTables or AlterTable wrote one
+func (s *S3Storage) downloadSchema(ctx context.Context) error {
+	schemaKey := s.prefix + "_schema.json"
+	storage.DebugLog("GetObject (schema)", schemaKey)
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(schemaKey),
+	})
 	if err != nil {
+		var nfe *types.NoSuchKey
+		if errors.As(err, &nfe) {
+			return nil
+		}
+		return fmt.Errorf("failed to get schema from S3: %w", err)
+	}
+	defer result.Body.Close()
+
+	sch, err := schema.Load(result.Body)
+	if err != nil {
+		return fmt.Errorf("failed to load schema %s: %w", schemaKey, err)
+	}
+	s.sch = sch
+	if s.verbose {
+		log.Printf("Loaded schema with %d tables from %s", len(sch.Tables), schemaKey)
+	}
+	return nil
+}
+
+// uploadSchema writes the schema to _schema.json in the schema file format
+func (s *S3Storage) uploadSchema(ctx context.Context) error {
+	var schemaData bytes.Buffer
+	if err := schema.Save(&schemaData, s.sch); err != nil {
 		return fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
 	schemaKey := s.prefix + "_schema.json"
 	storage.DebugLog("PutObject (schema)", schemaKey)
-	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(schemaKey),
-		Body:   bytes.NewReader(schemaData),
+		Body:   bytes.NewReader(schemaData.Bytes()),
 		Metadata: map[string]string{
 			"ddao-type":      "schema",
 			"ddao-timestamp": time.Now().UTC().Format(time.RFC3339),
@@ -148,72 +205,77 @@ func (s *S3Storage) CreateTables(ctx context.Context, schema *schema.Schema) err
 	if err != nil {
 		return fmt.Errorf("failed to upload schema: %w", err)
 	}
+	return nil
+}
 
-	// Create metadata files for each table
-	for _, table := range schema.Tables {
-		tableMetadata := map[string]interface{}{
-			"table_name": table.TableName,
-			"fields":     table.Fields,
-			"created_at": time.Now().UTC(),
-		}
-
-		metadataData, err := json.MarshalIndent(tableMetadata, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal table metadata for %s: %w", table.TableName, err)
-		}
-
-		metadataKey := s.prefix + "tables/" + table.TableName + "/_metadata.json"
-		storage.DebugLog("PutObject (table metadata)", metadataKey)
-		_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
-			Bucket: aws.String(s.bucket),
-			Key:    aws.String(metadataKey),
-			Body:   bytes.NewReader(metadataData),
-			Metadata: map[string]string{
-				"ddao-type":      "table-metadata",
-				"ddao-table":     table.TableName,
-				"ddao-timestamp": time.Now().UTC().Format(time.RFC3339),
-			},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to upload table metadata for %s: %w", table.TableName, err)
-		}
-
-		if s.verbose {
-			log.Printf("Created table metadata for: %s", table.TableName)
-		}
+// uploadTableMetadata writes table's definition to its _metadata.json
+func (s *S3Storage) uploadTableMetadata(ctx context.Context, table schema.TableSchema) error {
+	tableMetadata := map[string]interface{}{
+		"table_name": table.TableName,
+		"fields":     table.Fields,
+		"created_at": time.Now().UTC(),
 	}
 
-	if s.verbose {
-		log.Printf("Created %d tables in S3", len(schema.Tables))
+	metadataData, err := json.MarshalIndent(tableMetadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal table metadata for %s: %w", table.TableName, err)
 	}
 
+	metadataKey := s.prefix + "tables/" + table.TableName + "/_metadata.json"
+	storage.DebugLog("PutObject (table metadata)", metadataKey)
+	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(metadataKey),
+		Body:   bytes.NewReader(metadataData),
+		Metadata: map[string]string{
+			"ddao-type":      "table-metadata",
+			"ddao-table":     table.TableName,
+			"ddao-timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload table metadata for %s: %w", table.TableName, err)
+	}
 	return nil
 }
 
-// Insert creates a new object in S3
+// Insert creates a new object in S3, failing with a DuplicateKeyError if its ID already exists
 func (s *S3Storage) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return s.put(ctx, obj, false)
+}
+
+// Upsert creates an object in S3 or overwrites an existing one
+func (s *S3Storage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	return s.put(ctx, obj, true)
+}
+
+// put uploads obj, overwriting an existing object only when upsert is set.
+// Inserts are conditional on the key not existing (If-None-Match: *), so two
+// concurrent inserts of the same ID cannot both succeed.
+func (s *S3Storage) put(ctx context.Context, obj *object.Object, upsert bool) ([]byte, bool, error) {
 
 	if s.client == nil {
-		return nil, false, errors.New("not connected to S3")
+		return nil, false, ddaoerrors.ErrNotConnected
 	}
 
-	// Check if object already exists
-	objectKey := s.getObjectKey(obj.TableName, obj.ID)
-	storage.DebugLog("GetObject (check exists)", objectKey)
-	_, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(objectKey),
-	})
+	obj, err := s.keyed(obj)
+	if err != nil {
+		return nil, false, err
+	}
 
-	created := false
+	// Check if object already exists, keeping it to move its index entries
+	objectKey := s.getObjectKey(obj.TableName, obj.ID)
+	existing, err := s.readObject(ctx, objectKey)
 	if err != nil {
-		// Object doesn't exist, we can create it
-		var nfe *types.NoSuchKey
-		if errors.As(err, &nfe) {
-			created = true
-		} else {
-			return nil, false, fmt.Errorf("failed to check if object exists: %w", err)
-		}
+		return nil, false, fmt.Errorf("failed to check if object exists: %w", err)
+	}
+
+	created := existing == nil
+	if !created && !upsert {
+		return nil, false, s.duplicateKeyError(obj.TableName, nil)
+	}
+	if err := s.checkUnique(ctx, obj); err != nil {
+		return nil, false, err
 	}
 
 	// Create S3Object
@@ -235,9 +297,7 @@ func (s *S3Storage) Insert(ctx context.Context, obj *object.Object) ([]byte, boo
 		return nil, false, fmt.Errorf("failed to marshal object: %w", err)
 	}
 
-	// Upload to S3
-	storage.DebugLog("PutObject (insert)", objectKey)
-	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(objectKey),
 		Body:   bytes.NewReader(objData),
@@ -247,10 +307,23 @@ func (s *S3Storage) Insert(ctx context.Context, obj *object.Object) ([]byte, boo
 			"ddao-id":        obj.ID,
 			"ddao-timestamp": time.Now().UTC().Format(time.RFC3339),
 		},
-	})
+	}
+	if !upsert {
+		input.IfNoneMatch = aws.String("*")
+	}
+
+	// Upload to S3
+	storage.DebugLog("PutObject (insert)", objectKey)
+	_, err = s.uploader.Upload(ctx, input)
 	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, false, s.duplicateKeyError(obj.TableName, err)
+		}
 		return nil, false, fmt.Errorf("failed to upload object: %w", err)
 	}
+	if err := s.updateIndexes(ctx, existing, obj); err != nil {
+		return nil, false, err
+	}
 
 	if s.verbose {
 		log.Printf("Inserted object: %s/%s (created: %v)", obj.TableName, obj.ID, created)
@@ -263,24 +336,27 @@ func (s *S3Storage) Insert(ctx context.Context, obj *object.Object) ([]byte, boo
 func (s *S3Storage) Update(ctx context.Context, obj *object.Object) (bool, error) {
 
 	if s.client == nil {
-		return false, errors.New("not connected to S3")
+		return false, ddaoerrors.ErrNotConnected
+	}
+
+	obj, err := s.keyed(obj)
+	if err != nil {
+		return false, err
 	}
 
 	objectKey := s.getObjectKey(obj.TableName, obj.ID)
 
-	// Check if object exists
-	storage.DebugLog("GetObject (update check)", objectKey)
-	_, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(objectKey),
-	})
+	// Check if object exists, keeping it to move its index entries
+	existing, err := s.readObject(ctx, objectKey)
 	if err != nil {
-		var nfe *types.NoSuchKey
-		if errors.As(err, &nfe) {
-			return false, nil // Object doesn't exist
-		}
 		return false, fmt.Errorf("failed to check if object exists: %w", err)
 	}
+	if existing == nil {
+		return false, nil // Object doesn't exist
+	}
+	if err := s.checkUnique(ctx, obj); err != nil {
+		return false, err
+	}
 
 	// Create updated S3Object
 	s3Obj := &S3Object{
@@ -312,6 +388,9 @@ func (s *S3Storage) Update(ctx context.Context, obj *object.Object) (bool, error
 	if err != nil {
 		return false, fmt.Errorf("failed to upload updated object: %w", err)
 	}
+	if err := s.updateIndexes(ctx, existing, obj); err != nil {
+		return false, err
+	}
 
 	if s.verbose {
 		log.Printf("Updated object: %s/%s", obj.TableName, obj.ID)
@@ -320,21 +399,11 @@ func (s *S3Storage) Update(ctx context.Context, obj *object.Object) (bool, error
 	return true, nil
 }
 
-// Upsert inserts or updates an object, delegating to Insert which already implements upsert behavior
-func (s *S3Storage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
-	return s.Insert(ctx, obj)
-}
-
-// UpsertTx inserts or updates an object within a transaction, delegating to InsertTx which already implements upsert behavior
-func (s *S3Storage) UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	return s.InsertTx(ctx, tx, obj)
-}
-
 // FindByID retrieves an object by its ID
 func (s *S3Storage) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
 
 	if s.client == nil {
-		return nil, errors.New("not connected to S3")
+		return nil, ddaoerrors.ErrNotConnected
 	}
 
 	objectKey := s.getObjectKey(tblName, id)
@@ -348,7 +417,7 @@ func (s *S3Storage) FindByID(ctx context.Context, tblName, id string) (*object.O
 	if err != nil {
 		var nfe *types.NoSuchKey
 		if errors.As(err, &nfe) {
-			return nil, nil // Object not found
+			return nil, ddaoerrors.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get object from S3: %w", err)
 	}
@@ -380,11 +449,28 @@ func (s *S3Storage) FindByID(ctx context.Context, tblName, id string) (*object.O
 	return obj, nil
 }
 
-// FindByKey searches for objects by a specific field value
+// FindByKey searches for objects by a specific field value. Fields leading
+// one of the table's indexes are looked up through the index; any other field
+// scans every object in the table.
 func (s *S3Storage) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
 
 	if s.client == nil {
-		return nil, errors.New("not connected to S3")
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	if index, ok := s.keyIndex(tblName, key); ok {
+		prefix := s.indexPrefix(tblName, index, []string{url.PathEscape(value)})
+		found, err := s.indexed(ctx, tblName, prefix, 1, func(obj *object.Object) bool {
+			fieldValue, exists := obj.Fields[key]
+			return exists && fmt.Sprintf("%v", fieldValue) == value
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, ddaoerrors.ErrNotFound
+		}
+		return found[0], nil
 	}
 
 	// List all objects in the table
@@ -446,31 +532,315 @@ func (s *S3Storage) FindByKey(ctx context.Context, tblName, key, value string) (
 		}
 	}
 
-	return nil, nil // Not found
+	return nil, ddaoerrors.ErrNotFound
 }
 
-// DeleteByID removes an object by its ID
-func (s *S3Storage) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+// Find scans every object stored under a table and returns those matching q
+func (s *S3Storage) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	rows, err := s.FindRows(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := storage.Collect(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.verbose {
+		log.Printf("Found %d objects in %s", len(results), tblName)
+	}
+
+	return results, nil
+}
+
+// FindRows streams the objects stored under a table that match q, downloading
+// one object at a time and listing further keys only as they are needed
+func (s *S3Storage) FindRows(ctx context.Context, tblName string, q storage.Query) (storage.Rows, error) {
 
 	if s.client == nil {
-		return false, errors.New("not connected to S3")
+		return nil, ddaoerrors.ErrNotConnected
 	}
 
-	objectKey := s.getObjectKey(tblName, id)
+	if s.sch != nil {
+		if tbl, ok := s.sch.GetTable(tblName); ok {
+			if err := q.Validate(tbl); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tablePrefix := s.prefix + "tables/" + tblName + "/objects/"
+
+	storage.DebugLog("ListObjectsV2 (find)", tablePrefix)
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(tablePrefix),
+	})
+
+	return &s3Rows{
+		ctx:       ctx,
+		storage:   s,
+		paginator: paginator,
+		query:     q,
+	}, nil
+}
+
+// s3Rows walks a ListObjectsV2 paginator page by page, fetching and filtering
+// each object as the caller advances
+type s3Rows struct {
+	ctx       context.Context
+	storage   *S3Storage
+	paginator *s3.ListObjectsV2Paginator
+	query     storage.Query
+	keys      []string
+	current   *object.Object
+	closed    bool
+	err       error
+}
+
+func (r *s3Rows) Next() bool {
+	r.current = nil
+	for !r.closed {
+		if len(r.keys) == 0 {
+			if !r.paginator.HasMorePages() {
+				r.closed = true
+				return false
+			}
 
-	// Check if object exists
-	storage.DebugLog("GetObject (delete check)", objectKey)
-	_, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+			page, err := r.paginator.NextPage(r.ctx)
+			if err != nil {
+				r.err = fmt.Errorf("failed to list objects: %w", err)
+				r.closed = true
+				return false
+			}
+
+			for _, item := range page.Contents {
+				if strings.HasSuffix(*item.Key, ".json") {
+					r.keys = append(r.keys, *item.Key)
+				}
+			}
+			continue
+		}
+
+		key := r.keys[0]
+		r.keys = r.keys[1:]
+
+		obj, err := r.storage.readObject(r.ctx, key)
+		if err != nil {
+			r.err = err
+			r.closed = true
+			return false
+		}
+		if obj == nil {
+			continue // Deleted between listing and reading
+		}
+
+		if r.query.Match(obj) {
+			r.current = obj
+			return true
+		}
+	}
+	return false
+}
+
+func (r *s3Rows) Object() *object.Object {
+	return r.current
+}
+
+func (r *s3Rows) Err() error {
+	return r.err
+}
+
+func (r *s3Rows) Close() error {
+	r.closed = true
+	r.keys = nil
+	return nil
+}
+
+// List returns a page of objects from tblName. In the default order, by key,
+// it pages with ListObjectsV2 and uses its continuation token as the cursor.
+// Other orders read the whole table and sort it by the order column and key,
+// resuming after the last object's value and ID as the SQL backends do.
+func (s *S3Storage) List(ctx context.Context, tblName string, opts storage.ListOptions) ([]*object.Object, string, error) {
+
+	if s.client == nil {
+		return nil, "", ddaoerrors.ErrNotConnected
+	}
+
+	var tbl schema.TableSchema
+	if s.sch != nil {
+		if t, ok := s.sch.GetTable(tblName); ok {
+			tbl = t
+			if err := opts.Validate(tbl); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if column, desc := opts.Order(); column != "id" || desc {
+		return s.listSorted(ctx, tblName, tbl, opts)
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.prefix + "tables/" + tblName + "/objects/"),
+		MaxKeys: aws.Int32(int32(opts.PageSize())),
+	}
+	if opts.Cursor != "" {
+		cursor, err := storage.DecodeCursor(opts, tbl)
+		if err != nil {
+			return nil, "", err
+		}
+		input.ContinuationToken = aws.String(string(cursor.State))
+	}
+
+	storage.DebugLog("ListObjectsV2 (list)", *input.Prefix)
+	page, err := s.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	results := make([]*object.Object, 0, len(page.Contents))
+	for _, item := range page.Contents {
+		if !strings.HasSuffix(*item.Key, ".json") {
+			continue
+		}
+
+		obj, err := s.readObject(ctx, *item.Key)
+		if err != nil {
+			return nil, "", err
+		}
+		if obj != nil {
+			results = append(results, obj)
+		}
+	}
+
+	if !aws.ToBool(page.IsTruncated) || page.NextContinuationToken == nil {
+		return results, "", nil
+	}
+	return results, storage.NewStateCursor(opts, []byte(*page.NextContinuationToken)).Encode(), nil
+}
+
+// listSorted returns a page of tblName's objects in an order S3 does not
+// list keys in
+func (s *S3Storage) listSorted(ctx context.Context, tblName string, tbl schema.TableSchema, opts storage.ListOptions) ([]*object.Object, string, error) {
+	column, desc := opts.Order()
+	compare := func(a, b *object.Object) int {
+		c := 0
+		if column != "id" {
+			c, _ = storage.Compare(a.Fields[column], b.Fields[column])
+		}
+		if c == 0 {
+			c = compareIDs(tbl, a.ID, b.ID)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+
+	var after *object.Object
+	if opts.Cursor != "" {
+		cursor, err := storage.DecodeCursor(opts, tbl)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &object.Object{ID: cursor.ID, Fields: map[string]any{column: cursor.Value}}
+	}
+
+	objs, err := s.Find(ctx, tblName, storage.Query{})
+	if err != nil {
+		return nil, "", err
+	}
+	slices.SortFunc(objs, compare)
+	if after != nil {
+		start, _ := slices.BinarySearchFunc(objs, after, func(obj, after *object.Object) int {
+			if compare(obj, after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		objs = objs[start:]
+	}
+
+	limit := opts.PageSize()
+	if len(objs) <= limit {
+		return objs, "", nil
+	}
+	objs = objs[:limit]
+	last := objs[limit-1]
+	var value any
+	if column != "id" {
+		value = last.Fields[column]
+	}
+	return objs, storage.NewCursor(opts, value, last.ID).Encode(), nil
+}
+
+// compareIDs orders two IDs of tbl by their primary key values, or by their
+// text if either does not parse
+func compareIDs(tbl schema.TableSchema, a, b string) int {
+	aKey, aErr := storage.ParseID(tbl, a)
+	bKey, bErr := storage.ParseID(tbl, b)
+	if aErr == nil && bErr == nil && len(aKey) == len(bKey) {
+		for i := range aKey {
+			if c, ok := storage.Compare(aKey[i].Value, bKey[i].Value); ok && c != 0 {
+				return c
+			}
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// readObject downloads and decodes a single stored object, returning nil if it does not exist
+func (s *S3Storage) readObject(ctx context.Context, objectKey string) (*object.Object, error) {
+	storage.DebugLog("GetObject", objectKey)
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(objectKey),
 	})
 	if err != nil {
 		var nfe *types.NoSuchKey
 		if errors.As(err, &nfe) {
-			return false, nil // Object doesn't exist
+			return nil, nil
 		}
+		return nil, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	defer result.Body.Close()
+
+	objData, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object data: %w", err)
+	}
+
+	var s3Obj S3Object
+	if err := json.Unmarshal(objData, &s3Obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal object: %w", err)
+	}
+
+	return &object.Object{
+		ID:        s3Obj.ID,
+		TableName: s3Obj.TableName,
+		Fields:    s3Obj.Fields,
+	}, nil
+}
+
+// DeleteByID removes an object by its ID
+func (s *S3Storage) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+
+	if s.client == nil {
+		return false, ddaoerrors.ErrNotConnected
+	}
+
+	objectKey := s.getObjectKey(tblName, id)
+
+	// Check if object exists, keeping it to remove its index entries
+	existing, err := s.readObject(ctx, objectKey)
+	if err != nil {
 		return false, fmt.Errorf("failed to check if object exists: %w", err)
 	}
+	if existing == nil {
+		return false, nil // Object doesn't exist
+	}
 
 	// Delete object
 	storage.DebugLog("DeleteObject", objectKey)
@@ -481,6 +851,9 @@ func (s *S3Storage) DeleteByID(ctx context.Context, tblName, id string) (bool, e
 	if err != nil {
 		return false, fmt.Errorf("failed to delete object: %w", err)
 	}
+	if err := s.updateIndexes(ctx, existing, nil); err != nil {
+		return false, err
+	}
 
 	if s.verbose {
 		log.Printf("Deleted object: %s/%s", tblName, id)
@@ -502,56 +875,169 @@ func (s *S3Storage) ResetConnection(ctx context.Context) error {
 	return nil
 }
 
-// getObjectKey returns the S3 key for a specific object
-func (s *S3Storage) getObjectKey(tableName, id string) string {
-	return s.prefix + "tables/" + tableName + "/objects/" + id + ".json"
-}
+// keyed returns obj with its ID derived from its table's primary key fields,
+// so each object is stored under its key however the caller identified it.
+// Fields the table does not define are rejected.
+func (s *S3Storage) keyed(obj *object.Object) (*object.Object, error) {
+	if s.sch == nil {
+		return obj, nil
+	}
+	tbl, ok := s.sch.GetTable(obj.TableName)
+	if !ok {
+		return obj, nil
+	}
 
-// Transaction support - S3 doesn't have native transactions, so we implement a simple version
-// Note: This is a basic implementation and not ACID compliant
+	keyColumns := tbl.PrimaryKeyColumns()
+	for name := range obj.Fields {
+		if _, ok := tbl.Fields[name]; !ok && !slices.Contains(keyColumns, name) {
+			return nil, ddaoerrors.UnknownField(tbl.TableName, name)
+		}
+	}
 
-func (s *S3Storage) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	// S3 doesn't support traditional transactions
-	// Return a mock transaction that we'll handle in our transaction methods
-	return &sql.Tx{}, nil
+	key, err := obj.Key(keyColumns)
+	if err != nil {
+		return nil, err
+	}
+	if id := key.String(); id != obj.ID {
+		keyed := *obj
+		keyed.ID = id
+		return &keyed, nil
+	}
+	return obj, nil
 }
 
-func (s *S3Storage) CommitTx(tx *sql.Tx) error {
-	// For S3, commit is a no-op since we don't buffer operations
-	return nil
+// duplicateKeyError reports an insert into tblName whose key already exists
+func (s *S3Storage) duplicateKeyError(tblName string, err error) error {
+	column := "id"
+	if s.sch != nil {
+		if tbl, ok := s.sch.GetTable(tblName); ok {
+			column = ddaoerrors.KeyColumns(tbl.PrimaryKeyColumns())
+		}
+	}
+	return &ddaoerrors.DuplicateKeyError{Table: tblName, Column: column, Err: err}
 }
 
-func (s *S3Storage) RollbackTx(tx *sql.Tx) error {
-	// For S3, rollback is limited since we can't undo S3 operations easily
-	// In a real implementation, you might implement a transaction log
-	return nil
+// getObjectKey returns the S3 key for a specific object
+func (s *S3Storage) getObjectKey(tableName, id string) string {
+	return s.prefix + "tables/" + tableName + "/objects/" + id + ".json"
 }
 
-func (s *S3Storage) InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	// For simplicity, delegate to regular Insert
-	return s.Insert(ctx, obj)
+// isPreconditionFailed reports whether S3 rejected a conditional write because
+// the key already exists or another conditional write to it is in flight
+func isPreconditionFailed(err error) bool {
+	var apiErr interface{ ErrorCode() string }
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	code := apiErr.ErrorCode()
+	return code == "PreconditionFailed" || code == "ConditionalRequestConflict"
 }
 
-func (s *S3Storage) UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error) {
-	// For simplicity, delegate to regular Update
-	return s.Update(ctx, obj)
-}
+// AlterTable applies ops to tableName's definition in the schema. Objects are
+// schemaless JSON, so only renaming or dropping columns and renaming the
+// table touch them: every object of the table is rewritten, along with its
+// index entries. The rewrite is not atomic; a failure part way leaves the
+// objects before it rewritten.
+func (s *S3Storage) AlterTable(ctx context.Context, tableName string, ops []storage.AlterOp) error {
+	if s.client == nil {
+		return ddaoerrors.ErrNotConnected
+	}
+	if s.sch == nil {
+		return ddaoerrors.UnknownTable(tableName)
+	}
+	tbl, ok := s.sch.GetTable(tableName)
+	if !ok {
+		return ddaoerrors.UnknownTable(tableName)
+	}
+	altered, err := storage.AlterTableSchema(tbl, ops)
+	if err != nil {
+		return err
+	}
 
-func (s *S3Storage) FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error) {
-	// For simplicity, delegate to regular FindByID
-	return s.FindByID(ctx, tblName, id)
-}
+	var objs []*object.Object
+	if rewritesObjects(ops) {
+		// Read the objects and drop their index entries while the old definition is in place
+		opts := storage.ListOptions{}
+		for {
+			page, cursor, err := s.List(ctx, tableName, opts)
+			if err != nil {
+				return err
+			}
+			objs = append(objs, page...)
+			if cursor == "" {
+				break
+			}
+			opts.Cursor = cursor
+		}
+		for _, obj := range objs {
+			if err := s.updateIndexes(ctx, obj, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.sch.ReplaceTable(tableName, altered)
+
+	for _, obj := range objs {
+		if _, _, err := s.put(ctx, alterObject(obj, altered.TableName, ops), true); err != nil {
+			return err
+		}
+		if altered.TableName != tableName {
+			objectKey := s.getObjectKey(tableName, obj.ID)
+			storage.DebugLog("DeleteObject (renamed table)", objectKey)
+			if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(objectKey),
+			}); err != nil {
+				return fmt.Errorf("failed to delete object: %w", err)
+			}
+		}
+	}
 
-func (s *S3Storage) FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error) {
-	// For simplicity, delegate to regular FindByKey
-	return s.FindByKey(ctx, tblName, key, value)
+	if altered.TableName != tableName {
+		metadataKey := s.prefix + "tables/" + tableName + "/_metadata.json"
+		storage.DebugLog("DeleteObject (table metadata)", metadataKey)
+		if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(metadataKey),
+		}); err != nil {
+			return fmt.Errorf("failed to delete table metadata for %s: %w", tableName, err)
+		}
+	}
+	if err := s.uploadSchema(ctx); err != nil {
+		return err
+	}
+	return s.uploadTableMetadata(ctx, *altered)
 }
 
-func (s *S3Storage) DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error) {
-	// For simplicity, delegate to regular DeleteByID
-	return s.DeleteByID(ctx, tblName, id)
+// rewritesObjects reports whether one of ops changes the stored objects
+func rewritesObjects(ops []storage.AlterOp) bool {
+	for _, op := range ops {
+		switch op.Kind {
+		case storage.AlterDropColumn, storage.AlterRenameColumn, storage.AlterRenameTable:
+			return true
+		}
+	}
+	return false
 }
 
-func (s *S3Storage) AlterTable(ctx context.Context, tableName, columnName, dataType string, nullable bool) error {
-	return errors.New("S3 storage does not support ALTER TABLE operations - schema changes are handled dynamically during object operations")
-}
\ No newline at end of file
+// alterObject returns a copy of obj in tableName with ops' column renames
+// and drops applied to its fields
+func alterObject(obj *object.Object, tableName string, ops []storage.AlterOp) *object.Object {
+	fields := make(map[string]any, len(obj.Fields))
+	for name, value := range obj.Fields {
+		fields[name] = value
+	}
+	for _, op := range ops {
+		switch op.Kind {
+		case storage.AlterDropColumn:
+			delete(fields, op.Column)
+		case storage.AlterRenameColumn:
+			if value, ok := fields[op.Column]; ok {
+				delete(fields, op.Column)
+				fields[op.NewName] = value
+			}
+		}
+	}
+	return &object.Object{ID: obj.ID, TableName: tableName, Fields: fields}
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
+	ddaostorage "github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 	"github.com/jadedragon942/ddao/storagetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -211,7 +213,7 @@ func TestS3Storage_BasicCRUD(t *testing.T) {
 
 	// Verify deletion
 	deletedUser, err := storage.FindByID(ctx, "users", "user123")
-	require.NoError(t, err)
+	require.ErrorIs(t, err, ddaoerrors.ErrNotFound)
 	assert.Nil(t, deletedUser)
 }
 
@@ -227,7 +229,7 @@ func TestS3Storage_FindByKeyNotFound(t *testing.T) {
 
 	// Search for non-existent user
 	user, err := storage.FindByKey(ctx, "users", "email", "nonexistent@example.com")
-	require.NoError(t, err)
+	require.ErrorIs(t, err, ddaoerrors.ErrNotFound)
 	assert.Nil(t, user)
 }
 
@@ -241,8 +243,8 @@ func TestS3Storage_TransactionMethods(t *testing.T) {
 	err := storage.CreateTables(ctx, schema)
 	require.NoError(t, err)
 
-	// Test transaction methods (note: S3 doesn't have real transactions)
-	tx, err := storage.BeginTx(ctx)
+	// Test transaction methods (S3 buffers writes until Commit)
+	tx, err := storage.BeginTx(ctx, nil)
 	require.NoError(t, err)
 	assert.NotNil(t, tx)
 
@@ -256,37 +258,43 @@ func TestS3Storage_TransactionMethods(t *testing.T) {
 		"created_at": time.Now().Format(time.RFC3339),
 	}
 
-	data, created, err := storage.InsertTx(ctx, tx, user)
+	data, created, err := tx.Insert(ctx, user)
 	require.NoError(t, err)
 	assert.True(t, created)
 	assert.NotNil(t, data)
 
 	// Test FindByIDTx
-	foundUser, err := storage.FindByIDTx(ctx, tx, "users", "txuser123")
+	foundUser, err := tx.FindByID(ctx, "users", "txuser123")
 	require.NoError(t, err)
 	require.NotNil(t, foundUser)
 	assert.Equal(t, "txuser123", foundUser.ID)
 
 	// Test UpdateTx
 	user.Fields["name"] = "Updated Transaction User"
-	updated, err := storage.UpdateTx(ctx, tx, user)
+	updated, err := tx.Update(ctx, user)
 	require.NoError(t, err)
 	assert.True(t, updated)
 
 	// Test FindByKeyTx
-	userByEmail, err := storage.FindByKeyTx(ctx, tx, "users", "email", "tx@example.com")
+	userByEmail, err := tx.FindByKey(ctx, "users", "email", "tx@example.com")
 	require.NoError(t, err)
 	require.NotNil(t, userByEmail)
 	assert.Equal(t, "txuser123", userByEmail.ID)
 
 	// Test DeleteByIDTx
-	deleted, err := storage.DeleteByIDTx(ctx, tx, "users", "txuser123")
+	deleted, err := tx.DeleteByID(ctx, "users", "txuser123")
 	require.NoError(t, err)
 	assert.True(t, deleted)
 
+	// Nothing is uploaded before Commit
+	committed, err := storage.FindByID(ctx, "users", "txuser123")
+	require.ErrorIs(t, err, ddaoerrors.ErrNotFound)
+	assert.Nil(t, committed)
+
 	// Commit transaction
-	err = storage.CommitTx(tx)
+	err = tx.Commit()
 	require.NoError(t, err)
+	assert.ErrorIs(t, tx.Commit(), ddaostorage.ErrTxDone)
 }
 
 func TestS3Storage_MultipleObjects(t *testing.T) {
@@ -376,6 +384,84 @@ func TestS3Storage_CRUDTest(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+// TestS3Storage_UpsertTest runs the standard DDAO upsert tests
+func TestS3Storage_UpsertTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 upsert test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard upsert tests
+	storagetest.UpsertTest(t, storage)
+}
+
+// TestS3Storage_BatchTest runs the standard DDAO batch tests
+func TestS3Storage_BatchTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 batch test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard batch tests
+	storagetest.BatchTest(t, storage)
+}
+
+// TestS3Storage_ListTest runs the standard DDAO list tests
+func TestS3Storage_ListTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 list test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard list tests
+	storagetest.ListTest(t, storage)
+}
+
+// TestS3Storage_KeysTest runs the standard DDAO key tests
+func TestS3Storage_KeysTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 key test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard key tests
+	storagetest.KeysTest(t, storage)
+}
+
+// TestS3Storage_IndexesTest runs the standard DDAO index tests
+func TestS3Storage_IndexesTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 index test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard index tests
+	storagetest.IndexesTest(t, storage)
+}
+
+// TestS3Storage_AlterTableTest runs the standard DDAO alter table tests
+func TestS3Storage_AlterTableTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping S3 alter table test in short mode")
+	}
+
+	storage := createTestStorage(t)
+	defer storage.ResetConnection(context.Background())
+
+	// Run the standard alter table tests
+	storagetest.AlterTableTest(t, storage)
+}
+
 // BenchmarkS3Storage_Insert benchmarks the insert operation
 func BenchmarkS3Storage_Insert(b *testing.B) {
 	storage := createTestStorage(&testing.T{})
@@ -388,12 +474,14 @@ func BenchmarkS3Storage_Insert(b *testing.B) {
 	}
 	defer storage.ResetConnection(ctx)
 
+	// Insert rejects existing IDs, so every run writes fresh ones
+	run := time.Now().UnixNano()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
 		user := object.New()
 		user.TableName = "users"
-		user.ID = fmt.Sprintf("benchuser%d", i)
+		user.ID = fmt.Sprintf("benchuser%d_%d", run, i)
 		user.Fields = map[string]interface{}{
 			"email":      fmt.Sprintf("bench%d@example.com", i),
 			"name":       fmt.Sprintf("Bench User %d", i),
@@ -429,7 +517,7 @@ func BenchmarkS3Storage_FindByID(b *testing.B) {
 		"created_at": time.Now().Format(time.RFC3339),
 	}
 
-	_, _, err = storage.Insert(ctx, user)
+	_, _, err = storage.Upsert(ctx, user)
 	if err != nil {
 		b.Fatalf("Failed to insert test data: %v", err)
 	}
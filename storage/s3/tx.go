@@ -0,0 +1,240 @@
+package s3
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// s3Tx buffers writes in memory and uploads them on Commit. Reads see the
+// bucket's current contents with the transaction's own writes layered on top.
+// S3 has no multi-object atomicity: if Commit fails part way, the writes made
+// before the failure remain applied.
+type s3Tx struct {
+	s        *S3Storage
+	ctx      context.Context
+	writes   storage.WriteSet
+	readOnly bool
+	done     bool
+}
+
+// BeginTx starts a transaction whose writes are buffered until Commit
+func (s *S3Storage) BeginTx(ctx context.Context, opts *storage.TxOptions) (storage.Tx, error) {
+	if s.client == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	tx := &s3Tx{s: s, ctx: ctx}
+	if opts != nil {
+		if opts.Isolation != sql.LevelDefault {
+			return nil, ddaoerrors.NotSupported("S3", "isolation level "+opts.Isolation.String())
+		}
+		tx.readOnly = opts.ReadOnly
+	}
+	return tx, nil
+}
+
+func (tx *s3Tx) check() error {
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	if tx.s.client == nil {
+		return ddaoerrors.ErrNotConnected
+	}
+	return nil
+}
+
+func (tx *s3Tx) checkWrite() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if tx.readOnly {
+		return errors.New("cannot write in a read-only transaction")
+	}
+	return nil
+}
+
+// Insert buffers obj, failing with a DuplicateKeyError if its ID already exists
+// as seen by the transaction. Commit uploads it with a conditional write, so it
+// also fails if another caller creates the object first.
+func (tx *s3Tx) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return nil, false, err
+	}
+	obj, err := tx.s.keyed(obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.FindByID(ctx, obj.TableName, obj.ID)
+	if err == nil {
+		return nil, false, tx.s.duplicateKeyError(obj.TableName, nil)
+	}
+	if !errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal object: %w", err)
+	}
+
+	tx.writes.Add(storage.WriteInsert, obj)
+	return data, true, nil
+}
+
+func (tx *s3Tx) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+	obj, err := tx.s.keyed(obj)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.FindByID(ctx, obj.TableName, obj.ID); err != nil {
+		if errors.Is(err, ddaoerrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	tx.writes.Add(storage.WriteUpdate, obj)
+	return true, nil
+}
+
+// Upsert buffers obj, overwriting any existing object with the same ID on Commit
+func (tx *s3Tx) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return nil, false, err
+	}
+	obj, err := tx.s.keyed(obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.FindByID(ctx, obj.TableName, obj.ID)
+	created := errors.Is(err, ddaoerrors.ErrNotFound)
+	if err != nil && !created {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal object: %w", err)
+	}
+
+	tx.writes.Add(storage.WriteUpsert, obj)
+	return data, created, nil
+}
+
+func (tx *s3Tx) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	committed, err := tx.committed(ctx, tblName, id)
+	if err != nil {
+		return nil, err
+	}
+	if current := tx.writes.Apply(tblName, id, committed); current != nil {
+		return current, nil
+	}
+	return nil, ddaoerrors.ErrNotFound
+}
+
+// committed loads the committed state of an object, returning nil if it does not exist
+func (tx *s3Tx) committed(ctx context.Context, tblName, id string) (*object.Object, error) {
+	obj, err := tx.s.FindByID(ctx, tblName, id)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+func (tx *s3Tx) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	if key == "id" {
+		return tx.FindByID(ctx, tblName, value)
+	}
+
+	results, err := tx.Find(ctx, tblName, storage.Eq(key, value))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return results[0], nil
+}
+
+func (tx *s3Tx) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	committed, err := tx.s.Find(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+	return tx.writes.Overlay(tblName, q, committed, func(id string) (*object.Object, error) {
+		return tx.committed(ctx, tblName, id)
+	})
+}
+
+func (tx *s3Tx) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.FindByID(ctx, tblName, id); err != nil {
+		if errors.Is(err, ddaoerrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	tx.writes.Add(storage.WriteDelete, &object.Object{TableName: tblName, ID: id})
+	return true, nil
+}
+
+// Commit uploads the buffered writes in the order they were made
+func (tx *s3Tx) Commit() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	tx.done = true
+
+	for _, w := range tx.writes.Writes() {
+		var err error
+		switch w.Op {
+		case storage.WriteInsert:
+			_, _, err = tx.s.Insert(tx.ctx, w.Object)
+		case storage.WriteUpsert:
+			_, _, err = tx.s.Upsert(tx.ctx, w.Object)
+		case storage.WriteUpdate:
+			_, err = tx.s.Update(tx.ctx, w.Object)
+		case storage.WriteDelete:
+			_, err = tx.s.DeleteByID(tx.ctx, w.Object.TableName, w.Object.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to commit %s/%s: %w", w.Object.TableName, w.Object.ID, err)
+		}
+	}
+	tx.writes.Reset()
+	return nil
+}
+
+// Rollback discards the buffered writes; nothing has been uploaded yet
+func (tx *s3Tx) Rollback() error {
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	tx.done = true
+	tx.writes.Reset()
+	return nil
+}
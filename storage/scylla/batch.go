@@ -0,0 +1,115 @@
+package scylla
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// InsertMany creates objs with lightweight transactions, failing the objects
+// whose ID already exists with a DuplicateKeyError. A conditional batch cannot
+// span partitions, so instead of being batched the inserts are sent
+// concurrently, opts.Size() at a time.
+func (s *ScyllaDBStorage) InsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewObjectsResult(objs)
+	storage.RunConcurrently(len(objs), opts.Size(), func(i int) {
+		_, _, result.Items[i].Err = s.Insert(ctx, objs[i])
+	})
+	return result, ctx.Err()
+}
+
+// UpsertMany creates or overwrites objs with unlogged batches of up to
+// opts.Size() INSERTs. Keep batches small: ScyllaDB warns about, and
+// eventually rejects, batches over its size thresholds.
+func (s *ScyllaDBStorage) UpsertMany(ctx context.Context, objs []*object.Object, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewObjectsResult(objs)
+	size := opts.Size()
+	for start := 0; start < len(objs); start += size {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(start, err)
+			return result, err
+		}
+
+		end := min(start+size, len(objs))
+		batch := s.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+		var queued []int
+		for i := start; i < end; i++ {
+			query, values, err := s.insertStatement(objs[i])
+			if err != nil {
+				result.Items[i].Err = err
+				continue
+			}
+			storage.DebugLog(query, values...)
+			batch.Query(query, values...)
+			queued = append(queued, i)
+		}
+		if len(queued) == 0 {
+			continue
+		}
+
+		if err := s.session.ExecuteBatch(batch); err != nil {
+			// An unlogged batch may be partly applied, but upserts are
+			// idempotent, so retry each object to find the ones that fail
+			for _, i := range queued {
+				_, _, result.Items[i].Err = s.Upsert(ctx, objs[i])
+			}
+		}
+	}
+	return result, nil
+}
+
+// DeleteMany deletes ids from tblName with unlogged batches of up to
+// opts.Size() DELETEs. As with DeleteByID, ScyllaDB does not report whether a
+// row existed, so Deleted counts every ID deleted without an error.
+func (s *ScyllaDBStorage) DeleteMany(ctx context.Context, tblName string, ids []string, opts storage.BatchOptions) (*storage.BatchResult, error) {
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	result := storage.NewIDsResult(tblName, ids)
+	size := opts.Size()
+	for start := 0; start < len(ids); start += size {
+		if err := ctx.Err(); err != nil {
+			result.FailAll(start, err)
+			return result, err
+		}
+
+		end := min(start+size, len(ids))
+		batch := s.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+		var queued []int
+		for i := start; i < end; i++ {
+			query, values, err := s.deleteStatement(tblName, ids[i])
+			if err != nil {
+				result.Items[i].Err = err
+				continue
+			}
+			storage.DebugLog(query, values...)
+			batch.Query(query, values...)
+			queued = append(queued, i)
+		}
+		if len(queued) == 0 {
+			continue
+		}
+
+		if err := s.session.ExecuteBatch(batch); err != nil {
+			err = translateError(err, tblName)
+			for _, i := range queued {
+				result.Items[i].Err = err
+			}
+			continue
+		}
+		result.Deleted += int64(len(queued))
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package scylla
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// translateError maps gocql errors from operations on tblName onto storage/errors.
+// CQL has no unique constraints, so there is no duplicate key translation.
+func translateError(err error, tblName string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gocql.ErrNotFound):
+		return ddaoerrors.ErrNotFound
+	case errors.Is(err, gocql.ErrNoConnections), errors.Is(err, gocql.ErrSessionClosed):
+		return fmt.Errorf("%w: %w", ddaoerrors.ErrNotConnected, err)
+	}
+
+	var reqErr gocql.RequestError
+	if errors.As(err, &reqErr) && reqErr.Code() == gocql.ErrCodeInvalid {
+		msg := reqErr.Message()
+		switch {
+		case strings.Contains(msg, "unconfigured table"):
+			return &ddaoerrors.SchemaMismatchError{Table: tblName, Err: err}
+		case strings.Contains(msg, "Undefined column name"), strings.Contains(msg, "Undefined name"):
+			// "Undefined column name email" or "Undefined name email in selection clause"
+			_, field, _ := strings.Cut(msg, "name ")
+			field, _, _ = strings.Cut(field, " ")
+			return &ddaoerrors.SchemaMismatchError{Table: tblName, Field: field, Err: err}
+		}
+	}
+	return err
+}
@@ -2,11 +2,11 @@ package scylla
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,6 +14,8 @@ import (
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
 	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
 )
 
 type ScyllaDBStorage struct {
@@ -27,10 +29,17 @@ func New() storage.Storage {
 	return &ScyllaDBStorage{}
 }
 
+func init() {
+	storage.Register("scylla", New)
+}
+
 // Connect establishes a connection to ScyllaDB
-// connStr format: "hosts,hosts,hosts/keyspace?consistency=quorum&timeout=10s"
+// connStr format: "hosts,hosts,hosts/keyspace?consistency=quorum&timeout=10s", optionally after scylla://
 // Example: "localhost:9042,192.168.1.2:9042/mykeyspace?consistency=quorum&timeout=5s"
 func (s *ScyllaDBStorage) Connect(ctx context.Context, connStr string) error {
+	if rest, ok := storage.TrimScheme(connStr, "scylla"); ok {
+		connStr = rest
+	}
 	parts := strings.Split(connStr, "/")
 	if len(parts) != 2 {
 		return fmt.Errorf("invalid connection string format, expected: hosts/keyspace?options")
@@ -125,41 +134,32 @@ func (s *ScyllaDBStorage) parseConsistency(value string) (gocql.Consistency, err
 	}
 }
 
+// CreateTables creates the keyspace, the tables and their indexes. CQL has
+// no foreign keys: they are kept in the schema but not enforced.
 func (s *ScyllaDBStorage) CreateTables(ctx context.Context, schema *schema.Schema) error {
 	if s.session == nil {
-		return errors.New("not connected")
+		return ddaoerrors.ErrNotConnected
 	}
 
-	// Create keyspace if it doesn't exist
-	createKeyspaceQuery := fmt.Sprintf(
-		"CREATE KEYSPACE IF NOT EXISTS %s WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 3}",
-		s.keyspace)
-
+	d := dialect.ScyllaKeyspace(s.keyspace)
+	createKeyspaceQuery := dialect.CreateKeyspace(s.keyspace)
 	storage.DebugLog(createKeyspaceQuery)
 	if err := s.session.Query(createKeyspaceQuery).Exec(); err != nil {
 		return fmt.Errorf("failed to create keyspace %s: %w", s.keyspace, err)
 	}
 
-	for _, table := range schema.Tables {
-		createTableQuery := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (id text PRIMARY KEY", s.keyspace, table.TableName)
-
-		for _, field := range table.Fields {
-			if field.Name == "id" {
-				continue // Skip the id field, it's already handled
+	// Tables are created in the order RenderDDL lists them
+	for _, name := range schema.CreationOrder() {
+		table := schema.Tables[name]
+		for _, query := range d.CreateTable(*table) {
+			storage.DebugLog(query)
+			if err := s.session.Query(query).Exec(); err != nil {
+				return fmt.Errorf("failed to create table %s: %w", table.TableName, err)
 			}
-
-			// Map data types to ScyllaDB/Cassandra equivalents
-			scyllaDataType := s.mapDataType(field.DataType)
-			createTableQuery += fmt.Sprintf(", %s %s", field.Name, scyllaDataType)
 		}
 
-		createTableQuery += ")"
-
-		storage.DebugLog(createTableQuery)
-		log.Printf("Creating table %s with query: %s", table.TableName, createTableQuery)
-
-		if err := s.session.Query(createTableQuery).Exec(); err != nil {
-			return fmt.Errorf("failed to create table %s: %w", table.TableName, err)
+		if err := s.createIndexes(*table); err != nil {
+			return err
 		}
 	}
 
@@ -169,36 +169,34 @@ func (s *ScyllaDBStorage) CreateTables(ctx context.Context, schema *schema.Schem
 	return nil
 }
 
-func (s *ScyllaDBStorage) mapDataType(dataType string) string {
-	switch strings.ToUpper(dataType) {
-	case "TEXT", "VARCHAR", "CHAR":
-		return "text"
-	case "INTEGER", "INT":
-		return "bigint"
-	case "REAL", "FLOAT":
-		return "double"
-	case "BLOB":
-		return "blob"
-	case "BOOLEAN":
-		return "boolean"
-	case "JSON":
-		return "text" // ScyllaDB doesn't have native JSON type, store as text
-	case "DATETIME", "TIMESTAMP":
-		return "timestamp"
-	case "DATE":
-		return "date"
-	case "TIME":
-		return "time"
-	case "UUID":
-		return "uuid"
-	default:
-		return "text"
+// GetSchema returns the schema given to CreateTables, nil before it is called
+func (s *ScyllaDBStorage) GetSchema() *schema.Schema {
+	return s.sch
+}
+
+// createIndexes creates the secondary indexes of tbl. CQL indexes cover a
+// single column, so a multi-column index becomes one index per column and
+// uniqueness cannot be enforced.
+func (s *ScyllaDBStorage) createIndexes(tbl schema.TableSchema) error {
+	for _, index := range tbl.SecondaryIndexes() {
+		if index.Unique {
+			log.Printf("Warning: ScyllaDB cannot enforce unique index %s on %s, creating a plain index", index.Name, tbl.TableName)
+		}
+	}
+	for _, query := range dialect.ScyllaKeyspace(s.keyspace).CreateIndexes(tbl) {
+		storage.DebugLog(query)
+		if err := s.session.Query(query).Exec(); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", tbl.TableName, err)
+		}
 	}
+	return nil
 }
 
+// Insert creates obj with a lightweight transaction (INSERT ... IF NOT EXISTS),
+// failing with a DuplicateKeyError if its ID already exists
 func (s *ScyllaDBStorage) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
 	if s.session == nil {
-		return nil, false, errors.New("not connected")
+		return nil, false, ddaoerrors.ErrNotConnected
 	}
 
 	data, err := json.Marshal(obj)
@@ -206,29 +204,55 @@ func (s *ScyllaDBStorage) Insert(ctx context.Context, obj *object.Object) ([]byt
 		return nil, false, err
 	}
 
-	if s.sch == nil {
-		return nil, false, errors.New("schema not initialized")
+	query, values, err := s.insertStatement(obj)
+	if err != nil {
+		return nil, false, err
 	}
+	query += " IF NOT EXISTS"
 
-	s.sch.Lock()
-	defer s.sch.Unlock()
+	storage.DebugLog(query, values...)
+
+	applied, err := s.session.Query(query, values...).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return nil, false, translateError(err, obj.TableName)
+	}
+	if !applied {
+		return nil, false, s.duplicateKeyError(obj.TableName)
+	}
+
+	return data, true, nil
+}
+
+// insertStatement builds the INSERT for obj; shared by Insert and transaction batches
+func (s *ScyllaDBStorage) insertStatement(obj *object.Object) (string, []interface{}, error) {
+	if s.sch == nil {
+		return "", nil, errors.New("schema not initialized")
+	}
 
 	tbl, ok := s.sch.GetTable(obj.TableName)
 	if !ok {
-		return nil, false, fmt.Errorf("table %s not found in schema", obj.TableName)
+		return "", nil, ddaoerrors.UnknownTable(obj.TableName)
 	}
 
-	columns := make([]string, 0, len(obj.Fields)+1)
-	placeholders := make([]string, 0, len(obj.Fields)+1)
-	values := make([]interface{}, 0, len(obj.Fields)+1)
+	keyColumns := tbl.PrimaryKeyColumns()
+	key, err := obj.Key(keyColumns)
+	if err != nil {
+		return "", nil, err
+	}
 
-	columns = append(columns, "id")
-	placeholders = append(placeholders, "?")
-	values = append(values, obj.ID)
+	columns := make([]string, 0, len(obj.Fields)+len(key))
+	placeholders := make([]string, 0, len(obj.Fields)+len(key))
+	values := make([]interface{}, 0, len(obj.Fields)+len(key))
+
+	for _, part := range key {
+		columns = append(columns, part.Column)
+		placeholders = append(placeholders, "?")
+		values = append(values, part.Value)
+	}
 
 	for name, field := range obj.Fields {
-		if strings.ToLower(name) == "id" {
-			continue
+		if isKeyColumn(keyColumns, name) {
+			continue // Key columns are already handled
 		}
 
 		columns = append(columns, name)
@@ -236,12 +260,12 @@ func (s *ScyllaDBStorage) Insert(ctx context.Context, obj *object.Object) ([]byt
 
 		schField, ok := tbl.Fields[name]
 		if !ok {
-			return nil, false, fmt.Errorf("field %s not found in table %s schema", name, tbl.TableName)
+			return "", nil, ddaoerrors.UnknownField(tbl.TableName, name)
 		}
 		if strings.ToLower(schField.DataType) == "json" {
 			jsonData, err := json.Marshal(field)
 			if err != nil {
-				return nil, false, fmt.Errorf("failed to marshal JSON field %s: %w", name, err)
+				return "", nil, fmt.Errorf("failed to marshal JSON field %s: %w", name, err)
 			}
 			values = append(values, string(jsonData))
 		} else {
@@ -249,73 +273,139 @@ func (s *ScyllaDBStorage) Insert(ctx context.Context, obj *object.Object) ([]byt
 		}
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
-		s.keyspace,
-		tbl.TableName,
-		strings.Join(columns, ", "),
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		s.table(tbl.TableName),
+		quoteColumns(columns),
 		strings.Join(placeholders, ", "))
 
+	return query, values, nil
+}
+
+func (s *ScyllaDBStorage) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	if s.session == nil {
+		return false, ddaoerrors.ErrNotConnected
+	}
+
+	query, values, err := s.updateStatement(obj)
+	if err != nil {
+		return false, err
+	}
+
 	storage.DebugLog(query, values...)
 
-	if err := s.session.Query(query, values...).Exec(); err != nil {
-		return nil, false, err
+	if err := s.session.Query(query, values...).WithContext(ctx).Exec(); err != nil {
+		return false, translateError(err, obj.TableName)
 	}
 
-	return data, true, nil
+	// ScyllaDB doesn't return affected rows count in the same way as SQL databases
+	// We assume the update was successful if no error occurred
+	return true, nil
 }
 
-func (s *ScyllaDBStorage) Update(ctx context.Context, obj *object.Object) (bool, error) {
-	if s.session == nil {
-		return false, errors.New("not connected")
+// updateStatement builds the UPDATE for obj; shared by Update and transaction batches
+func (s *ScyllaDBStorage) updateStatement(obj *object.Object) (string, []interface{}, error) {
+	if s.sch == nil {
+		return "", nil, errors.New("schema not initialized")
 	}
 
-	s.sch.Lock()
-	defer s.sch.Unlock()
-
 	tbl, ok := s.sch.GetTable(obj.TableName)
 	if !ok {
-		return false, fmt.Errorf("table %s not found in schema", obj.TableName)
+		return "", nil, ddaoerrors.UnknownTable(obj.TableName)
 	}
 
-	setClauses := make([]string, 0, len(obj.Fields)-1)
-	values := make([]interface{}, 0, len(obj.Fields))
+	keyColumns := tbl.PrimaryKeyColumns()
+	key, err := obj.Key(keyColumns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	setClauses := make([]string, 0, len(obj.Fields))
+	values := make([]interface{}, 0, len(obj.Fields)+len(key))
 
 	for name, value := range obj.Fields {
-		if strings.ToLower(name) == "id" {
-			continue
+		if isKeyColumn(keyColumns, name) {
+			continue // Key columns identify the row
 		}
-		setClauses = append(setClauses, fmt.Sprintf("%s = ?", name))
+		setClauses = append(setClauses, dialect.QuoteCQL(name)+" = ?")
 		values = append(values, value)
 	}
 
-	values = append(values, obj.ID)
+	where, keyValues := keyWhere(key)
+	values = append(values, keyValues...)
 
-	query := fmt.Sprintf("UPDATE %s.%s SET %s WHERE id = ?",
-		s.keyspace, tbl.TableName, strings.Join(setClauses, ", "))
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		s.table(tbl.TableName), strings.Join(setClauses, ", "), where)
 
-	storage.DebugLog(query, values...)
+	return query, values, nil
+}
 
-	if err := s.session.Query(query, values...).Exec(); err != nil {
-		return false, err
+// Upsert inserts or overwrites an object with a plain INSERT INTO, which CQL applies as an upsert
+func (s *ScyllaDBStorage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if s.session == nil {
+		return nil, false, ddaoerrors.ErrNotConnected
 	}
 
-	// ScyllaDB doesn't return affected rows count in the same way as SQL databases
-	// We assume the update was successful if no error occurred
-	return true, nil
-}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, err
+	}
 
-// Upsert inserts or updates an object, delegating to Insert which already implements upsert behavior using INSERT INTO
-func (s *ScyllaDBStorage) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
-	return s.Insert(ctx, obj)
-}
+	query, values, err := s.insertStatement(obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	storage.DebugLog(query, values...)
+
+	if err := s.session.Query(query, values...).WithContext(ctx).Exec(); err != nil {
+		return nil, false, translateError(err, obj.TableName)
+	}
 
-// UpsertTx inserts or updates an object within a transaction, delegating to InsertTx which returns an error since ScyllaDB doesn't support SQL-style transactions
-func (s *ScyllaDBStorage) UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	return s.InsertTx(ctx, tx, obj)
+	return data, true, nil
 }
 
 func (s *ScyllaDBStorage) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
-	return s.FindByKey(ctx, tblName, "id", id)
+	if tblName == "" || id == "" {
+		return nil, errors.New("table name and id must not be empty")
+	}
+
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	if s.sch == nil {
+		return nil, errors.New("schema not initialized")
+	}
+
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+
+	key, err := storage.ParseID(tbl, id)
+	if err != nil {
+		return nil, err
+	}
+	where, values := keyWhere(key)
+
+	columns, columnPointers := s.scanTargets(tbl)
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s",
+		quoteColumns(columns), s.table(tbl.TableName), where)
+
+	storage.DebugLog(query, values...)
+
+	iter := s.session.Query(query, values...).WithContext(ctx).Iter()
+	defer iter.Close()
+
+	if !iter.Scan(columnPointers...) {
+		if err := iter.Close(); err != nil {
+			return nil, translateError(err, tblName)
+		}
+		return nil, ddaoerrors.ErrNotFound
+	}
+
+	return s.scannedObject(tbl, columns, columnPointers), nil
 }
 
 func (s *ScyllaDBStorage) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
@@ -324,28 +414,297 @@ func (s *ScyllaDBStorage) FindByKey(ctx context.Context, tblName, key, value str
 	}
 
 	if s.session == nil {
-		return nil, errors.New("not connected")
+		return nil, ddaoerrors.ErrNotConnected
 	}
 
-	s.sch.Lock()
-	defer s.sch.Unlock()
+	if s.sch == nil {
+		return nil, errors.New("schema not initialized")
+	}
 
 	tbl, ok := s.sch.GetTable(tblName)
 	if !ok {
-		return nil, fmt.Errorf("table %s not found in schema", tblName)
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+	// key names a column of the query, so it must be one of the table's
+	if _, ok := tbl.Fields[key]; !ok {
+		return nil, ddaoerrors.UnknownField(tblName, key)
 	}
 
-	// Create ordered slices of fields to ensure consistent mapping
-	fieldNames := make([]string, 0, len(tbl.Fields))
-	for _, field := range tbl.Fields {
-		fieldNames = append(fieldNames, field.Name)
+	columns, columnPointers := s.scanTargets(tbl)
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		quoteColumns(columns), s.table(tbl.TableName), dialect.QuoteCQL(key))
+
+	storage.DebugLog(query, value)
+
+	iter := s.session.Query(query, value).Iter()
+	defer iter.Close()
+
+	if !iter.Scan(columnPointers...) {
+		if err := iter.Close(); err != nil {
+			return nil, translateError(err, tblName)
+		}
+		return nil, ddaoerrors.ErrNotFound
 	}
 
-	columns := make([]string, 0, len(fieldNames))
-	columnPointers := make([]interface{}, 0, len(fieldNames))
+	return s.scannedObject(tbl, columns, columnPointers), nil
+}
 
-	for _, fieldName := range fieldNames {
-		field := tbl.Fields[fieldName]
+// Find returns every object in tblName matching q. Comparisons that CQL can
+// evaluate are pushed down (with ALLOW FILTERING when they touch non-key columns);
+// OR, NOT, != and IS NULL are applied to the scanned rows instead.
+func (s *ScyllaDBStorage) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	rows, err := s.FindRows(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Collect(rows)
+}
+
+// FindRows streams the objects matching q from a gocql iterator, which fetches
+// further pages from the cluster as the caller advances
+func (s *ScyllaDBStorage) FindRows(ctx context.Context, tblName string, q storage.Query) (storage.Rows, error) {
+	if tblName == "" {
+		return nil, errors.New("table name must not be empty")
+	}
+
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+
+	if s.sch == nil {
+		return nil, errors.New("schema not initialized")
+	}
+
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return nil, ddaoerrors.UnknownTable(tblName)
+	}
+
+	if err := q.Validate(tbl); err != nil {
+		return nil, err
+	}
+
+	pushdown, residual := splitCQLQuery(q)
+	columns, columnPointers := s.scanTargets(tbl)
+
+	whereClauses := make([]string, 0, len(pushdown))
+	values := make([]interface{}, 0, len(pushdown))
+	for _, term := range pushdown {
+		clause, args := cqlCondition(term)
+		whereClauses = append(whereClauses, clause)
+		values = append(values, args...)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", quoteColumns(columns), s.table(tbl.TableName))
+	if len(whereClauses) > 0 {
+		query += " WHERE " + strings.Join(whereClauses, " AND ")
+		keyColumns := tbl.PrimaryKeyColumns()
+		if !(len(pushdown) == 1 && pushdown[0].Op == storage.OpEq && len(keyColumns) == 1 && pushdown[0].Field == keyColumns[0]) {
+			query += " ALLOW FILTERING"
+		}
+	}
+
+	storage.DebugLog(query, values...)
+
+	return &scyllaRows{
+		storage:        s,
+		iter:           s.session.Query(query, values...).WithContext(ctx).Iter(),
+		tbl:            tbl,
+		columns:        columns,
+		columnPointers: columnPointers,
+		residual:       residual,
+	}, nil
+}
+
+// scyllaRows streams objects from a gocql iterator, skipping rows rejected by
+// the part of the query that could not be pushed down to CQL
+type scyllaRows struct {
+	storage        *ScyllaDBStorage
+	iter           *gocql.Iter
+	tbl            schema.TableSchema
+	columns        []string
+	columnPointers []interface{}
+	residual       storage.Query
+	current        *object.Object
+	closed         bool
+	err            error
+}
+
+func (r *scyllaRows) Next() bool {
+	r.current = nil
+	if r.closed {
+		return false
+	}
+
+	for r.iter.Scan(r.columnPointers...) {
+		obj := r.storage.scannedObject(r.tbl, r.columns, r.columnPointers)
+		if r.residual.Match(obj) {
+			r.current = obj
+			return true
+		}
+	}
+
+	r.closed = true
+	r.err = translateError(r.iter.Close(), r.tbl.TableName)
+	return false
+}
+
+func (r *scyllaRows) Object() *object.Object {
+	return r.current
+}
+
+func (r *scyllaRows) Err() error {
+	return r.err
+}
+
+func (r *scyllaRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.err = translateError(r.iter.Close(), r.tbl.TableName)
+	return r.err
+}
+
+// List returns a page of objects from tblName using CQL paging state as the cursor.
+// Rows come back in partition token order, so only the default ordering is supported.
+func (s *ScyllaDBStorage) List(ctx context.Context, tblName string, opts storage.ListOptions) ([]*object.Object, string, error) {
+	if tblName == "" {
+		return nil, "", errors.New("table name must not be empty")
+	}
+
+	if s.session == nil {
+		return nil, "", ddaoerrors.ErrNotConnected
+	}
+
+	if s.sch == nil {
+		return nil, "", errors.New("schema not initialized")
+	}
+
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return nil, "", ddaoerrors.UnknownTable(tblName)
+	}
+
+	if err := opts.Validate(tbl); err != nil {
+		return nil, "", err
+	}
+	if column, desc := opts.Order(); column != "id" || desc {
+		return nil, "", ddaoerrors.NotSupported("ScyllaDB", "ordered listing")
+	}
+
+	var pageState []byte
+	if opts.Cursor != "" {
+		cursor, err := storage.DecodeCursor(opts, tbl)
+		if err != nil {
+			return nil, "", err
+		}
+		pageState = cursor.State
+	}
+
+	columns, columnPointers := s.scanTargets(tbl)
+	query := fmt.Sprintf("SELECT %s FROM %s", quoteColumns(columns), s.table(tbl.TableName))
+
+	storage.DebugLog(query)
+
+	// Setting PageState disables automatic paging, so the iterator stops after one page
+	iter := s.session.Query(query).WithContext(ctx).PageSize(opts.PageSize()).PageState(pageState).Iter()
+	nextPageState := iter.PageState()
+
+	results := make([]*object.Object, 0, opts.PageSize())
+	for iter.Scan(columnPointers...) {
+		results = append(results, s.scannedObject(tbl, columns, columnPointers))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, "", translateError(err, tblName)
+	}
+
+	if len(nextPageState) == 0 {
+		return results, "", nil
+	}
+	return results, storage.NewStateCursor(opts, nextPageState).Encode(), nil
+}
+
+// splitCQLQuery separates the top-level conjuncts of q that CQL can evaluate
+// from those that must be filtered client-side
+func splitCQLQuery(q storage.Query) ([]storage.Query, storage.Query) {
+	var conjuncts []storage.Query
+	switch {
+	case q.IsZero():
+		return nil, storage.Query{}
+	case q.Op == storage.OpAnd:
+		conjuncts = q.Children
+	default:
+		conjuncts = []storage.Query{q}
+	}
+
+	pushdown := make([]storage.Query, 0, len(conjuncts))
+	residual := make([]storage.Query, 0)
+	for _, term := range conjuncts {
+		switch term.Op {
+		case storage.OpEq, storage.OpLt, storage.OpLte, storage.OpGt, storage.OpGte, storage.OpIn, storage.OpLike:
+			if term.Op == storage.OpIn && len(term.Values) == 0 {
+				residual = append(residual, term)
+				continue
+			}
+			pushdown = append(pushdown, term)
+		default:
+			residual = append(residual, term)
+		}
+	}
+
+	if len(residual) == 0 {
+		return pushdown, storage.Query{}
+	}
+	return pushdown, storage.And(residual...)
+}
+
+// cqlCondition renders a single comparison as a CQL WHERE condition
+func cqlCondition(q storage.Query) (string, []interface{}) {
+	column := dialect.QuoteCQL(q.Field)
+	switch q.Op {
+	case storage.OpIn:
+		placeholders := make([]string, len(q.Values))
+		for i := range placeholders {
+			placeholders[i] = "?"
+		}
+		return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")), q.Values
+	case storage.OpLike:
+		return column + " LIKE ?", []interface{}{q.Value}
+	case storage.OpLt:
+		return column + " < ?", []interface{}{q.Value}
+	case storage.OpLte:
+		return column + " <= ?", []interface{}{q.Value}
+	case storage.OpGt:
+		return column + " > ?", []interface{}{q.Value}
+	case storage.OpGte:
+		return column + " >= ?", []interface{}{q.Value}
+	default:
+		return column + " = ?", []interface{}{q.Value}
+	}
+}
+
+// table returns the quoted name of tableName in the storage's keyspace
+func (s *ScyllaDBStorage) table(tableName string) string {
+	return dialect.QuoteCQL(s.keyspace) + "." + dialect.QuoteCQL(tableName)
+}
+
+// quoteColumns quotes columns and joins them with commas
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = dialect.QuoteCQL(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// scanTargets returns the column list and scan destinations for a table
+func (s *ScyllaDBStorage) scanTargets(tbl schema.TableSchema) ([]string, []interface{}) {
+	columns := make([]string, 0, len(tbl.Fields))
+	columnPointers := make([]interface{}, 0, len(tbl.Fields))
+
+	for _, field := range tbl.Fields {
 		columns = append(columns, field.Name)
 
 		switch strings.ToUpper(field.DataType) {
@@ -374,27 +733,16 @@ func (s *ScyllaDBStorage) FindByKey(ctx context.Context, tblName, key, value str
 		}
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s = ?",
-		strings.Join(columns, ", "), s.keyspace, tbl.TableName, key)
-
-	storage.DebugLog(query, value)
-
-	iter := s.session.Query(query, value).Iter()
-	defer iter.Close()
-
-	if !iter.Scan(columnPointers...) {
-		if err := iter.Close(); err != nil {
-			return nil, err
-		}
-		return nil, nil // No rows found
-	}
+	return columns, columnPointers
+}
 
-	// Create the object and populate fields after scanning
+// scannedObject builds an object from the values scanned into columnPointers
+func (s *ScyllaDBStorage) scannedObject(tbl schema.TableSchema, columns []string, columnPointers []interface{}) *object.Object {
 	var obj object.Object
 	obj.TableName = tbl.TableName
 	obj.Fields = make(map[string]interface{})
 
-	for i, fieldName := range fieldNames {
+	for i, fieldName := range columns {
 		field := tbl.Fields[fieldName]
 		switch strings.ToUpper(field.DataType) {
 		case "TEXT", "VARCHAR", "CHAR":
@@ -419,26 +767,27 @@ func (s *ScyllaDBStorage) FindByKey(ctx context.Context, tblName, key, value str
 		}
 	}
 
-	// Set the ID field from the object fields
-	if idValue, exists := obj.Fields["id"]; exists && idValue != nil {
-		obj.ID = idValue.(string)
-	}
+	// Set the ID from the primary key fields
+	obj.SetIDFromKey(tbl.PrimaryKeyColumns())
 
-	return &obj, nil
+	return &obj
 }
 
 func (s *ScyllaDBStorage) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
 	if s.session == nil {
-		return false, errors.New("not connected")
+		return false, ddaoerrors.ErrNotConnected
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s.%s WHERE id = ?", s.keyspace, tblName)
-
-	storage.DebugLog(query, id)
-	if err := s.session.Query(query, id).Exec(); err != nil {
+	query, values, err := s.deleteStatement(tblName, id)
+	if err != nil {
 		return false, err
 	}
 
+	storage.DebugLog(query, values...)
+	if err := s.session.Query(query, values...).WithContext(ctx).Exec(); err != nil {
+		return false, translateError(err, tblName)
+	}
+
 	// ScyllaDB doesn't return affected rows count in the same way as SQL databases
 	// We assume the delete was successful if no error occurred
 	return true, nil
@@ -452,80 +801,142 @@ func (s *ScyllaDBStorage) ResetConnection(ctx context.Context) error {
 	return nil
 }
 
-// Transaction support methods - ScyllaDB doesn't support traditional ACID transactions
-// These methods return errors indicating that transactions are not supported
-func (s *ScyllaDBStorage) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	return nil, errors.New("ScyllaDB does not support SQL-style transactions")
-}
+// deleteStatement builds the DELETE of the object id identifies in tblName
+func (s *ScyllaDBStorage) deleteStatement(tblName, id string) (string, []interface{}, error) {
+	if s.sch == nil {
+		return "", nil, errors.New("schema not initialized")
+	}
 
-func (s *ScyllaDBStorage) CommitTx(tx *sql.Tx) error {
-	return errors.New("ScyllaDB does not support SQL-style transactions")
-}
+	tbl, ok := s.sch.GetTable(tblName)
+	if !ok {
+		return "", nil, ddaoerrors.UnknownTable(tblName)
+	}
 
-func (s *ScyllaDBStorage) RollbackTx(tx *sql.Tx) error {
-	return errors.New("ScyllaDB does not support SQL-style transactions")
+	key, err := storage.ParseID(tbl, id)
+	if err != nil {
+		return "", nil, err
+	}
+	where, values := keyWhere(key)
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", s.table(tbl.TableName), where), values, nil
 }
 
-func (s *ScyllaDBStorage) InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error) {
-	return nil, false, errors.New("ScyllaDB does not support SQL-style transactions")
-}
+// objectID returns the ID of obj as derived from its table's primary key
+func (s *ScyllaDBStorage) objectID(obj *object.Object) (string, error) {
+	if s.sch == nil {
+		return "", errors.New("schema not initialized")
+	}
+
+	tbl, ok := s.sch.GetTable(obj.TableName)
+	if !ok {
+		return "", ddaoerrors.UnknownTable(obj.TableName)
+	}
 
-func (s *ScyllaDBStorage) UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error) {
-	return false, errors.New("ScyllaDB does not support SQL-style transactions")
+	key, err := obj.Key(tbl.PrimaryKeyColumns())
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
 }
 
-func (s *ScyllaDBStorage) FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error) {
-	return nil, errors.New("ScyllaDB does not support SQL-style transactions")
+// duplicateKeyError reports an insert into tblName whose key already exists
+func (s *ScyllaDBStorage) duplicateKeyError(tblName string) error {
+	column := "id"
+	if tbl, ok := s.sch.GetTable(tblName); ok {
+		column = ddaoerrors.KeyColumns(tbl.PrimaryKeyColumns())
+	}
+	return &ddaoerrors.DuplicateKeyError{Table: tblName, Column: column}
 }
 
-func (s *ScyllaDBStorage) FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error) {
-	return nil, errors.New("ScyllaDB does not support SQL-style transactions")
+// keyWhere renders the CQL condition matching key
+func keyWhere(key object.Key) (string, []interface{}) {
+	conditions := make([]string, len(key))
+	for i, part := range key {
+		conditions[i] = dialect.QuoteCQL(part.Column) + " = ?"
+	}
+	return strings.Join(conditions, " AND "), key.Values()
 }
 
-func (s *ScyllaDBStorage) DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error) {
-	return false, errors.New("ScyllaDB does not support SQL-style transactions")
+// isKeyColumn reports whether name is one of the key columns
+func isKeyColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *ScyllaDBStorage) AlterTable(ctx context.Context, tableName, columnName, dataType string, nullable bool) error {
+// AlterTable applies ops to tableName in order, updating the schema after
+// each. CQL adds and drops columns and renames primary key columns only. It has
+// no NOT NULL or column defaults and cannot change a column's type or rename
+// a table: dropping NOT NULL or a default only updates the schema, and the
+// other ops fail with ErrNotSupported before any is run.
+func (s *ScyllaDBStorage) AlterTable(ctx context.Context, tableName string, ops []storage.AlterOp) error {
 	if s.session == nil {
-		return errors.New("not connected to ScyllaDB")
+		return ddaoerrors.ErrNotConnected
 	}
-
-	cqlDataType := s.mapDataTypeForScylla(dataType)
-	query := fmt.Sprintf("ALTER TABLE %s.%s ADD %s %s", s.keyspace, tableName, columnName, cqlDataType)
-
-	storage.DebugLog(query)
-	err := s.session.Query(query).Exec()
-	if err != nil {
-		return fmt.Errorf("failed to alter table %s: %w", tableName, err)
+	if s.sch == nil {
+		return ddaoerrors.UnknownTable(tableName)
+	}
+	tbl, ok := s.sch.GetTable(tableName)
+	if !ok {
+		return ddaoerrors.UnknownTable(tableName)
+	}
+	altered := &tbl
+	for _, op := range ops {
+		switch op.Kind {
+		case storage.AlterRenameTable, storage.AlterChangeType, storage.AlterSetNotNull, storage.AlterSetDefault:
+			return ddaoerrors.NotSupported("ScyllaDB", op.Kind.String())
+		case storage.AlterRenameColumn:
+			if !slices.Contains(altered.PrimaryKeyColumns(), op.Column) {
+				return ddaoerrors.NotSupported("ScyllaDB", "renaming a column outside the primary key")
+			}
+		}
+		var err error
+		if altered, err = storage.AlterTableSchema(*altered, []storage.AlterOp{op}); err != nil {
+			return err
+		}
 	}
 
-	return nil
-}
+	for _, op := range ops {
+		before, _ := s.sch.GetTable(tableName)
+		after, err := storage.AlterTableSchema(before, []storage.AlterOp{op})
+		if err != nil {
+			return err
+		}
 
-func (s *ScyllaDBStorage) mapDataTypeForScylla(dataType string) string {
-	switch strings.ToUpper(dataType) {
-	case "TEXT", "VARCHAR", "CHAR":
-		return "text"
-	case "INTEGER", "INT":
-		return "int"
-	case "BIGINT":
-		return "bigint"
-	case "REAL", "FLOAT":
-		return "float"
-	case "DOUBLE":
-		return "double"
-	case "BOOLEAN":
-		return "boolean"
-	case "JSON":
-		return "text"
-	case "TIMESTAMP":
-		return "timestamp"
-	case "UUID":
-		return "uuid"
-	case "BLOB":
-		return "blob"
-	default:
-		return "text"
+		var queries []string
+		switch op.Kind {
+		case storage.AlterAddColumn:
+			queries = append(queries, fmt.Sprintf("ALTER TABLE %s ADD %s", s.table(tableName), dialect.Scylla.ColumnDefinition(*after, op.Field)))
+		case storage.AlterDropColumn:
+			// A column cannot be dropped while an index depends on it
+			for _, index := range before.SecondaryIndexes() {
+				if slices.Contains(index.Columns, op.Column) {
+					name := index.Name
+					if len(index.Columns) > 1 {
+						name += "_" + op.Column
+					}
+					queries = append(queries, "DROP INDEX IF EXISTS "+s.table(name))
+				}
+			}
+			queries = append(queries, fmt.Sprintf("ALTER TABLE %s DROP %s", s.table(tableName), dialect.QuoteCQL(op.Column)))
+		case storage.AlterRenameColumn:
+			queries = append(queries, fmt.Sprintf("ALTER TABLE %s RENAME %s TO %s", s.table(tableName), dialect.QuoteCQL(op.Column), dialect.QuoteCQL(op.NewName)))
+		}
+
+		for _, query := range queries {
+			storage.DebugLog(query)
+			if err := s.session.Query(query).WithContext(ctx).Exec(); err != nil {
+				return fmt.Errorf("failed to alter table %s: %w", tableName, err)
+			}
+		}
+		if op.Kind == storage.AlterAddColumn {
+			if err := s.createIndexes(*after); err != nil {
+				return err
+			}
+		}
+		s.sch.ReplaceTable(tableName, after)
 	}
+	return nil
 }
@@ -7,6 +7,9 @@ import (
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/jadedragon942/ddao/storagetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -102,8 +105,6 @@ func TestScyllaDBParseConsistency(t *testing.T) {
 }
 
 func TestScyllaDBMapDataType(t *testing.T) {
-	storage := &ScyllaDBStorage{}
-
 	tests := []struct {
 		input    string
 		expected string
@@ -120,7 +121,7 @@ func TestScyllaDBMapDataType(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := storage.mapDataType(test.input)
+		result := dialect.Scylla.MapDataType(test.input)
 		assert.Equal(t, test.expected, result)
 	}
 }
@@ -160,8 +161,7 @@ func TestScyllaDBOperations_NotConnected(t *testing.T) {
 
 	// Test FindByID without connection
 	_, err = storage.FindByID(ctx, "users", "test-id")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not connected")
+	assert.ErrorIs(t, err, ddaoerrors.ErrNotConnected)
 
 	// Test DeleteByID without connection
 	_, err = storage.DeleteByID(ctx, "users", "test-id")
@@ -173,40 +173,11 @@ func TestScyllaDBTransactionMethods(t *testing.T) {
 	storage := New()
 	ctx := context.Background()
 
-	// Test that all transaction methods return appropriate errors
-	tx, err := storage.BeginTx(ctx)
+	// Transactions need a session to batch their writes against
+	tx, err := storage.BeginTx(ctx, nil)
 	assert.Error(t, err)
 	assert.Nil(t, tx)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	err = storage.CommitTx(nil)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	err = storage.RollbackTx(nil)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	obj := object.New()
-	_, _, err = storage.InsertTx(ctx, nil, obj)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	_, err = storage.UpdateTx(ctx, nil, obj)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	_, err = storage.FindByIDTx(ctx, nil, "users", "test-id")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	_, err = storage.FindByKeyTx(ctx, nil, "users", "id", "test-id")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
-
-	_, err = storage.DeleteByIDTx(ctx, nil, "users", "test-id")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "does not support SQL-style transactions")
+	assert.Contains(t, err.Error(), "not connected")
 }
 
 // TestScyllaDBLocal tests against a local ScyllaDB instance
@@ -283,10 +254,28 @@ func TestScyllaDBLocal(t *testing.T) {
 
 	// Verify deletion
 	deletedObj, err := storage.FindByID(ctx, "users", "test-user-1")
-	require.NoError(t, err)
+	require.ErrorIs(t, err, ddaoerrors.ErrNotFound)
 	assert.Nil(t, deletedObj)
 }
 
+// TestScyllaDBKeys runs the standard key tests against a local ScyllaDB instance
+func TestScyllaDBKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping ScyllaDB integration test in short mode")
+	}
+
+	storage := New()
+	ctx := context.Background()
+
+	err := storage.Connect(ctx, "localhost:9042/testks?consistency=one&timeout=5s")
+	if err != nil {
+		t.Skipf("ScyllaDB not available: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
 func TestScyllaDBFindByKey_InvalidInputs(t *testing.T) {
 	storage := &ScyllaDBStorage{}
 	ctx := context.Background()
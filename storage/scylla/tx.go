@@ -0,0 +1,261 @@
+package scylla
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/gocql/gocql"
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// scyllaTx buffers writes and applies them atomically as a single logged batch
+// on Commit. ScyllaDB has no isolation, so reads see committed data with the
+// transaction's own writes layered on top.
+type scyllaTx struct {
+	s        *ScyllaDBStorage
+	ctx      context.Context
+	writes   storage.WriteSet
+	readOnly bool
+	done     bool
+}
+
+// BeginTx starts a transaction whose writes are sent as one logged batch on Commit
+func (s *ScyllaDBStorage) BeginTx(ctx context.Context, opts *storage.TxOptions) (storage.Tx, error) {
+	if s.session == nil {
+		return nil, ddaoerrors.ErrNotConnected
+	}
+	tx := &scyllaTx{s: s, ctx: ctx}
+	if opts != nil {
+		if opts.Isolation != sql.LevelDefault {
+			return nil, ddaoerrors.NotSupported("ScyllaDB", "isolation level "+opts.Isolation.String())
+		}
+		tx.readOnly = opts.ReadOnly
+	}
+	return tx, nil
+}
+
+func (tx *scyllaTx) check() error {
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	if tx.s.session == nil {
+		return ddaoerrors.ErrNotConnected
+	}
+	return nil
+}
+
+func (tx *scyllaTx) checkWrite() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if tx.readOnly {
+		return errors.New("cannot write in a read-only transaction")
+	}
+	return nil
+}
+
+// Insert buffers obj, failing with a DuplicateKeyError if its ID already exists
+// as seen by the transaction. The check is not repeated at Commit, since a
+// conditional batch cannot span partitions.
+func (tx *scyllaTx) Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return nil, false, err
+	}
+
+	id, err := tx.s.objectID(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	_, err = tx.FindByID(ctx, obj.TableName, id)
+	if err == nil {
+		return nil, false, tx.s.duplicateKeyError(obj.TableName)
+	}
+	if !errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, false, err
+	}
+
+	return tx.put(storage.WriteInsert, obj)
+}
+
+// Upsert buffers obj, overwriting any existing object with the same ID on Commit
+func (tx *scyllaTx) Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return nil, false, err
+	}
+	return tx.put(storage.WriteUpsert, obj)
+}
+
+func (tx *scyllaTx) put(op storage.WriteOp, obj *object.Object) ([]byte, bool, error) {
+	// Build the statement now so schema errors surface at the call site
+	if _, _, err := tx.s.insertStatement(obj); err != nil {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx.writes.Add(op, tx.keyed(obj))
+	return data, true, nil
+}
+
+func (tx *scyllaTx) Update(ctx context.Context, obj *object.Object) (bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+	if _, _, err := tx.s.updateStatement(obj); err != nil {
+		return false, err
+	}
+
+	keyed := tx.keyed(obj)
+	if _, err := tx.FindByID(ctx, keyed.TableName, keyed.ID); err != nil {
+		if errors.Is(err, ddaoerrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	tx.writes.Add(storage.WriteUpdate, keyed)
+	return true, nil
+}
+
+// keyed returns obj with its ID derived from its primary key fields, so the
+// buffered writes are matched by key however the caller identified the object.
+// Callers must have built obj's statement already, which validates its key.
+func (tx *scyllaTx) keyed(obj *object.Object) *object.Object {
+	id, err := tx.s.objectID(obj)
+	if err != nil || id == obj.ID {
+		return obj
+	}
+	keyed := *obj
+	keyed.ID = id
+	return &keyed
+}
+
+func (tx *scyllaTx) FindByID(ctx context.Context, tblName, id string) (*object.Object, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	committed, err := tx.committed(ctx, tblName, id)
+	if err != nil {
+		return nil, err
+	}
+	if current := tx.writes.Apply(tblName, id, committed); current != nil {
+		return current, nil
+	}
+	return nil, ddaoerrors.ErrNotFound
+}
+
+// committed loads the committed state of an object, returning nil if it does not exist
+func (tx *scyllaTx) committed(ctx context.Context, tblName, id string) (*object.Object, error) {
+	obj, err := tx.s.FindByID(ctx, tblName, id)
+	if errors.Is(err, ddaoerrors.ErrNotFound) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+func (tx *scyllaTx) FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error) {
+	if tblName == "" || key == "" || value == "" {
+		return nil, errors.New("table name, key, and value must not be empty")
+	}
+	if key == "id" {
+		return tx.FindByID(ctx, tblName, value)
+	}
+
+	results, err := tx.Find(ctx, tblName, storage.Eq(key, value))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ddaoerrors.ErrNotFound
+	}
+	return results[0], nil
+}
+
+func (tx *scyllaTx) Find(ctx context.Context, tblName string, q storage.Query) ([]*object.Object, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	committed, err := tx.s.Find(ctx, tblName, q)
+	if err != nil {
+		return nil, err
+	}
+	return tx.writes.Overlay(tblName, q, committed, func(id string) (*object.Object, error) {
+		return tx.committed(ctx, tblName, id)
+	})
+}
+
+func (tx *scyllaTx) DeleteByID(ctx context.Context, tblName, id string) (bool, error) {
+	if err := tx.checkWrite(); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.FindByID(ctx, tblName, id); err != nil {
+		if errors.Is(err, ddaoerrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	tx.writes.Add(storage.WriteDelete, &object.Object{TableName: tblName, ID: id})
+	return true, nil
+}
+
+// Commit sends every buffered write as one logged batch, so either all of
+// them are eventually applied or none are
+func (tx *scyllaTx) Commit() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	tx.done = true
+
+	if tx.writes.Len() == 0 {
+		return nil
+	}
+
+	batch := tx.s.session.NewBatch(gocql.LoggedBatch).WithContext(tx.ctx)
+	for _, w := range tx.writes.Writes() {
+		var (
+			query  string
+			values []interface{}
+			err    error
+		)
+		switch w.Op {
+		case storage.WriteInsert, storage.WriteUpsert:
+			query, values, err = tx.s.insertStatement(w.Object)
+		case storage.WriteUpdate:
+			query, values, err = tx.s.updateStatement(w.Object)
+		case storage.WriteDelete:
+			query, values, err = tx.s.deleteStatement(w.Object.TableName, w.Object.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		storage.DebugLog(query, values...)
+		batch.Query(query, values...)
+	}
+
+	if err := tx.s.session.ExecuteBatch(batch); err != nil {
+		return translateError(err, "")
+	}
+	return nil
+}
+
+// Rollback discards the buffered writes
+func (tx *scyllaTx) Rollback() error {
+	if tx.done {
+		return storage.ErrTxDone
+	}
+	tx.done = true
+	tx.writes.Reset()
+	return nil
+}
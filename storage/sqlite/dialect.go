@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDialect renders SQLite's statements
+type sqliteDialect struct {
+	dialect.Dialect
+}
+
+var _ common.Dialect = sqliteDialect{}
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (d sqliteDialect) Column(field schema.ColumnData) string { return d.QuoteIdent(field.Name) }
+
+func (sqliteDialect) BindValue(_ schema.ColumnData, value any) any { return value }
+
+func (sqliteDialect) InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	return common.OnConflictInsert(table, columns, keys, rows, upsert)
+}
+
+func (sqliteDialect) Paginate(query, orderBy string, limit, offset int) string {
+	return common.LimitOffset(query, orderBy, limit, offset)
+}
+
+// Returning supports SQLite 3.35 and later, which the bundled driver builds
+func (sqliteDialect) Returning(columns []string) string {
+	return "RETURNING " + strings.Join(columns, ", ")
+}
+
+// BatchLimits allows SQLite's default SQLITE_MAX_VARIABLE_NUMBER
+func (sqliteDialect) BatchLimits() (int, int) { return 32766, 0 }
+
+// AlterQueries renders the ops SQLite's ALTER TABLE can make in place; the
+// others are left to SQLiteStorage.AlterTable, which rebuilds the table
+func (d sqliteDialect) AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+	table := d.QuoteIdent(before.TableName)
+	switch op.Kind {
+	case storage.AlterAddColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, d.ColumnDefinition(after, op.Field))}, nil
+	case storage.AlterRenameColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, d.QuoteIdent(op.Column), d.QuoteIdent(op.NewName))}, nil
+	case storage.AlterRenameTable:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, d.QuoteIdent(op.NewName))}, nil
+	}
+	return nil, fmt.Errorf("%s requires rebuilding the table", op.Kind)
+}
+
+func (sqliteDialect) TranslateError(err error, tbl schema.TableSchema) error {
+	return translateError(err, tbl)
+}
+
+// IsRetryable reports whether err is SQLITE_BUSY or SQLITE_LOCKED, returned
+// when another connection holds a lock the transaction needed
+func (sqliteDialect) IsRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
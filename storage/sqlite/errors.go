@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+	"github.com/mattn/go-sqlite3"
+)
+
+// translateError maps SQLite errors from writes to tbl onto storage/errors
+func translateError(err error, tbl schema.TableSchema) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	msg := sqliteErr.Error()
+	switch {
+	case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique, sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: constraintColumns(msg), Err: err}
+	case strings.HasPrefix(msg, "no such table"):
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Err: err}
+	case strings.HasPrefix(msg, "no such column"), strings.Contains(msg, "has no column named"):
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Field: msg[strings.LastIndex(msg, " ")+1:], Err: err}
+	}
+	return err
+}
+
+// constraintColumns extracts the columns from a message such as
+// "UNIQUE constraint failed: people.email, people.name"
+func constraintColumns(msg string) string {
+	_, list, ok := strings.Cut(msg, "failed: ")
+	if !ok {
+		return ""
+	}
+
+	columns := strings.Split(list, ", ")
+	for i, column := range columns {
+		if _, name, ok := strings.Cut(column, "."); ok {
+			columns[i] = name
+		}
+	}
+	return ddaoerrors.KeyColumns(columns)
+}
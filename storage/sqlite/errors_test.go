@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jadedragon942/ddao/schema"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+func TestTranslateError(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tbl := *schema.NewTableSchema("people")
+	if _, err := db.Exec("CREATE TABLE people (id TEXT PRIMARY KEY, email TEXT UNIQUE)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO people (id, email) VALUES ('a', 'a@example.com')"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO people (id, email) VALUES ('b', 'a@example.com')")
+	var dup *ddaoerrors.DuplicateKeyError
+	if err := translateError(err, tbl); !errors.As(err, &dup) {
+		t.Fatalf("expected DuplicateKeyError, got %v", err)
+	}
+	if dup.Table != "people" || dup.Column != "email" {
+		t.Errorf("expected duplicate on people.email, got %s.%s", dup.Table, dup.Column)
+	}
+
+	_, err = db.Exec("INSERT INTO people (id, email) VALUES ('a', 'b@example.com')")
+	if err := translateError(err, tbl); !errors.As(err, &dup) || dup.Column != "id" {
+		t.Errorf("expected duplicate on id, got %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO people (id, phone) VALUES ('c', '555')")
+	var mismatch *ddaoerrors.SchemaMismatchError
+	if err := translateError(err, tbl); !errors.As(err, &mismatch) || mismatch.Field != "phone" {
+		t.Errorf("expected schema mismatch on phone, got %v", err)
+	}
+
+	_, err = db.Exec("SELECT * FROM nonexistent")
+	if err := translateError(err, tbl); !errors.Is(err, ddaoerrors.ErrSchemaMismatch) {
+		t.Errorf("expected ErrSchemaMismatch, got %v", err)
+	}
+}
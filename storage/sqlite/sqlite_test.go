@@ -2,8 +2,13 @@ package sqlite
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/jadedragon942/ddao/object"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
 	"github.com/jadedragon942/ddao/storagetest"
 )
 
@@ -31,6 +36,18 @@ func TestSQLiteCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestSQLiteUpsert(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 func TestSQLiteTransactions(t *testing.T) {
 	storage := New()
 	ctx := context.Background()
@@ -42,3 +59,265 @@ func TestSQLiteTransactions(t *testing.T) {
 
 	storagetest.TransactionTest(t, storage)
 }
+
+func TestSQLiteQuery(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestSQLiteList(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestSQLiteRows(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestSQLiteErrors(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestSQLiteBatch(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestSQLiteKeys(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestSQLiteReservedWords(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestSQLiteCreateIndexes(t *testing.T) {
+	store := New().(*SQLiteStorage)
+	ctx := context.Background()
+	err := store.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer store.ResetConnection(ctx)
+
+	if err := store.CreateTables(ctx, storagetest.IndexesSchema()); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	rows, err := store.GetDB().QueryContext(ctx, "SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = 'members' AND sql IS NOT NULL ORDER BY name")
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	defer rows.Close()
+
+	indexes := make(map[string]string)
+	var names []string
+	for rows.Next() {
+		var name, sql string
+		if err := rows.Scan(&name, &sql); err != nil {
+			t.Fatalf("Failed to scan index: %v", err)
+		}
+		indexes[name] = sql
+		names = append(names, name)
+	}
+
+	want := []string{"idx_members_email", "idx_members_team_role", "uq_members_team_handle"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("expected indexes %v, got %v", want, names)
+	}
+	if sql := indexes["uq_members_team_handle"]; sql != `CREATE UNIQUE INDEX "uq_members_team_handle" ON "members" ("team", "handle")` {
+		t.Errorf("unexpected unique index definition: %s", sql)
+	}
+}
+
+func TestSQLiteIndexes(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestSQLiteAlterTable(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
+
+func TestSQLiteAlterTableRebuild(t *testing.T) {
+	store := New().(*SQLiteStorage)
+	ctx := context.Background()
+	err := store.Connect(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer store.ResetConnection(ctx)
+
+	if err := store.CreateTables(ctx, storagetest.IndexesSchema()); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+	if _, _, err := store.Insert(ctx, &object.Object{TableName: "members", ID: "m1", Fields: map[string]any{
+		"id": "m1", "email": "ann@example.com", "team": "red", "role": "lead", "handle": "ann",
+	}}); err != nil {
+		t.Fatalf("Failed to insert member: %v", err)
+	}
+
+	// Changing nullability and dropping a column cannot be done in place
+	err = store.AlterTable(ctx, "members", []storage.AlterOp{
+		storage.RenameColumn("handle", "nick"),
+		storage.DropNotNull("email"),
+		storage.SetDefault("role", "dev"),
+		storage.DropColumn("team"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to alter table: %v", err)
+	}
+
+	var tableSQL string
+	if err := store.GetDB().QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'members'").Scan(&tableSQL); err != nil {
+		t.Fatalf("Failed to read table definition: %v", err)
+	}
+	for _, want := range []string{`"email" text NULL`, `"role" text NOT NULL DEFAULT 'dev'`, `"nick" text NOT NULL`} {
+		if !strings.Contains(tableSQL, want) {
+			t.Errorf("expected %q in the rebuilt table, got %s", want, tableSQL)
+		}
+	}
+	if strings.Contains(tableSQL, "team") {
+		t.Errorf("expected team to be dropped, got %s", tableSQL)
+	}
+
+	// The indexes on team went with it; the one on email is recreated
+	var names []string
+	rows, err := store.GetDB().QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'members' AND sql IS NOT NULL ORDER BY name")
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	rows.Close()
+	if want := []string{"idx_members_email"}; !slices.Equal(names, want) {
+		t.Errorf("expected indexes %v, got %v", want, names)
+	}
+
+	obj, err := store.FindByKey(ctx, "members", "nick", "ann")
+	if err != nil {
+		t.Fatalf("Failed to find member by renamed column: %v", err)
+	}
+	if obj.ID != "m1" || obj.Fields["role"] != "lead" {
+		t.Errorf("expected member m1 to keep its values, got %v", obj.Fields)
+	}
+	if _, ok := obj.Fields["team"]; ok {
+		t.Errorf("expected no team field, got %v", obj.Fields)
+	}
+}
+
+func TestSQLiteInsertReturnsDefaults(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+	if err := store.Connect(ctx, ":memory:"); err != nil {
+		t.Fatalf("Failed to connect to SQLite storage: %v", err)
+	}
+	defer store.ResetConnection(ctx)
+
+	sch := schema.New()
+	tasks := schema.NewTableSchema("tasks")
+	tasks.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	tasks.AddField(schema.ColumnData{Name: "title", DataType: "text"})
+	tasks.AddField(schema.ColumnData{Name: "status", DataType: "text", Default: "open"})
+	tasks.AddField(schema.ColumnData{Name: "priority", DataType: "integer", Nullable: true, Default: 3})
+	tasks.AddField(schema.ColumnData{Name: "estimate", DataType: "real", Nullable: true})
+	sch.AddTable(tasks)
+	if err := store.CreateTables(ctx, sch); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	obj := &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"id": "t1", "title": "Write docs"}}
+	data, _, err := store.Insert(ctx, obj)
+	if err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if obj.Fields["status"] != "open" || obj.Fields["priority"] != int64(3) {
+		t.Errorf("expected the column defaults to be filled in, got %v", obj.Fields)
+	}
+	if _, ok := obj.Fields["estimate"]; ok {
+		t.Errorf("expected NULL estimate to be left unset, got %v", obj.Fields["estimate"])
+	}
+	if !strings.Contains(string(data), `"status":"open"`) {
+		t.Errorf("expected the returned JSON to hold the defaults, got %s", data)
+	}
+
+	// An upsert returns the row it overwrote, with the columns it left alone
+	obj = &object.Object{TableName: "tasks", ID: "t1", Fields: map[string]any{"id": "t1", "title": "Review docs", "status": "done"}}
+	if _, _, err := store.Upsert(ctx, obj); err != nil {
+		t.Fatalf("Failed to upsert task: %v", err)
+	}
+	if obj.Fields["status"] != "done" || obj.Fields["priority"] != int64(3) {
+		t.Errorf("expected the upsert to keep its status and fill in the priority, got %v", obj.Fields)
+	}
+}
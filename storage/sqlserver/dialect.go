@@ -0,0 +1,150 @@
+package sqlserver
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// sqlserverDialect renders SQL Server's statements, bracketing every name
+type sqlserverDialect struct {
+	dialect.Dialect
+}
+
+var _ common.Dialect = sqlserverDialect{}
+
+func (sqlserverDialect) Placeholder(int) string { return "?" }
+
+func (sqlserverDialect) Column(field schema.ColumnData) string { return dialect.Bracket(field.Name) }
+
+func (sqlserverDialect) BindValue(_ schema.ColumnData, value any) any { return value }
+
+// InsertQuery renders an INSERT of rows, or with upsert a MERGE whose source
+// is a table value constructor holding them, overwriting the other columns of
+// existing rows with the same key
+func (sqlserverDialect) InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	if !upsert {
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), common.ValuesList(rows))
+	}
+
+	sourceColumns := make([]string, len(columns))
+	for i, col := range columns {
+		sourceColumns[i] = "source." + col
+	}
+	matches := make([]string, keys)
+	for i, col := range columns[:keys] {
+		matches[i] = fmt.Sprintf("target.%s = source.%s", col, col)
+	}
+	updateClauses := make([]string, 0, len(columns)-keys)
+	for _, col := range columns[keys:] { // Skip key columns
+		updateClauses = append(updateClauses, fmt.Sprintf("%s = source.%s", col, col))
+	}
+
+	query := fmt.Sprintf(`
+		MERGE %s AS target
+		USING (VALUES %s) AS source (%s)
+		ON %s`,
+		table,
+		common.ValuesList(rows),
+		strings.Join(columns, ", "),
+		strings.Join(matches, " AND "))
+	if len(updateClauses) > 0 {
+		query += `
+		WHEN MATCHED THEN
+			UPDATE SET ` + strings.Join(updateClauses, ", ")
+	}
+	return query + fmt.Sprintf(`
+		WHEN NOT MATCHED THEN
+			INSERT (%s) VALUES (%s);`,
+		strings.Join(columns, ", "),
+		strings.Join(sourceColumns, ", "))
+}
+
+// Paginate uses OFFSET ... FETCH, which SQL Server requires instead of LIMIT
+func (sqlserverDialect) Paginate(query, orderBy string, limit, offset int) string {
+	return common.OffsetFetch(query, orderBy, limit, offset)
+}
+
+// Returning returns "": SQL Server's OUTPUT clause goes before VALUES
+func (sqlserverDialect) Returning([]string) string { return "" }
+
+// BatchLimits keeps statements under SQL Server's limit of 2100 parameters
+// and the 1000 rows an INSERT ... VALUES may list
+func (sqlserverDialect) BatchLimits() (int, int) { return 2099, 1000 }
+
+// AlterQueries renders op, run with the other ops in one transaction
+func (sqlserverDialect) AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+	table := dialect.Bracket(before.TableName)
+	column := dialect.Bracket(op.Column)
+	switch op.Kind {
+	case storage.AlterAddColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s ADD %s", table, dialect.SQLServer.ColumnDefinition(after, op.Field))}, nil
+	case storage.AlterDropColumn:
+		// The column's indexes and default constraint must go first
+		var queries []string
+		for _, index := range before.SecondaryIndexes() {
+			if slices.Contains(index.Columns, op.Column) {
+				queries = append(queries, fmt.Sprintf("DROP INDEX IF EXISTS %s ON %s", dialect.Bracket(index.Name), table))
+			}
+		}
+		queries = append(queries, dropDefaultQuery(before.TableName, op.Column))
+		return append(queries, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column)), nil
+	case storage.AlterRenameColumn:
+		// sp_rename takes the object as a string and the new name as it is
+		return []string{fmt.Sprintf("EXEC sp_rename %s, %s, 'COLUMN'", dialect.QuoteString(table+"."+column), dialect.QuoteString(op.NewName))}, nil
+	case storage.AlterRenameTable:
+		return []string{fmt.Sprintf("EXEC sp_rename %s, %s", dialect.QuoteString(table), dialect.QuoteString(op.NewName))}, nil
+	case storage.AlterChangeType, storage.AlterSetNotNull, storage.AlterDropNotNull:
+		// ALTER COLUMN restates the type and nullability; the default is a separate constraint
+		field := after.Fields[op.Column]
+		nullable := "NOT NULL"
+		if field.Nullable {
+			nullable = "NULL"
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s", table, column, dialect.SQLServer.ColumnType(after, field), nullable)}, nil
+	case storage.AlterSetDefault:
+		return []string{
+			dropDefaultQuery(before.TableName, op.Column),
+			fmt.Sprintf("ALTER TABLE %s ADD DEFAULT %s FOR %s", table, dialect.SQLServer.Literal(op.Default), column),
+		}, nil
+	case storage.AlterDropDefault:
+		return []string{dropDefaultQuery(before.TableName, op.Column)}, nil
+	}
+	return nil, nil
+}
+
+// dropDefaultQuery drops the default constraint of column, whose name SQL
+// Server generates, if it has one
+func dropDefaultQuery(table, column string) string {
+	object := dialect.QuoteString(dialect.Bracket(table))
+	return fmt.Sprintf("DECLARE @name sysname; "+
+		"SELECT @name = name FROM sys.default_constraints WHERE parent_object_id = OBJECT_ID(%s) "+
+		"AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId'); "+
+		"IF @name IS NOT NULL EXEC(%s + QUOTENAME(@name))",
+		object, object, dialect.QuoteString(column), dialect.QuoteString("ALTER TABLE "+dialect.Bracket(table)+" DROP CONSTRAINT "))
+}
+
+func (sqlserverDialect) TranslateError(err error, tbl schema.TableSchema) error {
+	return translateError(err, tbl)
+}
+
+// IsRetryable reports whether err chose the transaction as a deadlock victim
+// (1205) or is a snapshot isolation update conflict (3960), after which the
+// transaction should be run again
+func (sqlserverDialect) IsRetryable(err error) bool {
+	var sqlErr interface{ SQLErrorNumber() int32 }
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+	switch sqlErr.SQLErrorNumber() {
+	case 1205, 3960:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package sqlserver
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/common"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// translateError maps SQL Server errors from writes to tbl onto storage/errors.
+// SQL Server names the violated constraint or index rather than the column.
+func translateError(err error, tbl schema.TableSchema) error {
+	var sqlErr interface {
+		SQLErrorNumber() int32
+		SQLErrorMessage() string
+	}
+	if !errors.As(err, &sqlErr) {
+		return err
+	}
+
+	msg := sqlErr.SQLErrorMessage()
+	switch sqlErr.SQLErrorNumber() {
+	case 2627: // Violation of PRIMARY KEY / UNIQUE KEY constraint 'name'
+		column := quoted(msg, 0)
+		if strings.Contains(msg, "PRIMARY KEY") {
+			column = common.PrimaryKeyColumn(tbl)
+		}
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: column, Err: err}
+	case 2601: // Cannot insert duplicate key row in object 'table' with unique index 'name'
+		column := quoted(msg, 1)
+		if columns, ok := common.UniqueIndexColumns(tbl, column); ok {
+			column = columns
+		}
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: column, Err: err}
+	case 208: // Invalid object name 'table'
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Err: err}
+	case 207: // Invalid column name 'column'
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Field: quoted(msg, 0), Err: err}
+	}
+	return err
+}
+
+// quoted returns the n-th (0-based) single-quoted name in msg
+func quoted(msg string, n int) string {
+	parts := strings.Split(msg, "'")
+	if i := 2*n + 1; i < len(parts)-1 {
+		return parts[i]
+	}
+	return ""
+}
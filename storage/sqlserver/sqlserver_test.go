@@ -42,6 +42,23 @@ func TestSQLServerCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestSQLServerUpsert(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestSQLServerLocal runs tests against a local SQL Server instance
 // Use: docker run -e "ACCEPT_EULA=Y" -e "SA_PASSWORD=YourStrong@Passw0rd" -p 1433:1433 --name sqlserver-test -d mcr.microsoft.com/mssql/server:2019-latest
 func TestSQLServerLocal(t *testing.T) {
@@ -94,4 +111,157 @@ func TestSQLServerLocalTransactions(t *testing.T) {
 	defer storage.ResetConnection(ctx)
 
 	storagetest.TransactionTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestSQLServerQuery(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestSQLServerList(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestSQLServerRows(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestSQLServerErrors(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestSQLServerBatch(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestSQLServerKeys(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestSQLServerReservedWords(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestSQLServerIndexes(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestSQLServerAlterTable(t *testing.T) {
+	connStr := os.Getenv("SQLSERVER_TEST_URL")
+	if connStr == "" {
+		t.Skip("SQLSERVER_TEST_URL not set, skipping SQL Server alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to SQL Server storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"context"
-	"database/sql"
 	"log"
 	"os"
 
@@ -12,26 +11,37 @@ import (
 
 type Storage interface {
 	Connect(ctx context.Context, connStr string) error
+	// CreateTables creates the schema's tables and indexes if they do not
+	// exist. SQL backends declare the tables' foreign keys and the database
+	// enforces them; the scylla and s3 backends keep them in the schema only,
+	// leaving referential integrity to the application.
 	CreateTables(ctx context.Context, schema *schema.Schema) error
+	// Insert fails with an errors.DuplicateKeyError if obj's ID already exists;
+	// Upsert overwrites the existing object instead
 	Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
 	Update(ctx context.Context, obj *object.Object) (bool, error)
 	Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
 	FindByID(ctx context.Context, tblName, id string) (*object.Object, error)
 	FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error)
+	Find(ctx context.Context, tblName string, q Query) ([]*object.Object, error)
+	List(ctx context.Context, tblName string, opts ListOptions) ([]*object.Object, string, error)
+	FindRows(ctx context.Context, tblName string, q Query) (Rows, error)
 	DeleteByID(ctx context.Context, tblName, id string) (bool, error)
+
+	// Bulk writes, sent in batches of opts.BatchSize. The BatchResult reports
+	// each object's outcome; the error is only set when the whole call failed,
+	// such as when not connected or when ctx is canceled part way through.
+	InsertMany(ctx context.Context, objs []*object.Object, opts BatchOptions) (*BatchResult, error)
+	UpsertMany(ctx context.Context, objs []*object.Object, opts BatchOptions) (*BatchResult, error)
+	DeleteMany(ctx context.Context, tblName string, ids []string, opts BatchOptions) (*BatchResult, error)
+
 	ResetConnection(ctx context.Context) error
-	AlterTable(ctx context.Context, tableName, columnName, dataType string, nullable bool) error
-
-	// Transaction support
-	BeginTx(ctx context.Context) (*sql.Tx, error)
-	CommitTx(tx *sql.Tx) error
-	RollbackTx(tx *sql.Tx) error
-	InsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error)
-	UpdateTx(ctx context.Context, tx *sql.Tx, obj *object.Object) (bool, error)
-	UpsertTx(ctx context.Context, tx *sql.Tx, obj *object.Object) ([]byte, bool, error)
-	FindByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (*object.Object, error)
-	FindByKeyTx(ctx context.Context, tx *sql.Tx, tblName, key, value string) (*object.Object, error)
-	DeleteByIDTx(ctx context.Context, tx *sql.Tx, tblName, id string) (bool, error)
+	// AlterTable applies ops to tableName in order and updates the table in the
+	// schema given to CreateTables; see AlterOp
+	AlterTable(ctx context.Context, tableName string, ops []AlterOp) error
+
+	// Transaction support; see Tx
+	BeginTx(ctx context.Context, opts *TxOptions) (Tx, error)
 }
 
 // IsDebugEnabled checks if DEBUG environment variable is set
@@ -0,0 +1,107 @@
+package tidb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage"
+	"github.com/jadedragon942/ddao/storage/common"
+	"github.com/jadedragon942/ddao/storage/dialect"
+)
+
+// tidbDialect renders TiDB's MySQL-compatible statements
+type tidbDialect struct {
+	dialect.Dialect
+}
+
+var _ common.Dialect = tidbDialect{}
+
+func (tidbDialect) Placeholder(int) string { return "?" }
+
+func (d tidbDialect) Column(field schema.ColumnData) string { return d.QuoteIdent(field.Name) }
+
+func (tidbDialect) BindValue(_ schema.ColumnData, value any) any { return value }
+
+// InsertQuery renders an INSERT of one or more rows, or with upsert an INSERT ...
+// ON DUPLICATE KEY UPDATE that overwrites the other columns of an existing row.
+// Unlike REPLACE INTO it neither deletes the old row nor touches rows that
+// clash on other unique keys.
+func (tidbDialect) InsertQuery(table string, columns []string, keys int, rows [][]string, upsert bool) string {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		table,
+		strings.Join(columns, ", "),
+		common.ValuesList(rows))
+	if !upsert {
+		return query
+	}
+	if len(columns) == keys {
+		return query + fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", columns[0], columns[0])
+	}
+
+	updateClauses := make([]string, 0, len(columns)-keys)
+	for _, column := range columns[keys:] { // Skip key columns
+		updateClauses = append(updateClauses, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+	return query + " ON DUPLICATE KEY UPDATE " + strings.Join(updateClauses, ", ")
+}
+
+func (tidbDialect) Paginate(query, orderBy string, limit, offset int) string {
+	return common.LimitOffset(query, orderBy, limit, offset)
+}
+
+// Returning returns "": TiDB has no INSERT ... RETURNING
+func (tidbDialect) Returning([]string) string { return "" }
+
+// BatchLimits allows the most placeholders the MySQL protocol allows in one prepared statement
+func (tidbDialect) BatchLimits() (int, int) { return 65535, 0 }
+
+// AlterQueries renders op. TiDB commits each DDL statement on its own, so a
+// failed AlterTable keeps the ops run before it.
+func (d tidbDialect) AlterQueries(before, after schema.TableSchema, op storage.AlterOp) ([]string, error) {
+	table := d.QuoteIdent(before.TableName)
+	column := d.QuoteIdent(op.Column)
+	var query string
+	switch op.Kind {
+	case storage.AlterAddColumn:
+		query = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, d.ColumnDefinition(after, op.Field))
+	case storage.AlterDropColumn:
+		query = fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column)
+	case storage.AlterRenameColumn:
+		query = fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, column, d.QuoteIdent(op.NewName))
+	case storage.AlterRenameTable:
+		query = fmt.Sprintf("RENAME TABLE %s TO %s", table, d.QuoteIdent(op.NewName))
+	case storage.AlterChangeType, storage.AlterSetNotNull, storage.AlterDropNotNull:
+		// MODIFY restates the whole column; UNIQUE is left out so no second index is added
+		field := after.Fields[op.Column]
+		field.Unique = false
+		query = fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, d.ColumnDefinition(after, field))
+	case storage.AlterSetDefault:
+		query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, column, d.Literal(op.Default))
+	case storage.AlterDropDefault:
+		query = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, column)
+	}
+	return []string{query}, nil
+}
+
+func (tidbDialect) TranslateError(err error, tbl schema.TableSchema) error {
+	return translateError(err, tbl)
+}
+
+// IsRetryable reports whether err is one of TiDB's transient transaction errors
+// after which the transaction should be run again: optimistic write conflicts
+// (9007, 8002), retryable KV errors (8022), concurrent schema changes (8028)
+// and deadlocks (1213)
+func (tidbDialect) IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 9007, 8002, 8022, 8028, 1213:
+		return true
+	}
+	return false
+}
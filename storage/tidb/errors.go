@@ -0,0 +1,53 @@
+package tidb
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jadedragon942/ddao/schema"
+	"github.com/jadedragon942/ddao/storage/common"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// duplicateKeyName extracts the key from "Duplicate entry 'x' for key 'people.email'"
+var duplicateKeyName = regexp.MustCompile(`for key '([^']+)'`)
+
+// translateError maps MySQL protocol errors from writes to tbl onto storage/errors
+func translateError(err error, tbl schema.TableSchema) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	switch mysqlErr.Number {
+	case 1062: // ER_DUP_ENTRY
+		var column string
+		if m := duplicateKeyName.FindStringSubmatch(mysqlErr.Message); m != nil {
+			// Newer servers qualify the key with its table
+			column = m[1][strings.LastIndex(m[1], ".")+1:]
+		}
+		if column == "PRIMARY" {
+			column = common.PrimaryKeyColumn(tbl)
+		} else if columns, ok := common.UniqueIndexColumns(tbl, column); ok {
+			column = columns
+		}
+		return &ddaoerrors.DuplicateKeyError{Table: tbl.TableName, Column: column, Err: err}
+	case 1146: // ER_NO_SUCH_TABLE
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Err: err}
+	case 1054: // ER_BAD_FIELD_ERROR
+		return &ddaoerrors.SchemaMismatchError{Table: tbl.TableName, Field: quoted(mysqlErr.Message), Err: err}
+	}
+	return err
+}
+
+// quoted returns the first single-quoted name in msg
+func quoted(msg string) string {
+	_, rest, ok := strings.Cut(msg, "'")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, "'")
+	return name
+}
@@ -2,9 +2,12 @@ package tidb
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jadedragon942/ddao/storagetest"
 )
 
@@ -42,6 +45,23 @@ func TestTiDBCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestTiDBUpsert(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestTiDBLocal runs tests against a local TiDB instance
 // Use: docker run --name tidb-server -d -p 4000:4000 pingcap/tidb:latest
 func TestTiDBLocal(t *testing.T) {
@@ -65,4 +85,190 @@ func TestTiDBLocal(t *testing.T) {
 
 	storagetest.StorageTest(t, storage)
 	storagetest.CRUDTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestTiDBQuery(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestTiDBList(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestTiDBRows(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestTiDBErrors(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestTiDBIsRetryable(t *testing.T) {
+	s := New().(*TiDBStorage)
+
+	writeConflict := &mysql.MySQLError{Number: 9007, Message: "Write conflict"}
+	if !s.IsRetryable(writeConflict) {
+		t.Error("write conflict should be retryable")
+	}
+	if !s.IsRetryable(fmt.Errorf("commit: %w", &mysql.MySQLError{Number: 1213})) {
+		t.Error("wrapped deadlock should be retryable")
+	}
+	if s.IsRetryable(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}) {
+		t.Error("duplicate key should not be retryable")
+	}
+	if s.IsRetryable(errors.New("connection refused")) {
+		t.Error("non-MySQL error should not be retryable")
+	}
+}
+
+func TestDataSourceName(t *testing.T) {
+	for connStr, want := range map[string]string{
+		"tidb://root:p%40ss@db:4001/app?parseTime=true": "root:p@ss@tcp(db:4001)/app?parseTime=true",
+		"mysql://root@localhost/test":                   "root@tcp(localhost:4000)/test",
+		"root:@tcp(localhost:4000)/test":                "root:@tcp(localhost:4000)/test",
+	} {
+		dsn, err := dataSourceName(connStr)
+		if err != nil {
+			t.Errorf("dataSourceName(%q) failed: %v", connStr, err)
+		} else if dsn != want {
+			t.Errorf("dataSourceName(%q) = %q, want %q", connStr, dsn, want)
+		}
+	}
+}
+
+func TestTiDBBatch(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestTiDBKeys(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestTiDBReservedWords(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestTiDBIndexes(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestTiDBAlterTable(t *testing.T) {
+	connStr := os.Getenv("TIDB_TEST_URL")
+	if connStr == "" {
+		t.Skip("TIDB_TEST_URL not set, skipping TiDB alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to TiDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
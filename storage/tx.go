@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jadedragon942/ddao/object"
+)
+
+// ErrTxDone is returned by operations on a transaction that has already been committed or rolled back
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// TxOptions configures a transaction started with Storage.BeginTx; nil selects the backend's defaults
+type TxOptions struct {
+	// Isolation is the isolation level; sql.LevelDefault leaves it to the backend
+	Isolation sql.IsolationLevel
+	// ReadOnly rejects writes made through the transaction
+	ReadOnly bool
+}
+
+// SQLOptions converts opts for database/sql
+func (opts *TxOptions) SQLOptions() *sql.TxOptions {
+	if opts == nil {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+}
+
+// RetryClassifier is implemented by backends whose transactions can fail with
+// transient conflicts, such as serialization failures, that succeed when the
+// whole transaction is run again
+type RetryClassifier interface {
+	IsRetryable(err error) bool
+}
+
+// Tx is a backend-neutral transaction returned by Storage.BeginTx.
+// SQL backends wrap a database transaction; backends without one buffer
+// writes and apply them on Commit. In every case a transaction sees its own
+// uncommitted writes, and nothing is visible to other callers before Commit.
+type Tx interface {
+	Insert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	Update(ctx context.Context, obj *object.Object) (bool, error)
+	Upsert(ctx context.Context, obj *object.Object) ([]byte, bool, error)
+	FindByID(ctx context.Context, tblName, id string) (*object.Object, error)
+	FindByKey(ctx context.Context, tblName, key, value string) (*object.Object, error)
+	Find(ctx context.Context, tblName string, q Query) ([]*object.Object, error)
+	DeleteByID(ctx context.Context, tblName, id string) (bool, error)
+
+	Commit() error
+	Rollback() error
+}
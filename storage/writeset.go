@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"maps"
+
+	"github.com/jadedragon942/ddao/object"
+)
+
+// WriteOp identifies the kind of a buffered write
+type WriteOp int
+
+const (
+	WriteInsert WriteOp = iota
+	WriteUpdate
+	WriteDelete
+	// WriteUpsert creates the object or overwrites it if it already exists
+	WriteUpsert
+)
+
+// Write is a single buffered change. Inserts and upserts carry the full object, updates
+// only the fields being changed, and deletes only TableName and ID.
+type Write struct {
+	Op     WriteOp
+	Object *object.Object
+}
+
+// WriteSet buffers the writes of a transaction for backends that cannot hold
+// one open on the server. Writes are replayed in order on Commit, and reads
+// inside the transaction are layered over committed data with Apply and Overlay.
+type WriteSet struct {
+	writes []Write
+}
+
+// Add records a write. The object is copied so later changes by the caller do not leak in.
+func (ws *WriteSet) Add(op WriteOp, obj *object.Object) {
+	ws.writes = append(ws.writes, Write{Op: op, Object: copyObject(obj)})
+}
+
+// Writes returns the buffered writes in the order they were made
+func (ws *WriteSet) Writes() []Write {
+	return ws.writes
+}
+
+// Len returns the number of buffered writes
+func (ws *WriteSet) Len() int {
+	return len(ws.writes)
+}
+
+// Reset discards every buffered write
+func (ws *WriteSet) Reset() {
+	ws.writes = nil
+}
+
+// Touches reports whether any buffered write affects the given object
+func (ws *WriteSet) Touches(tblName, id string) bool {
+	for _, w := range ws.writes {
+		if w.Object.TableName == tblName && w.Object.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply replays the buffered writes for one object over its committed state,
+// returning nil if the object does not exist from the transaction's point of view
+func (ws *WriteSet) Apply(tblName, id string, committed *object.Object) *object.Object {
+	current := committed
+	for _, w := range ws.writes {
+		if w.Object.TableName != tblName || w.Object.ID != id {
+			continue
+		}
+
+		switch w.Op {
+		case WriteInsert, WriteUpsert:
+			current = copyObject(w.Object)
+		case WriteUpdate:
+			if current != nil {
+				current = copyObject(current)
+				maps.Copy(current.Fields, w.Object.Fields)
+			}
+		case WriteDelete:
+			current = nil
+		}
+	}
+	return current
+}
+
+// Overlay merges buffered writes into objs, the committed objects of tblName
+// matching q. Objects written by the transaction but absent from objs are
+// loaded with fetch so that updates which make them match q are honoured.
+func (ws *WriteSet) Overlay(tblName string, q Query, objs []*object.Object, fetch func(id string) (*object.Object, error)) ([]*object.Object, error) {
+	if ws.Len() == 0 {
+		return objs, nil
+	}
+
+	results := make([]*object.Object, 0, len(objs))
+	seen := make(map[string]bool, len(objs))
+	for _, obj := range objs {
+		seen[obj.ID] = true
+		if !ws.Touches(tblName, obj.ID) {
+			results = append(results, obj)
+			continue
+		}
+		if current := ws.Apply(tblName, obj.ID, obj); current != nil && q.Match(current) {
+			results = append(results, current)
+		}
+	}
+
+	for _, w := range ws.writes {
+		if w.Object.TableName != tblName || seen[w.Object.ID] {
+			continue
+		}
+		seen[w.Object.ID] = true
+
+		committed, err := fetch(w.Object.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current := ws.Apply(tblName, w.Object.ID, committed); current != nil && q.Match(current) {
+			results = append(results, current)
+		}
+	}
+
+	return results, nil
+}
+
+func copyObject(obj *object.Object) *object.Object {
+	if obj == nil {
+		return nil
+	}
+	fields := make(map[string]any, len(obj.Fields))
+	maps.Copy(fields, obj.Fields)
+	return &object.Object{
+		ID:        obj.ID,
+		TableName: obj.TableName,
+		Fields:    fields,
+	}
+}
@@ -42,6 +42,23 @@ func TestYugabyteDBCRUD(t *testing.T) {
 	storagetest.CRUDTest(t, storage)
 }
 
+func TestYugabyteDBUpsert(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB upsert tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.UpsertTest(t, storage)
+}
+
 // TestYugabyteDBLocal runs tests against a local YugabyteDB instance
 // Use: docker run -d --name yugabyte -p 7000:7000 -p 9000:9000 -p 5433:5433 -p 9042:9042 yugabytedb/yugabyte:latest bin/yugabyted start --daemon=false
 func TestYugabyteDBLocal(t *testing.T) {
@@ -60,4 +77,157 @@ func TestYugabyteDBLocal(t *testing.T) {
 
 	storagetest.StorageTest(t, storage)
 	storagetest.CRUDTest(t, storage)
-}
\ No newline at end of file
+}
+
+func TestYugabyteDBQuery(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB query tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.QueryTest(t, storage)
+}
+
+func TestYugabyteDBList(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB list tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ListTest(t, storage)
+}
+
+func TestYugabyteDBRows(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB rows tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.RowsTest(t, storage)
+}
+
+func TestYugabyteDBErrors(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB errors tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ErrorsTest(t, storage)
+}
+
+func TestYugabyteDBBatch(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB batch tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.BatchTest(t, storage)
+}
+
+func TestYugabyteDBKeys(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB key tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.KeysTest(t, storage)
+}
+
+func TestYugabyteDBReservedWords(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB reserved word tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.ReservedWordsTest(t, storage)
+}
+
+func TestYugabyteDBIndexes(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB index tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.IndexesTest(t, storage)
+}
+
+func TestYugabyteDBAlterTable(t *testing.T) {
+	connStr := os.Getenv("YUGABYTE_TEST_URL")
+	if connStr == "" {
+		t.Skip("YUGABYTE_TEST_URL not set, skipping YugabyteDB alter table tests")
+	}
+
+	storage := New()
+	ctx := context.Background()
+	err := storage.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to YugabyteDB storage: %v", err)
+	}
+	defer storage.ResetConnection(ctx)
+
+	storagetest.AlterTableTest(t, storage)
+}
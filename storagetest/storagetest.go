@@ -2,12 +2,22 @@ package storagetest
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/jadedragon942/ddao/object"
 	"github.com/jadedragon942/ddao/schema"
 	"github.com/jadedragon942/ddao/storage"
+	ddaoerrors "github.com/jadedragon942/ddao/storage/errors"
+)
+
+// errTxDone and errNotFound are referenced from tests whose storage parameter shadows the package name
+var (
+	errTxDone   = storage.ErrTxDone
+	errNotFound = ddaoerrors.ErrNotFound
 )
 
 // StorageTest is a simple sanity check for a storage.Storage backend
@@ -21,7 +31,7 @@ func StorageTest(t *testing.T, storage storage.Storage) {
 	}
 
 	v, err := storage.FindByID(ctx, "people", "hello")
-	if err != nil {
+	if !errors.Is(err, errNotFound) {
 		t.Errorf("getting a non-existent key was 'ok': v=%v err=%v\n", v, err)
 	}
 
@@ -64,7 +74,7 @@ func StorageTest(t *testing.T, storage storage.Storage) {
 	}
 
 	v, err = storage.FindByID(ctx, "people", "hello")
-	if v != nil || err != nil {
+	if v != nil || !errors.Is(err, errNotFound) {
 		t.Fatalf("getting a non-existent key post-delete was 'ok': v=%v err=%v\n", v, err)
 	}
 
@@ -84,6 +94,11 @@ func CRUDTest(t *testing.T, storage storage.Storage) {
 		t.Fatalf("failed to create tables: %v", err)
 	}
 
+	// Insert does not overwrite, so remove objects left over from earlier runs
+	for _, id := range []string{"user1", "user2", "user3", "user4"} {
+		storage.DeleteByID(ctx, "people", id)
+	}
+
 	// Test CREATE operations
 	testObjects := []*object.Object{
 		{
@@ -156,8 +171,8 @@ func CRUDTest(t *testing.T, storage storage.Storage) {
 
 	// Test non-existent object
 	nonExistent, err := storage.FindByID(ctx, "people", "nonexistent")
-	if err != nil {
-		t.Errorf("unexpected error when finding non-existent object: %v", err)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("expected not found error when finding non-existent object, got %v", err)
 	}
 	if nonExistent != nil {
 		t.Error("expected nil for non-existent object")
@@ -229,8 +244,8 @@ func CRUDTest(t *testing.T, storage storage.Storage) {
 
 	// Verify deletion
 	obj, err = storage.FindByID(ctx, "people", "user2")
-	if err != nil {
-		t.Errorf("unexpected error when finding deleted object: %v", err)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("expected not found error when finding deleted object, got %v", err)
 	}
 	if obj != nil {
 		t.Error("deleted object still exists")
@@ -264,7 +279,7 @@ func CRUDTest(t *testing.T, storage storage.Storage) {
 		t.Error("new object was not created")
 	}
 
-	// Update the same object via Insert (upsert)
+	// Update the same object via Upsert
 	upsertObj := &object.Object{
 		TableName: "people",
 		ID:        "user4",
@@ -274,7 +289,7 @@ func CRUDTest(t *testing.T, storage storage.Storage) {
 		},
 	}
 
-	_, created, err = storage.Insert(ctx, upsertObj)
+	_, _, err = storage.Upsert(ctx, upsertObj)
 	if err != nil {
 		t.Errorf("failed to upsert object: %v", err)
 	}
@@ -384,8 +399,33 @@ func UpsertTest(t *testing.T, storage storage.Storage) {
 		t.Errorf("expected metadata to contain 'update', got '%s'", metadata)
 	}
 
-	// Test 3: UpsertTx with new object
-	tx, err := storage.BeginTx(ctx)
+	// Test 3: Insert must not overwrite an existing object
+	_, _, err = storage.Insert(ctx, &object.Object{
+		TableName: "people",
+		ID:        "upsert_user1",
+		Fields: map[string]any{
+			"name":     "Inserted Over Existing",
+			"metadata": `{"operation": "insert_existing"}`,
+		},
+	})
+	var dup *ddaoerrors.DuplicateKeyError
+	if !errors.As(err, &dup) {
+		t.Errorf("expected DuplicateKeyError inserting an existing ID, got %v", err)
+	} else if !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected DuplicateKeyError to match ErrConflict, got %v", err)
+	}
+
+	foundObj, err = storage.FindByID(ctx, "people", "upsert_user1")
+	if err != nil {
+		t.Fatalf("failed to find object after rejected insert: %v", err)
+	}
+	name, _ = foundObj.GetString("name")
+	if name != "Updated Upsert User" {
+		t.Errorf("rejected insert overwrote the object: expected name 'Updated Upsert User', got '%s'", name)
+	}
+
+	// Test 4: UpsertTx with new object
+	tx, err := storage.BeginTx(ctx, nil)
 	if err != nil {
 		t.Fatalf("failed to begin transaction: %v", err)
 	}
@@ -399,14 +439,14 @@ func UpsertTest(t *testing.T, storage storage.Storage) {
 		},
 	}
 
-	data, created, err = storage.UpsertTx(ctx, tx, txObj)
+	data, created, err = tx.Upsert(ctx, txObj)
 	if err != nil {
-		storage.RollbackTx(tx)
+		tx.Rollback()
 		t.Fatalf("failed to upsert in transaction: %v", err)
 	}
 
 	// Commit transaction
-	err = storage.CommitTx(tx)
+	err = tx.Commit()
 	if err != nil {
 		t.Fatalf("failed to commit transaction: %v", err)
 	}
@@ -425,8 +465,8 @@ func UpsertTest(t *testing.T, storage storage.Storage) {
 		t.Errorf("expected name 'Transaction Upsert User', got '%s'", name)
 	}
 
-	// Test 4: UpsertTx with existing object (update)
-	tx, err = storage.BeginTx(ctx)
+	// Test 5: UpsertTx with existing object (update)
+	tx, err = storage.BeginTx(ctx, nil)
 	if err != nil {
 		t.Fatalf("failed to begin transaction for update: %v", err)
 	}
@@ -440,14 +480,14 @@ func UpsertTest(t *testing.T, storage storage.Storage) {
 		},
 	}
 
-	data, created, err = storage.UpsertTx(ctx, tx, txUpdateObj)
+	data, created, err = tx.Upsert(ctx, txUpdateObj)
 	if err != nil {
-		storage.RollbackTx(tx)
+		tx.Rollback()
 		t.Fatalf("failed to upsert existing object in transaction: %v", err)
 	}
 
 	// Commit transaction
-	err = storage.CommitTx(tx)
+	err = tx.Commit()
 	if err != nil {
 		t.Fatalf("failed to commit update transaction: %v", err)
 	}
@@ -471,6 +511,34 @@ func UpsertTest(t *testing.T, storage storage.Storage) {
 		t.Errorf("expected metadata to contain 'tx_update', got '%s'", metadata)
 	}
 
+	// Test 6: Insert within a transaction must not overwrite an existing object
+	tx, err = storage.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction for insert: %v", err)
+	}
+
+	_, _, err = tx.Insert(ctx, &object.Object{
+		TableName: "people",
+		ID:        "upsert_tx_user",
+		Fields: map[string]any{
+			"name":     "Inserted Over Existing",
+			"metadata": `{"operation": "tx_insert_existing"}`,
+		},
+	})
+	if !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict inserting an existing ID in transaction, got %v", err)
+	}
+	tx.Rollback()
+
+	foundObj, err = storage.FindByID(ctx, "people", "upsert_tx_user")
+	if err != nil {
+		t.Fatalf("failed to find object after rejected transaction insert: %v", err)
+	}
+	name, _ = foundObj.GetString("name")
+	if name != "Updated Transaction User" {
+		t.Errorf("rejected transaction insert overwrote the object: expected name 'Updated Transaction User', got '%s'", name)
+	}
+
 	// Clean up
 	err = storage.ResetConnection(ctx)
 	if err != nil {
@@ -488,9 +556,14 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		t.Fatalf("failed to create tables: %v", err)
 	}
 
+	// Insert does not overwrite, so remove objects left over from earlier runs
+	for _, id := range []string{"tx_user1", "tx_user2", "tx_user3", "tx_user4", "tx_user5"} {
+		storage.DeleteByID(ctx, "people", id)
+	}
+
 	// Test 1: Basic transaction operations - commit
 	t.Run("BasicTransactionCommit", func(t *testing.T) {
-		tx, err := storage.BeginTx(ctx)
+		tx, err := storage.BeginTx(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to begin transaction: %v", err)
 		}
@@ -504,13 +577,13 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 			},
 		}
 
-		_, created, err := storage.InsertTx(ctx, tx, obj)
+		_, created, err := tx.Insert(ctx, obj)
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to insert in transaction: %v", err)
 		}
 		if !created {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object was not created in transaction")
 		}
 
@@ -525,18 +598,18 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 
 		// Object should be visible within transaction
-		foundObjTx, err := storage.FindByIDTx(ctx, tx, "people", "tx_user1")
+		foundObjTx, err := tx.FindByID(ctx, "people", "tx_user1")
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to find object in transaction: %v", err)
 		}
 		if foundObjTx == nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object not found in transaction")
 		}
 
 		// Commit transaction
-		err = storage.CommitTx(tx)
+		err = tx.Commit()
 		if err != nil {
 			t.Fatalf("failed to commit transaction: %v", err)
 		}
@@ -556,7 +629,7 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 
 	// Test 2: Transaction rollback
 	t.Run("TransactionRollback", func(t *testing.T) {
-		tx, err := storage.BeginTx(ctx)
+		tx, err := storage.BeginTx(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to begin transaction: %v", err)
 		}
@@ -570,37 +643,37 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 			},
 		}
 
-		_, created, err := storage.InsertTx(ctx, tx, obj)
+		_, created, err := tx.Insert(ctx, obj)
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to insert in transaction: %v", err)
 		}
 		if !created {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object was not created in transaction")
 		}
 
 		// Object should be visible within transaction
-		foundObjTx, err := storage.FindByIDTx(ctx, tx, "people", "tx_user2")
+		foundObjTx, err := tx.FindByID(ctx, "people", "tx_user2")
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to find object in transaction: %v", err)
 		}
 		if foundObjTx == nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object not found in transaction")
 		}
 
 		// Rollback transaction
-		err = storage.RollbackTx(tx)
+		err = tx.Rollback()
 		if err != nil {
 			t.Fatalf("failed to rollback transaction: %v", err)
 		}
 
 		// Object should not be visible after rollback
 		foundObj, err := storage.FindByID(ctx, "people", "tx_user2")
-		if err != nil {
-			t.Fatalf("unexpected error finding rolled back object: %v", err)
+		if !errors.Is(err, errNotFound) {
+			t.Fatalf("expected not found error for rolled back object, got %v", err)
 		}
 		if foundObj != nil {
 			t.Error("rolled back object should not be visible")
@@ -628,7 +701,7 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 
 		// Start transaction and update
-		tx, err := storage.BeginTx(ctx)
+		tx, err := storage.BeginTx(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to begin transaction: %v", err)
 		}
@@ -642,34 +715,34 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 			},
 		}
 
-		updated, err := storage.UpdateTx(ctx, tx, updateObj)
+		updated, err := tx.Update(ctx, updateObj)
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to update in transaction: %v", err)
 		}
 		if !updated {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object was not updated in transaction")
 		}
 
 		// Verify update within transaction
-		foundObjTx, err := storage.FindByIDTx(ctx, tx, "people", "tx_user3")
+		foundObjTx, err := tx.FindByID(ctx, "people", "tx_user3")
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to find updated object in transaction: %v", err)
 		}
 		if foundObjTx == nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("updated object not found in transaction")
 		}
 
 		name, ok := foundObjTx.GetString("name")
 		if !ok {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("failed to get name field")
 		}
 		if name != "Transaction User 3 Updated" {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Errorf("expected updated name 'Transaction User 3 Updated', got '%s'", name)
 		}
 
@@ -685,7 +758,7 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 
 		// Commit transaction
-		err = storage.CommitTx(tx)
+		err = tx.Commit()
 		if err != nil {
 			t.Fatalf("failed to commit transaction: %v", err)
 		}
@@ -729,29 +802,29 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 
 		// Start transaction and delete
-		tx, err := storage.BeginTx(ctx)
+		tx, err := storage.BeginTx(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to begin transaction: %v", err)
 		}
 
-		deleted, err := storage.DeleteByIDTx(ctx, tx, "people", "tx_user4")
+		deleted, err := tx.DeleteByID(ctx, "people", "tx_user4")
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to delete in transaction: %v", err)
 		}
 		if !deleted {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object was not deleted in transaction")
 		}
 
 		// Object should not be visible within transaction
-		foundObjTx, err := storage.FindByIDTx(ctx, tx, "people", "tx_user4")
-		if err != nil {
-			storage.RollbackTx(tx)
-			t.Fatalf("unexpected error finding deleted object in transaction: %v", err)
+		foundObjTx, err := tx.FindByID(ctx, "people", "tx_user4")
+		if !errors.Is(err, errNotFound) {
+			tx.Rollback()
+			t.Fatalf("expected not found error for deleted object in transaction, got %v", err)
 		}
 		if foundObjTx != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Error("deleted object should not be visible in transaction")
 		}
 
@@ -766,15 +839,15 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 
 		// Commit transaction
-		err = storage.CommitTx(tx)
+		err = tx.Commit()
 		if err != nil {
 			t.Fatalf("failed to commit transaction: %v", err)
 		}
 
 		// Object should now be deleted outside transaction
 		foundObj, err = storage.FindByID(ctx, "people", "tx_user4")
-		if err != nil {
-			t.Fatalf("unexpected error finding deleted object: %v", err)
+		if !errors.Is(err, errNotFound) {
+			t.Fatalf("expected not found error for deleted object, got %v", err)
 		}
 		if foundObj != nil {
 			t.Error("committed deleted object should not be visible")
@@ -783,7 +856,7 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 
 	// Test 5: Transaction with FindByKey operations
 	t.Run("TransactionFindByKey", func(t *testing.T) {
-		tx, err := storage.BeginTx(ctx)
+		tx, err := storage.BeginTx(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to begin transaction: %v", err)
 		}
@@ -797,33 +870,33 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 			},
 		}
 
-		_, created, err := storage.InsertTx(ctx, tx, obj)
+		_, created, err := tx.Insert(ctx, obj)
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to insert in transaction: %v", err)
 		}
 		if !created {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object was not created in transaction")
 		}
 
 		// Find by key within transaction
-		foundObjTx, err := storage.FindByKeyTx(ctx, tx, "people", "name", "Transaction User 5")
+		foundObjTx, err := tx.FindByKey(ctx, "people", "name", "Transaction User 5")
 		if err != nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatalf("failed to find object by key in transaction: %v", err)
 		}
 		if foundObjTx == nil {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Fatal("object not found by key in transaction")
 		}
 		if foundObjTx.ID != "tx_user5" {
-			storage.RollbackTx(tx)
+			tx.Rollback()
 			t.Errorf("expected ID 'tx_user5', got '%s'", foundObjTx.ID)
 		}
 
 		// Commit transaction
-		err = storage.CommitTx(tx)
+		err = tx.Commit()
 		if err != nil {
 			t.Fatalf("failed to commit transaction: %v", err)
 		}
@@ -841,57 +914,74 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		}
 	})
 
-	// Test 6: Error handling - nil transaction
-	t.Run("NilTransactionHandling", func(t *testing.T) {
+	// Test 6: Error handling - finished transaction
+	t.Run("FinishedTransactionHandling", func(t *testing.T) {
 		obj := &object.Object{
 			TableName: "people",
-			ID:        "nil_tx_test",
+			ID:        "done_tx_test",
 			Fields: map[string]any{
-				"name":     "Nil TX Test",
-				"metadata": `{"test": "nil"}`,
+				"name":     "Done TX Test",
+				"metadata": `{"test": "done"}`,
 			},
 		}
 
-		// Test InsertTx with nil transaction
-		_, _, err := storage.InsertTx(ctx, nil, obj)
-		if err == nil {
-			t.Error("expected error when using nil transaction for InsertTx")
+		tx, err := storage.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("failed to begin transaction: %v", err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("failed to rollback transaction: %v", err)
 		}
 
-		// Test UpdateTx with nil transaction
-		_, err = storage.UpdateTx(ctx, nil, obj)
+		// Test Insert on a finished transaction
+		_, _, err = tx.Insert(ctx, obj)
 		if err == nil {
-			t.Error("expected error when using nil transaction for UpdateTx")
+			t.Error("expected error when inserting with a finished transaction")
 		}
 
-		// Test FindByIDTx with nil transaction
-		_, err = storage.FindByIDTx(ctx, nil, "people", "nil_tx_test")
+		// Test Update on a finished transaction
+		_, err = tx.Update(ctx, obj)
 		if err == nil {
-			t.Error("expected error when using nil transaction for FindByIDTx")
+			t.Error("expected error when updating with a finished transaction")
 		}
 
-		// Test FindByKeyTx with nil transaction
-		_, err = storage.FindByKeyTx(ctx, nil, "people", "name", "Nil TX Test")
+		// Test FindByID on a finished transaction
+		_, err = tx.FindByID(ctx, "people", "done_tx_test")
 		if err == nil {
-			t.Error("expected error when using nil transaction for FindByKeyTx")
+			t.Error("expected error when finding by ID with a finished transaction")
 		}
 
-		// Test DeleteByIDTx with nil transaction
-		_, err = storage.DeleteByIDTx(ctx, nil, "people", "nil_tx_test")
+		// Test FindByKey on a finished transaction
+		_, err = tx.FindByKey(ctx, "people", "name", "Done TX Test")
 		if err == nil {
-			t.Error("expected error when using nil transaction for DeleteByIDTx")
+			t.Error("expected error when finding by key with a finished transaction")
 		}
 
-		// Test CommitTx with nil transaction
-		err = storage.CommitTx(nil)
+		// Test DeleteByID on a finished transaction
+		_, err = tx.DeleteByID(ctx, "people", "done_tx_test")
 		if err == nil {
-			t.Error("expected error when committing nil transaction")
+			t.Error("expected error when deleting with a finished transaction")
 		}
 
-		// Test RollbackTx with nil transaction
-		err = storage.RollbackTx(nil)
-		if err == nil {
-			t.Error("expected error when rolling back nil transaction")
+		// Test Commit after Rollback
+		err = tx.Commit()
+		if !errors.Is(err, errTxDone) {
+			t.Errorf("expected ErrTxDone when committing a finished transaction, got %v", err)
+		}
+
+		// Test Rollback twice
+		err = tx.Rollback()
+		if !errors.Is(err, errTxDone) {
+			t.Errorf("expected ErrTxDone when rolling back a finished transaction, got %v", err)
+		}
+
+		// Nothing was written
+		found, err := storage.FindByID(ctx, "people", "done_tx_test")
+		if !errors.Is(err, errNotFound) {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+		if found != nil {
+			t.Error("finished transaction should not have written anything")
 		}
 	})
 
@@ -901,3 +991,1142 @@ func TransactionTest(t *testing.T, storage storage.Storage) {
 		t.Errorf("failed to reset connection: %v", err)
 	}
 }
+
+// QueryTest exercises Find and FindTx with the predicate API for a storage.Storage backend
+func QueryTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, schema.GetTestSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	testObjects := []*object.Object{
+		{
+			TableName: "people",
+			ID:        "query_user1",
+			Fields: map[string]any{
+				"name":     "Alice Query",
+				"metadata": `{"team": "red"}`,
+			},
+		},
+		{
+			TableName: "people",
+			ID:        "query_user2",
+			Fields: map[string]any{
+				"name":     "Bob Query",
+				"metadata": `{"team": "blue"}`,
+			},
+		},
+		{
+			TableName: "people",
+			ID:        "query_user3",
+			Fields: map[string]any{
+				"name": "Carol Search",
+			},
+		},
+	}
+
+	for _, obj := range testObjects {
+		// Remove leftovers from previous runs against persistent databases
+		store.DeleteByID(ctx, "people", obj.ID)
+
+		if _, _, err := store.Insert(ctx, obj); err != nil {
+			t.Fatalf("failed to insert object %s: %v", obj.ID, err)
+		}
+	}
+
+	// Restrict every query to the objects created above
+	scoped := func(q storage.Query) storage.Query {
+		return storage.And(storage.Like("id", "query_user%"), q)
+	}
+
+	ids := func(objs []*object.Object) []string {
+		result := make([]string, 0, len(objs))
+		for _, obj := range objs {
+			result = append(result, obj.ID)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    storage.Query
+		expected []string
+	}{
+		{"eq", storage.Eq("name", "Bob Query"), []string{"query_user2"}},
+		{"ne", storage.Ne("name", "Bob Query"), []string{"query_user1", "query_user3"}},
+		{"gt", storage.Gt("id", "query_user1"), []string{"query_user2", "query_user3"}},
+		{"lte", storage.Lte("id", "query_user2"), []string{"query_user1", "query_user2"}},
+		{"in", storage.In("id", "query_user1", "query_user3", "missing"), []string{"query_user1", "query_user3"}},
+		{"empty in", storage.In("id"), []string{}},
+		{"like", storage.Like("name", "% Query"), []string{"query_user1", "query_user2"}},
+		{"is null", storage.IsNull("metadata"), []string{"query_user3"}},
+		{"or", storage.Or(storage.Eq("name", "Alice Query"), storage.Eq("name", "Carol Search")), []string{"query_user1", "query_user3"}},
+		{"not", storage.Not(storage.Like("name", "%Query")), []string{"query_user3"}},
+		{"match all", storage.And(), []string{"query_user1", "query_user2", "query_user3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := store.Find(ctx, "people", scoped(tt.query))
+			if err != nil {
+				t.Fatalf("failed to find objects: %v", err)
+			}
+
+			got := ids(objs)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Returned objects carry their fields
+	objs, err := store.Find(ctx, "people", storage.Eq("id", "query_user1"))
+	if err != nil {
+		t.Fatalf("failed to find object by id: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objs))
+	}
+	if name, _ := objs[0].GetString("name"); name != "Alice Query" {
+		t.Errorf("expected name 'Alice Query', got '%s'", name)
+	}
+
+	// Unknown fields are rejected before reaching the database
+	if _, err := store.Find(ctx, "people", storage.Eq("nonexistent", "x")); err == nil {
+		t.Error("expected error when querying unknown field")
+	}
+
+	// FindTx sees uncommitted writes made in the same transaction
+	txObj := &object.Object{
+		TableName: "people",
+		ID:        "query_user4",
+		Fields: map[string]any{
+			"name": "Dave Query",
+		},
+	}
+	store.DeleteByID(ctx, "people", txObj.ID)
+
+	tx, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	if _, _, err := tx.Insert(ctx, txObj); err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to insert object in transaction: %v", err)
+	}
+
+	objs, err = tx.Find(ctx, "people", scoped(storage.Like("name", "% Query")))
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to find objects in transaction: %v", err)
+	}
+	if len(objs) != 3 {
+		t.Errorf("expected 3 objects in transaction, got %d", len(objs))
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to rollback transaction: %v", err)
+	}
+
+	for _, obj := range testObjects {
+		store.DeleteByID(ctx, "people", obj.ID)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// ListTest pages through a table with List and checks the cursor contract for a storage.Storage backend
+func ListTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, schema.GetTestSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	// List walks the whole table, so start from an empty one
+	existing, err := store.Find(ctx, "people", storage.Query{})
+	if err != nil {
+		t.Fatalf("failed to find existing objects: %v", err)
+	}
+	for _, obj := range existing {
+		store.DeleteByID(ctx, "people", obj.ID)
+	}
+
+	names := []string{"Eve", "Dan", "Cat", "Bob", "Amy", "Fay", "Gus"}
+	expected := make([]string, 0, len(names))
+	for i, name := range names {
+		obj := &object.Object{
+			TableName: "people",
+			ID:        "list_user" + strconv.Itoa(i),
+			Fields: map[string]any{
+				"name": name,
+			},
+		}
+		if _, _, err := store.Insert(ctx, obj); err != nil {
+			t.Fatalf("failed to insert object %s: %v", obj.ID, err)
+		}
+		expected = append(expected, obj.ID)
+	}
+
+	// collect pages until the cursor runs out
+	collect := func(opts storage.ListOptions) ([]*object.Object, int) {
+		var all []*object.Object
+		pages := 0
+		for {
+			objs, cursor, err := store.List(ctx, "people", opts)
+			if err != nil {
+				t.Fatalf("failed to list page %d: %v", pages+1, err)
+			}
+			if len(objs) > opts.Limit {
+				t.Fatalf("page %d has %d objects, limit is %d", pages+1, len(objs), opts.Limit)
+			}
+			all = append(all, objs...)
+			pages++
+			if cursor == "" {
+				return all, pages
+			}
+			if pages > len(names) {
+				t.Fatalf("cursor never ran out after %d pages", pages)
+			}
+			opts.Cursor = cursor
+		}
+	}
+
+	// Every object is returned exactly once
+	all, pages := collect(storage.ListOptions{Limit: 3})
+	if pages < 3 {
+		t.Errorf("expected at least 3 pages, got %d", pages)
+	}
+	seen := make(map[string]int)
+	for _, obj := range all {
+		seen[obj.ID]++
+	}
+	for _, id := range expected {
+		if seen[id] != 1 {
+			t.Errorf("expected %s exactly once, got %d times", id, seen[id])
+		}
+	}
+	if len(all) != len(expected) {
+		t.Errorf("expected %d objects, got %d", len(expected), len(all))
+	}
+
+	// A limit covering the table returns everything without a cursor
+	objs, cursor, err := store.List(ctx, "people", storage.ListOptions{Limit: len(names) + 1})
+	if err != nil {
+		t.Fatalf("failed to list all objects: %v", err)
+	}
+	if len(objs) != len(names) || cursor != "" {
+		t.Errorf("expected %d objects and no cursor, got %d objects and cursor %q", len(names), len(objs), cursor)
+	}
+
+	// Garbage cursors are rejected
+	if _, _, err := store.List(ctx, "people", storage.ListOptions{Limit: 3, Cursor: "not a cursor!"}); err == nil {
+		t.Error("expected error for invalid cursor")
+	}
+
+	// Ordered listing is optional; backends that support it must honour it across pages
+	ordered, _, err := store.List(ctx, "people", storage.ListOptions{Limit: 1, OrderBy: "name"})
+	if err != nil {
+		t.Logf("ordered listing not supported: %v", err)
+	} else {
+		if len(ordered) != 1 {
+			t.Fatalf("expected 1 object, got %d", len(ordered))
+		}
+
+		for _, orderBy := range []string{"name", "-name", "id", "-id"} {
+			all, _ := collect(storage.ListOptions{Limit: 2, OrderBy: orderBy})
+			column := strings.TrimPrefix(orderBy, "-")
+
+			values := make([]string, 0, len(all))
+			for _, obj := range all {
+				if column == "id" {
+					values = append(values, obj.ID)
+				} else {
+					value, _ := obj.GetString(column)
+					values = append(values, value)
+				}
+			}
+
+			sorted := sort.StringsAreSorted(values)
+			if strings.HasPrefix(orderBy, "-") {
+				sorted = sort.SliceIsSorted(values, func(i, j int) bool { return values[i] > values[j] })
+			}
+			if !sorted || len(values) != len(names) {
+				t.Errorf("order %s: expected %d sorted values, got %v", orderBy, len(names), values)
+			}
+		}
+
+		// A cursor only resumes the ordering it was issued for
+		_, cursor, err := store.List(ctx, "people", storage.ListOptions{Limit: 1, OrderBy: "name"})
+		if err != nil {
+			t.Fatalf("failed to list by name: %v", err)
+		}
+		if _, _, err := store.List(ctx, "people", storage.ListOptions{Limit: 1, Cursor: cursor}); err == nil {
+			t.Error("expected error when reusing a cursor with a different order")
+		}
+	}
+
+	for _, id := range expected {
+		store.DeleteByID(ctx, "people", id)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// RowsTest streams results with FindRows and storage.All for a storage.Storage backend
+func RowsTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, schema.GetTestSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	const count = 25
+	expected := make(map[string]bool, count)
+	for i := 0; i < count; i++ {
+		obj := &object.Object{
+			TableName: "people",
+			ID:        "rows_user" + strconv.Itoa(i),
+			Fields: map[string]any{
+				"name": "Rows User " + strconv.Itoa(i),
+			},
+		}
+		store.DeleteByID(ctx, "people", obj.ID)
+		if _, _, err := store.Insert(ctx, obj); err != nil {
+			t.Fatalf("failed to insert object %s: %v", obj.ID, err)
+		}
+		expected[obj.ID] = true
+	}
+
+	scope := storage.Like("id", "rows_user%")
+
+	// Next/Object/Err/Close
+	rows, err := store.FindRows(ctx, "people", scope)
+	if err != nil {
+		t.Fatalf("failed to find rows: %v", err)
+	}
+
+	seen := make(map[string]bool, count)
+	for rows.Next() {
+		obj := rows.Object()
+		if obj == nil {
+			t.Fatal("Next returned true but Object is nil")
+		}
+		if !expected[obj.ID] {
+			t.Errorf("unexpected object %s", obj.ID)
+		}
+		if seen[obj.ID] {
+			t.Errorf("object %s returned twice", obj.ID)
+		}
+		seen[obj.ID] = true
+
+		name, _ := obj.GetString("name")
+		if name != "Rows User "+strings.TrimPrefix(obj.ID, "rows_user") {
+			t.Errorf("object %s has wrong name %q", obj.ID, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Errorf("failed to close rows: %v", err)
+	}
+	if len(seen) != count {
+		t.Errorf("expected %d objects, got %d", count, len(seen))
+	}
+	if rows.Next() {
+		t.Error("expected Next to return false after Close")
+	}
+
+	// Range-over-func, stopping early
+	rows, err = store.FindRows(ctx, "people", scope)
+	if err != nil {
+		t.Fatalf("failed to find rows: %v", err)
+	}
+	taken := 0
+	for obj, err := range storage.All(rows) {
+		if err != nil {
+			t.Fatalf("iteration failed: %v", err)
+		}
+		if !expected[obj.ID] {
+			t.Errorf("unexpected object %s", obj.ID)
+		}
+		taken++
+		if taken == 5 {
+			break
+		}
+	}
+	if taken != 5 {
+		t.Errorf("expected to take 5 objects, got %d", taken)
+	}
+
+	// Filters apply while streaming
+	rows, err = store.FindRows(ctx, "people", storage.And(scope, storage.Eq("name", "Rows User 7")))
+	if err != nil {
+		t.Fatalf("failed to find rows: %v", err)
+	}
+	objs, err := storage.Collect(rows)
+	if err != nil {
+		t.Fatalf("failed to collect rows: %v", err)
+	}
+	if len(objs) != 1 || objs[0].ID != "rows_user7" {
+		t.Errorf("expected only rows_user7, got %d objects", len(objs))
+	}
+
+	// Invalid queries fail before any rows are produced
+	if _, err := store.FindRows(ctx, "people", storage.Eq("nonexistent", "x")); err == nil {
+		t.Error("expected error when querying unknown field")
+	}
+
+	for id := range expected {
+		store.DeleteByID(ctx, "people", id)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// ErrorsTest checks that the backend reports failures with the errors defined
+// in storage/errors
+func ErrorsTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, schema.GetTestSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	obj, err := store.FindByID(ctx, "people", "errors_missing")
+	if !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected ErrNotFound finding a missing ID, got %v", err)
+	}
+	if obj != nil {
+		t.Errorf("expected no object for a missing ID, got %v", obj)
+	}
+
+	_, err = store.FindByKey(ctx, "people", "name", "Errors Missing")
+	if !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected ErrNotFound finding a missing key, got %v", err)
+	}
+
+	var mismatch *ddaoerrors.SchemaMismatchError
+	_, err = store.FindByID(ctx, "nonexistent", "errors_missing")
+	if !errors.As(err, &mismatch) {
+		t.Errorf("expected SchemaMismatchError for an unknown table, got %v", err)
+	} else if mismatch.Table != "nonexistent" {
+		t.Errorf("expected mismatch on table 'nonexistent', got %q", mismatch.Table)
+	}
+
+	_, err = store.Find(ctx, "people", storage.Eq("nonexistent", "x"))
+	if !errors.As(err, &mismatch) {
+		t.Errorf("expected SchemaMismatchError for an unknown field, got %v", err)
+	} else if mismatch.Field != "nonexistent" {
+		t.Errorf("expected mismatch on field 'nonexistent', got %q", mismatch.Field)
+	}
+
+	// A key is a field name, never SQL
+	_, err = store.FindByKey(ctx, "people", "name = name OR 1=1 --", "x")
+	if !errors.As(err, &mismatch) {
+		t.Errorf("expected SchemaMismatchError for a key that is not a field, got %v", err)
+	}
+
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "people",
+		ID:        "errors_unknown_field",
+		Fields:    map[string]any{"name": "Errors", "nonexistent": "x"},
+	})
+	if !errors.Is(err, ddaoerrors.ErrSchemaMismatch) {
+		t.Errorf("expected ErrSchemaMismatch inserting an unknown field, got %v", err)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// BatchTest checks InsertMany, UpsertMany and DeleteMany, including that a
+// failing object is reported on its own without failing the rest of its batch
+func BatchTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, schema.GetTestSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	const count = 25
+	ids := make([]string, 0, count+2)
+	objs := make([]*object.Object, 0, count)
+	for i := 0; i < count; i++ {
+		id := "batch_user" + strconv.Itoa(i)
+		ids = append(ids, id)
+		objs = append(objs, &object.Object{
+			TableName: "people",
+			ID:        id,
+			Fields: map[string]any{
+				"name":     "Batch User " + strconv.Itoa(i),
+				"metadata": map[string]any{"index": i},
+			},
+		})
+	}
+	// A nil field among the values of a column is written with the rest
+	objs[3].Fields["metadata"] = nil
+	ids = append(ids, "batch_new", "batch_bad")
+
+	// Clean up leftovers from earlier runs against a persistent database
+	if _, err := store.DeleteMany(ctx, "people", ids, storage.BatchOptions{}); err != nil {
+		t.Fatalf("failed to clean up: %v", err)
+	}
+
+	// Test 1: InsertMany across several batches
+	result, err := store.InsertMany(ctx, objs, storage.BatchOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatalf("failed to insert many: %v", err)
+	}
+	if len(result.Items) != count {
+		t.Fatalf("expected %d result items, got %d", count, len(result.Items))
+	}
+	if err := result.Err(); err != nil {
+		t.Fatalf("expected every insert to succeed, got %v", err)
+	}
+	for i, item := range result.Items {
+		if item.ID != objs[i].ID {
+			t.Errorf("expected result item %d for %s, got %s", i, objs[i].ID, item.ID)
+		}
+	}
+	for _, i := range []int{0, 9, 10, count - 1} {
+		found, err := store.FindByID(ctx, "people", objs[i].ID)
+		if err != nil {
+			t.Fatalf("failed to find inserted object %s: %v", objs[i].ID, err)
+		}
+		if name, _ := found.GetString("name"); name != "Batch User "+strconv.Itoa(i) {
+			t.Errorf("expected name 'Batch User %d', got '%s'", i, name)
+		}
+	}
+
+	// Test 2: a duplicate and an unknown field fail on their own
+	mixed := []*object.Object{
+		{TableName: "people", ID: "batch_new", Fields: map[string]any{"name": "Batch New"}},
+		{TableName: "people", ID: "batch_user0", Fields: map[string]any{"name": "Batch Duplicate"}},
+		{TableName: "people", ID: "batch_bad", Fields: map[string]any{"name": "Batch Bad", "nonexistent": "x"}},
+	}
+	result, err = store.InsertMany(ctx, mixed, storage.BatchOptions{})
+	if err != nil {
+		t.Fatalf("failed to insert many: %v", err)
+	}
+	if result.Items[0].Err != nil {
+		t.Errorf("expected new object to be inserted, got %v", result.Items[0].Err)
+	}
+	if !errors.Is(result.Items[1].Err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict for an existing ID, got %v", result.Items[1].Err)
+	}
+	if !errors.Is(result.Items[2].Err, ddaoerrors.ErrSchemaMismatch) {
+		t.Errorf("expected ErrSchemaMismatch for an unknown field, got %v", result.Items[2].Err)
+	}
+	if len(result.Failed()) != 2 || result.Succeeded() != 1 || result.Err() == nil {
+		t.Errorf("expected 1 success and 2 failures, got %d and %d", result.Succeeded(), len(result.Failed()))
+	}
+	if _, err := store.FindByID(ctx, "people", "batch_new"); err != nil {
+		t.Errorf("failed to find object inserted alongside failures: %v", err)
+	}
+	found, err := store.FindByID(ctx, "people", "batch_user0")
+	if err != nil {
+		t.Fatalf("failed to find object: %v", err)
+	}
+	if name, _ := found.GetString("name"); name != "Batch User 0" {
+		t.Errorf("failed insert should leave the existing object unchanged, got name '%s'", name)
+	}
+
+	// Test 3: UpsertMany overwrites existing objects and creates new ones
+	upserts := make([]*object.Object, 0, 5)
+	for i := 0; i < 5; i++ {
+		upserts = append(upserts, &object.Object{
+			TableName: "people",
+			ID:        "batch_user" + strconv.Itoa(i),
+			Fields:    map[string]any{"name": "Upserted " + strconv.Itoa(i)},
+		})
+	}
+	upserts = append(upserts, &object.Object{
+		TableName: "people",
+		ID:        "batch_bad",
+		Fields:    map[string]any{"name": "Upserted New"},
+	})
+	result, err = store.UpsertMany(ctx, upserts, storage.BatchOptions{BatchSize: 4})
+	if err != nil {
+		t.Fatalf("failed to upsert many: %v", err)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatalf("expected every upsert to succeed, got %v", err)
+	}
+	for _, obj := range upserts {
+		found, err := store.FindByID(ctx, "people", obj.ID)
+		if err != nil {
+			t.Fatalf("failed to find upserted object %s: %v", obj.ID, err)
+		}
+		want, _ := obj.GetString("name")
+		if name, _ := found.GetString("name"); name != want {
+			t.Errorf("expected name '%s' for %s, got '%s'", want, obj.ID, name)
+		}
+	}
+
+	// Test 4: DeleteMany removes every existing ID and ignores missing ones
+	result, err = store.DeleteMany(ctx, "people", append(ids, "batch_missing"), storage.BatchOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatalf("failed to delete many: %v", err)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatalf("expected every delete to succeed, got %v", err)
+	}
+	if result.Deleted < int64(len(ids)) {
+		t.Errorf("expected at least %d deleted, got %d", len(ids), result.Deleted)
+	}
+	for _, id := range ids {
+		if _, err := store.FindByID(ctx, "people", id); !errors.Is(err, ddaoerrors.ErrNotFound) {
+			t.Errorf("expected not found error for deleted object %s, got %v", id, err)
+		}
+	}
+
+	// Test 5: empty input is a no-op
+	result, err = store.InsertMany(ctx, nil, storage.BatchOptions{})
+	if err != nil || len(result.Items) != 0 {
+		t.Errorf("expected empty result for no objects, got %v, %v", result, err)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// KeysSchema returns the tables KeysTest runs against: accounts, keyed on an
+// integer account_no column, and stock, keyed on (region, sku)
+func KeysSchema() *schema.Schema {
+	sch := schema.New()
+	sch.SetDatabaseName("testdb")
+
+	accounts := schema.NewTableSchema("accounts")
+	accounts.AddField(schema.ColumnData{Name: "account_no", DataType: "integer", PrimaryKey: true})
+	accounts.AddField(schema.ColumnData{Name: "owner", DataType: "text"})
+	sch.AddTable(accounts)
+
+	stock := schema.NewTableSchema("stock")
+	stock.AddField(schema.ColumnData{Name: "region", DataType: "text", PrimaryKey: true})
+	stock.AddField(schema.ColumnData{Name: "sku", DataType: "integer", PrimaryKey: true})
+	stock.AddField(schema.ColumnData{Name: "qty", DataType: "integer"})
+	sch.AddTable(stock)
+
+	return sch
+}
+
+// KeysTest checks CRUD on tables keyed on a column other than id and on a
+// composite key, whose IDs are the key values as encoded by object.Key
+func KeysTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, KeysSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	// Clean up leftovers from earlier runs against a persistent database
+	for _, id := range []string{"1001", "1002"} {
+		store.DeleteByID(ctx, "accounts", id)
+	}
+	for _, id := range []string{"eu/7", "eu/8", "us/7"} {
+		store.DeleteByID(ctx, "stock", id)
+	}
+
+	// Test 1: an integer key given as a field, or only as the ID
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "accounts",
+		Fields:    map[string]any{"account_no": int64(1001), "owner": "Ann"},
+	})
+	if err != nil {
+		t.Fatalf("failed to insert account by key field: %v", err)
+	}
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "accounts",
+		ID:        "1002",
+		Fields:    map[string]any{"owner": "Ben"},
+	})
+	if err != nil {
+		t.Fatalf("failed to insert account by ID: %v", err)
+	}
+
+	found, err := store.FindByID(ctx, "accounts", "1001")
+	if err != nil {
+		t.Fatalf("failed to find account: %v", err)
+	}
+	if found.ID != "1001" {
+		t.Errorf("expected ID '1001', got '%s'", found.ID)
+	}
+	if no, _ := found.GetInt64("account_no"); no != 1001 {
+		t.Errorf("expected account_no 1001, got %v", found.Fields["account_no"])
+	}
+	if owner, _ := found.GetString("owner"); owner != "Ann" {
+		t.Errorf("expected owner 'Ann', got '%s'", owner)
+	}
+	if found, err := store.FindByID(ctx, "accounts", "1002"); err != nil {
+		t.Errorf("failed to find account inserted by ID: %v", err)
+	} else if owner, _ := found.GetString("owner"); owner != "Ben" {
+		t.Errorf("expected owner 'Ben', got '%s'", owner)
+	}
+
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "accounts",
+		Fields:    map[string]any{"account_no": int64(1001), "owner": "Duplicate"},
+	})
+	if !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict inserting an existing key, got %v", err)
+	}
+
+	updated, err := store.Update(ctx, &object.Object{
+		TableName: "accounts",
+		ID:        "1001",
+		Fields:    map[string]any{"owner": "Ann Updated"},
+	})
+	if err != nil || !updated {
+		t.Fatalf("failed to update account: %v", err)
+	}
+	if found, err := store.FindByID(ctx, "accounts", "1001"); err != nil {
+		t.Errorf("failed to find updated account: %v", err)
+	} else if owner, _ := found.GetString("owner"); owner != "Ann Updated" {
+		t.Errorf("expected owner 'Ann Updated', got '%s'", owner)
+	}
+
+	// Test 2: a composite key
+	for _, fields := range []map[string]any{
+		{"region": "eu", "sku": int64(7), "qty": int64(3)},
+		{"region": "eu", "sku": int64(8), "qty": int64(5)},
+		{"region": "us", "sku": int64(7), "qty": int64(1)},
+	} {
+		if _, _, err := store.Insert(ctx, &object.Object{TableName: "stock", Fields: fields}); err != nil {
+			t.Fatalf("failed to insert stock %v: %v", fields, err)
+		}
+	}
+
+	found, err = store.FindByID(ctx, "stock", "eu/7")
+	if err != nil {
+		t.Fatalf("failed to find stock by composite ID: %v", err)
+	}
+	if found.ID != "eu/7" {
+		t.Errorf("expected ID 'eu/7', got '%s'", found.ID)
+	}
+	if qty, _ := found.GetInt64("qty"); qty != 3 {
+		t.Errorf("expected qty 3, got %v", found.Fields["qty"])
+	}
+
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "stock",
+		Fields:    map[string]any{"region": "us", "sku": int64(7), "qty": int64(9)},
+	})
+	if !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict inserting an existing composite key, got %v", err)
+	}
+	var dup *ddaoerrors.DuplicateKeyError
+	if errors.As(err, &dup) && dup.Column != "region,sku" {
+		t.Errorf("expected the composite key's columns as \"region,sku\", got %q", dup.Column)
+	}
+
+	updated, err = store.Update(ctx, &object.Object{
+		TableName: "stock",
+		Fields:    map[string]any{"region": "eu", "sku": int64(8), "qty": int64(6)},
+	})
+	if err != nil || !updated {
+		t.Fatalf("failed to update stock: %v", err)
+	}
+	_, _, err = store.Upsert(ctx, &object.Object{
+		TableName: "stock",
+		Fields:    map[string]any{"region": "us", "sku": int64(7), "qty": int64(2)},
+	})
+	if err != nil {
+		t.Fatalf("failed to upsert stock: %v", err)
+	}
+	for id, want := range map[string]int64{"eu/7": 3, "eu/8": 6, "us/7": 2} {
+		found, err := store.FindByID(ctx, "stock", id)
+		if err != nil {
+			t.Errorf("failed to find stock %s: %v", id, err)
+			continue
+		}
+		if qty, _ := found.GetInt64("qty"); qty != want {
+			t.Errorf("expected qty %d for %s, got %v", want, id, found.Fields["qty"])
+		}
+	}
+
+	if _, err := store.FindByID(ctx, "stock", "eu/9"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found error for a missing composite key, got %v", err)
+	}
+
+	// Test 3: List pages through every object of a composite key table
+	var listed []string
+	opts := storage.ListOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("cursor never ran out after %d pages", pages)
+		}
+		objs, cursor, err := store.List(ctx, "stock", opts)
+		if err != nil {
+			t.Fatalf("failed to list stock: %v", err)
+		}
+		for _, obj := range objs {
+			listed = append(listed, obj.ID)
+		}
+		if cursor == "" {
+			break
+		}
+		opts.Cursor = cursor
+	}
+	sort.Strings(listed)
+	if strings.Join(listed, ",") != "eu/7,eu/8,us/7" {
+		t.Errorf("expected to list eu/7, eu/8 and us/7, got %v", listed)
+	}
+
+	// Test 4: transactions identify objects by the same IDs
+	tx, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if _, err := tx.DeleteByID(ctx, "stock", "us/7"); err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to delete stock in transaction: %v", err)
+	}
+	if _, err := tx.Update(ctx, &object.Object{
+		TableName: "stock",
+		ID:        "eu/7",
+		Fields:    map[string]any{"region": "eu", "sku": int64(7), "qty": int64(4)},
+	}); err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to update stock in transaction: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit transaction: %v", err)
+	}
+	if _, err := store.FindByID(ctx, "stock", "us/7"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected stock deleted in transaction to be gone, got %v", err)
+	}
+	if found, err := store.FindByID(ctx, "stock", "eu/7"); err != nil {
+		t.Errorf("failed to find stock updated in transaction: %v", err)
+	} else if qty, _ := found.GetInt64("qty"); qty != 4 {
+		t.Errorf("expected qty 4 after transaction, got %v", found.Fields["qty"])
+	}
+
+	// Test 5: deletes by composite ID
+	deleted, err := store.DeleteByID(ctx, "stock", "eu/8")
+	if err != nil || !deleted {
+		t.Errorf("failed to delete stock eu/8: %v", err)
+	}
+	result, err := store.DeleteMany(ctx, "stock", []string{"eu/7", "us/7"}, storage.BatchOptions{})
+	if err != nil {
+		t.Fatalf("failed to delete many: %v", err)
+	}
+	if err := result.Err(); err != nil {
+		t.Errorf("expected every delete to succeed, got %v", err)
+	}
+	if _, err := store.FindByID(ctx, "stock", "eu/7"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found error for deleted stock, got %v", err)
+	}
+	if _, err := store.DeleteMany(ctx, "accounts", []string{"1001", "1002"}, storage.BatchOptions{}); err != nil {
+		t.Errorf("failed to delete accounts: %v", err)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// IndexesSchema returns the table IndexesTest runs against: members, with an
+// index on email, one on (team, role) and a unique index on (team, handle)
+func IndexesSchema() *schema.Schema {
+	sch := schema.New()
+	sch.SetDatabaseName("testdb")
+
+	members := schema.NewTableSchema("members")
+	members.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	members.AddField(schema.ColumnData{Name: "email", DataType: "text", Index: true})
+	members.AddField(schema.ColumnData{Name: "team", DataType: "text"})
+	members.AddField(schema.ColumnData{Name: "role", DataType: "text"})
+	members.AddField(schema.ColumnData{Name: "handle", DataType: "text"})
+	members.AddIndex("", false, "team", "role")
+	members.AddIndex("", true, "team", "handle")
+	sch.AddTable(members)
+
+	return sch
+}
+
+// IndexesTest checks that CreateTables creates secondary indexes idempotently,
+// that FindByKey finds objects by indexed fields as they change, and that
+// unique composite indexes reject duplicates
+func IndexesTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	// Creating the tables again must not fail on the existing indexes
+	for i := 0; i < 2; i++ {
+		if err := store.CreateTables(ctx, IndexesSchema()); err != nil {
+			t.Fatalf("failed to create tables (pass %d): %v", i+1, err)
+		}
+	}
+
+	// Clean up leftovers from earlier runs against a persistent database
+	for _, id := range []string{"m1", "m2", "m3"} {
+		store.DeleteByID(ctx, "members", id)
+	}
+
+	for _, fields := range []map[string]any{
+		{"id": "m1", "email": "ann@example.com", "team": "red", "role": "lead", "handle": "ann"},
+		{"id": "m2", "email": "ben@example.com", "team": "red", "role": "dev", "handle": "ben"},
+		{"id": "m3", "email": "cat@example.com", "team": "blue", "role": "dev", "handle": "ann"},
+	} {
+		if _, _, err := store.Insert(ctx, &object.Object{TableName: "members", ID: fields["id"].(string), Fields: fields}); err != nil {
+			t.Fatalf("failed to insert member %v: %v", fields["id"], err)
+		}
+	}
+
+	// Test 1: lookups on an indexed column and on the leading column of a composite index
+	found, err := store.FindByKey(ctx, "members", "email", "ben@example.com")
+	if err != nil {
+		t.Fatalf("failed to find member by email: %v", err)
+	}
+	if found.ID != "m2" {
+		t.Errorf("expected member m2 by email, got %s", found.ID)
+	}
+	found, err = store.FindByKey(ctx, "members", "team", "blue")
+	if err != nil {
+		t.Fatalf("failed to find member by team: %v", err)
+	}
+	if found.ID != "m3" {
+		t.Errorf("expected member m3 by team, got %s", found.ID)
+	}
+
+	// Test 2: the unique composite index rejects a repeated (team, handle)
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "members",
+		ID:        "m4",
+		Fields:    map[string]any{"id": "m4", "email": "dup@example.com", "team": "red", "role": "dev", "handle": "ann"},
+	})
+	if !errors.Is(err, ddaoerrors.ErrConflict) {
+		t.Errorf("expected ErrConflict inserting a duplicate (team, handle), got %v", err)
+	}
+	var dup *ddaoerrors.DuplicateKeyError
+	if errors.As(err, &dup) && dup.Column != "team,handle" {
+		t.Errorf("expected the unique index's columns as \"team,handle\", got %q", dup.Column)
+	}
+
+	// Test 3: lookups follow updates and deletes
+	updated, err := store.Update(ctx, &object.Object{
+		TableName: "members",
+		ID:        "m1",
+		Fields:    map[string]any{"email": "ann@example.org"},
+	})
+	if err != nil || !updated {
+		t.Fatalf("failed to update member email: %v", err)
+	}
+	if _, err := store.FindByKey(ctx, "members", "email", "ann@example.com"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found error for a replaced email, got %v", err)
+	}
+	if found, err := store.FindByKey(ctx, "members", "email", "ann@example.org"); err != nil {
+		t.Errorf("failed to find member by updated email: %v", err)
+	} else if found.ID != "m1" {
+		t.Errorf("expected member m1 by updated email, got %s", found.ID)
+	}
+
+	if deleted, err := store.DeleteByID(ctx, "members", "m2"); err != nil || !deleted {
+		t.Errorf("failed to delete member m2: %v", err)
+	}
+	if _, err := store.FindByKey(ctx, "members", "email", "ben@example.com"); !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected not found error for a deleted member, got %v", err)
+	}
+
+	if _, err := store.DeleteMany(ctx, "members", []string{"m1", "m3"}, storage.BatchOptions{}); err != nil {
+		t.Errorf("failed to delete members: %v", err)
+	}
+
+	err = store.ResetConnection(ctx)
+	if err != nil {
+		t.Errorf("failed to reset connection: %v", err)
+	}
+}
+
+// AlterTableSchema returns the schema used by AlterTableTest
+func AlterTableSchema() *schema.Schema {
+	sch := schema.New()
+	sch.SetDatabaseName("testdb")
+
+	gadgets := schema.NewTableSchema("gadgets")
+	gadgets.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	gadgets.AddField(schema.ColumnData{Name: "name", DataType: "text"})
+	gadgets.AddField(schema.ColumnData{Name: "color", DataType: "text", Nullable: true})
+	sch.AddTable(gadgets)
+
+	return sch
+}
+
+// AlterTableTest checks that AlterTable changes the table, keeps its rows,
+// and updates the schema so later calls use the new definition. It renames
+// the table and column back at the end, so it can run again on a persistent database.
+func AlterTableTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	if err := store.CreateTables(ctx, AlterTableSchema()); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	for _, id := range []string{"g1", "g2"} {
+		store.DeleteByID(ctx, "gadgets", id)
+	}
+	if _, _, err := store.Insert(ctx, &object.Object{TableName: "gadgets", ID: "g1", Fields: map[string]any{"name": "bolt", "color": "red"}}); err != nil {
+		t.Fatalf("failed to insert gadget: %v", err)
+	}
+
+	alter := func(tableName string, ops ...storage.AlterOp) {
+		t.Helper()
+		if err := store.AlterTable(ctx, tableName, ops); err != nil {
+			t.Fatalf("failed to alter table %s: %v", tableName, err)
+		}
+	}
+
+	// Test 1: an added column can be written at once
+	alter("gadgets", storage.AddColumn(schema.ColumnData{Name: "size", DataType: "integer", Nullable: true}))
+	if _, _, err := store.Insert(ctx, &object.Object{TableName: "gadgets", ID: "g2", Fields: map[string]any{"name": "nut", "size": 3}}); err != nil {
+		t.Fatalf("failed to insert gadget with the added column: %v", err)
+	}
+
+	// Test 2: a renamed column keeps its values and is queried by its new name
+	alter("gadgets", storage.RenameColumn("color", "colour"))
+	found, err := store.Find(ctx, "gadgets", storage.Eq("colour", "red"))
+	if err != nil {
+		t.Fatalf("failed to find by renamed column: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "g1" {
+		t.Errorf("expected gadget g1 by colour, got %d gadgets", len(found))
+	}
+
+	// Test 3: nullability and defaults
+	alter("gadgets",
+		storage.SetDefault("colour", "grey"),
+		storage.DropNotNull("name"),
+		storage.SetNotNull("name"),
+		storage.DropDefault("colour"),
+	)
+
+	// Test 4: a dropped column is gone from the rows
+	alter("gadgets", storage.DropColumn("size"))
+	obj, err := store.FindByID(ctx, "gadgets", "g2")
+	if err != nil {
+		t.Fatalf("failed to find gadget after dropping a column: %v", err)
+	}
+	if value, ok := obj.Fields["size"]; ok && value != nil {
+		t.Errorf("expected no size after dropping the column, got %v", value)
+	}
+
+	// Test 5: a renamed table is read under its new name
+	alter("gadgets", storage.RenameTable("gizmos"))
+	obj, err = store.FindByID(ctx, "gizmos", "g1")
+	if err != nil {
+		t.Fatalf("failed to find gadget in the renamed table: %v", err)
+	}
+	if name, _ := obj.GetString("name"); name != "bolt" {
+		t.Errorf("expected name bolt in the renamed table, got %v", obj.Fields["name"])
+	}
+	if _, err := store.FindByID(ctx, "gadgets", "g1"); !errors.Is(err, ddaoerrors.ErrSchemaMismatch) && !errors.Is(err, ddaoerrors.ErrNotFound) {
+		t.Errorf("expected the old table name to be unknown, got %v", err)
+	}
+
+	// Test 6: invalid ops fail without changing anything
+	if err := store.AlterTable(ctx, "gizmos", []storage.AlterOp{storage.DropColumn("missing")}); !errors.Is(err, ddaoerrors.ErrSchemaMismatch) {
+		t.Errorf("expected ErrSchemaMismatch dropping a missing column, got %v", err)
+	}
+	if err := store.AlterTable(ctx, "missing", []storage.AlterOp{storage.DropColumn("name")}); !errors.Is(err, ddaoerrors.ErrSchemaMismatch) {
+		t.Errorf("expected ErrSchemaMismatch altering a missing table, got %v", err)
+	}
+
+	alter("gizmos", storage.RenameTable("gadgets"), storage.RenameColumn("colour", "color"))
+}
+
+// ReservedWordsSchema returns the table ReservedWordsTest runs against: user,
+// whose columns are named after SQL keywords, with an integer and a
+// CURRENT_TIMESTAMP default and an index
+func ReservedWordsSchema() *schema.Schema {
+	sch := schema.New()
+	sch.SetDatabaseName("testdb")
+
+	user := schema.NewTableSchema("user")
+	user.AddField(schema.ColumnData{Name: "id", DataType: "text", PrimaryKey: true})
+	user.AddField(schema.ColumnData{Name: "group", DataType: "text", Index: true})
+	user.AddField(schema.ColumnData{Name: "order", DataType: "integer", Default: int64(1)})
+	user.AddField(schema.ColumnData{Name: "select", DataType: "timestamp", Default: schema.Expr("CURRENT_TIMESTAMP")})
+	sch.AddTable(user)
+
+	return sch
+}
+
+// ReservedWordsTest checks that tables and columns named after SQL keywords
+// can be created, written and queried, and that omitted columns take their
+// defaults
+func ReservedWordsTest(t *testing.T, store storage.Storage) {
+	ctx := context.Background()
+
+	err := store.CreateTables(ctx, ReservedWordsSchema())
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	store.DeleteByID(ctx, "user", "reserved_1")
+
+	_, _, err = store.Insert(ctx, &object.Object{
+		TableName: "user",
+		ID:        "reserved_1",
+		Fields:    map[string]any{"group": "admins"},
+	})
+	if err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	found, err := store.FindByKey(ctx, "user", "group", "admins")
+	if err != nil {
+		t.Fatalf("failed to find user by group: %v", err)
+	}
+	if found.ID != "reserved_1" {
+		t.Errorf("expected ID 'reserved_1', got '%s'", found.ID)
+	}
+	if order, _ := found.GetInt64("order"); order != 1 {
+		t.Errorf("expected the default order 1, got %v", found.Fields["order"])
+	}
+	if found.Fields["select"] == nil {
+		t.Error("expected select to default to the current time")
+	}
+
+	updated, err := store.Update(ctx, &object.Object{
+		TableName: "user",
+		ID:        "reserved_1",
+		Fields:    map[string]any{"order": int64(2)},
+	})
+	if err != nil || !updated {
+		t.Fatalf("failed to update user: %v", err)
+	}
+	objs, err := store.Find(ctx, "user", storage.And(storage.Eq("group", "admins"), storage.Eq("order", int64(2))))
+	if err != nil {
+		t.Fatalf("failed to find user by order: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Errorf("expected 1 user with order 2, got %d", len(objs))
+	}
+
+	deleted, err := store.DeleteByID(ctx, "user", "reserved_1")
+	if err != nil || !deleted {
+		t.Errorf("failed to delete user: %v", err)
+	}
+}